@@ -4,10 +4,23 @@ import { Camera } from './camera.js';
 import { GameRenderer } from './game-renderer.js';
 import { InputHandler } from './input-handler.js';
 import { UIManager } from './ui-manager.js';
+import { MsgWelcome, MsgState, MsgDeath, MsgError, MsgMap, MsgAte, MsgKill, MsgAnnounce, MsgStats, MsgMOTD, MsgObjective, MsgShutdown, MsgRecords, MsgEcho, MsgJoin, MsgRespawn, MsgInput, CloseKicked } from './protocol.js';
 
-const SERVER_TICK_MS = 50;       // 20Hz server tick — used for interpolation window
+const DEFAULT_TICK_MS = 50;      // 20Hz until the welcome advertises the server's rate
 const RECONNECT_DELAY_MS = 2000;
-const INPUT_HZ_MS = 50;          // 20Hz input send rate
+const EFFECT_COMEBACK = 1 << 6;  // StatsMsg effect bit: comeback bonus active
+const ECHO_KEEP = 600;           // input echoes kept with ?echo=1 (30 s at 20 Hz)
+
+// Close codes from CloseKicked up to 4199 mean "don't come back automatically"
+function isPermanentClose(code) {
+  return code >= CloseKicked && code < 4200;
+}
+
+// Flag emoji for an ISO 3166-1 alpha-2 code ("" for none): two regional indicators
+function countryFlag(code) {
+  if (!code) return '';
+  return String.fromCodePoint(...[...code].map(ch => 0x1F1E6 + ch.charCodeAt(0) - 65));
+}
 
 export class GameClient {
   constructor() {
@@ -20,7 +33,12 @@ export class GameClient {
     this.input = new InputHandler(this.canvas);
 
     // Game state
-    this.myId = null;
+    this.myId = null;       // numeric entity id of the snake the camera follows
+    this.ownId = null;      // entity id of our own snake (myId differs while only an extra snake lives)
+    this.sessionId = null;  // session UUID from welcome
+    this.tickMs = DEFAULT_TICK_MS; // server tick — interpolation window and input send rate
+    this.rules = null; // server physics constants from welcome
+    this.lang = ''; // community language from welcome ('' = international)
     this.playerName = 'Anonymous';
     this.alive = false;
     // Circular world: center=(worldRadius, worldRadius), radius=worldRadius
@@ -30,6 +48,13 @@ export class GameClient {
     this._prevState = null;
     this._currState = null;
     this._lastStateTime = 0;  // timestamp when currState arrived
+    // Raw snakes, food and blobs in view, as rebuilt from keyframes and deltas
+    this._view = null;
+
+    // Input sequence numbers, echoed back with ?echo=1 on the page: the
+    // last ECHO_KEEP echoes stay in _echoes for inspection from the console
+    this._inputSeq = 0;
+    this._echoes = [];
 
     // Loop
     this._rafId = null;
@@ -53,14 +78,24 @@ export class GameClient {
 
   // ── WebSocket ─────────────────────────────────────────────────────────────
 
-  _connect() {
+  // reconnect marks an automatic retry after a lost connection, so the
+  // server can count reconnect successes and failures
+  _connect(reconnect = false) {
     if (this._ws) {
       this._intentionallyClosed = true;
       this._ws.close();
     }
 
     const proto = location.protocol === 'https:' ? 'wss:' : 'ws:';
-    const url = `${proto}//${window.location.host}/ws`;
+    // ?room=name on the page joins that room instead of the least-full one;
+    // ?echo=1 asks for an echo of the inputs the server applies each tick
+    const params = new URLSearchParams(window.location.search);
+    const query = new URLSearchParams();
+    if (params.get('room')) query.set('room', params.get('room'));
+    if (params.get('echo') === '1') query.set('echo', '1');
+    if (reconnect) query.set('reconnect', '1');
+    const qs = query.toString();
+    const url = `${proto}//${window.location.host}/ws` + (qs ? `?${qs}` : '');
 
     try {
       this._ws = new WebSocket(url);
@@ -76,6 +111,7 @@ export class GameClient {
       this._wsReady = true;
       this.ui.setConnectionStatus(true);
       console.log('WebSocket connected');
+      this._checkClientVersion();
     });
 
     this._ws.addEventListener('message', (ev) => {
@@ -86,8 +122,10 @@ export class GameClient {
       }
     });
 
-    this._ws.addEventListener('close', () => {
+    this._ws.addEventListener('close', (ev) => {
       this._wsReady = false;
+      // 41xx close codes (kicked, banned) are permanent — don't reconnect
+      if (isPermanentClose(ev.code)) this._intentionallyClosed = true;
       if (!this._intentionallyClosed) {
         this.ui.setConnectionStatus(false);
         this._scheduleReconnect();
@@ -99,11 +137,26 @@ export class GameClient {
     });
   }
 
+  // After a (re)connect, compare the build this page was served with against
+  // the server's; a deploy in between gets a refresh prompt instead of
+  // protocol errors. Unbundled dev servers have no version to compare.
+  async _checkClientVersion() {
+    const mine = document.querySelector('meta[name="slether-version"]')?.content;
+    if (!mine) return;
+    try {
+      const res = await fetch('/api/client-version', { cache: 'no-store' });
+      const { version } = await res.json();
+      if (version && version !== mine) this.ui.showUpdatePrompt();
+    } catch (e) {
+      console.warn('client version check failed:', e);
+    }
+  }
+
   _scheduleReconnect() {
     if (this._reconnectTimer) return;
     this._reconnectTimer = setTimeout(() => {
       this._reconnectTimer = null;
-      this._connect();
+      this._connect(true);
     }, RECONNECT_DELAY_MS);
   }
 
@@ -118,46 +171,151 @@ export class GameClient {
   _handleMessage(msg) {
     // Feature 7: Binary protocol — use single-char keys (msg.t = type)
     switch (msg.t) {
-      case 'w':
+      case MsgWelcome:
         this._onWelcome(msg);
         break;
-      case 's':
+      case MsgState:
         this._onState(msg);
         break;
-      case 'd':
+      case MsgDeath:
         this._onDeath(msg);
         break;
-      case 'e':
+      case MsgError:
         this._onError(msg);
         break;
+      case MsgMap:
+        this._onMap(msg);
+        break;
+      case MsgKill:
+        // Kill feed: k=killer, v=victim, a=assist, n=streak, m=milestone, x=shutdown streak, p=bonus, u=mutual
+        this.ui.addKillFeed({
+          killer: msg.k, victim: msg.v, assist: msg.a || '', streak: msg.n,
+          milestone: msg.m === 1, shutdown: msg.x || 0, bonus: msg.p || 0,
+          mutual: msg.u === 1,
+        });
+        break;
+      case MsgAnnounce:
+        // Banner: m=text, s=severity (info|warn|alert), d=duration ms
+        this.ui.showAnnouncement(msg.m, msg.s, msg.d, this.lang);
+        break;
+      case MsgShutdown:
+        // s = seconds until the server closes the connection (4002, then we reconnect)
+        this.ui.showShutdown(msg.s);
+        break;
+      case MsgStats:
+        // Own HUD stats, ~1 Hz: p=score, r/n=rank of alive, l=length, k=kills, f=effect bits
+        this.ui.updateScore(msg.p);
+        this.ui.updateStats({
+          rank: msg.r, of: msg.n, length: msg.l, kills: msg.k,
+          comeback: (msg.f & EFFECT_COMEBACK) !== 0,
+        });
+        break;
+      case MsgMOTD:
+        // Operator MOTD: n=name, g=region, o=mode, m=text, l=[{n:label, u:url}]
+        this.ui.showMOTD({
+          name: msg.n || '', region: msg.g || '', mode: msg.o || '', text: msg.m || '',
+          links: (msg.l || []).map(l => ({ label: l.n, url: l.u })),
+        });
+        break;
+      case MsgObjective:
+        // Golden apple: s=1 out at x,y (also sent on join); s=0 eaten by n for p score
+        if (msg.s === 1) {
+          this.renderer.setObjective({ x: msg.x, y: msg.y });
+          this.ui.showAnnouncement('A golden apple has appeared — check the minimap!', 'info', 5000);
+        } else {
+          this.renderer.setObjective(null);
+          if (msg.n) this.ui.showAnnouncement(`${msg.n} ate the golden apple (+${msg.p || 0})`, 'info', 5000);
+        }
+        break;
+      case MsgRecords:
+        // Persistent high scores: a=all-time, d=today (UTC), each [{n:name, p:score}]
+        this.ui.showRecords({
+          allTime: msg.a.map(e => ({ name: e.n, score: e.p })),
+          today: msg.d.map(e => ({ name: e.n, score: e.p })),
+        });
+        break;
+      case MsgEcho:
+        // Input applied this tick: k=tick, q=our sequence number, a=heading after clamp, b=boost
+        this._echoes.push({ tick: msg.k, seq: msg.q, heading: msg.a, boost: msg.b === 1, sent: this._inputSeq });
+        if (this._echoes.length > ECHO_KEEP) this._echoes.shift();
+        break;
+      case MsgAte:
+        // Food value eaten this tick — cosmetic score pop
+        this.ui.showScorePop(msg.v);
+        break;
       default:
         console.warn('Unknown message type:', msg.t);
     }
   }
 
+  /** @param {import('./protocol').WelcomeMsg} msg */
   _onWelcome(msg) {
-    // Feature 7: msg.i=id, msg.r=worldRadius, msg.c=color
-    this.myId = msg.i;
+    // Feature 7: msg.i=session id, msg.e=entity id, msg.r=worldRadius, msg.c=color
+    // State messages identify snakes by compact entity id, not the session UUID
+    this.sessionId = msg.i;
+    this._view = null;  // new connection: the server starts over with a keyframe
+    this.myId = this.ownId = msg.e;
     this.worldRadius = msg.r || 10500;
     this.renderer.setWorldRadius(this.worldRadius);
+    // msg.h = server tick rate (Hz): interpolate over one tick, send input once per tick
+    this.tickMs = msg.h ? 1000 / msg.h : DEFAULT_TICK_MS;
+    this.input.setSendInterval(this.tickMs);
+    // msg.u = server physics (speeds, turn rate, radii) for prediction and rendering
+    this.rules = msg.u || null;
+    // rb/rm = food within rb px of the boundary is worth rm times as much
+    const band = this.rules && this.rules.rm ? { width: this.rules.rb, multiplier: this.rules.rm } : null;
+    this.renderer.setRiskBand(band);
+    // cs/cf = comeback speed and food bonuses for snakes below the median length
+    const comeback = this.rules && (this.rules.cs || this.rules.cf)
+      ? { speed: this.rules.cs || 0, food: this.rules.cf || 0 } : null;
+    this.ui.showRules(band, comeback);
+    // msg.v = captcha widget to complete before joining, if the server requires one
+    this.ui.showCaptcha(msg.v || null);
+    // msg.l = the world's community language, for the MOTD and server announcements
+    this.lang = msg.l || '';
+    this.ui.setCommunityLang(this.lang);
+    // A fresh session learns about any golden apple from its own join message
+    this.renderer.setObjective(null);
     console.log('Connected as', this.myId);
   }
 
+  /** @param {import('./protocol').MapMsg} msg */
+  _onMap(msg) {
+    // Static layout, sent once after welcome: r=boundary radius, o/z/p=obstacles/zones/portals
+    this.worldRadius = msg.r || this.worldRadius;
+    this.renderer.setWorldRadius(this.worldRadius);
+    // m = a danger zone's score multiplier
+    const features = (list) => (list || []).map(f => ({
+      x: f.x, y: f.y, r: f.r, tx: f.tx, ty: f.ty, m: f.m,
+    }));
+    this.renderer.setMapFeatures({
+      obstacles: features(msg.o),
+      zones: features(msg.z),
+      portals: features(msg.p),
+    });
+  }
+
+  /** @param {import('./protocol').StateMsg} msg */
   _onState(msg) {
     // Feature 7: msg.s=snakes, msg.f=food, msg.l=leaderboard
+    const view = this._applyView(msg);
     // Snake segments arrive as [[x,y],[x,y]] arrays — convert to {x,y} objects
-    const snakes = (msg.s || []).map(s => ({
+    const snakes = view.snakes.map(s => ({
       id: s.i,
       name: s.n,
+      flag: countryFlag(s.f),  // opted-in country flag
       color: s.c,
       score: s.p,
       boosting: s.b === 1,
       width: s.w || 10,
+      layer: s.y || 0,   // phase layer: 0=normal, 1=tiny, 2=giant
+      hit: s.h === 1,    // invulnerable after a damage-model hit
+      crown: s.k === 1,  // ate the golden apple recently
       segments: (s.s || []).map(seg => ({ x: seg[0], y: seg[1] })),
     }));
 
-    // Food: f.l=level, f.m=isMoving
-    const food = (msg.f || []).map(f => ({
+    // Food: f.l=level, f.m=isMoving, f.n=new
+    const food = view.food.map(f => ({
       id: f.i,
       x: f.x,
       y: f.y,
@@ -165,14 +323,33 @@ export class GameClient {
       color: f.c,
       level: f.l || 1,
       isMoving: f.m === 1,
+      isNew: f.n === 1,   // spawned since our last state — play pop-in
     }));
 
-    // Leaderboard: e.i=id, e.n=name, e.p=score
-    const leaderboard = (msg.l || []).map(e => ({
+    // Food blobs: merged piles far from us — b.v=combined value, b.n=item count
+    const blobs = view.blobs.map(b => ({
+      x: b.x,
+      y: b.y,
+      value: b.v,
+      color: b.c,
+      level: b.l || 1,
+      count: b.n || 1,
+    }));
+
+    // Leaderboard: e.i=id, e.n=name, e.p=score, e.r=rating (ranked worlds), e.f=country.
+    // null between updates when the server sends it less often than every tick
+    const boardEntry = e => ({
       id: e.i,
       name: e.n,
+      flag: countryFlag(e.f),
       score: e.p,
-    }));
+      rating: e.r || 0,
+    });
+    const leaderboard = msg.l ? msg.l.map(boardEntry)
+      : (this._currState ? this._currState.leaderboard : []);
+    // msg.u = humans-only board, sent with l while bots are on it
+    const humansBoard = msg.l ? (msg.u ? msg.u.map(boardEntry) : null)
+      : (this._currState ? this._currState.humansBoard : null);
 
     // Minimap snakes: downsampled segments + color + width (only visible-size snakes)
     const minimap = (msg.m || []).map(d => ({
@@ -181,8 +358,13 @@ export class GameClient {
       width: d.w || 10,
     }));
 
+    // Our own segments still to be added server-side, one per tick
+    const pendingGrowth = msg.g || 0;
+    // msg.y = extra snake the server follows once our own has died (multi-snake modes)
+    this.myId = msg.y || this.ownId;
+
     this._prevState = this._currState;
-    this._currState = { snakes, food, leaderboard, minimap };
+    this._currState = { snakes, food, blobs, leaderboard, humansBoard, minimap, pendingGrowth };
     this._lastStateTime = performance.now();
 
     // Attach color from snake data into leaderboard entries
@@ -190,11 +372,12 @@ export class GameClient {
     for (const s of snakes) {
       snakeColorMap[s.id] = s.color;
     }
-    const lbWithColor = leaderboard.map(e => ({
+    const withColor = e => ({
       ...e,
       color: snakeColorMap[e.id] || '#888',
-    }));
-    this.ui.updateLeaderboard(lbWithColor, this.myId);
+    });
+    this.ui.updateLeaderboard(leaderboard.map(withColor), this.myId,
+      humansBoard ? humansBoard.map(withColor) : null);
 
     // Update score display
     if (this.myId && this.alive) {
@@ -212,15 +395,51 @@ export class GameClient {
     }
   }
 
+  /**
+   * Rebuilds the raw snakes, food and blobs in view from a state message.
+   * A keyframe carries all of them. A delta (msg.d=1) lists every snake,
+   * either in full or as a delta entry (a = the head, then newly laid segments; z = length) to
+   * apply to the body we hold; food only when it entered or moved, with
+   * msg.r = food IDs gone; and blobs only when they changed.
+   * @param {import('./protocol').StateMsg} msg
+   */
+  _applyView(msg) {
+    const prev = this._view;
+    const delta = msg.d === 1 && prev !== null;
+    const snakes = (msg.s || []).map(s => {
+      const held = delta && !s.s ? prev.snakes.get(s.i) : null;
+      if (!held) return s;
+      return { ...s, n: held.n, f: held.f, c: held.c, s: s.a.concat(held.s.slice(1)).slice(0, s.z) };
+    });
+
+    // Food is stored without its pop-in flag so it only animates once
+    const food = delta ? prev.food : new Map();
+    const fresh = new Map((msg.f || []).map(f => [f.i, f]));
+    for (const id of msg.r || []) food.delete(id);
+    for (const f of fresh.values()) food.set(f.i, f.n ? { ...f, n: 0 } : f);
+
+    const blobs = delta && !msg.b ? prev.blobs : (msg.b || []);
+    this._view = { snakes: new Map(snakes.map(s => [s.i, s])), food, blobs };
+    return {
+      snakes,
+      food: Array.from(food.values(), f => fresh.get(f.i) || f),
+      blobs,
+    };
+  }
+
+  /** @param {import('./protocol').DeathMsg} msg */
   _onDeath(msg) {
-    // Feature 7: msg.k=killer, msg.p=score
+    // Feature 7: msg.k=killer, msg.p=score, msg.m=1 if the killer died to us too
     this.alive = false;
-    this.ui.showDeathScreen(msg.p, msg.k);
+    this.ui.showDeathScreen(msg.p, msg.k, msg.m === 1);
   }
 
+  /** @param {import('./protocol').ErrorMsg} msg */
   _onError(msg) {
-    // Server rejected connection (rate limit, full, etc)
-    this._intentionallyClosed = true; // don't auto-reconnect
+    // Server rejected or dropped the connection; c = close code that follows.
+    // 40xx (full, rate limited, maintenance) may be retried automatically;
+    // permanent or uncoded rejections stop the reconnect loop.
+    if (!msg.c || isPermanentClose(msg.c)) this._intentionallyClosed = true;
     this.ui.showError(msg.m);
   }
 
@@ -228,38 +447,40 @@ export class GameClient {
 
   _bindEvents() {
     // UI callbacks
-    this.ui.onJoin((name) => {
+    this.ui.onJoin((name, pin, captcha, flag) => {
       this.playerName = name;
       this.alive = true;
       this._prevState = null;
       this._currState = null;
       this.ui.showGame();
-      // Feature 7: join uses {t:"j", n:name}
-      this._send({ t: 'j', n: name });
+      // Feature 7: join uses {t:"j", n:name}; k = optional profile PIN, c = captcha token,
+      // f = 1 to show our country flag
+      this._send({ t: MsgJoin, n: name, k: pin || undefined, c: captcha || undefined, f: flag ? 1 : undefined });
     });
 
-    this.ui.onRespawn((name) => {
+    this.ui.onRespawn((name, pin, flag) => {
       this.playerName = name;
       this.alive = true;
       this._prevState = null;
       this._currState = null;
       this.ui.showGame();
-      // Feature 7: respawn uses {t:"r", n:name}
-      this._send({ t: 'r', n: name });
+      // Feature 7: respawn uses {t:"r", n:name}; k = optional profile PIN
+      this._send({ t: MsgRespawn, n: name, k: pin || undefined, f: flag ? 1 : undefined });
     });
 
     // Input → server
     this.input.onInput(({ angle, boost }) => {
       if (this.alive && this._wsReady) {
-        // Feature 7: input uses {t:"i", a:angle, b:boost?1:0}
-        this._send({ t: 'i', a: angle, b: boost ? 1 : 0 });
+        // Feature 7: input uses {t:"i", a:angle, b:boost?1:0, q:sequence number}
+        this._inputSeq = (this._inputSeq + 1) >>> 0 || 1; // uint32, 0 means unnumbered
+        this._send({ t: MsgInput, a: angle, b: boost ? 1 : 0, q: this._inputSeq });
       }
     });
 
     // Retry after rate limit countdown
     window.addEventListener('slether-retry', () => {
       this._intentionallyClosed = false;
-      this._connect();
+      this._connect(true);
     });
 
     // Canvas resize
@@ -281,21 +502,22 @@ export class GameClient {
 
       // Compute interpolation alpha between prev and curr server states
       const timeSinceState = now - this._lastStateTime;
-      const alpha = Math.min(1, timeSinceState / SERVER_TICK_MS);
+      const alpha = Math.min(1, timeSinceState / this.tickMs);
 
       // Build render state — interpolate snakes
       const renderState = {
         prev: this._prevState ? this._prevState.snakes : null,
         curr: this._currState ? this._currState.snakes : [],
         food: this._currState ? this._currState.food : [],
+        blobs: this._currState ? this._currState.blobs : [],
         minimap: this._currState ? this._currState.minimap : [],
       };
 
       this.renderer.render(renderState, this.myId, alpha, now);
 
-      // Throttled input tick (ensures 20Hz even without mouse move)
+      // Throttled input tick (once per server tick even without mouse move)
       this._inputAccum += dt;
-      if (this._inputAccum >= INPUT_HZ_MS) {
+      if (this._inputAccum >= this.tickMs) {
         this._inputAccum = 0;
         this.input.tick();
       }
@@ -5,6 +5,14 @@ const SEGMENT_RADIUS = 10;      // body segment draw radius
 const HEAD_RADIUS = 10;         // head draw radius (same as body)
 const MINIMAP_SIZE = 160;       // minimap is a circle with this diameter
 const MINIMAP_MARGIN = 16;
+const PHASED_ALPHA = 0.35;      // opacity for snakes in a non-interacting phase layer
+const HIT_BLINK_HZ = 8;         // flicker rate for snakes invulnerable after a hit
+const APPLE_RADIUS = 18;        // golden apple draw radius (matches the server's reach)
+const APPLE_GOLD = '#ffd700';
+
+// Phase layers (server y field): tiny (1) and giant (2) pass through each other
+const LAYER_TINY = 1;
+const LAYER_GIANT = 2;
 
 // Food level → base radius mapping
 const FOOD_RADIUS = { 1: 4, 3: 7, 5: 10, 10: 14 };
@@ -19,6 +27,9 @@ const FOOD_PULSE = {
 // Max trail history kept per moving food id
 const TRAIL_LENGTH = 4;
 
+// Pop-in animation length for food the server flags as newly spawned
+const FOOD_POP_MS = 250;
+
 export class GameRenderer {
   constructor(canvas, camera) {
     this.canvas = canvas;
@@ -27,6 +38,16 @@ export class GameRenderer {
     // Feature 1: Circular world — center=(worldRadius, worldRadius)
     this.worldRadius = 10500;
 
+    // Static map layout from the server's one-time map message
+    this.mapFeatures = { obstacles: [], zones: [], portals: [] };
+    // Risk band from welcome rules: { width, multiplier } or null
+    this.riskBand = null;
+    // Golden apple objective, announced by the server wherever it is
+    this.objective = null;
+
+    // Pop-in start time per newly spawned food id: Map<foodId, ms>
+    this._foodPopStart = new Map();
+
     // Feature 6: Track previous positions of moving food for trail rendering
     // Map<foodId, Array<{x,y}>>
     this._movingFoodTrails = new Map();
@@ -37,6 +58,20 @@ export class GameRenderer {
     this.worldRadius = r;
   }
 
+  setMapFeatures(features) {
+    this.mapFeatures = features;
+  }
+
+  // Band inside the boundary where food is worth more, or null when off
+  setRiskBand(band) {
+    this.riskBand = band;
+  }
+
+  // Golden apple position {x, y}, or null when none is out
+  setObjective(apple) {
+    this.objective = apple;
+  }
+
   // Main render entry called every frame
   // now = performance.now() timestamp for neon pulse animation
   render(state, myId, alpha, now = 0) {
@@ -50,9 +85,13 @@ export class GameRenderer {
     ctx.fillRect(0, 0, W, H);
 
     this._drawGrid();
+    this._drawMapFeatures();
+    this._drawRiskBand();
     this._drawHazardZone();          // Feature 1: fading red ring hazard zone
     this._drawWorldBoundary();       // Feature 1: circular boundary
+    this._drawFoodBlobs(state.blobs);
     this._drawFood(state.food, now); // Feature 3 & 6: multi-size + neon blink + trail
+    this._drawObjective();
     this._drawSnakes(state.prev, state.curr, myId, alpha);
     this._drawMinimap(state.minimap || [], myId);
   }
@@ -93,6 +132,72 @@ export class GameRenderer {
     ctx.restore();
   }
 
+  // ── Map features: zones, obstacles, portals (static, from map message) ──
+
+  _drawMapFeatures() {
+    const { obstacles, zones, portals } = this.mapFeatures;
+    if (!obstacles.length && !zones.length && !portals.length) return;
+    const ctx = this.ctx;
+    const cam = this.camera;
+    const scale = cam.width / cam.viewW;
+    const circle = (f) => {
+      const p = cam.worldToScreen(f.x, f.y);
+      ctx.beginPath();
+      ctx.arc(p.x, p.y, f.r * scale, 0, Math.PI * 2);
+    };
+
+    ctx.save();
+    ctx.fillStyle = 'rgba(80,160,255,0.06)';
+    for (const z of zones) { if (!z.m) { circle(z); ctx.fill(); } }
+    // Danger zones (m = score multiplier) are amber and labelled
+    ctx.fillStyle = 'rgba(255,190,60,0.07)';
+    ctx.strokeStyle = 'rgba(255,190,60,0.35)';
+    ctx.lineWidth = 2;
+    ctx.setLineDash([12, 10]);
+    for (const z of zones) { if (z.m) { circle(z); ctx.fill(); ctx.stroke(); } }
+    ctx.setLineDash([]);
+    ctx.fillStyle = 'rgba(255,190,60,0.5)';
+    ctx.font = 'bold 20px sans-serif';
+    ctx.textAlign = 'center';
+    ctx.textBaseline = 'middle';
+    for (const z of zones) {
+      if (!z.m) continue;
+      const p = cam.worldToScreen(z.x, z.y);
+      ctx.fillText(`×${z.m}`, p.x, p.y);
+    }
+    ctx.fillStyle = '#1c1c2c';
+    ctx.strokeStyle = 'rgba(255,255,255,0.15)';
+    ctx.lineWidth = 2;
+    for (const o of obstacles) { circle(o); ctx.fill(); ctx.stroke(); }
+    ctx.strokeStyle = 'rgba(190,110,255,0.7)';
+    ctx.lineWidth = 3;
+    for (const p of portals) { circle(p); ctx.stroke(); }
+    ctx.restore();
+  }
+
+  // ── Risk band: dashed amber ring where food starts paying extra ──────────
+
+  _drawRiskBand() {
+    const band = this.riskBand;
+    if (!band) return;
+    const ctx = this.ctx;
+    const cam = this.camera;
+    const r = this.worldRadius;
+    const center = cam.worldToScreen(r, r);
+    const scale = cam.width / cam.viewW;
+    const innerR = (r - band.width) * scale;
+    if (innerR <= 0) return;
+
+    ctx.save();
+    ctx.strokeStyle = 'rgba(255,190,60,0.3)';
+    ctx.lineWidth = 2;
+    ctx.setLineDash([16, 14]);
+    ctx.beginPath();
+    ctx.arc(center.x, center.y, innerR, 0, Math.PI * 2);
+    ctx.stroke();
+    ctx.restore();
+  }
+
   // ── Feature 1: Hazard zone — fading red ring inside boundary edge ─────────
 
   _drawHazardZone() {
@@ -163,6 +268,7 @@ export class GameRenderer {
 
     // Feature 6: Update trails for moving food before rendering
     this._updateMovingFoodTrails(foodList);
+    this._updateFoodPops(foodList, now);
 
     ctx.save();
     for (const food of foodList) {
@@ -192,8 +298,9 @@ export class GameRenderer {
       ctx.shadowColor = color;
       ctx.shadowBlur = currentBlur;
 
+      const radius = baseRadius * this._foodPopScale(food.id, now);
       ctx.beginPath();
-      ctx.arc(s.x, s.y, baseRadius, 0, Math.PI * 2);
+      ctx.arc(s.x, s.y, radius, 0, Math.PI * 2);
       ctx.fillStyle = color;
       ctx.fill();
 
@@ -203,7 +310,7 @@ export class GameRenderer {
         ctx.shadowBlur = 0;
         ctx.fillStyle = '#ffffff';
         ctx.beginPath();
-        ctx.arc(s.x, s.y, baseRadius * 0.35, 0, Math.PI * 2);
+        ctx.arc(s.x, s.y, radius * 0.35, 0, Math.PI * 2);
         ctx.fill();
       }
 
@@ -213,6 +320,50 @@ export class GameRenderer {
     ctx.restore();
   }
 
+  // Start pop-in for food flagged new and forget finished animations
+  _updateFoodPops(foodList, now) {
+    for (const food of foodList) {
+      if (food.isNew && !this._foodPopStart.has(food.id)) {
+        this._foodPopStart.set(food.id, now);
+      }
+    }
+    for (const [id, start] of this._foodPopStart) {
+      if (now - start >= FOOD_POP_MS) this._foodPopStart.delete(id);
+    }
+  }
+
+  // Radius multiplier for a popping food: overshoots slightly, settles at 1
+  _foodPopScale(id, now) {
+    const start = this._foodPopStart.get(id);
+    if (start === undefined) return 1;
+    const t = Math.min(1, (now - start) / FOOD_POP_MS);
+    const c = 1.7;
+    return 1 + (c + 1) * Math.pow(t - 1, 3) + c * Math.pow(t - 1, 2); // easeOutBack
+  }
+
+  // Aggregated food piles far from the player — one soft glowing disc per blob,
+  // sized by combined value. Server splits them back into items as we approach.
+  _drawFoodBlobs(blobs) {
+    if (!blobs || blobs.length === 0) return;
+    const ctx = this.ctx;
+    const cam = this.camera;
+    ctx.save();
+    for (const blob of blobs) {
+      const radius = Math.min(40, 6 + Math.sqrt(blob.value) * 2);
+      if (!cam.isVisible(blob.x, blob.y, radius + 40)) continue;
+      const s = cam.worldToScreen(blob.x, blob.y);
+      const color = blob.color || '#ff6b6b';
+      ctx.globalAlpha = 0.85;
+      ctx.shadowColor = color;
+      ctx.shadowBlur = radius;
+      ctx.fillStyle = color;
+      ctx.beginPath();
+      ctx.arc(s.x, s.y, radius, 0, Math.PI * 2);
+      ctx.fill();
+    }
+    ctx.restore();
+  }
+
   // Returns a stable phase offset in radians derived from food id or position
   _foodPhaseOffset(food) {
     if (food.id) {
@@ -275,6 +426,35 @@ export class GameRenderer {
     ctx.shadowBlur = 0;
   }
 
+  // ── Golden apple ──────────────────────────────────────────────────────────
+
+  _drawObjective() {
+    const apple = this.objective;
+    if (!apple || !this.camera.isVisible(apple.x, apple.y, APPLE_RADIUS + 30)) return;
+    const ctx = this.ctx;
+    const s = this.camera.worldToScreen(apple.x, apple.y);
+    const pulse = (Math.sin((this._now || 0) / 250) + 1) * 0.5;
+
+    ctx.save();
+    ctx.shadowColor = APPLE_GOLD;
+    ctx.shadowBlur = 20 + pulse * 20;
+    ctx.fillStyle = APPLE_GOLD;
+    ctx.beginPath();
+    ctx.arc(s.x, s.y, APPLE_RADIUS, 0, Math.PI * 2);
+    ctx.fill();
+    // Shine and leaf
+    ctx.shadowBlur = 0;
+    ctx.fillStyle = 'rgba(255,255,255,0.45)';
+    ctx.beginPath();
+    ctx.arc(s.x - APPLE_RADIUS * 0.35, s.y - APPLE_RADIUS * 0.35, APPLE_RADIUS * 0.3, 0, Math.PI * 2);
+    ctx.fill();
+    ctx.fillStyle = '#6bcb77';
+    ctx.beginPath();
+    ctx.ellipse(s.x + 5, s.y - APPLE_RADIUS - 3, 7, 3.5, -0.5, 0, Math.PI * 2);
+    ctx.fill();
+    ctx.restore();
+  }
+
   // ── Snakes ────────────────────────────────────────────────────────────────
 
   _drawSnakes(prevSnakes, currSnakes, myId, alpha) {
@@ -291,13 +471,19 @@ export class GameRenderer {
     const me = currSnakes.find(s => s.id === myId);
 
     for (const snake of others) {
-      this._drawSnake(snake, prevMap[snake.id], alpha, false);
+      const phased = me && this._isPhased(me.layer, snake.layer);
+      this._drawSnake(snake, prevMap[snake.id], alpha, false, phased);
     }
     if (me) {
       this._drawSnake(me, prevMap[me.id], alpha, true);
     }
   }
 
+  // True when two phase layers can't collide (tiny vs giant)
+  _isPhased(a, b) {
+    return (a === LAYER_TINY && b === LAYER_GIANT) || (a === LAYER_GIANT && b === LAYER_TINY);
+  }
+
   _lerpSegments(prev, curr, alpha) {
     if (!prev || !prev.segments || prev.segments.length === 0) return curr.segments;
     const out = [];
@@ -315,7 +501,7 @@ export class GameRenderer {
     return out;
   }
 
-  _drawSnake(snake, prev, alpha, isMe) {
+  _drawSnake(snake, prev, alpha, isMe, phased = false) {
     const cam = this.camera;
     const segments = this._lerpSegments(prev, snake, alpha);
     if (!segments || segments.length === 0) return;
@@ -341,6 +527,12 @@ export class GameRenderer {
     if (!anyVisible) return;
 
     ctx.save();
+    // Snakes we can't collide with are drawn translucent
+    if (phased) ctx.globalAlpha = PHASED_ALPHA;
+    // Recently hit (damage model): flicker while invulnerable
+    if (snake.hit && Math.sin(this._now / 1000 * HIT_BLINK_HZ * Math.PI * 2) < 0) {
+      ctx.globalAlpha *= 0.4;
+    }
 
     // Pass 1: If boosting, draw glow layer FIRST (behind everything)
     if (boosting) {
@@ -402,8 +594,25 @@ export class GameRenderer {
       }
     }
 
+    // Golden apple crown: pulsing gold aura around the head
+    if (snake.crown) {
+      const h = cam.worldToScreen(segments[0].x, segments[0].y);
+      const pulse = (Math.sin((this._now || 0) / 200) + 1) * 0.5;
+      ctx.save();
+      ctx.strokeStyle = APPLE_GOLD;
+      ctx.shadowColor = APPLE_GOLD;
+      ctx.shadowBlur = 18;
+      ctx.lineWidth = 3;
+      ctx.globalAlpha *= 0.6 + pulse * 0.4;
+      ctx.beginPath();
+      ctx.arc(h.x, h.y, r + 6 + pulse * 4, 0, Math.PI * 2);
+      ctx.stroke();
+      ctx.restore();
+    }
+
     // Draw head (same width as body)
-    this._drawHead(ctx, cam, segments, color, isMe, snake.name, boosting, r);
+    const label = snake.flag ? `${snake.flag} ${snake.name}` : snake.name;
+    this._drawHead(ctx, cam, segments, color, isMe, label, boosting, r);
 
     ctx.restore();
   }
@@ -541,6 +750,18 @@ export class GameRenderer {
       }
     }
 
+    // Golden apple — pulsing gold dot wherever it is
+    if (this.objective) {
+      const pulse = (Math.sin((this._now || 0) / 250) + 1) * 0.5;
+      ctx.beginPath();
+      ctx.arc(cx + (this.objective.x - worldR) * scale, cy + (this.objective.y - worldR) * scale, 3 + pulse * 2, 0, Math.PI * 2);
+      ctx.fillStyle = APPLE_GOLD;
+      ctx.shadowColor = APPLE_GOLD;
+      ctx.shadowBlur = 8;
+      ctx.fill();
+      ctx.shadowBlur = 0;
+    }
+
     // Player position indicator — white ring at camera center (player head)
     const camX = this.camera.x;
     const camY = this.camera.y;
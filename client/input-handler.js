@@ -9,7 +9,7 @@ export class InputHandler {
     this.mouseY = 0;
     this._sendCallback = null;
     this._lastSendTime = 0;
-    this._sendIntervalMs = 50; // 20 Hz
+    this._sendIntervalMs = 50; // server tick; see setSendInterval
     this._bound = {};
 
     // Feature 2: Touch indicator element for mobile
@@ -18,7 +18,7 @@ export class InputHandler {
     this._attach();
   }
 
-  // Register a callback that receives { angle, boost } at 20Hz
+  // Register a callback that receives { angle, boost } once per server tick
   onInput(fn) {
     this._sendCallback = fn;
   }
@@ -132,7 +132,12 @@ export class InputHandler {
     }
   }
 
-  // Tick called from game loop to ensure we always send at ~20Hz even without mouse movement
+  // Match the input send rate to the server tick advertised in welcome
+  setSendInterval(ms) {
+    this._sendIntervalMs = ms;
+  }
+
+  // Tick called from game loop to ensure we send once per server tick even without mouse movement
   tick() {
     this._trySend();
   }
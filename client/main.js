@@ -0,0 +1,7 @@
+// main.js — bootstrap (kept out of index.html so the CSP can forbid inline scripts)
+
+import { GameClient } from './game-client.js';
+
+const game = new GameClient();
+game.start();
+window._game = game; // expose for debugging
@@ -0,0 +1,32 @@
+// Code generated by server/cmd/protogen from server/protocol.go. DO NOT EDIT.
+
+// Message type identifiers — value of the "t" field
+export const MsgJoin = 'j';
+export const MsgInput = 'i';
+export const MsgRespawn = 'r';
+export const MsgWelcome = 'w';
+export const MsgState = 's';
+export const MsgDeath = 'd';
+export const MsgError = 'e';
+export const MsgMap = 'm';
+export const MsgAte = 'a';
+export const MsgKill = 'k';
+export const MsgAnnounce = 'n';
+export const MsgStats = 'p';
+export const MsgMOTD = 'o';
+export const MsgObjective = 'g';
+export const MsgShutdown = 'x';
+export const MsgRecords = 'h';
+export const MsgEcho = 'q';
+
+// WebSocket close codes the server disconnects with
+export const CloseServerFull = 4000;
+export const CloseRateLimited = 4001;
+export const CloseMaintenance = 4002;
+export const CloseIdle = 4003;
+export const CloseSlow = 4004;
+export const CloseCaptcha = 4005;
+export const CloseKicked = 4100;
+export const CloseBanned = 4101;
+export const CloseNoSuchRoom = 4102;
+export const CloseRoomClosed = 4103;
@@ -1,8 +1,24 @@
 // ui-manager.js — Join screen, death screen, leaderboard overlay, score display
 
+const KILL_FEED_MAX = 5; // visible kill feed lines
+
+// Captcha widget scripts, loaded only when the server requires a captcha
+const CAPTCHA_SCRIPTS = {
+  turnstile: 'https://challenges.cloudflare.com/turnstile/v0/api.js?render=explicit',
+  hcaptcha: 'https://js.hcaptcha.com/1/api.js?render=explicit',
+};
+
+// Wraps a player name in a bidi isolate so a right-to-left name can't
+// reorder the text around it
+function isolate(name) {
+  return `\u2068${name}\u2069`;
+}
+
 export class UIManager {
   constructor() {
     this.joinScreen = document.getElementById('joinScreen');
+    this._motdEl = document.getElementById('motd');
+    this._rulesEl = document.getElementById('rules');
     this.deathScreen = document.getElementById('deathScreen');
     this.leaderboard = document.getElementById('leaderboard');
     this.scoreDisplay = document.getElementById('scoreDisplay');
@@ -11,27 +27,44 @@ export class UIManager {
     this._canvas = document.getElementById('gameCanvas');
 
     this._nameInput = document.getElementById('nameInput');
+    this._pinInput = document.getElementById('pinInput');
+    this._flagInput = document.getElementById('flagInput');
     this._playBtn = document.getElementById('playBtn');
     this._respawnBtn = document.getElementById('respawnBtn');
     this._deathScoreEl = document.getElementById('deathScore');
     this._deathKillerEl = document.getElementById('deathKiller');
+    this._recordsEl = document.getElementById('records');
     this._scoreValueEl = document.getElementById('scoreValue');
+    this._statsLineEl = document.getElementById('statsLine');
     this._lbList = document.getElementById('lbList');
+    this._lbHumans = document.getElementById('lbHumans');
     this._connDot = document.getElementById('connDot');
     this._connLabel = document.getElementById('connLabel');
+    this._killFeed = document.getElementById('killFeed');
+    this._announcement = document.getElementById('announcement');
+    this._announceTimer = null;
+    this._shutdownTimer = null;
+    this._captchaEl = document.getElementById('captcha');
+    this._captchaRequired = false;
+    this._captcha = null;     // { api, widget } once rendered
+    this._captchaToken = '';
 
     this._onJoin = null;
     this._onRespawn = null;
 
     this._playBtn.addEventListener('click', () => this._handleJoin());
     this._respawnBtn.addEventListener('click', () => this._handleRespawn());
-    this._nameInput.addEventListener('keydown', (e) => {
-      if (e.key === 'Enter') this._handleJoin();
-    });
+    for (const input of [this._nameInput, this._pinInput]) {
+      input.addEventListener('keydown', (e) => {
+        if (e.key === 'Enter') this._handleJoin();
+      });
+    }
 
-    // Load saved name
+    // Load saved name and profile PIN
     const saved = localStorage.getItem('slether_name');
     if (saved) this._nameInput.value = saved;
+    this._pinInput.value = localStorage.getItem('slether_pin') || '';
+    this._flagInput.checked = localStorage.getItem('slether_flag') === '1';
   }
 
   // Callbacks
@@ -39,14 +72,28 @@ export class UIManager {
   onRespawn(fn) { this._onRespawn = fn; }
 
   _handleJoin() {
+    if (this._captchaRequired && !this._captchaToken) {
+      this._connLabel.textContent = 'Complete the check below to play';
+      return;
+    }
     const name = this._nameInput.value.trim() || 'Anonymous';
+    const pin = this._pinInput.value;
     localStorage.setItem('slether_name', name);
-    if (this._onJoin) this._onJoin(name);
+    if (pin) localStorage.setItem('slether_pin', pin);
+    else localStorage.removeItem('slether_pin');
+    const flag = this._flagInput.checked;
+    localStorage.setItem('slether_flag', flag ? '1' : '0');
+    const captcha = this._captchaToken;
+    // The server verifies once per connection and then only accepts small
+    // frames, so the token is sent with this join and never again
+    this._captchaToken = '';
+    this._captchaRequired = false;
+    if (this._onJoin) this._onJoin(name, pin, captcha, flag);
   }
 
   _handleRespawn() {
     const name = this._nameInput.value.trim() || 'Anonymous';
-    if (this._onRespawn) this._onRespawn(name);
+    if (this._onRespawn) this._onRespawn(name, this._pinInput.value, this._flagInput.checked);
   }
 
   showJoinScreen() {
@@ -59,10 +106,12 @@ export class UIManager {
     this._nameInput.focus();
   }
 
-  showDeathScreen(score, killerName) {
+  showDeathScreen(score, killerName, mutual = false) {
     this._deathScoreEl.textContent = score;
-    if (killerName) {
-      this._deathKillerEl.innerHTML = `Killed by <span>${this._escape(killerName)}</span>`;
+    if (killerName && mutual) {
+      this._deathKillerEl.innerHTML = `You and <span dir="auto">${this._escape(killerName)}</span> took each other out`;
+    } else if (killerName) {
+      this._deathKillerEl.innerHTML = `Killed by <span dir="auto">${this._escape(killerName)}</span>`;
     } else {
       this._deathKillerEl.textContent = 'You ran into a wall';
     }
@@ -86,9 +135,82 @@ export class UIManager {
     this._scoreValueEl.textContent = score;
   }
 
-  // leaderboardEntries: [{id, name, score, color}], myId: string
-  updateLeaderboard(entries, myId) {
-    this._lbList.innerHTML = '';
+  // Personal stats (~1 Hz): rank among alive snakes, length, kills this life,
+  // and whether the comeback bonus is on
+  updateStats({ rank, of, length, kills, comeback }) {
+    this._statsLineEl.textContent = `#${rank} of ${of} · length ${length} · ${kills} kills`
+      + (comeback ? ' · comeback' : '');
+  }
+
+  // Floating "+value" above the score box for food eaten this tick
+  showScorePop(value) {
+    if (this.scoreDisplay.classList.contains('hidden')) return;
+    const pop = document.createElement('span');
+    pop.className = 'score-pop';
+    pop.textContent = '+' + value;
+    pop.addEventListener('animationend', () => pop.remove());
+    this.scoreDisplay.appendChild(pop);
+  }
+
+  // Kill feed entry: {killer, victim, assist, streak, milestone, shutdown, bonus, mutual}
+  addKillFeed(entry) {
+    const li = document.createElement('li');
+    const killers = entry.assist
+      ? `${isolate(entry.killer)} + ${isolate(entry.assist)}`
+      : isolate(entry.killer);
+    let text = `${killers} ⚔ ${isolate(entry.victim)}`;
+    if (entry.mutual) {
+      text += ' — took each other out';
+      li.classList.add('mutual');
+    } else if (entry.shutdown) {
+      text += ` — shut down a ${entry.shutdown}-kill streak (+${entry.bonus})`;
+      li.classList.add('shutdown');
+    } else if (entry.milestone) {
+      text += ` — ${entry.streak} kill streak!`;
+      li.classList.add('milestone');
+    }
+    li.textContent = text;
+    li.addEventListener('animationend', () => li.remove());
+    this._killFeed.prepend(li);
+    while (this._killFeed.children.length > KILL_FEED_MAX) {
+      this._killFeed.lastChild.remove();
+    }
+  }
+
+  // Announcement banner; a new one replaces any still showing. lang tags
+  // server text with the world's community language for font selection.
+  showAnnouncement(text, severity, durationMs, lang = '') {
+    const el = this._announcement;
+    el.textContent = text;
+    el.lang = lang;
+    el.className = 'severity-' + (severity || 'info');
+    clearTimeout(this._announceTimer);
+    this._announceTimer = setTimeout(() => el.classList.add('hidden'), durationMs);
+  }
+
+  // Server shutdown warning: counts the seconds down in the banner
+  showShutdown(seconds) {
+    clearInterval(this._shutdownTimer);
+    const end = Date.now() + seconds * 1000;
+    const tick = () => {
+      const left = Math.max(0, Math.ceil((end - Date.now()) / 1000));
+      this.showAnnouncement(`Server shutting down in ${left}s`, 'alert', 1500);
+      if (left === 0) clearInterval(this._shutdownTimer);
+    };
+    tick();
+    this._shutdownTimer = setInterval(tick, 1000);
+  }
+
+  // leaderboardEntries: [{id, name, score, rating, color}], myId: string,
+  // humans: the humans-only board while bots are on the main one, else null
+  updateLeaderboard(entries, myId, humans = null) {
+    this._fillBoard(this._lbList, entries, myId);
+    this._lbHumans.classList.toggle('hidden', !humans);
+    if (humans) this._fillBoard(this._lbHumans.querySelector('ol'), humans, myId);
+  }
+
+  _fillBoard(list, entries, myId) {
+    list.innerHTML = '';
     entries.forEach((entry, i) => {
       const li = document.createElement('li');
       if (entry.id === myId) li.classList.add('is-me');
@@ -103,7 +225,14 @@ export class UIManager {
 
       const nameEl = document.createElement('span');
       nameEl.className = 'lb-name';
-      nameEl.textContent = entry.name;
+      nameEl.dir = 'auto';
+      nameEl.textContent = entry.flag ? `${entry.flag} ${entry.name}` : entry.name;
+      if (entry.rating) {
+        const ratingEl = document.createElement('span');
+        ratingEl.className = 'lb-rating';
+        ratingEl.textContent = entry.rating;
+        nameEl.appendChild(ratingEl);
+      }
 
       const scoreEl = document.createElement('span');
       scoreEl.className = 'lb-score';
@@ -113,10 +242,149 @@ export class UIManager {
       li.appendChild(dot);
       li.appendChild(nameEl);
       li.appendChild(scoreEl);
-      this._lbList.appendChild(li);
+      list.appendChild(li);
     });
   }
 
+  // A newer client was deployed than the one running — offer a reload
+  showUpdatePrompt() {
+    if (document.getElementById('updatePrompt')) return;
+    const bar = document.createElement('div');
+    bar.id = 'updatePrompt';
+    bar.textContent = 'A new version of the game is available. ';
+    const btn = document.createElement('button');
+    btn.className = 'btn btn-primary';
+    btn.textContent = 'Refresh';
+    btn.addEventListener('click', () => location.reload());
+    bar.appendChild(btn);
+    document.body.appendChild(bar);
+  }
+
+  // Tags the MOTD with the world's community language ('' = unknown)
+  setCommunityLang(lang) {
+    this._motdEl.lang = lang;
+  }
+
+  // Join-screen notes on the world's scoring rules from welcome: the risk
+  // band and the comeback bonus, each null when off
+  showRules(band, comeback) {
+    const pct = (f) => `${Math.round(f * 100)}%`;
+    const lines = [];
+    if (band) lines.push(`Food near the edge is worth ×${band.multiplier}.`);
+    if (comeback) {
+      const perks = [];
+      if (comeback.speed) perks.push(`move ${pct(comeback.speed)} faster`);
+      if (comeback.food) perks.push(`get ${pct(comeback.food)} more from food`);
+      lines.push(`Snakes in the shorter half ${perks.join(' and ')}.`);
+    }
+    this._rulesEl.textContent = lines.join(' ');
+    this._rulesEl.classList.toggle('hidden', lines.length === 0);
+  }
+
+  // Operator MOTD on the join screen; an all-empty message hides it
+  showMOTD({ name, region, mode, text, links }) {
+    const el = this._motdEl;
+    el.replaceChildren();
+    if (name) {
+      const h = document.createElement('div');
+      h.className = 'motd-name';
+      h.textContent = name;
+      el.appendChild(h);
+    }
+    const meta = [region, mode].filter(Boolean).join(' · ');
+    if (meta) {
+      const m = document.createElement('div');
+      m.className = 'motd-meta';
+      m.textContent = meta;
+      el.appendChild(m);
+    }
+    if (text) {
+      const p = document.createElement('p');
+      p.className = 'motd-text';
+      p.textContent = text;
+      el.appendChild(p);
+    }
+    if (links.length > 0) {
+      const nav = document.createElement('div');
+      nav.className = 'motd-links';
+      for (const link of links) {
+        const a = document.createElement('a');
+        a.href = link.url; // server only accepts http(s) URLs
+        a.target = '_blank';
+        a.rel = 'noopener noreferrer';
+        a.textContent = link.label;
+        nav.appendChild(a);
+      }
+      el.appendChild(nav);
+    }
+    el.classList.toggle('hidden', el.childElementCount === 0);
+  }
+
+  // Persistent high scores on the death screen: today's and all-time best,
+  // side by side; hidden until anyone has a record
+  showRecords({ allTime, today }) {
+    const el = this._recordsEl;
+    el.replaceChildren();
+    for (const [title, entries] of [['Today', today], ['All time', allTime]]) {
+      if (entries.length === 0) continue;
+      const col = document.createElement('div');
+      const h = document.createElement('div');
+      h.className = 'records-title';
+      h.textContent = title;
+      const ol = document.createElement('ol');
+      for (const entry of entries) {
+        const li = document.createElement('li');
+        const name = document.createElement('span');
+        name.className = 'records-name';
+        name.dir = 'auto';
+        name.textContent = entry.name;
+        const score = document.createElement('span');
+        score.className = 'records-score';
+        score.textContent = entry.score;
+        li.append(score, name); // score floats right
+        ol.appendChild(li);
+      }
+      col.append(h, ol);
+      el.appendChild(col);
+    }
+    el.classList.toggle('hidden', el.childElementCount === 0);
+  }
+
+  // Render the captcha widget the server asked for in welcome (p=provider,
+  // k=site key), or hide it when captcha is null. The token is used once, by
+  // the next join; a reconnect gets a fresh widget.
+  showCaptcha(captcha) {
+    this._captchaToken = '';
+    this._captchaRequired = !!captcha;
+    if (!captcha || !CAPTCHA_SCRIPTS[captcha.p]) {
+      this._captchaEl.classList.add('hidden');
+      return;
+    }
+    this._captchaEl.classList.remove('hidden');
+    const render = () => {
+      const api = window[captcha.p];
+      if (this._captcha) {
+        api.reset(this._captcha.widget);
+        return;
+      }
+      const widget = api.render(this._captchaEl, {
+        sitekey: captcha.k,
+        callback: (token) => { this._captchaToken = token; },
+        'expired-callback': () => { this._captchaToken = ''; },
+      });
+      this._captcha = { api, widget };
+    };
+    if (window[captcha.p]) {
+      render();
+      return;
+    }
+    const script = document.createElement('script');
+    script.src = CAPTCHA_SCRIPTS[captcha.p];
+    script.async = true;
+    script.onload = render;
+    document.head.appendChild(script);
+  }
+
   showError(message) {
     // Show error on join screen with countdown
     this.showJoinScreen();
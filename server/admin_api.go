@@ -0,0 +1,653 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// AdminAPI serves operator endpoints under AdminPathPrefix. Every request
+// needs the bearer token from SLETHER_ADMIN_TOKEN; with no token configured
+// the API is not mounted at all.
+//
+// Handlers run on HTTP goroutines: reads take World.rlock, mutations are
+// Posted as WorldCommands like any other off-loop change.
+//
+// Most endpoints act on one room, picked with ?room= (the default room when
+// absent, see inRoom). Sanctions, bans, kicks, announcements, the MOTD,
+// content packs and the audit log are deployment-wide. Tenant keys reach
+// the per-room endpoints of their own rooms only, and create and close
+// hosted rooms (see tenant_keys.go and hosted_rooms.go).
+type AdminAPI struct {
+	rooms *RoomManager
+	// world, conns and loop are the room a request acts on; the default
+	// room outside inRoom
+	world *World
+	conns *ConnManager
+	loop  *GameLoop
+	token string
+	audit *AuditLog
+	keys  *TenantKeys
+	// tenant is the key a request authenticated with, nil for the operator
+	tenant *TenantKey
+}
+
+// newAdminAPI returns nil when SLETHER_ADMIN_TOKEN is unset. Actions are
+// audited to SLETHER_AUDIT_LOG if set (JSON lines, append-only); a log path
+// that can't be opened is fatal rather than running the API unaudited.
+// Tenant keys and the rooms created with them are kept in
+// SLETHER_TENANT_KEYS if set; those rooms are recreated here.
+func newAdminAPI(rooms *RoomManager) *AdminAPI {
+	token := os.Getenv("SLETHER_ADMIN_TOKEN")
+	if token == "" {
+		log.Printf("admin API disabled (SLETHER_ADMIN_TOKEN not set)")
+		return nil
+	}
+	audit, err := openAuditLog(os.Getenv("SLETHER_AUDIT_LOG"))
+	if err != nil {
+		log.Fatalf("admin audit log: %v", err)
+	}
+	keys, err := loadTenantKeys(os.Getenv("SLETHER_TENANT_KEYS"))
+	if err != nil {
+		log.Fatalf("tenant keys: %v", err)
+	}
+	rooms.restoreHosted(keys)
+	main := rooms.Rooms()[0]
+	return &AdminAPI{rooms: rooms, world: main.World, conns: main.Conns, loop: main.Loop, token: token, audit: audit, keys: keys}
+}
+
+// register mounts the admin routes on mux
+func (a *AdminAPI) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+AdminPathPrefix+"feeding", a.roomAuth((*AdminAPI).handleFeeding))
+	mux.HandleFunc("GET "+AdminPathPrefix+"machines", a.roomAuth((*AdminAPI).handleMachines))
+	mux.HandleFunc("GET "+AdminPathPrefix+"audit", a.auth(a.handleAudit))
+	mux.HandleFunc("POST "+AdminPathPrefix+"announce", a.auth(a.handleAnnounce))
+	mux.HandleFunc("GET "+AdminPathPrefix+"rooms", a.tenantAuth((*AdminAPI).handleRooms))
+	mux.HandleFunc("POST "+AdminPathPrefix+"rooms", a.tenantAuth((*AdminAPI).handleCreateRoom))
+	mux.HandleFunc("DELETE "+AdminPathPrefix+"rooms/{name}", a.tenantAuth((*AdminAPI).handleDeleteRoom))
+	mux.HandleFunc("GET "+AdminPathPrefix+"players", a.roomAuth((*AdminAPI).handlePlayers))
+	mux.HandleFunc("GET "+AdminPathPrefix+"clients", a.roomAuth((*AdminAPI).handleClients))
+	mux.HandleFunc("GET "+AdminPathPrefix+"stats", a.roomAuth((*AdminAPI).handleStats))
+	mux.HandleFunc("GET "+AdminPathPrefix+"area", a.roomAuth((*AdminAPI).handleArea))
+	mux.HandleFunc("GET "+AdminPathPrefix+"bots", a.roomAuth((*AdminAPI).handleBots))
+	mux.HandleFunc("PUT "+AdminPathPrefix+"bots", a.roomAuth((*AdminAPI).handleSetBots))
+	mux.HandleFunc("GET "+AdminPathPrefix+"economy", a.roomAuth((*AdminAPI).handleEconomy))
+	mux.HandleFunc("GET "+AdminPathPrefix+"metrics", a.auth(a.inRoom((*AdminAPI).handleMetrics)))
+	mux.HandleFunc("GET "+AdminPathPrefix+"history", a.roomAuth((*AdminAPI).handleHistory))
+	mux.HandleFunc("GET "+AdminPathPrefix+"worldlog", a.roomAuth((*AdminAPI).handleWorldLog))
+	mux.HandleFunc("POST "+AdminPathPrefix+"worldlog/dump", a.auth(a.inRoom((*AdminAPI).handleDumpWorldLog)))
+	mux.HandleFunc("GET "+AdminPathPrefix+"sanctions", a.auth(a.handleListSanctions))
+	mux.HandleFunc("POST "+AdminPathPrefix+"sanctions", a.auth(a.handleSetSanction))
+	mux.HandleFunc("DELETE "+AdminPathPrefix+"sanctions/{network...}", a.auth(a.handleClearSanction))
+	mux.HandleFunc("POST "+AdminPathPrefix+"players/{id}/snakes", a.roomAuth((*AdminAPI).handleGrantSnake))
+	mux.HandleFunc("POST "+AdminPathPrefix+"players/{id}/kick", a.auth(a.handleKick))
+	mux.HandleFunc("GET "+AdminPathPrefix+"bans", a.auth(a.handleListBans))
+	mux.HandleFunc("POST "+AdminPathPrefix+"bans", a.auth(a.handleSetBan))
+	mux.HandleFunc("DELETE "+AdminPathPrefix+"bans/{network...}", a.auth(a.handleClearBan))
+	mux.HandleFunc("GET "+AdminPathPrefix+"motd", a.auth(a.handleGetMOTD))
+	mux.HandleFunc("PUT "+AdminPathPrefix+"motd", a.auth(a.handleSetMOTD))
+	mux.HandleFunc("GET "+AdminPathPrefix+"packs", a.auth(a.inRoom((*AdminAPI).handleListPacks)))
+	mux.HandleFunc("GET "+AdminPathPrefix+"packs/{id}", a.auth(a.handleGetPack))
+	mux.HandleFunc("POST "+AdminPathPrefix+"packs", a.auth(a.handleUploadPack))
+	mux.HandleFunc("POST "+AdminPathPrefix+"reset", a.roomAuth((*AdminAPI).handleReset))
+	mux.HandleFunc("GET "+AdminPathPrefix+"keys", a.auth(a.handleListKeys))
+	mux.HandleFunc("POST "+AdminPathPrefix+"keys", a.auth(a.handleIssueKey))
+	mux.HandleFunc("DELETE "+AdminPathPrefix+"keys/{id}", a.auth(a.handleRevokeKey))
+}
+
+// inRoom runs h on a copy of the API scoped to the room picked with the
+// ?room= query parameter
+func (a *AdminAPI) inRoom(h func(*AdminAPI, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		room, ok := a.room(r)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such room"})
+			return
+		}
+		scoped := *a
+		scoped.world, scoped.conns, scoped.loop = room.World, room.Conns, room.Loop
+		h(&scoped, w, r)
+	}
+}
+
+// adminRoom is one room in GET /admin/rooms
+type adminRoom struct {
+	Name     string `json:"name"`
+	Tenant   string `json:"tenant,omitempty"` // hosted rooms only
+	Players  int    `json:"players"`
+	Capacity int    `json:"capacity"`
+	Snakes   int    `json:"alive_snakes"`
+	Bots     int    `json:"bots"`
+	Tick     uint64 `json:"tick"`
+}
+
+// handleRooms lists the rooms with their player counts; pass a name as
+// ?room= to the per-room endpoints
+func (a *AdminAPI) handleRooms(w http.ResponseWriter, r *http.Request) {
+	all := a.rooms.Rooms()
+	rooms := make([]adminRoom, 0, len(all))
+	for _, room := range all {
+		if !a.owns(room) {
+			continue
+		}
+		snap := room.World.Snapshot()
+		rooms = append(rooms, adminRoom{
+			Name:     room.Name,
+			Tenant:   room.Tenant,
+			Players:  room.Conns.Count(),
+			Capacity: room.Capacity,
+			Snakes:   snap.AliveSnakes,
+			Bots:     snap.Bots,
+			Tick:     snap.Tick,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"rooms": rooms})
+}
+
+// auth rejects requests without the configured bearer token
+func (a *AdminAPI) auth(next http.HandlerFunc) http.HandlerFunc {
+	want := []byte("Bearer " + a.token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleFeeding lists victim/killer pairs currently flagged for feeding
+func (a *AdminAPI) handleFeeding(w http.ResponseWriter, r *http.Request) {
+	unlock := a.world.rlock("admin.feeding")
+	flags := a.world.Feeding.Flags()
+	unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"flags": flags})
+}
+
+// adminPlayer is one connected player in GET /admin/players
+type adminPlayer struct {
+	ID    string `json:"id"`
+	Snake string `json:"snake"` // the player's own snake ID (as in events and analytics)
+	Name  string `json:"name"`
+	Alive bool   `json:"alive"`
+	Score int    `json:"score"`
+	Extra int    `json:"extra_snakes,omitempty"` // live snakes controlled beyond its own
+}
+
+// handlePlayers lists connected players so admins can find IDs to sanction
+func (a *AdminAPI) handlePlayers(w http.ResponseWriter, r *http.Request) {
+	conns := a.conns.Snapshot()
+	players := make([]adminPlayer, 0, len(conns))
+	unlock := a.world.rlock("admin.players")
+	for _, c := range conns {
+		p := adminPlayer{ID: c.ID, Snake: c.snakeID, Name: c.name()}
+		for i, id := range c.SnakeIDs() {
+			s, ok := a.world.Snakes[id]
+			switch {
+			case ok && i == 0:
+				p.Alive, p.Score = s.Alive, s.Score
+			case ok && s.Alive:
+				p.Extra++
+			}
+		}
+		players = append(players, p)
+	}
+	unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"players": players})
+}
+
+// handleStats returns the latest tick snapshot's counts and leaderboard.
+// Lock-free: every field comes from the same published tick.
+func (a *AdminAPI) handleStats(w http.ResponseWriter, r *http.Request) {
+	snap := a.world.Snapshot()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"tick":         snap.Tick,
+		"time":         snap.Time,
+		"players":      snap.Players,
+		"alive_snakes": snap.AliveSnakes,
+		"bots":         snap.Bots,
+		"food":         snap.Food,
+		"deaths":       len(snap.Deaths),
+		"leaderboard":  snap.Leaderboard,
+		"humans_board": snap.HumanBoard,
+	})
+}
+
+// handleBots compares the bot A/B cohorts (see bot_cohorts.go)
+func (a *AdminAPI) handleBots(w http.ResponseWriter, r *http.Request) {
+	snap := a.world.Snapshot()
+	writeJSON(w, http.StatusOK, map[string]any{"tick": snap.Tick, "ai_cadence": snap.BotCadence, "cohorts": snap.Cohorts})
+}
+
+// setBotsRequest is the PUT /admin/bots body
+type setBotsRequest struct {
+	Count int `json:"count"`
+}
+
+// handleSetBots sets the bot population a room maintains (?room=), like the
+// live stream's bots command; surplus bots retire out of sight
+func (a *AdminAPI) handleSetBots(w http.ResponseWriter, r *http.Request) {
+	var req setBotsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	room := a.world.Room
+	if err := a.applyLive(liveCommand{Cmd: "bots", Value: req.Count, Room: room}); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errCommandQueueFull) {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	a.record(r, "bots.set", map[string]any{"count": req.Count, "room": room})
+	writeJSON(w, http.StatusOK, map[string]int{"bot_target": req.Count})
+}
+
+// handleEconomy returns the score economy audit, per minute and in total
+func (a *AdminAPI) handleEconomy(w http.ResponseWriter, r *http.Request) {
+	unlock := a.world.rlock("admin.economy")
+	report := a.world.Economy.Report()
+	unlock()
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleWorldLog returns the room's world log, the last ticks' mutations
+func (a *AdminAPI) handleWorldLog(w http.ResponseWriter, r *http.Request) {
+	if a.world.Log == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "world log disabled (set SLETHER_WORLD_LOG)"})
+		return
+	}
+	writeJSON(w, http.StatusOK, a.world.Log.Dump(a.world.Room, "admin"))
+}
+
+// handleDumpWorldLog writes the room's world log to a file on the server,
+// e.g. right after a player reports a death that looked wrong
+func (a *AdminAPI) handleDumpWorldLog(w http.ResponseWriter, r *http.Request) {
+	if a.world.Log == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "world log disabled (set SLETHER_WORLD_LOG)"})
+		return
+	}
+	d := a.world.Log.Dump(a.world.Room, "admin")
+	path, err := a.world.Log.write(d)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	a.record(r, "worldlog.dump", map[string]any{"room": a.world.Room, "path": path, "ticks": len(d.Ticks)})
+	writeJSON(w, http.StatusOK, map[string]any{"path": path, "ticks": len(d.Ticks)})
+}
+
+// handleArea describes the circle ?x=&y=&r= (r defaults to one viewport
+// width): density counts plus the snakes whose heads are inside it
+func (a *AdminAPI) handleArea(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	x, errX := strconv.ParseFloat(q.Get("x"), 64)
+	y, errY := strconv.ParseFloat(q.Get("y"), 64)
+	radius := ViewportWidth
+	var errR error
+	if v := q.Get("r"); v != "" {
+		radius, errR = strconv.ParseFloat(v, 64)
+	}
+	if errX != nil || errY != nil || errR != nil || radius <= 0 || radius > WorldRadius {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "want numeric x, y and 0 < r <= world radius"})
+		return
+	}
+	view := a.world.FrozenView()
+	snakes := []SnakeView{}
+	view.NearbySnakes(x, y, radius, func(s SnakeView) bool {
+		snakes = append(snakes, s)
+		return true
+	})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"tick":    view.Tick(),
+		"density": view.Density(x, y, radius),
+		"snakes":  snakes,
+	})
+}
+
+// handleListSanctions lists active shadow sanctions by network
+func (a *AdminAPI) handleListSanctions(w http.ResponseWriter, r *http.Request) {
+	active := make(map[string]Sanction)
+	for network, s := range a.world.Moderation.Active(time.Now()) {
+		active[network.String()] = s
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"sanctions": active})
+}
+
+// setSanctionRequest is the POST /admin/sanctions body: a connected
+// player whose address to sanction, or an IP address or CIDR network
+type setSanctionRequest struct {
+	ID          string  `json:"id"`
+	IP          string  `json:"ip"`
+	Mute        bool    `json:"mute"`
+	InputLagMS  int     `json:"input_lag_ms"`
+	ScoreFactor float64 `json:"score_factor"`
+	DurationSec int     `json:"duration_sec"`
+}
+
+// handleSetSanction sets (replaces) the shadow sanction on a player's
+// address or a network. Like a ban it outlives the session, so reconnecting
+// doesn't shed it. The sanction table has its own lock, so no world command
+// is needed; the loop picks it up on its next tick. Unknown fields are
+// refused, so a typo isn't accepted and audited as a sanction.
+func (a *AdminAPI) handleSetSanction(w http.ResponseWriter, r *http.Request) {
+	var req setSanctionRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	target := req.IP
+	if req.ID != "" {
+		c, ok := a.rooms.findConn(req.ID)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such player"})
+			return
+		}
+		target = c.ip
+	}
+	network, err := parseBanTarget(target)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	dur := time.Duration(req.DurationSec) * time.Second
+	if dur <= 0 || dur > ModerationMaxDuration {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "duration_sec out of range"})
+		return
+	}
+	s := a.world.Moderation.Set(network, Sanction{
+		Mute:        req.Mute,
+		InputLagMS:  req.InputLagMS,
+		ScoreFactor: req.ScoreFactor,
+		Expires:     time.Now().Add(dur),
+	})
+	a.record(r, "sanction.set", map[string]any{"network": network.String(), "id": req.ID, "sanction": s})
+	writeJSON(w, http.StatusOK, map[string]any{"network": network.String(), "sanction": s})
+}
+
+// handleClearSanction lifts the sanction on an address or network early
+func (a *AdminAPI) handleClearSanction(w http.ResponseWriter, r *http.Request) {
+	network, err := parseBanTarget(r.PathValue("network"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if !a.world.Moderation.Clear(network) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no sanction on network"})
+		return
+	}
+	a.record(r, "sanction.clear", map[string]any{"network": network.String()})
+	writeJSON(w, http.StatusOK, map[string]string{"cleared": network.String()})
+}
+
+// kickRequest is the optional POST /admin/players/{id}/kick body
+type kickRequest struct {
+	Reason string `json:"reason"`
+}
+
+// handleKick disconnects a player, whichever room they play in. The client
+// shows the reason and doesn't reconnect on its own; nothing stops the
+// player from coming back (see handleSetBan).
+func (a *AdminAPI) handleKick(w http.ResponseWriter, r *http.Request) {
+	var req kickRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+			return
+		}
+	}
+	id := r.PathValue("id")
+	c, ok := a.rooms.findConn(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such player"})
+		return
+	}
+	reason := req.Reason
+	if reason == "" {
+		reason = "Kicked by an admin"
+	}
+	c.Disconnect(CloseKicked, reason)
+	a.record(r, "player.kick", map[string]any{"id": id, "reason": reason})
+	writeJSON(w, http.StatusOK, map[string]string{"kicked": id})
+}
+
+// handleListBans lists active IP bans by network
+func (a *AdminAPI) handleListBans(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"bans": a.world.Bans.Active(time.Now())})
+}
+
+// setBanRequest is the POST /admin/bans body: an IP address or CIDR
+// network, or a connected player whose address to ban
+type setBanRequest struct {
+	IP          string `json:"ip"`
+	PlayerID    string `json:"player_id"`
+	Reason      string `json:"reason"`
+	DurationSec int    `json:"duration_sec"`
+}
+
+// handleSetBan bans an address or network and disconnects every player
+// connected from it, in every room
+func (a *AdminAPI) handleSetBan(w http.ResponseWriter, r *http.Request) {
+	var req setBanRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	target := req.IP
+	if req.PlayerID != "" {
+		c, ok := a.rooms.findConn(req.PlayerID)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such player"})
+			return
+		}
+		target = c.ip
+	}
+	network, err := parseBanTarget(target)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	dur := time.Duration(req.DurationSec) * time.Second
+	if dur <= 0 || dur > BanMaxDuration {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "duration_sec out of range"})
+		return
+	}
+	ban := Ban{Reason: req.Reason, Expires: time.Now().Add(dur)}
+	a.world.Bans.Set(network, ban)
+	kicked := 0
+	for _, room := range a.rooms.Rooms() {
+		for _, c := range room.Conns.Snapshot() {
+			if _, banned := a.world.Bans.Banned(c.ip); banned {
+				c.Disconnect(CloseBanned, "You are banned from this server.")
+				kicked++
+			}
+		}
+	}
+	a.record(r, "ban.set", map[string]any{"network": network.String(), "player_id": req.PlayerID, "ban": ban, "kicked": kicked})
+	writeJSON(w, http.StatusOK, map[string]any{"network": network.String(), "ban": ban, "kicked": kicked})
+}
+
+// handleClearBan lifts the ban on an address or network early
+func (a *AdminAPI) handleClearBan(w http.ResponseWriter, r *http.Request) {
+	network, err := parseBanTarget(r.PathValue("network"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if !a.world.Bans.Clear(network) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no ban on network"})
+		return
+	}
+	a.record(r, "ban.clear", map[string]any{"network": network.String()})
+	writeJSON(w, http.StatusOK, map[string]string{"cleared": network.String()})
+}
+
+// announceRequest is the POST /admin/announce body; an empty PlayerID
+// targets everyone
+type announceRequest struct {
+	Text        string `json:"text"`
+	Severity    string `json:"severity"`
+	DurationSec int    `json:"duration_sec"`
+	PlayerID    string `json:"player_id"`
+}
+
+// handleAnnounce shows a banner to all players, in every room, or a single one
+func (a *AdminAPI) handleAnnounce(w http.ResponseWriter, r *http.Request) {
+	var req announceRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	msg, err := NewAnnouncement(req.Text, req.Severity, time.Duration(req.DurationSec)*time.Second)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	sent := 0
+	for _, room := range a.rooms.Rooms() {
+		sent += room.Conns.Announce(msg, req.PlayerID)
+	}
+	if req.PlayerID != "" && sent == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such player"})
+		return
+	}
+	a.record(r, "announce", map[string]any{"player_id": req.PlayerID, "announcement": msg, "recipients": sent})
+	writeJSON(w, http.StatusOK, map[string]int{"recipients": sent})
+}
+
+// handleGetMOTD returns the current message of the day
+func (a *AdminAPI) handleGetMOTD(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.world.MOTD.Get())
+}
+
+// handleSetMOTD replaces the message of the day (persisted to SLETHER_MOTD
+// if set) and pushes it to every connected player, in every room
+func (a *AdminAPI) handleSetMOTD(w http.ResponseWriter, r *http.Request) {
+	var motd MOTD
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8192)).Decode(&motd); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if err := motd.validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := a.world.MOTD.Set(motd); err != nil {
+		log.Printf("admin: save motd: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not save MOTD"})
+		return
+	}
+	for _, room := range a.rooms.Rooms() {
+		room.Conns.BroadcastMOTD(motd)
+	}
+	a.record(r, "motd.set", motd)
+	writeJSON(w, http.StatusOK, motd)
+}
+
+// handleListPacks lists the content packs a world can be built from
+func (a *AdminAPI) handleListPacks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"current": a.world.Pack, "packs": a.world.Packs.List()})
+}
+
+// handleGetPack returns a pack by ID, bundled or uploaded
+func (a *AdminAPI) handleGetPack(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	p, ok := a.world.Packs.Get(id)
+	if !ok && slices.Contains(bundledPackNames(), id) {
+		p, _ = loadContentPack(id)
+		ok = p != nil
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such pack"})
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// handleUploadPack validates a content pack from a map editor and adds it
+// to the library (persisted to SLETHER_PACK_DIR if set)
+func (a *AdminAPI) handleUploadPack(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, PackUploadMaxBytes))
+	if err != nil {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "pack too large"})
+		return
+	}
+	p, err := parseContentPack(data)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	id, err := a.world.Packs.Put(p)
+	if errors.Is(err, errPackStore) {
+		log.Printf("admin: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not save pack"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	a.record(r, "pack.upload", p.summary(id, false))
+	writeJSON(w, http.StatusCreated, p.summary(id, false))
+}
+
+// record audits an admin action made by request r
+func (a *AdminAPI) record(r *http.Request, action string, params any) {
+	actor := r.Header.Get("X-Admin-Actor")
+	if actor == "" {
+		actor = "admin"
+	}
+	if a.tenant != nil {
+		actor = a.tenant.Tenant + ":" + actor
+	}
+	a.audit.Record(AuditEntry{
+		Time:   time.Now(),
+		Actor:  actor,
+		Remote: r.RemoteAddr,
+		Action: action,
+		Params: params,
+	})
+}
+
+// handleAudit returns recent audit entries, newest first (?limit=N, default 50)
+func (a *AdminAPI) handleAudit(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = n
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"entries": a.audit.Recent(limit)})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writePublicJSON writes v as a 200 response that shared caches may keep
+// for maxAge, for public endpoints embedded by community sites
+func writePublicJSON(w http.ResponseWriter, maxAge time.Duration, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// TickTiming summarizes how long ticks took over one second of game time
+type TickTiming struct {
+	Ticks    int     `json:"ticks"`
+	MeanMS   float64 `json:"mean_ms"`
+	MaxMS    float64 `json:"max_ms"`
+	BudgetMS float64 `json:"budget_ms"` // one tick interval
+	// BotsMS is the mean time spent on bot AI decisions per tick
+	BotsMS float64 `json:"bots_ms"`
+	// BotCadence is how many ticks apart bots make decisions (see bot_cadence.go)
+	BotCadence int `json:"bot_cadence"`
+}
+
+// tickWindow accumulates tick durations; game-loop goroutine only
+type tickWindow struct {
+	n        int
+	sum, max time.Duration
+	bots     time.Duration
+}
+
+// recordTickTime adds one tick's duration, publishing a TickTiming and
+// retuning the bot AI cadence once a second's worth of ticks has run
+func (gl *GameLoop) recordTickTime(d time.Duration) {
+	win := &gl.tickWindow
+	win.n++
+	win.sum += d
+	win.max = max(win.max, d)
+	if win.n < TickRate {
+		return
+	}
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	t := &TickTiming{
+		Ticks:    win.n,
+		MeanMS:   ms(win.sum / time.Duration(win.n)),
+		MaxMS:    ms(win.max),
+		BudgetMS: ms(tickDuration()),
+		BotsMS:   ms(win.bots / time.Duration(win.n)),
+	}
+	gl.bots.adaptCadence(t)
+	t.BotCadence = gl.bots.cadence
+	gl.tickTiming.Store(t)
+	*win = tickWindow{}
+}
+
+// liveFrame is one message of the admin live stream
+type liveFrame struct {
+	Type     string      `json:"type"` // "stats"
+	Time     time.Time   `json:"time"`
+	Tick     uint64      `json:"tick"`
+	TickRate int         `json:"tick_rate"`
+	Timing   *TickTiming `json:"tick_timing,omitempty"`
+	Rooms    []liveRoom  `json:"rooms"`
+}
+
+// liveRoom is one room's counts. The frame's tick and tick_timing are the
+// default room's; each room has its own here.
+type liveRoom struct {
+	Name        string      `json:"name"`
+	Pack        string      `json:"pack,omitempty"`
+	Lang        string      `json:"lang,omitempty"`
+	Tick        uint64      `json:"tick"`
+	Timing      *TickTiming `json:"tick_timing,omitempty"`
+	Players     int         `json:"players"`
+	AliveSnakes int         `json:"alive_snakes"`
+	Bots        int         `json:"bots"`
+	BotTarget   int         `json:"bot_target"`
+	Food        int         `json:"food"`
+	FoodTarget  int         `json:"food_target"`
+	GoldenApple bool        `json:"golden_apple"`
+}
+
+// liveCommand is a tuning command sent by the dashboard
+type liveCommand struct {
+	Cmd   string `json:"cmd"`
+	Value int    `json:"value"`
+	Room  string `json:"room,omitempty"` // "" for the default room
+}
+
+// liveAck answers a liveCommand
+type liveAck struct {
+	Type  string `json:"type"` // "ack"
+	Cmd   string `json:"cmd"`
+	Value int    `json:"value,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// authLive is auth for the live stream, which also accepts ?token= since
+// browsers can't set headers on a WebSocket handshake
+func (a *AdminAPI) authLive(next http.HandlerFunc) http.HandlerFunc {
+	authed := a.auth(next)
+	want := []byte(a.token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if tok := r.URL.Query().Get("token"); tok != "" && subtle.ConstantTimeCompare([]byte(tok), want) == 1 {
+			next(w, r)
+			return
+		}
+		authed(w, r)
+	}
+}
+
+// handleLive upgrades to a WebSocket that streams a liveFrame every
+// LiveInterval and applies tuning commands (see applyLive) as they arrive
+func (a *AdminAPI) handleLive(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("admin live: upgrade: %v", err)
+		return
+	}
+	defer ws.Close()
+	ws.SetReadLimit(4096)
+
+	// gorilla allows one writer at a time: the reader hands acks to this
+	// goroutine rather than writing them itself
+	acks := make(chan liveAck, 8)
+	go func() {
+		defer close(acks)
+		for {
+			var cmd liveCommand
+			if err := ws.ReadJSON(&cmd); err != nil {
+				return
+			}
+			ack := liveAck{Type: "ack", Cmd: cmd.Cmd, Value: cmd.Value}
+			if err := a.applyLive(cmd); err != nil {
+				ack.Error = err.Error()
+			} else {
+				a.record(r, "live."+cmd.Cmd, map[string]any{"value": cmd.Value, "room": cmd.Room})
+			}
+			acks <- ack
+		}
+	}()
+
+	send := func(v any) bool {
+		_ = ws.SetWriteDeadline(time.Now().Add(LiveWriteTimeout))
+		return ws.WriteJSON(v) == nil
+	}
+	ticker := time.NewTicker(LiveInterval)
+	defer ticker.Stop()
+	for ok := send(a.liveFrame()); ok; {
+		select {
+		case ack, open := <-acks:
+			if !open {
+				return
+			}
+			ok = send(ack)
+		case <-ticker.C:
+			ok = send(a.liveFrame())
+		}
+	}
+}
+
+// liveFrame builds a frame from each room's latest tick snapshot
+func (a *AdminAPI) liveFrame() liveFrame {
+	snap := a.world.Snapshot()
+	frame := liveFrame{
+		Type:     "stats",
+		Time:     snap.Time,
+		Tick:     snap.Tick,
+		TickRate: TickRate,
+		Timing:   a.loop.tickTiming.Load(),
+	}
+	for _, room := range a.rooms.Rooms() {
+		snap := room.World.Snapshot()
+		frame.Rooms = append(frame.Rooms, liveRoom{
+			Name:        room.Name,
+			Pack:        room.World.Pack,
+			Lang:        room.World.Lang,
+			Tick:        snap.Tick,
+			Timing:      room.Loop.tickTiming.Load(),
+			Players:     snap.Players,
+			AliveSnakes: snap.AliveSnakes,
+			Bots:        snap.Bots,
+			BotTarget:   snap.BotTarget,
+			Food:        snap.Food,
+			FoodTarget:  snap.FoodTarget,
+			GoldenApple: snap.Apple != nil,
+		})
+	}
+	return frame
+}
+
+// applyLive runs a tuning command on a room's game loop and waits for it:
+//
+//	bots         bot population to maintain, 0..LiveMaxBots
+//	food_target  ambient food count to maintain, 0..LiveMaxFoodTarget
+//	golden_apple spawn the golden apple now (value ignored)
+func (a *AdminAPI) applyLive(cmd liveCommand) error {
+	room := a.rooms.Rooms()[0]
+	if cmd.Room != "" {
+		var ok bool
+		if room, ok = a.rooms.Get(cmd.Room); !ok {
+			return fmt.Errorf("no room %q", cmd.Room)
+		}
+	}
+	var apply func(w *World) error
+	switch cmd.Cmd {
+	case "bots":
+		if cmd.Value < 0 || cmd.Value > LiveMaxBots {
+			return fmt.Errorf("bots must be 0-%d", LiveMaxBots)
+		}
+		bots := room.Loop.bots
+		apply = func(w *World) error {
+			bots.target = cmd.Value
+			return nil
+		}
+	case "food_target":
+		if cmd.Value < 0 || cmd.Value > LiveMaxFoodTarget {
+			return fmt.Errorf("food_target must be 0-%d", LiveMaxFoodTarget)
+		}
+		apply = func(w *World) error {
+			w.FoodTarget = cmd.Value
+			return nil
+		}
+	case "golden_apple":
+		if !GoldenAppleEnabled {
+			return errors.New("golden apple disabled")
+		}
+		apply = func(w *World) error {
+			if w.Apple.Active {
+				return errors.New("golden apple already out")
+			}
+			w.Apple.respawnAt = w.Tick // updateGoldenApple spawns it this tick
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown command %q", cmd.Cmd)
+	}
+	done := make(chan error, 1)
+	if err := room.World.Post(func(w *World) { done <- apply(w) }); err != nil {
+		return err
+	}
+	return <-done
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Announcement severities, in increasing order of urgency
+const (
+	SeverityInfo  = "info"
+	SeverityWarn  = "warn"
+	SeverityAlert = "alert"
+)
+
+// NewAnnouncement validates an announcement and builds its message. Text is
+// trimmed and must be 1..AnnouncementMaxLength runes; an empty severity means
+// info; the duration is clamped to [AnnouncementMinDuration, AnnouncementMaxDuration].
+func NewAnnouncement(text, severity string, d time.Duration) (AnnouncementMsg, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return AnnouncementMsg{}, errors.New("announcement text is empty")
+	}
+	if utf8.RuneCountInString(text) > AnnouncementMaxLength {
+		return AnnouncementMsg{}, errors.New("announcement text too long")
+	}
+	switch severity {
+	case "":
+		severity = SeverityInfo
+	case SeverityInfo, SeverityWarn, SeverityAlert:
+	default:
+		return AnnouncementMsg{}, errors.New("unknown announcement severity")
+	}
+	d = max(AnnouncementMinDuration, min(d, AnnouncementMaxDuration))
+	return AnnouncementMsg{
+		Type:     MsgAnnounce,
+		Text:     text,
+		Severity: severity,
+		Duration: int(d / time.Millisecond),
+	}, nil
+}
+
+// Announce sends msg to one player, or to everyone when playerID is empty.
+// Announcements are critical priority so a shutdown warning is never dropped.
+// Returns the number of connections it was queued for.
+func (m *ConnManager) Announce(msg AnnouncementMsg, playerID string) int {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return 0
+	}
+	if playerID != "" {
+		c, ok := m.Get(playerID)
+		if !ok {
+			return 0
+		}
+		c.sendEncoded(PriorityCritical, data)
+		return 1
+	}
+	conns := m.Snapshot()
+	for _, c := range conns {
+		c.sendEncoded(PriorityCritical, data)
+	}
+	return len(conns)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditEntry records one admin action
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`  // X-Admin-Actor header, "admin" if absent
+	Remote string    `json:"remote"` // client address of the request
+	Action string    `json:"action"`
+	Params any       `json:"params,omitempty"`
+}
+
+// AuditLog appends admin actions as JSON lines to a file and keeps the most
+// recent AuditRecentMax in memory for the admin API. Safe for concurrent use.
+type AuditLog struct {
+	mu     sync.Mutex
+	file   *os.File // nil: memory only
+	recent []AuditEntry
+}
+
+// openAuditLog opens (creating if needed) path for appending; an empty path
+// keeps the log in memory only
+func openAuditLog(path string) (*AuditLog, error) {
+	l := &AuditLog{}
+	if path == "" {
+		return l, nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l.file = f
+	return l, nil
+}
+
+// Record appends e. A failed file write is logged but doesn't fail the
+// action — the entry still reaches the process log and the recent list.
+func (l *AuditLog) Record(e AuditEntry) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("audit: encode %s: %v", e.Action, err)
+		return
+	}
+	log.Printf("audit: %s", line)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		if _, err := l.file.Write(append(line, '\n')); err != nil {
+			log.Printf("audit: write: %v", err)
+		}
+	}
+	if len(l.recent) >= AuditRecentMax {
+		copy(l.recent, l.recent[1:])
+		l.recent = l.recent[:len(l.recent)-1]
+	}
+	l.recent = append(l.recent, e)
+}
+
+// Recent returns up to n of the newest entries, newest first
+func (l *AuditLog) Recent(n int) []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n = max(0, min(n, len(l.recent)))
+	out := make([]AuditEntry, n)
+	for i := range out {
+		out[i] = l.recent[len(l.recent)-1-i]
+	}
+	return out
+}
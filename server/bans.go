@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ban refuses connections from an address or network until it expires
+type Ban struct {
+	Reason  string    `json:"reason,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+// BanList holds IP bans set by admins, keyed by network (a single address
+// is a /32 or /128). Safe for concurrent use — the admin API writes, the
+// WebSocket handler checks every new connection. Bans are kept in memory
+// and last until they expire or the server restarts.
+type BanList struct {
+	mu    sync.Mutex
+	byNet map[netip.Prefix]Ban
+}
+
+// NewBanList creates an empty ban list
+func NewBanList() *BanList {
+	return &BanList{byNet: make(map[netip.Prefix]Ban)}
+}
+
+// parseBanTarget reads an IP address or CIDR network, e.g. "203.0.113.7"
+// or "203.0.113.0/24"
+func parseBanTarget(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%q is not an IP address or CIDR network", s)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is not an IP address or CIDR network", s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Set bans network, replacing any ban on it
+func (b *BanList) Set(network netip.Prefix, ban Ban) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.byNet[network] = ban
+}
+
+// Clear lifts the ban on network; returns false if there was none
+func (b *BanList) Clear(network netip.Prefix) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.byNet[network]
+	delete(b.byNet, network)
+	return ok
+}
+
+// Active returns unexpired bans by network, dropping expired ones
+func (b *BanList) Active(now time.Time) map[string]Ban {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	active := make(map[string]Ban, len(b.byNet))
+	for network, ban := range b.byNet {
+		if now.After(ban.Expires) {
+			delete(b.byNet, network)
+			continue
+		}
+		active[network.String()] = ban
+	}
+	return active
+}
+
+// Banned reports the ban covering a client address (see client_ip.go);
+// an empty or unparsable address is never banned
+func (b *BanList) Banned(ip string) (Ban, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return Ban{}, false
+	}
+	addr = addr.Unmap()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	for network, ban := range b.byNet {
+		if network.Contains(addr) && now.Before(ban.Expires) {
+			return ban, true
+		}
+	}
+	return Ban{}, false
+}
@@ -4,51 +4,86 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"slices"
+	"time"
 )
 
-// botNames is a multilingual pool of snake/warrior themed names
-var botNames = []string{
-	// Vietnamese
-	"Rắn Thần", "Sấm Sét", "Bão Tố", "Tia Chớp", "Ma Tốc Độ",
-	"Rồng Lửa", "Bóng Đêm", "Sát Thủ", "Độc Xà", "Vua Rắn",
-	"Hắc Mamba", "Kim Xà", "Thanh Xà", "Bạch Xà", "Thần Xà",
-	"Hỏa Long", "Băng Xà", "Quỷ Xà", "Điện Xà", "Lôi Thần",
-	// English
-	"Viper", "Cobra", "Mamba", "Python", "Anaconda",
-	"Sidewinder", "Rattlesnake", "Phantom", "Shadow", "Blaze",
-	"Frostbite", "Venom", "Reaper", "Striker", "Apex",
-	"Cyclone", "Tempest", "Havoc", "Wraith", "Spectre",
-	// Japanese
-	"蛇神", "雷蛇", "龍王", "鬼蛇", "忍者",
-	"侍", "影", "嵐", "炎蛇", "氷龍",
-	// Korean
-	"독사왕", "번개뱀", "용의발톱", "그림자", "폭풍",
-	"흑사", "천둥", "불뱀", "얼음독", "광전사",
-	// Chinese
-	"毒蛇王", "雷电蛇", "火龙", "冰蟒", "暗影",
-	"狂蛇", "风暴", "霸蛇", "鬼火", "战神",
-	// Spanish
-	"Serpiente", "Víbora", "Trueno", "Tormenta", "Fuego",
-	"Sombra", "Veneno", "Relámpago", "Fantasma", "Dragón",
-	// Russian
-	"Гадюка", "Кобра", "Гром", "Буря", "Тень",
-	"Пламя", "Мороз", "Ужас", "Змей", "Дракон",
-	// Arabic
-	"الأفعى", "البرق", "العاصفة", "الظل", "النار",
-	// Thai
-	"พญานาค", "สายฟ้า", "มังกร", "เงา", "พิษ",
-	// Hindi
-	"नागराज", "बिजली", "तूफान", "अग्नि", "विष",
-	// Portuguese
-	"Serpente", "Raio", "Tempestade", "Sombra", "Veneno",
-	// French
-	"Vipère", "Éclair", "Tonnerre", "Ombre", "Flamme",
-	// German
-	"Schlange", "Blitz", "Donner", "Schatten", "Flamme",
+// botLocales is a multilingual pool of snake/warrior themed names, each
+// language with the countries its names plausibly come from (bot flags,
+// see country.go)
+var botLocales = []botLocale{
+	{Lang: "vi", Countries: []string{"VN"}, Names: []string{ // Vietnamese
+		"Rắn Thần", "Sấm Sét", "Bão Tố", "Tia Chớp", "Ma Tốc Độ",
+		"Rồng Lửa", "Bóng Đêm", "Sát Thủ", "Độc Xà", "Vua Rắn",
+		"Hắc Mamba", "Kim Xà", "Thanh Xà", "Bạch Xà", "Thần Xà",
+		"Hỏa Long", "Băng Xà", "Quỷ Xà", "Điện Xà", "Lôi Thần",
+	}},
+	{Lang: "en", Countries: []string{"US", "GB", "CA", "AU"}, Names: []string{ // English
+		"Viper", "Cobra", "Mamba", "Python", "Anaconda",
+		"Sidewinder", "Rattlesnake", "Phantom", "Shadow", "Blaze",
+		"Frostbite", "Venom", "Reaper", "Striker", "Apex",
+		"Cyclone", "Tempest", "Havoc", "Wraith", "Spectre",
+	}},
+	{Lang: "ja", Countries: []string{"JP"}, Names: []string{ // Japanese
+		"蛇神", "雷蛇", "龍王", "鬼蛇", "忍者",
+		"侍", "影", "嵐", "炎蛇", "氷龍",
+	}},
+	{Lang: "ko", Countries: []string{"KR"}, Names: []string{ // Korean
+		"독사왕", "번개뱀", "용의발톱", "그림자", "폭풍",
+		"흑사", "천둥", "불뱀", "얼음독", "광전사",
+	}},
+	{Lang: "zh", Countries: []string{"CN", "TW", "SG"}, Names: []string{ // Chinese
+		"毒蛇王", "雷电蛇", "火龙", "冰蟒", "暗影",
+		"狂蛇", "风暴", "霸蛇", "鬼火", "战神",
+	}},
+	{Lang: "es", Countries: []string{"ES", "MX", "AR", "CO"}, Names: []string{ // Spanish
+		"Serpiente", "Víbora", "Trueno", "Tormenta", "Fuego",
+		"Sombra", "Veneno", "Relámpago", "Fantasma", "Dragón",
+	}},
+	{Lang: "ru", Countries: []string{"RU", "KZ"}, Names: []string{ // Russian
+		"Гадюка", "Кобра", "Гром", "Буря", "Тень",
+		"Пламя", "Мороз", "Ужас", "Змей", "Дракон",
+	}},
+	{Lang: "ar", Countries: []string{"EG", "SA", "AE", "MA"}, Names: []string{ // Arabic
+		"الأفعى", "البرق", "العاصفة", "الظل", "النار",
+	}},
+	{Lang: "th", Countries: []string{"TH"}, Names: []string{ // Thai
+		"พญานาค", "สายฟ้า", "มังกร", "เงา", "พิษ",
+	}},
+	{Lang: "hi", Countries: []string{"IN"}, Names: []string{ // Hindi
+		"नागराज", "बिजली", "तूफान", "अग्नि", "विष",
+	}},
+	{Lang: "pt", Countries: []string{"BR", "PT"}, Names: []string{ // Portuguese
+		"Serpente", "Raio", "Tempestade", "Sombra", "Veneno",
+	}},
+	{Lang: "fr", Countries: []string{"FR", "BE", "CA"}, Names: []string{ // French
+		"Vipère", "Éclair", "Tonnerre", "Ombre", "Flamme",
+	}},
+	{Lang: "de", Countries: []string{"DE", "AT", "CH"}, Names: []string{ // German
+		"Schlange", "Blitz", "Donner", "Schatten", "Flamme",
+	}},
 }
 
-// botUsedNames tracks names currently in use to prevent duplicates
-var botUsedNames = map[string]bool{}
+// botLocale is one language's share of the bot name pool
+type botLocale struct {
+	Lang      string
+	Countries []string // ISO 3166-1 alpha-2
+	Names     []string
+}
+
+// botNames is every name in botLocales, once each (some languages share
+// a word, e.g. "Sombra")
+var botNames = func() []string {
+	var names []string
+	for _, l := range botLocales {
+		for _, name := range l.Names {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}()
 
 // Bot tracks per-bot AI state
 type Bot struct {
@@ -65,70 +100,93 @@ type Bot struct {
 	deathFoodX    float64 // center of death food zone
 	deathFoodY    float64
 	deathFoodTicks int    // ticks remaining to rush toward death food (0 = inactive)
+	// retireTicks > 0 marks a bot removed by population scaling: it despawns
+	// once no human can see it, or fades into food when this runs out
+	retireTicks int
+	// cohort is the A/B group this bot belongs to; its Policy, when set,
+	// steers the bot instead of the heuristic rules
+	cohort *BotCohort
+	// spawnTick is the World.Tick this life began, for cohort lifespans
+	spawnTick uint64
+	// Steering from the last decision, reused on the ticks in between when
+	// the AI runs every few ticks (see bot_cadence.go). step is the number
+	// of ticks the last decision covers; phase staggers bots across ticks.
+	steerAngle float64
+	steerBoost bool
+	step       int
+	phase      uint64
 }
 
 // BotManager manages all AI bot snakes
 type BotManager struct {
-	world *World
-	bots  map[string]*Bot // botID -> Bot
+	world  *World
+	bots    map[string]*Bot // botID -> Bot
+	target  int             // bot population to maintain (BotCount by default)
+	cohorts []*BotCohort    // A/B groups new bots are spread over (see bot_cohorts.go)
+	cadence int             // ticks between AI decisions, 1–BotMaxCadence (see bot_cadence.go)
+	names   *BotNames       // names held by this world's bots (see bot_names.go)
+	aiTime  time.Duration   // spent deciding this tick, for TickTiming.BotsMS
 }
 
 // NewBotManager creates a BotManager bound to the given world
 func NewBotManager(world *World) *BotManager {
 	return &BotManager{
-		world: world,
-		bots:  make(map[string]*Bot),
+		world:  world,
+		bots:    make(map[string]*Bot),
+		target:  BotCount,
+		cohorts: []*BotCohort{{Name: heuristicCohort}},
+		cadence: 1,
+		names:   NewBotNames(),
 	}
 }
 
 // SpawnBot creates a new bot snake and registers it in the world.
-// Caller must NOT hold world.mu — this method acquires the write lock.
+// Must be called from the game loop goroutine (or before the loop starts)
+// with world.mu held or not yet shared.
 func (bm *BotManager) SpawnBot() {
 	id := fmt.Sprintf("bot-%d", rand.Int63())
-	name := pickBotName()
+	name := bm.names.Acquire(bm.world.Lang)
 	color := PlayerColors[rand.Intn(len(PlayerColors))]
 
-	snake := NewSnake(id, name, color)
-
-	bm.world.mu.Lock()
+	x, y := bm.botSpawnPoint()
+	snake := newSnakeAt(id, name, color, x, y)
+	snake.Country = botCountry(name)
 	bm.world.AddSnake(snake)
-	bm.world.mu.Unlock()
+	bm.world.emit(snakeEvent(EventJoin, snake))
 
 	bot := &Bot{
 		ID:          id,
 		targetAngle: snake.Angle,
 		wanderTicks: randomWanderDuration(),
+		cohort:      bm.pickCohort(),
+		spawnTick:   bm.world.Tick,
+		steerAngle:  snake.Angle,
+		phase:       rand.Uint64(),
 	}
 	bm.bots[id] = bot
 }
 
-// Update runs AI logic for every bot. Must be called each tick while world.mu is held.
-func (bm *BotManager) Update() {
+// UpdateRetiring advances retiring bots, removing those that are gone.
+// Bots steer through their BotController (see controller.go). Must be
+// called each tick, before inputs are gathered, while world.mu is held.
+func (bm *BotManager) UpdateRetiring() {
 	w := bm.world
+	view := w.LiveView()
 	for _, bot := range bm.bots {
 		snake, ok := w.Snakes[bot.ID]
-		if !ok || !snake.Alive {
+		if !ok || !snake.Alive || bot.retireTicks == 0 {
 			continue
 		}
-
-		angle, boost := bm.decideBotInput(bot, snake)
-		if dropped := snake.ApplyInput(angle, boost); dropped != nil {
-			w.Food[dropped.ID] = dropped
-		}
-		outOfBounds := snake.Move()
-		if outOfBounds {
-			// Boundary death — drop food into world and mark dead
-			dropped := snake.DropFood()
-			w.AddFood(dropped)
-		}
+		bm.tryRetire(bot, snake, view)
 	}
 }
 
 // decideBotInput applies priority-based AI rules and returns (targetAngle, boost).
-// Must be called while world.mu is held (at least read).
-func (bm *BotManager) decideBotInput(bot *Bot, snake *Snake) (float64, bool) {
-	w := bm.world
+// The bot steers its own snake; everything else it sees comes through view.
+func (bm *BotManager) decideBotInput(bot *Bot, snake *Snake, view WorldView) (float64, bool) {
+	self := snakeViewOf(snake)
 	head := snake.Head()
+	step := max(bot.step, 1) // ticks this decision steers for
 	currentAngle := snake.Angle
 	boost := false
 
@@ -144,10 +202,14 @@ func (bm *BotManager) decideBotInput(bot *Bot, snake *Snake) (float64, bool) {
 	}
 
 	// --- Priority 2: Danger avoidance — body segments within BotDangerRadius ahead ---
-	nearby := w.Grid.NearbySnakeBody(head.X, head.Y, BotDangerRadius, snake.ID)
-	for _, entry := range nearby {
+	dangerFound := false
+	view.NearbyBodies(head.X, head.Y, BotDangerRadius, snake.ID, func(entry BodyView) bool {
+		// Bodies in a non-interacting phase layer are harmless — ignore them
+		if !layersInteract(self.Layer, entry.Layer) {
+			return true
+		}
 		// Check if the segment is within ±45° of the current heading (in our path)
-		segAngle := math.Atan2(entry.y-head.Y, entry.x-head.X)
+		segAngle := math.Atan2(entry.Y-head.Y, entry.X-head.X)
 		angleDiff := normalizeAngle(segAngle - currentAngle)
 		if math.Abs(angleDiff) < math.Pi/4 {
 			// Turn 90° away — choose left or right based on which avoids the obstacle
@@ -156,33 +218,32 @@ func (bm *BotManager) decideBotInput(bot *Bot, snake *Snake) (float64, bool) {
 			} else {
 				bot.targetAngle = currentAngle + math.Pi/2
 			}
-			bot.wanderTicks = randomWanderDuration()
-			return bot.targetAngle, false
+			dangerFound = true
+			return false
 		}
+		return true
+	})
+	if dangerFound {
+		bot.wanderTicks = randomWanderDuration()
+		return bot.targetAngle, false
 	}
 
 	// --- Priority 3: Flee bigger snakes ---
 	biggerFound := false
-	for _, other := range w.Snakes {
-		if other.ID == snake.ID || !other.Alive {
-			continue
+	view.NearbySnakes(head.X, head.Y, BotFleeRadius, func(other SnakeView) bool {
+		if other.ID == snake.ID || !self.CanInteract(other) || other.Score <= snake.Score {
+			return true
 		}
-		otherHead := other.Head()
-		ddx := otherHead.X - head.X
-		ddy := otherHead.Y - head.Y
-		dist := math.Sqrt(ddx*ddx + ddy*ddy)
-		if dist < BotFleeRadius && other.Score > snake.Score {
-			// Flee: steer directly away from the threat
-			bot.targetAngle = math.Atan2(head.Y-otherHead.Y, head.X-otherHead.X)
-			bot.boostTicks = 30 // boost for 30 ticks while fleeing
-			bot.wanderTicks = randomWanderDuration()
-			biggerFound = true
-			break
-		}
-	}
+		// Flee: steer directly away from the threat
+		bot.targetAngle = math.Atan2(head.Y-other.Y, head.X-other.X)
+		bot.boostTicks = ticksFor(1500 * time.Millisecond) // boost while fleeing
+		bot.wanderTicks = randomWanderDuration()
+		biggerFound = true
+		return false
+	})
 	if biggerFound {
 		if bot.boostTicks > 0 {
-			bot.boostTicks--
+			bot.boostTicks -= step
 			boost = true
 		}
 		return bot.targetAngle, boost
@@ -190,33 +251,45 @@ func (bm *BotManager) decideBotInput(bot *Bot, snake *Snake) (float64, bool) {
 
 	// Reset boost if not fleeing
 	if bot.boostTicks > 0 {
-		bot.boostTicks--
+		bot.boostTicks -= step
 		boost = true
 	}
 
-	// --- Priority 4: Chase smaller snakes ---
-	for _, other := range w.Snakes {
-		if other.ID == snake.ID || !other.Alive {
-			continue
-		}
-		otherHead := other.Head()
-		ddx := otherHead.X - head.X
-		ddy := otherHead.Y - head.Y
-		dist := math.Sqrt(ddx*ddx + ddy*ddy)
-		if dist < BotChaseRadius && other.Score < snake.Score {
-			bot.targetAngle = math.Atan2(ddy, ddx)
+	// --- Priority 3.5: Contest the golden apple (not while retiring) ---
+	if ax, ay, ok := view.Objective(); ok && bot.retireTicks == 0 {
+		adx, ady := ax-head.X, ay-head.Y
+		if dist := math.Hypot(adx, ady); dist < GoldenAppleBotRadius {
+			bot.targetAngle = math.Atan2(ady, adx)
 			bot.wanderTicks = randomWanderDuration()
-			// Boost toward smaller target only if we can afford it
-			if len(snake.Segments) > SnakeMinSegments+5 {
+			// Sprint the last stretch if we can afford it
+			if dist < BotChaseRadius*2 && len(snake.Segments) > SnakeMinSegments+5 {
 				boost = true
 			}
 			return bot.targetAngle, boost
 		}
 	}
 
+	// --- Priority 4: Chase smaller snakes, weighted by the world's hunt
+	// settings (not while retiring; see bot_hunt.go) ---
+	chasing := false
+	if bot.retireTicks == 0 {
+		if prey, ok := bm.chaseTarget(snake, view); ok {
+			bot.targetAngle = math.Atan2(prey.Y-head.Y, prey.X-head.X)
+			bot.wanderTicks = randomWanderDuration()
+			chasing = true
+		}
+	}
+	if chasing {
+		// Boost toward smaller target only if we can afford it
+		if len(snake.Segments) > SnakeMinSegments+5 {
+			boost = true
+		}
+		return bot.targetAngle, boost
+	}
+
 	// --- Priority 4.5: Rush to death food zone (after killing another snake) ---
 	if bot.deathFoodTicks > 0 {
-		bot.deathFoodTicks--
+		bot.deathFoodTicks -= step
 		ddx := bot.deathFoodX - head.X
 		ddy := bot.deathFoodY - head.Y
 		dist := math.Sqrt(ddx*ddx + ddy*ddy)
@@ -242,16 +315,12 @@ func (bm *BotManager) decideBotInput(bot *Bot, snake *Snake) (float64, bool) {
 	}
 	bot.lastScore = snake.Score
 
-	nearFoodIDs := w.Grid.NearbyFood(head.X, head.Y, BotFoodSeekRadius)
-	if len(nearFoodIDs) > 0 && bot.seekTicks < 60 {
+	if bot.seekTicks < ticksFor(BotSeekTimeout) {
 		// Find closest food ONLY in front of us (within ±90°)
 		bestDist := math.MaxFloat64
-		var bestFood *Food
-		for _, fid := range nearFoodIDs {
-			f, ok := w.Food[fid]
-			if !ok {
-				continue
-			}
+		var bestFood FoodView
+		found := false
+		view.NearbyFood(head.X, head.Y, BotFoodSeekRadius, func(f FoodView) bool {
 			fdx := f.X - head.X
 			fdy := f.Y - head.Y
 			d := math.Sqrt(fdx*fdx + fdy*fdy)
@@ -259,45 +328,47 @@ func (bm *BotManager) decideBotInput(bot *Bot, snake *Snake) (float64, bool) {
 			angleDiff := math.Abs(normalizeAngle(foodAngle - currentAngle))
 			// Skip food behind us entirely — chasing backward food causes orbits
 			if angleDiff > math.Pi/2 {
-				continue
+				return true
 			}
 			if d < bestDist {
 				bestDist = d
 				bestFood = f
+				found = true
 			}
-		}
-		if bestFood != nil {
+			return true
+		})
+		if found {
 			// Orbit detection: if distance to food isn't decreasing, we're circling
 			if bot.lastFoodDist > 0 && bestDist >= bot.lastFoodDist-1.0 {
-				bot.orbitCount++
+				bot.orbitCount += step
 			} else {
 				bot.orbitCount = 0
 			}
 			bot.lastFoodDist = bestDist
 
-			// If orbiting for 8+ ticks, abandon this food and break out
-			if bot.orbitCount >= 8 {
+			// If orbiting for BotOrbitBailout, abandon this food and break out
+			if bot.orbitCount >= ticksFor(BotOrbitBailout) {
 				bot.orbitCount = 0
 				bot.seekTicks = 0
 				bot.lastFoodDist = 0
 				bot.targetAngle = currentAngle + math.Pi/2 + rand.Float64()*math.Pi
-				bot.wanderTicks = 30 + rand.Intn(40)
+				bot.wanderTicks = randomTicks(1500*time.Millisecond, 3500*time.Millisecond)
 				return bot.targetAngle, false
 			}
 
 			// Steer directly at food
 			bot.targetAngle = math.Atan2(bestFood.Y-head.Y, bestFood.X-head.X)
-			bot.seekTicks++
+			bot.seekTicks += step
 			return bot.targetAngle, boost
 		}
 	}
 	// If seek timed out (circling), force a new direction away from current heading
-	if bot.seekTicks >= 60 {
+	if bot.seekTicks >= ticksFor(BotSeekTimeout) {
 		bot.seekTicks = 0
 		bot.orbitCount = 0
 		bot.lastFoodDist = 0
 		bot.targetAngle = currentAngle + math.Pi/2 + rand.Float64()*math.Pi
-		bot.wanderTicks = 30 + rand.Intn(40)
+		bot.wanderTicks = randomTicks(1500*time.Millisecond, 3500*time.Millisecond)
 		return bot.targetAngle, false
 	}
 
@@ -309,53 +380,52 @@ func (bm *BotManager) decideBotInput(bot *Bot, snake *Snake) (float64, bool) {
 		tx := WorldCenterX + targetR*math.Cos(targetA)
 		ty := WorldCenterY + targetR*math.Sin(targetA)
 		bot.targetAngle = math.Atan2(ty-head.Y, tx-head.X)
-		bot.wanderTicks = 40 + rand.Intn(60)
+		bot.wanderTicks = randomTicks(2*time.Second, 5*time.Second)
 	}
-	bot.wanderTicks--
+	bot.wanderTicks -= step
 	return bot.targetAngle, boost
 }
 
 // HandleDeaths scans for dead bot snakes (after game_loop processes deaths)
 // and starts their respawn countdown. Also notifies killer bots to rush death food.
+// deaths maps victim ID to killer ID (KillerBoundary for boundary deaths).
 // Must be called while world.mu is held.
 func (bm *BotManager) HandleDeaths(deaths map[string]string) {
 	// Notify killer bots to rush to victim's death location
-	for victimID, killerName := range deaths {
+	for victimID, killerID := range deaths {
 		victim, ok := bm.world.Snakes[victimID]
 		if !ok {
 			continue
 		}
-		// Find killer bot by name match
-		for _, bot := range bm.bots {
-			killerSnake, ok := bm.world.Snakes[bot.ID]
-			if !ok || !killerSnake.Alive {
-				continue
-			}
-			if killerSnake.Name == killerName {
-				head := victim.Head()
-				bot.deathFoodX = head.X
-				bot.deathFoodY = head.Y
-				bot.deathFoodTicks = 80 // rush for 4 seconds at 20tps
-				break
-			}
+		bot, isBot := bm.bots[killerID]
+		if !isBot {
+			continue
+		}
+		if killerSnake, ok := bm.world.Snakes[killerID]; ok && killerSnake.Alive {
+			head := victim.Head()
+			bot.deathFoodX = head.X
+			bot.deathFoodY = head.Y
+			bot.deathFoodTicks = ticksFor(4 * time.Second) // rush toward the drop
 		}
 	}
 
-	// Start respawn countdown for dead bots
+	// Start respawn countdown for dead bots, crediting the life to the cohort
 	for botID, bot := range bm.bots {
 		snake, ok := bm.world.Snakes[botID]
 		if !ok || !snake.Alive {
 			if bot.respawnIn == 0 {
-				bot.respawnIn = BotRespawnDelay
+				bot.respawnIn = ticksFor(BotRespawnDelay)
+				if ok {
+					bm.endLife(bot, snake, true)
+				}
 			}
 		}
 	}
 }
 
 // tickRespawns decrements respawn counters and triggers spawning when ready.
-// Must be called while world.mu is NOT held (SpawnBot acquires the lock).
-func (bm *BotManager) tickRespawns() {
-	// Collect IDs to respawn (can't modify map during range)
+// Returns the IDs of bots whose countdown finished. Does not touch the world.
+func (bm *BotManager) tickRespawns() []string {
 	var toRespawn []string
 	for botID, bot := range bm.bots {
 		if bot.respawnIn <= 0 {
@@ -366,67 +436,63 @@ func (bm *BotManager) tickRespawns() {
 			toRespawn = append(toRespawn, botID)
 		}
 	}
-	for _, oldID := range toRespawn {
-		// Release bot name before removing
-		bm.world.mu.Lock()
-		if s, ok := bm.world.Snakes[oldID]; ok {
-			delete(botUsedNames, s.Name)
-		}
-		delete(bm.world.Snakes, oldID)
-		bm.world.mu.Unlock()
-		delete(bm.bots, oldID)
-		bm.SpawnBot()
-	}
+	return toRespawn
 }
 
-// MaintainBotCount ensures exactly BotCount bots exist (alive + in-respawn).
-// Must be called while world.mu is NOT held.
+// MaintainBotCount replaces bots whose respawn countdown finished and moves
+// the population toward the target count (alive + in-respawn): one spawn per
+// tick when short, retiring the excess (see tryRetire) when over.
+// Must be called from the game loop while world.mu is held.
 func (bm *BotManager) MaintainBotCount() {
 	// tickRespawns first so dead bots count correctly
-	bm.tickRespawns()
-
-	if len(bm.bots) < BotCount {
-		bm.SpawnBot()
+	for _, oldID := range bm.tickRespawns() {
+		retiring := bm.bots[oldID].retireTicks > 0
+		bm.despawn(oldID)
+		if !retiring {
+			bm.SpawnBot()
+		}
 	}
-}
 
-// --- helpers ---
-
-// pickBotName returns a random unused name from the pool.
-// If all names are taken, appends a number suffix to make it unique.
-func pickBotName() string {
-	// Shuffle and find first unused
-	perm := rand.Perm(len(botNames))
-	for _, i := range perm {
-		name := botNames[i]
-		if !botUsedNames[name] {
-			botUsedNames[name] = true
-			return name
+	target := bm.currentTarget()
+	active := 0
+	for _, bot := range bm.bots {
+		if bot.retireTicks == 0 {
+			active++
+		}
+	}
+	switch {
+	case active > target:
+		bm.retireExcess(active - target)
+	case active < target:
+		// Reinstate a retiring bot before spawning a fresh one
+		if !bm.unretireOne() {
+			bm.SpawnBot()
 		}
 	}
-	// All names taken — pick random + suffix
-	base := botNames[rand.Intn(len(botNames))]
-	for i := 2; ; i++ {
-		name := fmt.Sprintf("%s %d", base, i)
-		if !botUsedNames[name] {
-			botUsedNames[name] = true
-			return name
+}
+
+// despawn removes a bot and its snake, releasing its name
+func (bm *BotManager) despawn(id string) {
+	if s, ok := bm.world.Snakes[id]; ok {
+		bm.names.Release(s.Name)
+		bm.world.emit(snakeEvent(EventLeave, s))
+		if s.Alive {
+			bm.endLife(bm.bots[id], s, false)
 		}
 	}
+	bm.world.RemoveSnake(id)
+	delete(bm.bots, id)
 }
 
-// randomWanderDuration returns a tick count in [60, 120]
+// --- helpers ---
+
+// randomWanderDuration returns a tick count for 3–6 sec
 func randomWanderDuration() int {
-	return 60 + rand.Intn(61)
+	return randomTicks(3*time.Second, 6*time.Second)
 }
 
-// normalizeAngle wraps an angle into (-π, π]
+// normalizeAngle wraps an angle into [-π, π]. Uses math.Remainder rather
+// than a subtract loop so huge magnitudes can't spin forever.
 func normalizeAngle(a float64) float64 {
-	for a > math.Pi {
-		a -= 2 * math.Pi
-	}
-	for a < -math.Pi {
-		a += 2 * math.Pi
-	}
-	return a
+	return math.Remainder(a, 2*math.Pi)
 }
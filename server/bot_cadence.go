@@ -0,0 +1,26 @@
+package main
+
+// Bot AI is the largest slice of the tick that players never see directly,
+// so it is the first thing to give way when ticks run long: bots decide
+// every cadence ticks instead of every tick, staggered by their phase, and
+// keep steering on their last decision in between. Movement, collisions and
+// everything sent to players still run every tick.
+
+// due reports whether bot decides this tick
+func (bm *BotManager) due(bot *Bot) bool {
+	return (bm.world.Tick+bot.phase)%uint64(bm.cadence) == 0
+}
+
+// adaptCadence retunes the AI cadence from the last second of tick timing,
+// one step at a time: slower decisions when the mean tick is over
+// BotCadenceRaiseAt of the budget, back towards every tick once it is
+// under BotCadenceLowerAt. Game-loop goroutine only.
+func (bm *BotManager) adaptCadence(t *TickTiming) {
+	load := t.MeanMS / t.BudgetMS
+	switch {
+	case load > BotCadenceRaiseAt && bm.cadence < BotMaxCadence:
+		bm.cadence++
+	case load < BotCadenceLowerAt && bm.cadence > 1:
+		bm.cadence--
+	}
+}
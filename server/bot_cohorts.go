@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BotCohort is a tagged group of bots sharing one decision-maker — the
+// built-in heuristic rules or a learned Policy. Several cohorts run side by
+// side in the same world, spawned in equal numbers, so a new strategy is
+// measured against the current one under identical conditions.
+type BotCohort struct {
+	Name   string
+	Policy *Policy // nil = heuristic rules (decideBotInput)
+
+	// Totals over finished lives; game loop goroutine only
+	lives       int // lives ended by death or retirement
+	deaths      int
+	kills       int
+	aliveTicks  int64
+	scoreGained int64 // score above the starting length, summed over lives
+	peakScore   int
+}
+
+// CohortStats is a cohort's comparative summary, published with each tick
+// snapshot. Only finished lives count, so a bot still alive doesn't skew
+// averages with a half-played life.
+type CohortStats struct {
+	Name           string  `json:"name"`
+	Policy         string  `json:"policy,omitempty"` // policy name; empty = heuristic
+	Bots           int     `json:"bots"`             // bots in the cohort now
+	Lives          int     `json:"lives"`
+	Deaths         int     `json:"deaths"`
+	Kills          int     `json:"kills"`
+	AvgLifespanSec float64 `json:"avg_lifespan_sec"`
+	ScorePerMin    float64 `json:"score_per_min"` // score gained per minute alive
+	KillsPerLife   float64 `json:"kills_per_life"`
+	PeakScore      int     `json:"peak_score"`
+}
+
+// heuristicCohort is the cohort used when none are configured
+const heuristicCohort = "heuristic"
+
+// botCohortsFromEnv builds the cohorts from SLETHER_BOT_COHORTS, a comma
+// list of "name" (heuristic rules) or "name=/path/policy.json" entries, e.g.
+// "control,ppo-v3=/srv/ppo-v3.json". Without it SLETHER_BOT_POLICY, if set,
+// puts every bot on that policy; otherwise all bots are heuristic.
+func botCohortsFromEnv() ([]*BotCohort, error) {
+	if spec := os.Getenv("SLETHER_BOT_COHORTS"); spec != "" {
+		return parseBotCohorts(spec)
+	}
+	if path := os.Getenv("SLETHER_BOT_POLICY"); path != "" {
+		p, err := loadPolicy(path)
+		if err != nil {
+			return nil, err
+		}
+		return []*BotCohort{{Name: p.Name, Policy: p}}, nil
+	}
+	return []*BotCohort{{Name: heuristicCohort}}, nil
+}
+
+func parseBotCohorts(spec string) ([]*BotCohort, error) {
+	var cohorts []*BotCohort
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(spec, ",") {
+		name, path, hasPolicy := strings.Cut(strings.TrimSpace(entry), "=")
+		if name == "" || seen[name] {
+			return nil, fmt.Errorf("cohort %q: names must be non-empty and unique", entry)
+		}
+		seen[name] = true
+		c := &BotCohort{Name: name}
+		if hasPolicy {
+			p, err := loadPolicy(path)
+			if err != nil {
+				return nil, fmt.Errorf("cohort %s: %w", name, err)
+			}
+			c.Policy = p
+		}
+		cohorts = append(cohorts, c)
+	}
+	return cohorts, nil
+}
+
+// setCohorts replaces the cohorts and reassigns existing bots evenly across
+// them. Call before the loop starts or from the loop goroutine.
+func (bm *BotManager) setCohorts(cohorts []*BotCohort) {
+	bm.cohorts = cohorts
+	i := 0
+	for _, bot := range bm.bots {
+		bot.cohort = cohorts[i%len(cohorts)]
+		i++
+	}
+}
+
+// pickCohort returns the cohort with the fewest bots, keeping them balanced
+// as bots die, respawn and retire
+func (bm *BotManager) pickCohort() *BotCohort {
+	counts := make(map[*BotCohort]int, len(bm.cohorts))
+	for _, bot := range bm.bots {
+		counts[bot.cohort]++
+	}
+	best := bm.cohorts[0]
+	for _, c := range bm.cohorts[1:] {
+		if counts[c] < counts[best] {
+			best = c
+		}
+	}
+	return best
+}
+
+// endLife credits a finished bot life to its cohort; died is false for a
+// bot retired while alive. Caller must hold w.mu.Lock.
+func (bm *BotManager) endLife(bot *Bot, snake *Snake, died bool) {
+	c := bot.cohort
+	c.lives++
+	if died {
+		c.deaths++
+	}
+	c.kills += snake.Kills
+	c.aliveTicks += int64(bm.world.Tick - bot.spawnTick)
+	c.scoreGained += int64(max(0, snake.Score-SnakeInitSegments))
+	c.peakScore = max(c.peakScore, snake.Score)
+}
+
+// cohortStats summarizes every cohort for the tick snapshot
+func (bm *BotManager) cohortStats() []CohortStats {
+	counts := make(map[*BotCohort]int, len(bm.cohorts))
+	for _, bot := range bm.bots {
+		counts[bot.cohort]++
+	}
+	stats := make([]CohortStats, len(bm.cohorts))
+	for i, c := range bm.cohorts {
+		s := CohortStats{
+			Name:      c.Name,
+			Bots:      counts[c],
+			Lives:     c.lives,
+			Deaths:    c.deaths,
+			Kills:     c.kills,
+			PeakScore: c.peakScore,
+		}
+		if c.Policy != nil {
+			s.Policy = c.Policy.Name
+		}
+		if c.lives > 0 {
+			aliveSec := float64(c.aliveTicks) / float64(TickRate)
+			s.AvgLifespanSec = aliveSec / float64(c.lives)
+			s.KillsPerLife = float64(c.kills) / float64(c.lives)
+			if aliveSec > 0 {
+				s.ScorePerMin = float64(c.scoreGained) / aliveSec * 60
+			}
+		}
+		stats[i] = s
+	}
+	return stats
+}
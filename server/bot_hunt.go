@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// BotHuntConfig is how hostile a world's bots are to players. A bot out
+// hunting weighs every smaller snake within BotChaseRadius by closeness;
+// a player's weight is further scaled by Humans (1 = no preference, 0.5 =
+// a bot twice as far is as tempting, 0 = bots never chase players), and
+// players scoring under MinScore are never chased, so newcomers get room
+// to grow. Bots still flee and dodge players as usual. Immutable once the
+// world runs.
+type BotHuntConfig struct {
+	Humans   float64
+	MinScore int
+}
+
+// defaultBotHunt is a world's bot hostility unless configured
+var defaultBotHunt = BotHuntConfig{Humans: 1}
+
+// botHuntFromEnv reads SLETHER_BOT_HUNT_HUMANS (the weight of players as
+// prey, 0-BotHuntMaxHumans) and SLETHER_BOT_HUNT_MIN_SCORE (players below
+// it are left alone, 0-BotHuntMaxMinScore)
+func botHuntFromEnv() (BotHuntConfig, error) {
+	cfg := defaultBotHunt
+	if v := os.Getenv("SLETHER_BOT_HUNT_HUMANS"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(f >= 0 && f <= BotHuntMaxHumans) {
+			return cfg, fmt.Errorf("SLETHER_BOT_HUNT_HUMANS=%q: want 0-%g", v, BotHuntMaxHumans)
+		}
+		cfg.Humans = f
+	}
+	if v := os.Getenv("SLETHER_BOT_HUNT_MIN_SCORE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > BotHuntMaxMinScore {
+			return cfg, fmt.Errorf("SLETHER_BOT_HUNT_MIN_SCORE=%q: want 0-%d", v, BotHuntMaxMinScore)
+		}
+		cfg.MinScore = n
+	}
+	return cfg, nil
+}
+
+// weight is how keen a bot is to chase prey, 0 for never
+func (c BotHuntConfig) weight(prey SnakeView) float64 {
+	if strings.HasPrefix(prey.ID, "bot-") {
+		return 1
+	}
+	if prey.Score < c.MinScore {
+		return 0
+	}
+	return c.Humans
+}
+
+// chaseTarget picks the smaller snake snake's bot should chase: the one
+// with the best hunt weight for its distance, if any is worth chasing
+func (bm *BotManager) chaseTarget(snake *Snake, view WorldView) (SnakeView, bool) {
+	self := snakeViewOf(snake)
+	head := snake.Head()
+	hunt := bm.world.BotHunt
+	var best SnakeView
+	bestPull := 0.0
+	view.NearbySnakes(head.X, head.Y, BotChaseRadius, func(other SnakeView) bool {
+		if other.ID == snake.ID || !self.CanInteract(other) || other.Score >= snake.Score {
+			return true
+		}
+		w := hunt.weight(other)
+		if w <= 0 {
+			return true
+		}
+		// Closer prey pulls harder; the floor keeps a head-to-head finite
+		pull := w / math.Max(math.Hypot(other.X-head.X, other.Y-head.Y), 1)
+		if pull > bestPull {
+			best, bestPull = other, pull
+		}
+		return true
+	})
+	return best, bestPull > 0
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// BotNames hands out bot names so that no two bots in one world share a
+// name. A name is held from spawn until the bot despawns (a dead bot
+// waiting to respawn keeps its own), then goes back in the pool. Each
+// world's BotManager owns one, so worlds never compete for names; training
+// resets start from a fresh pool instead of inheriting names from the
+// worlds they replaced. Safe for concurrent use.
+type BotNames struct {
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+// NewBotNames creates a registry with every name free
+func NewBotNames() *BotNames {
+	return &BotNames{used: make(map[string]bool)}
+}
+
+// Acquire reserves and returns a random free name, drawing from the
+// community language's names first CommunityNameShare of the time (see
+// community.go). Once every name is taken it appends a number ("Viper 2").
+func (n *BotNames) Acquire(lang string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if l := findBotLocale(lang); l != nil && rand.Float64() < CommunityNameShare {
+		if name, ok := n.takeFree(l.Names); ok {
+			return name
+		}
+	}
+	if name, ok := n.takeFree(botNames); ok {
+		return name
+	}
+	base := botNames[rand.Intn(len(botNames))]
+	for i := 2; ; i++ {
+		if name := fmt.Sprintf("%s %d", base, i); !n.used[name] {
+			n.used[name] = true
+			return name
+		}
+	}
+}
+
+// takeFree reserves a random unused name from pool. Caller must hold n.mu.
+func (n *BotNames) takeFree(pool []string) (string, bool) {
+	for _, i := range rand.Perm(len(pool)) {
+		if name := pool[i]; !n.used[name] {
+			n.used[name] = true
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Release returns name to the pool; releasing a free name does nothing
+func (n *BotNames) Release(name string) {
+	n.mu.Lock()
+	delete(n.used, name)
+	n.mu.Unlock()
+}
+
+// InUse reports how many names are currently reserved
+func (n *BotNames) InUse() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.used)
+}
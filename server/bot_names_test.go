@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestBotNamesUnique(t *testing.T) {
+	n := NewBotNames()
+	seen := make(map[string]bool)
+	// Past the pool size, so suffixed names are handed out too
+	for i := range 2*len(botNames) + 5 {
+		name := n.Acquire("")
+		if seen[name] {
+			t.Fatalf("acquire %d: %q handed out twice", i, name)
+		}
+		seen[name] = true
+	}
+	if got := n.InUse(); got != len(seen) {
+		t.Fatalf("InUse = %d, want %d", got, len(seen))
+	}
+}
+
+func TestBotNamesRelease(t *testing.T) {
+	n := NewBotNames()
+	held := make([]string, len(botNames))
+	for i := range held {
+		held[i] = n.Acquire("")
+	}
+	for _, name := range held {
+		if !slices.Contains(botNames, name) {
+			t.Fatalf("%q handed out while pool names were free", name)
+		}
+	}
+
+	// With the pool exhausted, the one released name is the only plain one left
+	n.Release(held[3])
+	if got := n.Acquire(""); got != held[3] {
+		t.Fatalf("after releasing %q, acquired %q", held[3], got)
+	}
+	n.Release("not a held name")
+	if got := n.InUse(); got != len(botNames) {
+		t.Fatalf("InUse = %d, want %d", got, len(botNames))
+	}
+}
+
+func TestBotNamesPerWorld(t *testing.T) {
+	// Two worlds each get the whole pool; neither falls back to suffixes
+	a, b := NewBotNames(), NewBotNames()
+	for range botNames {
+		for _, n := range []*BotNames{a, b} {
+			if name := n.Acquire(""); !slices.Contains(botNames, name) {
+				t.Fatalf("got %q with pool names free in this world", name)
+			}
+		}
+	}
+}
+
+func TestBotNamesCommunity(t *testing.T) {
+	n := NewBotNames()
+	de := findBotLocale("de")
+	for range 200 {
+		n.Acquire("de")
+	}
+	// With far more bots than German names, every German name gets taken
+	for _, name := range de.Names {
+		n.mu.Lock()
+		used := n.used[name]
+		n.mu.Unlock()
+		if !used {
+			t.Errorf("community name %q never handed out", name)
+		}
+	}
+}
+
+// TestBotNamesConcurrent acquires and releases from many goroutines at
+// once; run with -race. Names held at the same time must stay distinct.
+func TestBotNamesConcurrent(t *testing.T) {
+	n := NewBotNames()
+	var mu sync.Mutex
+	live := make(map[string]string) // name -> holder
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			holder := fmt.Sprint(g)
+			for i := range 200 {
+				name := n.Acquire("")
+				mu.Lock()
+				if other, ok := live[name]; ok {
+					mu.Unlock()
+					t.Errorf("%q held by %s and %s", name, other, holder)
+					return
+				}
+				live[name] = holder
+				mu.Unlock()
+				if i%3 != 0 {
+					mu.Lock()
+					delete(live, name)
+					mu.Unlock()
+					n.Release(name)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := n.InUse(); got != len(live) {
+		t.Fatalf("InUse = %d, want %d still held", got, len(live))
+	}
+}
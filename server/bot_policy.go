@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Policy is a trained feed-forward network that drives a bot from the
+// observation vector (see observation.go). It is loaded from JSON written by
+// the training side:
+//
+//	{"name": "ppo-v3", "layers": [{"w": [[...], ...], "b": [...], "act": "tanh"}, ...]}
+//
+// Each layer computes act(w·x + b), w being outputs × inputs. The first layer
+// takes ObsFeatures inputs and the last must give two outputs: turn in
+// [-1, 1] (clamped; see actionAngle) and boost, on when > 0.
+type Policy struct {
+	Name   string        `json:"name"`
+	Layers []PolicyLayer `json:"layers"`
+}
+
+// PolicyLayer is one dense layer; Act is "tanh", "relu" or "" for linear
+type PolicyLayer struct {
+	W   [][]float64 `json:"w"`
+	B   []float64   `json:"b"`
+	Act string      `json:"act"`
+}
+
+// loadPolicy reads and validates a policy file
+func loadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Name == "" {
+		p.Name = path
+	}
+	return &p, nil
+}
+
+// validate checks that layer shapes chain from ObsFeatures to 2 outputs
+func (p *Policy) validate() error {
+	if len(p.Layers) == 0 {
+		return fmt.Errorf("no layers")
+	}
+	in := ObsFeatures
+	for i, l := range p.Layers {
+		if len(l.W) == 0 || len(l.B) != len(l.W) {
+			return fmt.Errorf("layer %d: %d weight rows, %d biases", i, len(l.W), len(l.B))
+		}
+		for _, row := range l.W {
+			if len(row) != in {
+				return fmt.Errorf("layer %d: row of %d weights, want %d inputs", i, len(row), in)
+			}
+		}
+		switch l.Act {
+		case "", "tanh", "relu":
+		default:
+			return fmt.Errorf("layer %d: unknown activation %q", i, l.Act)
+		}
+		in = len(l.W)
+	}
+	if in != 2 {
+		return fmt.Errorf("last layer has %d outputs, want 2 (turn, boost)", in)
+	}
+	return nil
+}
+
+// Act runs the network on an observation and returns (turn, boost)
+func (p *Policy) Act(obs []float64) (float64, bool) {
+	x := obs
+	for _, l := range p.Layers {
+		y := make([]float64, len(l.W))
+		for i, row := range l.W {
+			sum := l.B[i]
+			for j, w := range row {
+				sum += w * x[j]
+			}
+			switch l.Act {
+			case "tanh":
+				sum = math.Tanh(sum)
+			case "relu":
+				sum = math.Max(0, sum)
+			}
+			y[i] = sum
+		}
+		x = y
+	}
+	return x[0], x[1] > 0
+}
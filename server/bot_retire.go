@@ -0,0 +1,81 @@
+package main
+
+import "math"
+
+// botSightRadius is how far from a human head a bot counts as on screen:
+// half the viewport diagonal including the off-screen buffer
+var botSightRadius = math.Hypot(ViewportWidth/2+ViewportBuffer, ViewportHeight/2+ViewportBuffer)
+
+// currentTarget is the bot population to maintain right now. With
+// BotScaleWithPlayers, each human present takes one bot's place, down to
+// BotMinCount.
+func (bm *BotManager) currentTarget() int {
+	if !BotScaleWithPlayers {
+		return bm.target
+	}
+	humans := 0
+	for id := range bm.world.Snakes {
+		if _, isBot := bm.bots[id]; !isBot {
+			humans++
+		}
+	}
+	return max(min(BotMinCount, bm.target), bm.target-humans)
+}
+
+// retireExcess marks n active bots for retirement, smallest first so the
+// disappearing snakes are the least noticeable
+func (bm *BotManager) retireExcess(n int) {
+	for ; n > 0; n-- {
+		var pick *Bot
+		pickLen := math.MaxInt
+		for id, bot := range bm.bots {
+			if bot.retireTicks > 0 {
+				continue
+			}
+			length := 0 // dead bots awaiting respawn go first
+			if s, ok := bm.world.Snakes[id]; ok && s.Alive {
+				length = len(s.Segments)
+			}
+			if length < pickLen {
+				pick, pickLen = bot, length
+			}
+		}
+		if pick == nil {
+			return
+		}
+		pick.retireTicks = ticksFor(BotRetireFadeAfter)
+	}
+}
+
+// unretireOne returns a retiring bot to service; false if none is retiring
+func (bm *BotManager) unretireOne() bool {
+	for _, bot := range bm.bots {
+		if bot.retireTicks > 0 {
+			bot.retireTicks = 0
+			return true
+		}
+	}
+	return false
+}
+
+// tryRetire advances a retiring bot. It vanishes as soon as no human has it
+// on screen; if still watched after BotRetireFadeAfter it fades into a small
+// food pile instead of popping out of existence. Returns true once the bot
+// is gone. Caller must hold w.mu.Lock.
+func (bm *BotManager) tryRetire(bot *Bot, snake *Snake, view WorldView) bool {
+	head := snake.Head()
+	watched := false
+	view.NearbySnakes(head.X, head.Y, botSightRadius, func(s SnakeView) bool {
+		_, isBot := bm.bots[s.ID]
+		watched = !isBot
+		return !watched
+	})
+	if watched {
+		if bot.retireTicks--; bot.retireTicks > 0 {
+			return false
+		}
+		bm.world.dropBody(snake, BotRetireFoodFactor)
+	}
+	bm.despawn(bot.ID)
+	return true
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// botSpawnPoint picks where a (re)spawning bot appears. With
+// BotSpawnAwayFromHumans off it is the usual uniform spawn. Otherwise
+// BotSpawnCandidates uniform points are drawn and weighted by distance from
+// the nearest human head (nothing inside BotSpawnMinHumanDist, full weight
+// from twice that) and by how empty their surroundings are; one is then
+// picked at random by weight, so bots still spread across the map instead of
+// piling into the single emptiest corner. Caller must hold w.mu.Lock.
+func (bm *BotManager) botSpawnPoint() (float64, float64) {
+	if !BotSpawnAwayFromHumans {
+		return randomSpawnPoint()
+	}
+	view := bm.world.LiveView()
+
+	type candidate struct {
+		x, y, weight float64
+		humanDist    float64
+	}
+	cands := make([]candidate, BotSpawnCandidates)
+	total := 0.0
+	for i := range cands {
+		x, y := randomSpawnPoint()
+		c := candidate{x: x, y: y, humanDist: math.Inf(1)}
+		view.NearbySnakes(x, y, 2*BotSpawnMinHumanDist, func(s SnakeView) bool {
+			if _, isBot := bm.bots[s.ID]; !isBot {
+				c.humanDist = min(c.humanDist, math.Hypot(s.X-x, s.Y-y))
+			}
+			return true
+		})
+		// 0 within the minimum distance, ramping to 1 at twice it
+		away := math.Max(0, math.Min(1, c.humanDist/BotSpawnMinHumanDist-1))
+		d := view.Density(x, y, BotSpawnDensityRadius)
+		c.weight = away / (1 + float64(d.Snakes) + float64(d.Segments)/SnakeInitSegments)
+		total += c.weight
+		cands[i] = c
+	}
+
+	if total == 0 {
+		// Humans near every candidate — take the one farthest from them
+		best := cands[0]
+		for _, c := range cands[1:] {
+			if c.humanDist > best.humanDist {
+				best = c
+			}
+		}
+		return best.x, best.y
+	}
+	pick := rand.Float64() * total
+	for _, c := range cands {
+		if pick < c.weight {
+			return c.x, c.y
+		}
+		pick -= c.weight
+	}
+	last := cands[len(cands)-1]
+	return last.x, last.y
+}
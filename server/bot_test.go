@@ -0,0 +1,237 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// stubView is a fixed WorldView for driving bot decisions without a world
+type stubView struct {
+	snakes []SnakeView
+	bodies []BodyView
+	food   []FoodView
+}
+
+func (v stubView) Tick() uint64 { return 0 }
+
+func (v stubView) Snake(id string) (SnakeView, bool) {
+	for _, s := range v.snakes {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return SnakeView{}, false
+}
+
+func (v stubView) NearbyFood(x, y, radius float64, fn func(FoodView) bool) {
+	for _, f := range v.food {
+		if math.Hypot(f.X-x, f.Y-y) <= radius && !fn(f) {
+			return
+		}
+	}
+}
+
+func (v stubView) NearbySnakes(x, y, radius float64, fn func(SnakeView) bool) {
+	for _, s := range v.snakes {
+		if math.Hypot(s.X-x, s.Y-y) <= radius && !fn(s) {
+			return
+		}
+	}
+}
+
+func (v stubView) NearbyBodies(x, y, radius float64, excludeID string, fn func(BodyView) bool) {
+	for _, b := range v.bodies {
+		if b.SnakeID != excludeID && math.Hypot(b.X-x, b.Y-y) <= radius && !fn(b) {
+			return
+		}
+	}
+}
+
+func (v stubView) Density(x, y, radius float64) Density {
+	return densityOf(v, x, y, radius)
+}
+
+func (v stubView) Objective() (float64, float64, bool) { return 0, 0, false }
+
+// botTestSnake builds a straight snake with its head at (x,y) facing angle
+func botTestSnake(id string, x, y, angle float64, length int) *Snake {
+	s := NewSnake(id, id, "#ffffff")
+	s.Angle = angle
+	s.Segments = make([]Point, length)
+	for i := range s.Segments {
+		s.Segments[i] = Point{
+			X: x - float64(i)*SnakeSegmentSpacing*math.Cos(angle),
+			Y: y - float64(i)*SnakeSegmentSpacing*math.Sin(angle),
+		}
+	}
+	s.Score = length
+	s.prevHead = s.Segments[0]
+	s.recomputeBounds()
+	return s
+}
+
+// angleClose reports whether two headings match within a small tolerance
+func angleClose(a, b float64) bool {
+	return math.Abs(normalizeAngle(a-b)) < 1e-6
+}
+
+func TestBotDecisions(t *testing.T) {
+	const cx, cy = WorldCenterX, WorldCenterY
+	tests := []struct {
+		name      string
+		head      Point
+		angle     float64
+		length    int
+		view      stubView
+		hunt      *BotHuntConfig // the world's, default if nil
+		wantAngle float64
+		wantBoost bool
+	}{
+		{
+			name:      "near boundary steers toward center without boosting",
+			head:      Point{X: cx + WorldRadius - BotBoundaryBuffer/2, Y: cy},
+			angle:     0,
+			length:    SnakeInitSegments,
+			wantAngle: math.Pi,
+		},
+		{
+			name:      "near boundary on a diagonal steers back through center",
+			head:      Point{X: cx - (WorldRadius-100)/math.Sqrt2, Y: cy - (WorldRadius-100)/math.Sqrt2},
+			angle:     math.Pi,
+			length:    SnakeInitSegments,
+			wantAngle: math.Pi / 4,
+		},
+		{
+			name:   "body just clockwise of heading turns 90 degrees counter-clockwise",
+			head:   Point{X: cx, Y: cy},
+			angle:  0,
+			length: SnakeInitSegments,
+			view: stubView{bodies: []BodyView{
+				{SnakeID: "other", X: cx + 40, Y: cy + 10},
+			}},
+			wantAngle: -math.Pi / 2,
+		},
+		{
+			name:   "bigger snake within flee radius: flee directly away and boost",
+			head:   Point{X: cx, Y: cy},
+			angle:  math.Pi / 2,
+			length: SnakeInitSegments,
+			view: stubView{snakes: []SnakeView{
+				{ID: "big", X: cx + BotFleeRadius/2, Y: cy, Score: 500},
+			}},
+			wantAngle: math.Pi,
+			wantBoost: true,
+		},
+		{
+			name:   "bigger snake outside flee radius is ignored",
+			head:   Point{X: cx, Y: cy},
+			angle:  math.Pi / 2,
+			length: SnakeInitSegments,
+			view: stubView{snakes: []SnakeView{
+				{ID: "big", X: cx + BotFleeRadius + 50, Y: cy, Score: 500},
+			}},
+			wantAngle: math.Pi / 2, // keeps the current wander heading
+		},
+		{
+			name:   "smaller snake within chase radius: chase and boost when affordable",
+			head:   Point{X: cx, Y: cy},
+			angle:  0,
+			length: SnakeMinSegments + 20,
+			view: stubView{snakes: []SnakeView{
+				{ID: "small", X: cx, Y: cy + BotChaseRadius/2, Score: 1},
+			}},
+			wantAngle: math.Pi / 2,
+			wantBoost: true,
+		},
+		{
+			name:   "players weighted at half: a farther bot is chased over a nearer player",
+			head:   Point{X: cx, Y: cy},
+			angle:  0,
+			length: SnakeMinSegments + 20,
+			view: stubView{snakes: []SnakeView{
+				{ID: "player-1", X: cx, Y: cy + 100, Score: 1},
+				{ID: "bot-7", X: cx - 150, Y: cy, Score: 1},
+			}},
+			hunt:      &BotHuntConfig{Humans: 0.5},
+			wantAngle: math.Pi,
+			wantBoost: true,
+		},
+		{
+			name:   "players under the hunt min score are left alone",
+			head:   Point{X: cx, Y: cy},
+			angle:  0,
+			length: SnakeMinSegments + 20,
+			view: stubView{snakes: []SnakeView{
+				{ID: "player-1", X: cx, Y: cy + 100, Score: 1},
+			}},
+			hunt:      &BotHuntConfig{Humans: 1, MinScore: 50},
+			wantAngle: 0, // keeps the current wander heading
+		},
+		{
+			name:   "food behind is skipped, food ahead is targeted",
+			head:   Point{X: cx, Y: cy},
+			angle:  0,
+			length: SnakeInitSegments,
+			view: stubView{food: []FoodView{
+				{ID: 1, X: cx - 20, Y: cy, Value: 1},
+				{ID: 2, X: cx + 100, Y: cy + 100, Value: 1},
+			}},
+			wantAngle: math.Pi / 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snake := botTestSnake("bot", tt.head.X, tt.head.Y, tt.angle, tt.length)
+			bot := &Bot{ID: "bot", targetAngle: tt.angle, wanderTicks: 100, lastScore: snake.Score}
+			world := newEmptyWorld()
+			if tt.hunt != nil {
+				world.BotHunt = *tt.hunt
+			}
+			bm := NewBotManager(world)
+
+			angle, boost := bm.decideBotInput(bot, snake, tt.view)
+			if !angleClose(angle, tt.wantAngle) {
+				t.Errorf("angle = %.4f, want %.4f", angle, tt.wantAngle)
+			}
+			if boost != tt.wantBoost {
+				t.Errorf("boost = %v, want %v", boost, tt.wantBoost)
+			}
+		})
+	}
+}
+
+// A bot whose distance to its food target stops shrinking is circling it;
+// after the orbit threshold it must give up and break away sideways.
+func TestBotOrbitBailout(t *testing.T) {
+	snake := botTestSnake("bot", WorldCenterX, WorldCenterY, 0, SnakeInitSegments)
+	bot := &Bot{ID: "bot", wanderTicks: 100, lastScore: snake.Score}
+	bm := NewBotManager(newEmptyWorld())
+	// Food ahead-left; the snake never moves, so the distance never shrinks
+	food := FoodView{ID: 1, X: WorldCenterX + 50, Y: WorldCenterY - 50, Value: 1}
+	view := stubView{food: []FoodView{food}}
+	toFood := math.Atan2(food.Y-WorldCenterY, food.X-WorldCenterX)
+
+	limit := ticksFor(BotOrbitBailout)
+	for i := 0; i < limit; i++ {
+		angle, _ := bm.decideBotInput(bot, snake, view)
+		if !angleClose(angle, toFood) {
+			t.Fatalf("call %d: angle %.4f, want food heading %.4f", i, angle, toFood)
+		}
+	}
+
+	angle, boost := bm.decideBotInput(bot, snake, view)
+	if boost {
+		t.Error("bailout should not boost")
+	}
+	// Break-away heading is current + [π/2, 3π/2): anywhere but straight on
+	if off := math.Abs(normalizeAngle(angle - snake.Angle)); off < math.Pi/2-1e-9 {
+		t.Errorf("bailout heading %.4f is only %.4f rad off current heading", angle, off)
+	}
+	if bot.orbitCount != 0 || bot.seekTicks != 0 || bot.lastFoodDist != 0 {
+		t.Errorf("seek state not reset: orbit=%d seek=%d lastDist=%v", bot.orbitCount, bot.seekTicks, bot.lastFoodDist)
+	}
+	if bot.wanderTicks <= 0 {
+		t.Errorf("wanderTicks = %d, want a fresh wander period", bot.wanderTicks)
+	}
+}
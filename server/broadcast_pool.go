@@ -0,0 +1,64 @@
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// sendJob is one pre-built message bound for one connection
+type sendJob struct {
+	conn *Conn
+	msg  interface{}
+}
+
+// BroadcastPool fans JSON encoding out across a fixed set of worker
+// goroutines, so a large player count doesn't serialize encode time into the
+// game-loop goroutine. Network writes happen in each Conn's own writer.
+type BroadcastPool struct {
+	jobs chan poolJob
+}
+
+// poolJob pairs a send with the WaitGroup of the batch it belongs to
+type poolJob struct {
+	sendJob
+	wg *sync.WaitGroup
+}
+
+// NewBroadcastPool starts workers goroutines that run until Close
+func NewBroadcastPool(workers int) *BroadcastPool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &BroadcastPool{jobs: make(chan poolJob, workers*4)}
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *BroadcastPool) worker() {
+	for j := range p.jobs {
+		if err := j.conn.Send(j.msg); err != nil {
+			log.Printf("send error to %s: %v", j.conn.ID, err)
+		}
+		j.wg.Done()
+	}
+}
+
+// SendAll queues every job and blocks until all have been encoded and handed
+// to their connections' send queues
+func (p *BroadcastPool) SendAll(jobs []sendJob) {
+	var wg sync.WaitGroup
+	wg.Add(len(jobs))
+	for _, j := range jobs {
+		p.jobs <- poolJob{sendJob: j, wg: &wg}
+	}
+	wg.Wait()
+}
+
+// Close stops the workers once queued jobs finish; SendAll must not be
+// called afterwards. Only needed for loops that don't live as long as the
+// process (the training environment).
+func (p *BroadcastPool) Close() {
+	close(p.jobs)
+}
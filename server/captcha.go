@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// captchaProvider holds where a provider's widget loads from and where its
+// tokens are checked
+type captchaProvider struct {
+	verifyURL string
+	sources   string // CSP sources the widget needs (script, frame, style, connect)
+}
+
+var captchaProviders = map[string]captchaProvider{
+	"turnstile": {
+		verifyURL: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+		sources:   "https://challenges.cloudflare.com",
+	},
+	"hcaptcha": {
+		verifyURL: "https://api.hcaptcha.com/siteverify",
+		sources:   "https://hcaptcha.com https://*.hcaptcha.com",
+	},
+}
+
+// CaptchaGate makes a connection prove it is a person before its first
+// join, for deployments flooded by bots. The welcome message names the
+// provider and site key; the client renders the widget and sends its token
+// with the join, which the server checks with the provider once per
+// connection. Clients connecting with one of the API keys skip the check.
+// A nil gate lets everyone through. Immutable after captchaFromEnv.
+type CaptchaGate struct {
+	provider string
+	siteKey  string
+	secret   string
+	apiKeys  []string
+	client   *http.Client
+}
+
+// captchaFromEnv reads SLETHER_CAPTCHA (turnstile or hcaptcha; unset
+// disables the gate) with SLETHER_CAPTCHA_SITE_KEY and
+// SLETHER_CAPTCHA_SECRET, and SLETHER_API_KEYS, a comma-separated list of
+// keys that exempt a client
+func captchaFromEnv() (*CaptchaGate, error) {
+	provider := os.Getenv("SLETHER_CAPTCHA")
+	if provider == "" {
+		return nil, nil
+	}
+	if _, ok := captchaProviders[provider]; !ok {
+		return nil, fmt.Errorf("SLETHER_CAPTCHA=%q: want turnstile or hcaptcha", provider)
+	}
+	g := &CaptchaGate{
+		provider: provider,
+		siteKey:  os.Getenv("SLETHER_CAPTCHA_SITE_KEY"),
+		secret:   os.Getenv("SLETHER_CAPTCHA_SECRET"),
+		client:   &http.Client{Timeout: CaptchaVerifyTimeout},
+	}
+	if g.siteKey == "" || g.secret == "" {
+		return nil, errors.New("SLETHER_CAPTCHA needs SLETHER_CAPTCHA_SITE_KEY and SLETHER_CAPTCHA_SECRET")
+	}
+	for _, k := range strings.Split(os.Getenv("SLETHER_API_KEYS"), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			g.apiKeys = append(g.apiKeys, k)
+		}
+	}
+	return g, nil
+}
+
+// exempt reports whether r needs no verification: the gate is off, or the
+// client presents an API key ("Authorization: Bearer <key>", or ?key= from
+// a browser)
+func (g *CaptchaGate) exempt(r *http.Request) bool {
+	if g == nil {
+		return true
+	}
+	key := r.URL.Query().Get("key")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		key = bearer
+	}
+	if key == "" {
+		return false
+	}
+	for _, k := range g.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// dto is what the welcome message tells an unverified client
+func (g *CaptchaGate) dto() *CaptchaDTO {
+	return &CaptchaDTO{Provider: g.provider, SiteKey: g.siteKey}
+}
+
+// cspSources lists the origins the widget loads from, "" when the gate is off
+func (g *CaptchaGate) cspSources() string {
+	if g == nil {
+		return ""
+	}
+	return captchaProviders[g.provider].sources
+}
+
+// Verify checks token with the provider; ip is passed along as a hint
+func (g *CaptchaGate) Verify(token, ip string) error {
+	if token == "" {
+		return errors.New("no verification token")
+	}
+	form := url.Values{"secret": {g.secret}, "response": {token}}
+	if ip, _, _ = strings.Cut(ip, ","); ip != "" {
+		form.Set("remoteip", strings.TrimSpace(ip))
+	}
+	resp, err := g.client.PostForm(captchaProviders[g.provider].verifyURL, form)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Success bool     `json:"success"`
+		Codes   []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return fmt.Errorf("verify: %s: %w", resp.Status, err)
+	}
+	if !result.Success {
+		return fmt.Errorf("rejected: %s", strings.Join(result.Codes, ", "))
+	}
+	return nil
+}
+
+// verifyJoin lets c's join through once token, the captcha token it sent,
+// checks out; otherwise c is disconnected so the client starts over with a
+// fresh widget. Runs on the connection's join path, under c.join.mu (see
+// join_gate.go).
+func (g *CaptchaGate) verifyJoin(c *Conn, token string) bool {
+	if c.verified.Load() {
+		return true
+	}
+	if err := g.Verify(token, c.ip); err != nil {
+		log.Printf("captcha: %s (%s) failed verification: %v", c.ID, c.ip, err)
+		slo.reject(rejectCaptcha, false)
+		c.Disconnect(CloseCaptcha, "Verification failed. Please try again.")
+		return false
+	}
+	c.verified.Store(true)
+	return true
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// connHealth tracks how well a connection keeps up with what is sent to
+// it. The writer goroutine records write times; the game loop checks every
+// connection once a second (checkClients) and switches one that falls
+// behind into lite mode: state every SlowClientStateEvery ticks without the
+// minimap, and no cosmetic low-priority messages. It switches back after
+// SlowClientRecoverChecks healthy checks in a row.
+type connHealth struct {
+	writes      atomic.Int64
+	writeNanos  atomic.Int64
+	maxNanos    atomic.Int64 // longest write since the last check
+	writeErrors atomic.Int64
+	lateWrites  atomic.Int64 // writes that missed the write deadline
+	lowDropped  atomic.Int64 // low-priority messages skipped in lite mode
+	lite        atomic.Bool
+
+	// Game-loop goroutine only
+	lastWrites, lastNanos, lastSuperseded int64
+	healthy                               int // consecutive healthy checks while lite
+	report                                atomic.Pointer[ClientHealth]
+}
+
+// ClientHealth is one connection's row in GET /admin/clients, as of the
+// last check. Rates and latencies cover the last SlowClientCheckInterval.
+type ClientHealth struct {
+	ID          string    `json:"id"`
+	Snake       string    `json:"snake"`
+	Name        string    `json:"name"`
+	Lite        bool      `json:"lite"`
+	LiteSince   time.Time `json:"lite_since,omitzero"`
+	Writes      int64     `json:"writes"`
+	WriteMeanMS float64   `json:"write_mean_ms"`
+	WriteMaxMS  float64   `json:"write_max_ms"`
+	Superseded  float64   `json:"superseded"` // share of state snapshots skipped or replaced while an older one was unsent
+	// Since connect
+	TotalSuperseded int64 `json:"total_superseded"`
+	LowDropped      int64 `json:"low_dropped"`
+	LateWrites      int64 `json:"late_writes"`
+	WriteErrors     int64 `json:"write_errors"`
+}
+
+// recordWrite adds one socket write taking d; writer goroutine only
+func (h *connHealth) recordWrite(d time.Duration) {
+	h.writes.Add(1)
+	h.writeNanos.Add(int64(d))
+	for {
+		cur := h.maxNanos.Load()
+		if int64(d) <= cur || h.maxNanos.CompareAndSwap(cur, int64(d)) {
+			return
+		}
+	}
+}
+
+// checkHealth evaluates the last interval and moves c in or out of lite mode.
+// Game-loop goroutine only.
+func (c *Conn) checkHealth(now time.Time) {
+	h := &c.health
+	writes, nanos := h.writes.Load(), h.writeNanos.Load()
+	superseded := c.out.supersededCount()
+	dw, dn, ds := writes-h.lastWrites, nanos-h.lastNanos, superseded-h.lastSuperseded
+	h.lastWrites, h.lastNanos, h.lastSuperseded = writes, nanos, superseded
+	maxNanos := h.maxNanos.Swap(0)
+
+	ms := func(ns int64) float64 { return float64(ns) / float64(time.Millisecond) }
+	r := &ClientHealth{
+		ID: c.ID, Snake: c.snakeID, Name: c.name(),
+		Writes:          dw,
+		WriteMaxMS:      ms(maxNanos),
+		TotalSuperseded: superseded,
+		LowDropped:      h.lowDropped.Load(),
+		LateWrites:      h.lateWrites.Load(),
+		WriteErrors:     h.writeErrors.Load(),
+	}
+	if dw > 0 {
+		r.WriteMeanMS = ms(dn / dw)
+	}
+	// Only state snapshots are superseded; those written plus those
+	// replaced is roughly what was offered
+	if offered := dw + ds; offered > 0 {
+		r.Superseded = float64(ds) / float64(offered)
+	}
+	slow := r.Superseded > SlowClientSuperseded || r.WriteMeanMS > float64(SlowClientWriteMean/time.Millisecond)
+
+	prev := h.report.Load()
+	switch {
+	case slow && !h.lite.Load():
+		h.lite.Store(true)
+		r.LiteSince = now
+		log.Printf("client %s (%s) is slow (%.0f%% snapshots superseded, %.1f ms mean write); lite mode on", c.ID, c.name(), 100*r.Superseded, r.WriteMeanMS)
+	case h.lite.Load():
+		if prev != nil {
+			r.LiteSince = prev.LiteSince
+		}
+		if slow {
+			h.healthy = 0
+		} else if h.healthy++; h.healthy >= SlowClientRecoverChecks {
+			h.lite.Store(false)
+			h.healthy = 0
+			r.LiteSince = time.Time{}
+			log.Printf("client %s (%s) caught up; lite mode off", c.ID, c.name())
+		}
+	}
+	r.Lite = h.lite.Load()
+	h.report.Store(r)
+}
+
+// checkClients runs every connection's health check each
+// SlowClientCheckInterval. Game-loop goroutine only.
+func (gl *GameLoop) checkClients() {
+	if gl.tickCount%ticksFor(SlowClientCheckInterval) != 0 {
+		return
+	}
+	now := time.Now()
+	for _, c := range gl.conns.Snapshot() {
+		c.checkHealth(now)
+	}
+}
+
+// liteSkip reports whether a lite connection skips this tick's state
+func (c *Conn) liteSkip(tick uint64) bool {
+	return c.health.lite.Load() && tick%SlowClientStateEvery != 0
+}
+
+// handleClients lists connections by health, lite ones first, then by
+// mean write time
+func (a *AdminAPI) handleClients(w http.ResponseWriter, r *http.Request) {
+	conns := a.conns.Snapshot()
+	list := make([]ClientHealth, 0, len(conns))
+	for _, c := range conns {
+		if h := c.health.report.Load(); h != nil {
+			list = append(list, *h)
+		}
+	}
+	slices.SortFunc(list, func(x, y ClientHealth) int {
+		switch {
+		case x.Lite != y.Lite:
+			if x.Lite {
+				return -1
+			}
+			return 1
+		case x.WriteMeanMS > y.WriteMeanMS:
+			return -1
+		case x.WriteMeanMS < y.WriteMeanMS:
+			return 1
+		}
+		return 0
+	})
+	lite := 0
+	for _, h := range list {
+		if h.Lite {
+			lite++
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"lite": lite, "clients": list})
+}
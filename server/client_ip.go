@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// Client addresses: a request's client is the peer it came from, unless
+// that peer is one of the reverse proxies the operator trusts, in which
+// case it is the address those proxies recorded in X-Forwarded-For. The
+// header is read right to left and trusted proxies skipped, since a client
+// can prepend any addresses it likes but not drop the one its first proxy
+// appends. A peer on a Unix socket is a local proxy and always trusted.
+// Bans, rate limits, captcha checks and machine-client flags all key on
+// this address, so a client can't shed them with a forged header.
+
+// trustedProxies are the networks whose X-Forwarded-For is believed
+type trustedProxies []netip.Prefix
+
+// trustedProxiesFromEnv reads SLETHER_TRUSTED_PROXIES, a comma-separated
+// list of proxy addresses or CIDR networks; unset trusts none
+func trustedProxiesFromEnv() (trustedProxies, error) {
+	v := os.Getenv("SLETHER_TRUSTED_PROXIES")
+	if v == "" {
+		return nil, nil
+	}
+	var proxies trustedProxies
+	for s := range strings.SplitSeq(v, ",") {
+		network, err := parseBanTarget(s)
+		if err != nil {
+			return nil, fmt.Errorf("SLETHER_TRUSTED_PROXIES: %v", err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// trusts reports whether addr is a trusted proxy
+func (p trustedProxies) trusts(addr netip.Addr) bool {
+	for _, network := range p {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerIP is the address r's connection comes from, "" on a Unix socket
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// clientIP is r's client address: the peer, or behind trusted proxies the
+// nearest X-Forwarded-For address that isn't one of them
+func (p trustedProxies) clientIP(r *http.Request) string {
+	peer := peerIP(r)
+	if addr, err := netip.ParseAddr(peer); err == nil && !p.trusts(addr.Unmap()) {
+		return peer
+	}
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break // a garbled hop: nothing further left is vouched for
+		}
+		if addr = addr.Unmap(); !p.trusts(addr) || i == 0 {
+			return addr.String()
+		}
+	}
+	return peer
+}
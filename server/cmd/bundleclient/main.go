@@ -0,0 +1,118 @@
+// Command bundleclient copies the static client into server/webclient so it
+// can be embedded in the server binary, writing Brotli- and gzip-compressed
+// siblings (name.br, name.gz) next to every compressible file large enough
+// to benefit.
+//
+// Usage (from server/):
+//
+//	go run ./cmd/bundleclient
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// minCompressSize skips files too small for compression framing to pay off
+const minCompressSize = 512
+
+// compressible lists extensions worth pre-compressing; images are already compressed
+var compressible = map[string]bool{
+	".html": true,
+	".css":  true,
+	".js":   true,
+	".json": true,
+	".svg":  true,
+	".txt":  true,
+	".ts":   true,
+}
+
+func main() {
+	src := flag.String("src", "../client", "client source directory")
+	dst := flag.String("dst", "webclient", "embed directory (contents are replaced)")
+	flag.Parse()
+
+	if err := clearDir(*dst); err != nil {
+		log.Fatalf("bundleclient: %v", err)
+	}
+
+	files, savedGz, savedBr := 0, 0, 0
+	err := filepath.WalkDir(*src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(*src, path)
+		if strings.HasPrefix(d.Name(), ".") && rel != "." {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		out := filepath.Join(*dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(out, 0o755)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(out, data, 0o644); err != nil {
+			return err
+		}
+		files++
+
+		if len(data) < minCompressSize || !compressible[filepath.Ext(path)] {
+			return nil
+		}
+		var gz bytes.Buffer
+		zw, _ := gzip.NewWriterLevel(&gz, gzip.BestCompression)
+		zw.Write(data)
+		zw.Close()
+		if gz.Len() < len(data) {
+			savedGz += len(data) - gz.Len()
+			if err := os.WriteFile(out+".gz", gz.Bytes(), 0o644); err != nil {
+				return err
+			}
+		}
+		var br bytes.Buffer
+		bw := brotli.NewWriterLevel(&br, brotli.BestCompression)
+		bw.Write(data)
+		bw.Close()
+		if br.Len() < len(data) {
+			savedBr += len(data) - br.Len()
+			return os.WriteFile(out+".br", br.Bytes(), 0o644)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("bundleclient: %v", err)
+	}
+	log.Printf("bundleclient: %d files into %s (gzip saves %d bytes, brotli %d)", files, *dst, savedGz, savedBr)
+}
+
+// clearDir empties dir, keeping the tracked .gitkeep placeholder that lets
+// the embed directive compile before the first bundle
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Name() == ".gitkeep" {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+// Command protogen generates client-side protocol definitions from
+// server/protocol.go, so the Go DTOs stay the single source of truth.
+//
+// It emits two files:
+//   - a TypeScript declaration file with one interface per DTO struct, for
+//     editors and `// @ts-check` in the vanilla JS client
+//   - an ES module with the Msg* type constants the client switches on and
+//     the Close* WebSocket close codes
+//
+// Usage (from server/):
+//
+//	go run ./cmd/protogen            # rewrite the client files
+//	go run ./cmd/protogen -check     # exit 1 if they are stale
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const header = "// Code generated by server/cmd/protogen from server/protocol.go. DO NOT EDIT.\n"
+
+func main() {
+	in := flag.String("in", "protocol.go", "Go source defining the wire protocol")
+	tsOut := flag.String("ts", "../client/protocol.d.ts", "TypeScript declaration output")
+	jsOut := flag.String("js", "../client/protocol.js", "JS constants module output")
+	check := flag.Bool("check", false, "verify outputs are up to date instead of writing them")
+	flag.Parse()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, *in, nil, parser.ParseComments)
+	if err != nil {
+		log.Fatalf("protogen: %v", err)
+	}
+
+	consts, codes, structs := collect(file)
+	outputs := map[string][]byte{
+		*tsOut: renderTS(consts, structs),
+		*jsOut: renderJS(consts, codes),
+	}
+
+	stale := false
+	for path, want := range outputs {
+		if !*check {
+			if err := os.WriteFile(path, want, 0o644); err != nil {
+				log.Fatalf("protogen: %v", err)
+			}
+			continue
+		}
+		have, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(have, want) {
+			fmt.Fprintf(os.Stderr, "protogen: %s is out of date — run `go generate` in server/\n", path)
+			stale = true
+		}
+	}
+	if stale {
+		os.Exit(1)
+	}
+}
+
+// msgConst is a message type identifier such as MsgJoin = "j"
+type msgConst struct {
+	name  string
+	value string
+}
+
+// closeCode is an application WebSocket close code such as CloseBanned = 4101
+type closeCode struct {
+	name  string
+	value string
+}
+
+// wireStruct is a DTO with at least one json-tagged field
+type wireStruct struct {
+	name   string
+	doc    string
+	fields []wireField
+}
+
+type wireField struct {
+	key      string
+	tsType   string
+	optional bool
+	comment  string
+}
+
+// collect pulls Msg* string constants, Close* integer constants and
+// json-tagged structs out of file, in source order
+func collect(file *ast.File) ([]msgConst, []closeCode, []wireStruct) {
+	var consts []msgConst
+	var codes []closeCode
+	var structs []wireStruct
+
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			switch s := spec.(type) {
+			case *ast.ValueSpec:
+				if gd.Tok != token.CONST {
+					continue
+				}
+				for i, name := range s.Names {
+					if i >= len(s.Values) {
+						continue
+					}
+					lit, ok := s.Values[i].(*ast.BasicLit)
+					if !ok {
+						continue
+					}
+					switch {
+					case strings.HasPrefix(name.Name, "Msg") && lit.Kind == token.STRING:
+						v, _ := strconv.Unquote(lit.Value)
+						consts = append(consts, msgConst{name: name.Name, value: v})
+					case strings.HasPrefix(name.Name, "Close") && lit.Kind == token.INT:
+						codes = append(codes, closeCode{name: name.Name, value: lit.Value})
+					}
+				}
+
+			case *ast.TypeSpec:
+				st, ok := s.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				ws := wireStruct{name: s.Name.Name, doc: gd.Doc.Text()}
+				for _, f := range st.Fields.List {
+					if f.Tag == nil || len(f.Names) == 0 {
+						continue
+					}
+					tag, _ := strconv.Unquote(f.Tag.Value)
+					key, opts, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+					if key == "" || key == "-" {
+						continue
+					}
+					ws.fields = append(ws.fields, wireField{
+						key:      key,
+						tsType:   tsType(f.Type),
+						optional: strings.Contains(opts, "omitempty"),
+						comment:  strings.TrimSpace(f.Comment.Text()),
+					})
+				}
+				if len(ws.fields) > 0 {
+					structs = append(structs, ws)
+				}
+			}
+		}
+	}
+	return consts, codes, structs
+}
+
+// tsType maps a Go field type to its JSON shape in TypeScript
+func tsType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return "string"
+		case "bool":
+			return "boolean"
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64",
+			"float32", "float64":
+			return "number"
+		default:
+			return t.Name
+		}
+	case *ast.StarExpr:
+		return tsType(t.X) + " | null"
+	case *ast.ArrayType:
+		elem := tsType(t.Elt)
+		if lit, ok := t.Len.(*ast.BasicLit); ok {
+			// Fixed-size arrays marshal as tuples, e.g. [2]float64 -> [number, number]
+			n, _ := strconv.Atoi(lit.Value)
+			parts := make([]string, n)
+			for i := range parts {
+				parts[i] = elem
+			}
+			return "[" + strings.Join(parts, ", ") + "]"
+		}
+		if strings.Contains(elem, " | ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case *ast.MapType:
+		return "Record<string, " + tsType(t.Value) + ">"
+	}
+	return "unknown"
+}
+
+func renderTS(consts []msgConst, structs []wireStruct) []byte {
+	var b strings.Builder
+	b.WriteString(header + "\n")
+
+	b.WriteString("/** Value of the \"t\" field on every message */\nexport type MsgType =\n")
+	for i, c := range consts {
+		sep := ""
+		if i == len(consts)-1 {
+			sep = ";"
+		}
+		fmt.Fprintf(&b, "  | %q%s // %s\n", c.value, sep, c.name)
+	}
+
+	for _, s := range structs {
+		b.WriteString("\n")
+		if doc := strings.TrimSpace(s.doc); doc != "" {
+			b.WriteString("/**\n")
+			for _, line := range strings.Split(doc, "\n") {
+				b.WriteString(strings.TrimRight(" * "+line, " ") + "\n")
+			}
+			b.WriteString(" */\n")
+		}
+		fmt.Fprintf(&b, "export interface %s {\n", s.name)
+		for _, f := range s.fields {
+			opt := ""
+			if f.optional {
+				opt = "?"
+			}
+			line := fmt.Sprintf("  %s%s: %s;", f.key, opt, f.tsType)
+			if f.comment != "" {
+				line += " // " + f.comment
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("}\n")
+	}
+	return []byte(b.String())
+}
+
+func renderJS(consts []msgConst, codes []closeCode) []byte {
+	var b strings.Builder
+	b.WriteString(header + "\n")
+	b.WriteString("// Message type identifiers — value of the \"t\" field\n")
+	for _, c := range consts {
+		fmt.Fprintf(&b, "export const %s = '%s';\n", c.name, c.value)
+	}
+	if len(codes) > 0 {
+		b.WriteString("\n// WebSocket close codes the server disconnects with\n")
+		for _, c := range codes {
+			fmt.Fprintf(&b, "export const %s = %s;\n", c.name, c.value)
+		}
+	}
+	return []byte(b.String())
+}
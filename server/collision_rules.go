@@ -0,0 +1,65 @@
+package main
+
+import "math"
+
+// tryGrazeBounce applies the casual-mode graze rule to snake whose head
+// touched the body point (bx,by). If the head is moving into the body at no
+// more than CasualBounceMaxAngle from its surface and isn't boosting, the
+// heading is reflected about the contact normal, the head is pushed back out
+// to touching distance, and true is returned. Otherwise the collision stands.
+func tryGrazeBounce(snake *Snake, bx, by float64) bool {
+	if !CasualBounceEnabled || snake.BoostActive {
+		return false
+	}
+	head := snake.Head()
+	nx, ny := head.X-bx, head.Y-by
+	dist := math.Hypot(nx, ny)
+	if dist == 0 {
+		return false // dead center: no usable normal
+	}
+	nx, ny = nx/dist, ny/dist
+
+	// Heading component along the normal; negative means moving into the body
+	vx, vy := math.Cos(snake.Angle), math.Sin(snake.Angle)
+	into := vx*nx + vy*ny
+	if into < 0 && math.Asin(-into) > CasualBounceMaxAngle {
+		return false // head-on hit, not a graze
+	}
+
+	if into < 0 {
+		// Reflect: v' = v - 2(v·n)n
+		vx -= 2 * into * nx
+		vy -= 2 * into * ny
+		snake.Angle = math.Atan2(vy, vx)
+	}
+	contact := SnakeHeadRadius + SnakeBodyRadius
+	snake.boundsRemove(head)
+	snake.Segments[0] = Point{X: bx + nx*contact, Y: by + ny*contact}
+	snake.boundsAddHead(snake.Segments[0])
+	return true
+}
+
+// applyDamage converts collision deaths into hits under the damage model:
+// each victim loses DamageSegmentsPerHit segments (dropping food where they
+// were) and survives unless that would leave SnakeMinSegments or fewer.
+// Invulnerable snakes ignore collisions entirely. Surviving victims are
+// removed from deaths. Caller must hold w.mu.Lock.
+func (gl *GameLoop) applyDamage(deaths map[string]string) {
+	w := gl.world
+	for victimID := range deaths {
+		snake := w.Snakes[victimID]
+		if snake == nil {
+			continue
+		}
+		if snake.InvulnTicks > 0 {
+			delete(deaths, victimID)
+			continue
+		}
+		before := snake.Score
+		if dropped, survived := snake.TakeHit(DamageSegmentsPerHit, w.FoodPool); survived {
+			w.AddFood(dropped)
+			w.Economy.damaged(before-snake.Score, dropped)
+			delete(deaths, victimID)
+		}
+	}
+}
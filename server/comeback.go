@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// ComebackConfig is a world's comeback rule: snakes shorter than the median
+// live snake get a modest passive bonus, so a player who just died can
+// catch back up. Off unless a bonus is set. Immutable once the world runs.
+type ComebackConfig struct {
+	Speed float64 // extra base (non-boost) speed, e.g. 0.1 = 10% faster
+	Food  float64 // extra food value, e.g. 0.25 = food worth 25% more
+}
+
+// enabled reports whether either bonus is set
+func (c ComebackConfig) enabled() bool {
+	return c.Speed > 0 || c.Food > 0
+}
+
+// comebackFromEnv reads SLETHER_COMEBACK_SPEED (0-ComebackMaxSpeed) and
+// SLETHER_COMEBACK_FOOD (0-ComebackMaxFood), both fractions
+func comebackFromEnv() (ComebackConfig, error) {
+	var cfg ComebackConfig
+	if v := os.Getenv("SLETHER_COMEBACK_SPEED"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(f >= 0 && f <= ComebackMaxSpeed) {
+			return cfg, fmt.Errorf("SLETHER_COMEBACK_SPEED=%q: want 0-%g", v, ComebackMaxSpeed)
+		}
+		cfg.Speed = f
+	}
+	if v := os.Getenv("SLETHER_COMEBACK_FOOD"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(f >= 0 && f <= ComebackMaxFood) {
+			return cfg, fmt.Errorf("SLETHER_COMEBACK_FOOD=%q: want 0-%g", v, ComebackMaxFood)
+		}
+		cfg.Food = f
+	}
+	return cfg, nil
+}
+
+// updateComeback sets this tick's comeback threshold: the median length of
+// live snakes, bots included. Caller must hold w.mu.Lock.
+func (w *World) updateComeback() {
+	if !w.Comeback.enabled() {
+		return
+	}
+	lengths := w.lengths[:0]
+	for _, s := range w.Snakes {
+		if s.Alive {
+			lengths = append(lengths, len(s.Segments))
+		}
+	}
+	slices.Sort(lengths)
+	w.comebackBelow = 0
+	if len(lengths) > 0 {
+		w.comebackBelow = lengths[len(lengths)/2]
+	}
+	w.lengths = lengths
+}
+
+// inComeback reports whether s gets the comeback bonus this tick. Caller
+// must hold at least w.rlock.
+func (w *World) inComeback(s *Snake) bool {
+	return w.Comeback.enabled() && len(s.Segments) < w.comebackBelow
+}
+
+// comebackSpeed speeds up a small snake's base speed after steering set it
+// for the tick; boosting is unchanged. Caller must hold w.mu.Lock.
+func (w *World) comebackSpeed(s *Snake) {
+	if !s.BoostActive && w.Comeback.Speed > 0 && w.inComeback(s) {
+		s.Speed *= 1 + w.Comeback.Speed
+	}
+}
+
+// comebackBonus is the extra value a small snake earns for food worth value
+func (w *World) comebackBonus(s *Snake, value int) int {
+	if w.Comeback.Food == 0 || !w.inComeback(s) {
+		return 0
+	}
+	return int(math.Round(float64(value) * w.Comeback.Food))
+}
+
+// rules is what the welcome message's rules block announces for this world:
+// the physics constants plus its own comeback rule
+func (w *World) rules() RulesDTO {
+	r := physicsRules()
+	r.ComebackSpeed, r.ComebackFood = w.Comeback.Speed, w.Comeback.Food
+	return r
+}
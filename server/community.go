@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// A community setting flavours the world for one language's players: most
+// bots are named in that language, server-generated announcements are
+// worded in it, and clients tag the MOTD and announcements with it so they
+// render in the right fonts (the same Han characters are drawn differently
+// for Japanese and Chinese readers). Operators write the MOTD and their
+// own announcements in whatever language they like. Unset, the world is
+// international. There is one world per server until rooms exist; each
+// room is meant to carry its own.
+
+// communityFromEnv reads SLETHER_COMMUNITY, one of the languages bots are
+// named in (vi, en, ja, ko, zh, es, ru, ar, th, hi, pt, fr, de); unset is the
+// international default
+func communityFromEnv() (string, error) {
+	lang := os.Getenv("SLETHER_COMMUNITY")
+	if lang == "" || findBotLocale(lang) != nil {
+		return lang, nil
+	}
+	return "", fmt.Errorf("SLETHER_COMMUNITY=%q: no bot names in that language", lang)
+}
+
+// findBotLocale returns the bot name locale for lang, nil if there is none
+func findBotLocale(lang string) *botLocale {
+	for i := range botLocales {
+		if botLocales[i].Lang == lang {
+			return &botLocales[i]
+		}
+	}
+	return nil
+}
+
+// localize formats an announcement in lang, falling back to the English
+// format, which is also the catalog key. Formats use explicit argument
+// indexes so translations can reorder them.
+func localize(lang, format string, args ...any) string {
+	if t, ok := announcementText[lang][format]; ok {
+		format = t
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// English formats of server-generated announcements; they double as the
+// catalog keys
+const (
+	textLookalike   = "Your name looks too much like %[1]s on the leaderboard; playing as %[2]s"
+	textWelcomeBack = "Welcome back, %[1]s: this life counts toward your profile"
+	textClaimed     = "You claimed %[1]s. Join with the same PIN to keep adding to your profile"
+	textNotRecorded = "Stats not recorded: %[1]v"
+	textSeasonOver  = "Season %[1]d is over! Season %[2]d starts now."
+	textSeasonWon   = "Season %[1]d is over: %[2]s wins with %[3]d! Season %[4]d starts now."
+	textReset       = "The arena has been reset. Join again to play on."
+)
+
+// announcementText translates them by language. Languages missing here (or
+// a missing format) read in English.
+var announcementText = map[string]map[string]string{
+	"es": {
+		textLookalike:   "Tu nombre se parece demasiado a %[1]s de la clasificación; juegas como %[2]s",
+		textWelcomeBack: "Bienvenido de nuevo, %[1]s: esta vida cuenta para tu perfil",
+		textClaimed:     "Has reclamado %[1]s. Únete con el mismo PIN para seguir sumando a tu perfil",
+		textNotRecorded: "Estadísticas no registradas: %[1]v",
+		textSeasonOver:  "¡La temporada %[1]d ha terminado! Empieza la temporada %[2]d.",
+		textSeasonWon:   "La temporada %[1]d ha terminado: ¡%[2]s gana con %[3]d! Empieza la temporada %[4]d.",
+		textReset:       "La arena se ha reiniciado. Vuelve a unirte para seguir jugando.",
+	},
+	"pt": {
+		textLookalike:   "Seu nome é parecido demais com %[1]s no placar; jogando como %[2]s",
+		textWelcomeBack: "Bem-vindo de volta, %[1]s: esta vida conta para o seu perfil",
+		textClaimed:     "Você registrou %[1]s. Entre com o mesmo PIN para continuar somando ao seu perfil",
+		textNotRecorded: "Estatísticas não registradas: %[1]v",
+		textSeasonOver:  "A temporada %[1]d terminou! A temporada %[2]d começa agora.",
+		textSeasonWon:   "A temporada %[1]d terminou: %[2]s vence com %[3]d! A temporada %[4]d começa agora.",
+		textReset:       "A arena foi reiniciada. Entre de novo para continuar jogando.",
+	},
+	"fr": {
+		textLookalike:   "Ton nom ressemble trop à %[1]s du classement ; tu joues sous le nom %[2]s",
+		textWelcomeBack: "Bon retour, %[1]s : cette vie compte pour ton profil",
+		textClaimed:     "Tu as réservé %[1]s. Rejoins avec le même PIN pour continuer à enrichir ton profil",
+		textNotRecorded: "Statistiques non enregistrées : %[1]v",
+		textSeasonOver:  "La saison %[1]d est terminée ! La saison %[2]d commence.",
+		textSeasonWon:   "La saison %[1]d est terminée : %[2]s gagne avec %[3]d ! La saison %[4]d commence.",
+		textReset:       "L'arène a été réinitialisée. Rejoins la partie pour continuer.",
+	},
+	"de": {
+		textLookalike:   "Dein Name ähnelt zu sehr %[1]s aus der Bestenliste; du spielst als %[2]s",
+		textWelcomeBack: "Willkommen zurück, %[1]s: Dieses Leben zählt für dein Profil",
+		textClaimed:     "Du hast %[1]s beansprucht. Tritt mit derselben PIN bei, um dein Profil weiter zu füllen",
+		textNotRecorded: "Statistik nicht gespeichert: %[1]v",
+		textSeasonOver:  "Saison %[1]d ist vorbei! Saison %[2]d beginnt jetzt.",
+		textSeasonWon:   "Saison %[1]d ist vorbei: %[2]s gewinnt mit %[3]d! Saison %[4]d beginnt jetzt.",
+		textReset:       "Die Arena wurde zurückgesetzt. Tritt erneut bei, um weiterzuspielen.",
+	},
+	"ru": {
+		textLookalike:   "Ваше имя слишком похоже на %[1]s из таблицы лидеров; вы играете как %[2]s",
+		textWelcomeBack: "С возвращением, %[1]s: эта жизнь засчитывается в ваш профиль",
+		textClaimed:     "Вы заняли имя %[1]s. Входите с тем же PIN-кодом, чтобы пополнять профиль",
+		textNotRecorded: "Статистика не записана: %[1]v",
+		textSeasonOver:  "Сезон %[1]d завершён! Начинается сезон %[2]d.",
+		textSeasonWon:   "Сезон %[1]d завершён: побеждает %[2]s с %[3]d очками! Начинается сезон %[4]d.",
+		textReset:       "Арена перезапущена. Войдите снова, чтобы продолжить игру.",
+	},
+	"ja": {
+		textLookalike:   "名前がリーダーボードの%[1]sに似すぎているため、%[2]sとしてプレイします",
+		textWelcomeBack: "おかえりなさい、%[1]sさん。このプレイはプロフィールに記録されます",
+		textClaimed:     "%[1]sを登録しました。同じPINで参加するとプロフィールに記録され続けます",
+		textNotRecorded: "統計は記録されませんでした：%[1]v",
+		textSeasonOver:  "シーズン%[1]dが終了しました！シーズン%[2]dが始まります。",
+		textSeasonWon:   "シーズン%[1]dが終了：%[2]sが%[3]dで優勝！シーズン%[4]dが始まります。",
+		textReset:       "アリーナがリセットされました。もう一度参加してプレイを続けてください。",
+	},
+	"zh": {
+		textLookalike:   "你的名字与排行榜上的%[1]s过于相似，将以%[2]s的身份游戏",
+		textWelcomeBack: "欢迎回来，%[1]s：本局将计入你的资料",
+		textClaimed:     "你已认领%[1]s。使用相同的PIN加入即可继续累积资料",
+		textNotRecorded: "统计未记录：%[1]v",
+		textSeasonOver:  "第%[1]d赛季结束！第%[2]d赛季现在开始。",
+		textSeasonWon:   "第%[1]d赛季结束：%[2]s以%[3]d分夺冠！第%[4]d赛季现在开始。",
+		textReset:       "竞技场已重置。请重新加入继续游戏。",
+	},
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errMockClosed = errors.New("mock websocket closed")
+
+// mockWS is an in-memory wsConn: reads come from inbox, writes are counted
+type mockWS struct {
+	inbox     chan []byte
+	closeOnce sync.Once
+	done      chan struct{}
+	writes    atomic.Int64
+}
+
+func newMockWS() *mockWS {
+	return &mockWS{inbox: make(chan []byte, 64), done: make(chan struct{})}
+}
+
+func (m *mockWS) ReadMessage() (int, []byte, error) {
+	select {
+	case msg := <-m.inbox:
+		return 1, msg, nil
+	case <-m.done:
+		return 0, nil, errMockClosed
+	}
+}
+
+func (m *mockWS) WriteMessage(_ int, _ []byte) error {
+	select {
+	case <-m.done:
+		return errMockClosed
+	default:
+	}
+	m.writes.Add(1)
+	return nil
+}
+
+func (m *mockWS) SetWriteDeadline(time.Time) error { return nil }
+
+func (m *mockWS) SetReadLimit(int64) {}
+
+func (m *mockWS) Close() error {
+	m.closeOnce.Do(func() { close(m.done) })
+	return nil
+}
+
+// push delivers a client message to the read loop
+func (m *mockWS) push(msg ClientMessage) {
+	data, _ := json.Marshal(msg)
+	select {
+	case m.inbox <- data:
+	case <-m.done:
+	}
+}
+
+// TestConcurrencyStress runs the real tick against connection goroutines
+// joining, steering and leaving every tick, admin-style commands killing bots
+// and dropping food, and concurrent read-only observers. Run with -race to
+// verify that only the loop goroutine mutates the world.
+func TestConcurrencyStress(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	world := NewWorld()
+	conns := NewConnManager()
+	gl := NewGameLoop(world, conns)
+
+	ticks := 150
+	if testing.Short() {
+		ticks = 30
+	}
+
+	loopDone := make(chan struct{})
+	go func() {
+		defer close(loopDone)
+		for i := 0; i < ticks; i++ {
+			gl.tick()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	running := func() bool {
+		select {
+		case <-loopDone:
+			return false
+		default:
+			return true
+		}
+	}
+
+	// Players: connect, join, steer for a few messages, disconnect — repeatedly
+	var sessions atomic.Int64
+	for p := 0; p < 8; p++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for running() {
+				ws := newMockWS()
+				c := NewConn(ws)
+				conns.Add(c)
+				readDone := make(chan struct{})
+				go func() {
+					defer close(readDone)
+					c.ReadLoop(world,
+						func(c *Conn, req joinRequest) { postJoin(world, c, req) },
+						func(c *Conn) { postDisconnect(world, conns, c, gl.stopped) })
+				}()
+				ws.push(ClientMessage{Type: MsgJoin, Name: "stress"})
+				for k := rng.Intn(30); k > 0; k-- {
+					ws.push(ClientMessage{Type: MsgInput, Angle: rng.Float64() * 6.28, Boost: rng.Intn(2)})
+					time.Sleep(time.Millisecond)
+				}
+				if rng.Intn(4) == 0 {
+					ws.push(ClientMessage{Type: MsgRespawn, Name: "again"})
+				}
+				ws.Close()
+				<-readDone
+				sessions.Add(1)
+			}
+		}(int64(p))
+	}
+
+	// Admin mutations: kill random bots (bot churn) and scatter food
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for running() {
+			world.Post(func(w *World) {
+				for id, s := range w.Snakes {
+					if s.Alive && len(id) > 4 && id[:4] == "bot-" {
+						w.AddFood(s.DropFood(w.FoodPool, 1))
+						break
+					}
+				}
+				w.AddFood(w.FoodPool.NewFoodCluster())
+			})
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	// Read-only observers, as an admin API or stats endpoint would be
+	for r := 0; r < 2; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for running() {
+				unlock := world.rlock("test.observer")
+				_, _ = world.Leaderboard()
+				_ = len(world.Food)
+				unlock()
+				time.Sleep(time.Millisecond)
+			}
+		}()
+	}
+
+	<-loopDone
+	wg.Wait()
+
+	// Apply whatever was queued after the last tick, then check invariants
+	unlock := world.lock("test.verify")
+	defer unlock()
+	world.applyCommands()
+
+	if sessions.Load() == 0 {
+		t.Fatal("no player sessions completed during the run")
+	}
+	for id, f := range world.Food {
+		if f.ID != id {
+			t.Fatalf("food map key %v holds food with ID %v", id, f.ID)
+		}
+	}
+	for id, s := range world.Snakes {
+		if s.ID != id {
+			t.Fatalf("snake map key %s holds snake %s", id, s.ID)
+		}
+		if s.NetID == 0 {
+			t.Fatalf("snake %s has no wire ID", id)
+		}
+	}
+	if n := len(gl.bots.bots); n != BotCount {
+		t.Fatalf("bot population %d, want %d", n, BotCount)
+	}
+}
+
+// TestDisconnectAfterLoopStops checks a disconnect doesn't wait forever on
+// a full command queue that a stopped loop will never drain
+func TestDisconnectAfterLoopStops(t *testing.T) {
+	quietLogs(t)
+	world := newEmptyWorld()
+	conns := NewConnManager()
+	c := NewConn(newMockWS())
+	conns.Add(c)
+	for world.Post(func(*World) {}) == nil {
+	}
+	stopped := make(chan struct{})
+	close(stopped)
+
+	done := make(chan struct{})
+	go func() {
+		postDisconnect(world, conns, c, stopped)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("disconnect still retrying after the loop stopped")
+	}
+	if _, ok := conns.Get(c.ID); ok {
+		t.Fatal("disconnected player still registered")
+	}
+}
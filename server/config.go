@@ -1,38 +1,103 @@
 package main
 
+import (
+	"math"
+	"time"
+)
+
 // Game configuration constants
 const (
 	// Server
-	ServerPort    = ":8080"
+	ServerPort    = ":8080" // default listen address (see SLETHER_LISTEN)
 	StaticDir     = "../client"
 	WebSocketPath = "/ws"
 
+	// HTTP server timeouts (the WebSocket route clears them after upgrade)
+	HTTPReadHeaderTimeout = 5 * time.Second
+	HTTPReadTimeout       = 10 * time.Second
+	HTTPWriteTimeout      = 15 * time.Second
+	HTTPIdleTimeout       = 60 * time.Second
+	HTTPShutdownTimeout   = 5 * time.Second // in-flight requests get this long on SIGTERM
+	// ShutdownCountdown is how long players are warned before a graceful
+	// shutdown closes their connections (see shutdown.go)
+	ShutdownCountdown = 5 * time.Second
+	// ReadyMaxTickAge fails /readyz when the game loop hasn't ticked this recently
+	ReadyMaxTickAge = time.Second
+	// TLSDefaultCacheDir keeps Let's Encrypt certificates and the account key
+	// across restarts (see tls_config.go)
+	TLSDefaultCacheDir = "autocert-cache"
+
 	// World — circular map: center=(10500,10500), radius=10500
 	// Boundary is death (not wrap). Diameter ~21000px.
 	WorldCenterX = 10500.0
 	WorldCenterY = 10500.0
 	WorldRadius  = 10500.0
+	// MinimapDiameter is the client minimap size in px (sets minimap scale)
+	MinimapDiameter = 160.0
 	// SpawnMargin keeps snakes away from the circular boundary on spawn
 	SpawnMargin = 500.0
 
-	// Game loop
-	TickRate = 20 // ticks per second
-	TickMS   = 1000 / TickRate
+	// Game loop — the rate itself is the TickRate var (see tick_rate.go).
+	// Rates and intervals below are per second / durations and converted to
+	// per-tick values with perTick and ticksFor.
+	DefaultTickRate = 20 // ticks per second
+	MinTickRate     = 10
+	MaxTickRate     = 60
+	// MaxCatchUpTicks bounds the back-to-back ticks run after a stall; time
+	// owed beyond that is dropped (counted in the periodic tick report)
+	MaxCatchUpTicks = 5
+	// BroadcastWorkers bounds the goroutines encoding and writing state each tick
+	BroadcastWorkers = 8
+	// LockStatsReportSec is how often world lock contention is logged
+	LockStatsReportSec = 60
+	// CommandQueueSize bounds pending world commands (joins, disconnects, admin actions)
+	CommandQueueSize = 4096
+	// CommandsPerTick caps the world commands one tick applies
+	CommandsPerTick = 256
+	// CloseHandshakeTimeout is how long a server-initiated close waits for
+	// the client's close frame before dropping the socket
+	CloseHandshakeTimeout = 2 * time.Second
+	// Socket writes (see Conn.writeLoop): a write taking longer than
+	// WriteDeadlineTicks tick periods is late, WriteDeadlineMisses late
+	// writes in a row disconnect the client, and a write blocked for
+	// WriteStallTimeout fails
+	WriteDeadlineTicks  = 2
+	WriteDeadlineMisses = 5
+	WriteStallTimeout   = 5 * time.Second
+	// Event stream (SLETHER_EVENTS): events buffered before dropping, and
+	// how long to wait between reconnects to a socket collector
+	EventStreamBuffer = 8192
+	EventStreamRetry  = 5 * time.Second
+	// Room webhook (SLETHER_ROOM_WEBHOOK): events buffered before dropping,
+	// and how long one delivery may take
+	RoomWebhookBuffer  = 1024
+	RoomWebhookTimeout = 5 * time.Second
+	// WorldLogMaxTicks caps SLETHER_WORLD_LOG, the ticks of world mutations
+	// kept for dumps (see world_log.go); 12000 is 10 minutes at 20 Hz
+	WorldLogMaxTicks = 12000
+	// StatsInterval is how often each player gets its personal StatsMsg
+	StatsInterval = time.Second
 
 	// Snake
-	SnakeNormalSpeed    = 3.0  // px per tick
-	SnakeBoostSpeed     = 5.0  // px per tick
-	SnakeBoostCostTicks = 3    // lose 1 length unit every N boost ticks
+	SnakeNormalSpeed    = 60.0  // px per second
+	SnakeBoostSpeed     = 100.0 // px per second
+	SnakeBoostCostEvery = 150 * time.Millisecond // lose 1 length unit per this much boosting
 	SnakeInitSegments   = 10   // starting segments
-	SnakeSegmentSpacing = 8.0  // px between segments
+	// SnakeSegmentSpacing is the px of head travel between body segments,
+	// at any tick rate. It is the per-tick travel at normal speed and the
+	// default rate, which is the spacing bodies always had in play (the
+	// minimap threshold below assumes it too), so a score still makes a
+	// snake of the same length and segment count.
+	SnakeSegmentSpacing = SnakeNormalSpeed / DefaultTickRate
 	SnakeHeadRadius     = 10.0 // collision radius for head
 	SnakeBodyRadius     = 8.0  // collision radius for body segments
 	SnakeMinSegments    = 3    // minimum segments before death from boost
 	SnakeBaseWidth      = 10.0 // starting visual radius
 	SnakeMaxWidth       = 28.0 // cap visual radius
-	// Turn rate: max radians per tick the snake can rotate.
+	SnakeWidthEase      = 300 * time.Millisecond // reported width closes on the real width with this time constant
+	// Turn rate: max radians per second the snake can rotate.
 	// Bigger snakes turn slower. Formula: MaxTurnRate / (1 + segments * TurnScaleFactor)
-	SnakeMaxTurnRate   = 0.18  // radians/tick at minimum size (~10 degrees)
+	SnakeMaxTurnRate   = 3.6   // radians/sec at minimum size (~10 degrees per 20 Hz tick)
 	SnakeTurnScaleFactor = 0.001 // very slight turn penalty per segment — big snakes stay agile
 
 	// Food
@@ -40,59 +105,358 @@ const (
 	TargetFoodCount  = 12500
 	FoodRadius       = 5.0
 	FoodBaseValue    = 1
-	DeathFoodPerUnit = 3  // drop 1 food per N body segments on death
-	FoodSpawnPerTick = 100 // max food respawn per tick to maintain target
-
-	// Food levels
-	// Level 1: value=1, common (90% of random spawns)
-	// Level 3: value=3, medium (10% of random spawns)
-	// Level 5: value=5, large (only from death drops)
-	// Level 10: value=10, rare moving food
+	DeathFoodPerUnit = 5  // mean body segments between death drops; 1 food per N segments of a damage hit
+	DeathDropShare   = 0.6 // share of a dead snake's body value dropped as food; the rest is the death sink
+	FoodSpawnPerSec  = 2000 // max food respawn per second to maintain target
+	// FoodBoundaryMargin keeps random spawns this far inside the boundary;
+	// they also avoid obstacles and snake bodies (see food_exclusion.go)
+	FoodBoundaryMargin = 60.0
+	// Food rebalancing — ambient spawns favour regions (FoodRegionCells grid
+	// cells square) that are short of food and have snakes in them: a region's
+	// spawn share rises to FoodRebalanceBias+1 times its area share when it is
+	// bare with about FoodRebalanceCrowd body segments in it (see food_rebalance.go)
+	FoodRebalanceEnabled  = true
+	FoodRegionCells       = 5
+	FoodRebalanceBias     = 6.0
+	FoodRebalanceCrowd    = 150.0
+	FoodRebalanceInterval = time.Second
+
+	// Food levels — values and random spawn weights are in FoodLevelTable
+	// Level 1: common
+	// Level 3: medium, also dropped by boosting
+	// Level 5: large, death and damage drops and rare random spawns
+	// Level 10: rare moving food
 	FoodLevel1 = 1
 	FoodLevel3 = 3
 	FoodLevel5 = 5
 	FoodLevel10 = 10
+	// DeathFoodLevel is the level of damage drops and the largest death drops
+	DeathFoodLevel = FoodLevel5
 
 	// Moving food (level 10)
-	MovingFoodSpawnInterval = 300 // ticks between moving food spawns (~15 sec at 20 tps)
-	MovingFoodMaxCount      = 3   // max moving food in world at once
-	MovingFoodSpeed         = 4.0 // px per tick
-	// Moving food changes direction every 60-120 ticks (random in that range)
-	MovingFoodDirMinTicks = 60
-	MovingFoodDirMaxTicks = 120
+	MovingFoodSpawnInterval = 15 * time.Second
+	MovingFoodMaxCount      = 3    // max moving food in world at once
+	MovingFoodSpeed         = 80.0 // px per second
+	// Moving food changes direction every 3-6 sec (random in that range)
+	MovingFoodDirMin = 3 * time.Second
+	MovingFoodDirMax = 6 * time.Second
+
+	// Golden apple — a single world objective (see golden_apple.go). Spawns
+	// GoldenAppleRespawnMin–Max after the last one was eaten, at a location
+	// announced to everyone; the eater gets GoldenAppleValue and a crown for
+	// GoldenAppleCrownFor. Bots within GoldenAppleBotRadius contest it.
+	GoldenAppleEnabled    = true
+	GoldenAppleRespawnMin = 3 * time.Minute
+	GoldenAppleRespawnMax = 5 * time.Minute
+	GoldenAppleValue      = 150
+	GoldenAppleRadius     = 18.0   // px
+	GoldenAppleMargin     = 1500.0 // px — spawns at least this far inside the boundary
+	GoldenAppleCrownFor   = 30 * time.Second
+	GoldenAppleBotRadius  = 2500.0 // px
+
+	// Risk zones — food eaten within RiskBandWidth of the deadly boundary is
+	// worth RiskBandMultiplier times its value (1 disables), so the whole map
+	// is worth playing rather than camping the center. Content packs can
+	// mark danger zones with their own multiplier, up to RiskMaxMultiplier.
+	RiskBandWidth      = 1000.0 // px
+	RiskBandMultiplier = 2.0
+	RiskMaxMultiplier  = 5.0
+
+	// Comeback rule bounds (off by default; see comeback.go for the
+	// per-world settings)
+	ComebackMaxSpeed = 0.25 // extra base speed, as a fraction
+	ComebackMaxFood  = 1.0  // extra food value, as a fraction
+
+	// Respawn head start (defaults and bounds; see head_start.go for the
+	// per-world settings): a respawn starts with HeadStartFraction of the
+	// score the previous life gained, up to HeadStartMax
+	HeadStartFraction    = 0.05
+	HeadStartMax         = 100
+	HeadStartMaxFraction = 0.25
+	HeadStartMaxCap      = 1000
 
 	// Magnetic food attraction
 	MagnetRadius = 16.0 // px — food within this radius gets pulled (1.6x head radius)
-	MagnetSpeed  = 3.0  // px per tick — how fast food moves toward snake head
+	MagnetSpeed  = 60.0 // px per second — how fast food moves toward snake head
 
 	// Viewport
 	ViewportWidth  = 1536.0 // 1920 * 0.8
 	ViewportHeight = 864.0  // 1080 * 0.8
 	ViewportBuffer = 800.0 // covers up to 4K screens (3840*0.8=3072, need (3072-1536)/2=768 extra)
 
+	// Food blobs — tight piles far from a viewer are sent as one aggregated item
+	FoodBlobEnabled  = true
+	FoodBlobMinDist  = 900.0 // px — grid cells farther than this from the viewer may aggregate
+	FoodBlobCellSize = 50.0  // px — food within the same sub-cell merges into one blob
+	FoodBlobMinItems = 4     // min items in a sub-cell to form a blob
+
+	// State deltas — between keyframes a viewer is sent only the snakes,
+	// food and blobs that entered, left or changed in its viewport
+	DeltaStateEnabled     = true
+	DeltaKeyframeInterval = 2 * time.Second // full state at least this often, for resync
+
 	// Spatial grid — covers bounding square of circular world (0..2*WorldRadius)
 	GridCellSize = 200.0
 
-	// Leaderboard
-	LeaderboardSize = 10
+	// Leaderboard (defaults; see leaderboard.go for the per-world settings)
+	LeaderboardSize        = 10
+	LeaderboardMaxSize     = 50
+	LeaderboardMaxInterval = 10 * time.Second
 
 	// Collision
 	CollisionCheckRadius = 20.0 // radius for head-to-body collision check
+	// BoostSubsteps splits a boosting head's per-tick move into this many
+	// increments for collision and eating checks (1 disables)
+	BoostSubsteps = 3
+
+	// Phase layers — tiny snakes and giants live in separate collision layers
+	// so new players aren't instantly flattened in crowded arenas.
+	// Normal-sized snakes interact with everyone.
+	PhaseLayersEnabled  = false
+	PhaseTinyMaxLength  = 40  // segments — at or below this a snake is in the tiny layer
+	PhaseGiantMinLength = 400 // segments — at or above this a snake is in the giant layer
+
+	// Casual mode — a non-boosting head that grazes another body at a shallow
+	// angle slides off it (heading reflected about the contact normal) instead of dying
+	CasualBounceEnabled  = false
+	CasualBounceMaxAngle = math.Pi / 6 // radians between heading and body surface
+
+	// Damage model — collisions cost a chunk of body instead of killing outright.
+	// A hit snake is briefly invulnerable; it dies only when a hit would take it
+	// to SnakeMinSegments or below.
+	DamageModelEnabled   = false
+	DamageSegmentsPerHit = 15 // segments removed per hit
+	DamageInvulnFor      = time.Second // invulnerability after a hit
+
+	// Kill streaks — killing a snake on a streak of ShutdownMinStreak or more
+	// ("shutdown") grows the killer by ShutdownBonusPerKill per streak kill
+	ShutdownMinStreak    = 3
+	ShutdownBonusPerKill = 5
+
+	// Assists — a snake whose head came within AssistRadius of the victim's
+	// head in the last AssistWindow (and isn't the killer) gets an assist
+	AssistRadius = 120.0 // px
+	AssistWindow = 2 * time.Second
 
 	// Bot AI
 	BotCount          = 50    // number of AI bots to maintain
-	BotRespawnDelay   = 100   // ticks before respawning a dead bot (~5 sec at 20 tps)
+	BotRespawnDelay   = 5 * time.Second // before respawning a dead bot
+	BotSeekTimeout    = 3 * time.Second // give up on a food target after seeking this long
+	BotOrbitBailout   = 400 * time.Millisecond // break away after circling food this long
+	// Bot spawns prefer quiet areas away from humans (see botSpawnPoint)
+	BotSpawnAwayFromHumans = true
+	BotSpawnCandidates     = 8      // random points scored per spawn
+	BotSpawnMinHumanDist   = 1500.0 // px — never chosen closer to a human head unless unavoidable
+	BotSpawnDensityRadius  = 600.0  // px — crowding measured within this radius
+	// Learned bots and the training environment see the world within this
+	// radius of their head (see observation.go)
+	ObsRadius = 600.0
+	// Training environment (SLETHER_TRAIN_LISTEN): agents per episode and
+	// the reward for dying
+	TrainingMaxAgents    = 64
+	TrainingDeathPenalty = 50.0
+	// Population scaling — humans displace bots down to BotMinCount; excess
+	// bots retire out of sight, or fade into BotRetireFoodFactor of their
+	// death drop if still watched after BotRetireFadeAfter
+	BotScaleWithPlayers = false
+	BotMinCount         = 10
+	BotRetireFadeAfter  = 10 * time.Second
+	BotRetireFoodFactor = 0.3
+	// BotCountryFlags gives each bot a random flag plausible for its name's
+	// language; off, bots show none (see country.go)
+	BotCountryFlags = false
+	// CommunityNameShare is the chance a bot is named in the world's
+	// community language when it has one (see community.go)
+	CommunityNameShare = 0.75
 	BotDangerRadius   = 80.0  // px — body segments closer than this trigger avoidance
 	BotFoodSeekRadius = 500.0 // px — food within this range is targeted (was 200)
 	BotChaseRadius    = 300.0 // px — smaller snake heads within this range are chased
 	BotFleeRadius     = 200.0 // px — bigger snake heads within this range trigger flee
 	BotBoundaryBuffer = 500.0 // px — steer toward center when this close to boundary
+	// Bounds for the per-world bot hunt settings (see bot_hunt.go)
+	BotHuntMaxHumans   = 10.0
+	BotHuntMaxMinScore = 100000
 
 	// Rate limiting / anti-abuse
-	MaxPlayers       = 8000 // max concurrent WebSocket connections
+	MaxPlayers       = 8000 // max concurrent WebSocket connections per room
+	MaxRooms         = 16   // upper bound for SLETHER_ROOMS
 	IPCooldownSec    = 30   // seconds between new connections from same IP
+	MaxNameLength    = 20   // user-perceived characters (see names.go)
+	MaxClientMsgSize = 1024 // bytes — larger frames close the connection
+	// MaxJoinMsgSize replaces MaxClientMsgSize until a connection passes the
+	// captcha: Turnstile and hCaptcha tokens run to about 2048 characters,
+	// and the join carrying one also holds a name and profile token
+	MaxJoinMsgSize = 4096
+	SendQueueLowMax  = 64   // queued low-priority (cosmetic) messages before dropping
+	// SendQueueCriticalMax is how many critical messages may wait unsent
+	// before the client is dropped as too slow (see sendQueue.push)
+	SendQueueCriticalMax = 256
+	// CaptchaVerifyTimeout bounds a captcha token check with the provider
+	CaptchaVerifyTimeout = 5 * time.Second
+	// JoinCooldown is the minimum time between a connection's joins and
+	// respawns; one sent sooner waits for it (see join_gate.go)
+	JoinCooldown = time.Second
+	// Names (see names.go): at most MaxNameRunes code points in all, and
+	// MaxNameMarks combining marks stacked on one character
+	MaxNameRunes = 48
+	MaxNameMarks = 3
+	// DefaultPlayerName replaces a blank join name; it can't be claimed
+	DefaultPlayerName = "Player"
+
+	// Slow clients (see client_health.go): checked every
+	// SlowClientCheckInterval; over either threshold switches to lite mode
+	SlowClientCheckInterval = time.Second
+	SlowClientSuperseded    = 0.3                  // share of snapshots replaced unsent
+	SlowClientWriteMean     = 20 * time.Millisecond // mean socket write time
+	SlowClientRecoverChecks = 10                   // healthy checks in a row to leave lite mode
+	SlowClientStateEvery    = 2                    // lite mode: state every Nth tick
+
+	// Anti-feeding — a player dying to the same killer FeedingFlagDeaths times
+	// within FeedingWindow flags the pair; flagged deaths drop only
+	// FeedingDropFactor of their usual food
+	FeedingFlagDeaths = 3
+	FeedingWindow     = 2 * time.Minute
+	FeedingDropFactor = 0.25
+
+	// Machine clients (see machine_clients.go): over each MachineWindow, a
+	// player whose input points within MachineAimTolerance of food in
+	// MachineAimRadius at least MachineAimRatio of the time, and who
+	// retargets within MachineReactionMax of eating, is flagged. Retargets
+	// slower than MachineReactionCap aren't timed.
+	MachineWindow       = 30 * time.Second
+	MachineAimRadius    = 300.0 // px
+	MachineAimTolerance = 2 * math.Pi / 180
+	MachineAimRatio     = 0.8
+	MachineReactionMax  = 100 * time.Millisecond
+	MachineReactionCap  = time.Second
+	MachineMinSamples   = 200 // ticks with food in range per window
+	MachineMinReactions = 10
+	// Restrict policy: flagged players eat at MachineScoreFactor with
+	// MachineInputLagMS of input lag; more than MachineMaxPerIP flagged
+	// from one address are kicked
+	MachineScoreFactor = 0.5
+	MachineInputLagMS  = 150
+	MachineMaxPerIP    = 2
+
+	// Admin API — disabled unless SLETHER_ADMIN_TOKEN is set; requests must
+	// send "Authorization: Bearer <token>"
+	AdminPathPrefix = "/admin/"
+
+	// Shadow moderation — bounds on sanctions set through the admin API
+	ModerationMaxInputLag = time.Second
+	ModerationMaxDuration = 7 * 24 * time.Hour
+	// IP bans set through the admin API last at most this long
+	BanMaxDuration = 90 * 24 * time.Hour
+
+	// Announcement banners — longer text is rejected, durations are clamped
+	AnnouncementMaxLength   = 200 // runes
+	AnnouncementMinDuration = time.Second
+	AnnouncementMaxDuration = time.Minute
+
+	// Message of the day (see motd.go)
+	MOTDMaxFieldLength = 40   // name, region, mode and link labels
+	MOTDMaxTextLength  = 1000 // rules / free text
+	MOTDMaxLinks       = 5
+
+	// Content packs (see content_pack.go) — bounds checked on load
+	PackMaxNameLength    = 40
+	PackMaxFeatures      = 64 // per kind: obstacles, zones, portals
+	PackMinFeatureRadius = 50.0
+	PackMaxFeatureRadius = 3000.0
+	PackMaxObstacleCover = 0.25 // of the world's area; players spawn anywhere, obstacles included
+	PackMaxEvents        = 32
+	PackMinEventInterval = time.Minute // shortest repeat for a scheduled event
+	PackLibraryMax       = 100         // uploaded packs kept (see pack_library.go)
+	PackUploadMaxBytes   = 64 << 10
+
+	// Admin audit entries kept in memory for GET /admin/audit
+	AuditRecentMax = 500
+	// Longest tenant name on a tenant key (see tenant_keys.go)
+	TenantNameMax = 64
+	// Hosted rooms (see hosted_rooms.go): the most a key's room quota may
+	// allow, the most tenants may create in all, and the most players one
+	// may take — kept under CommandQueueSize so every leave from a room
+	// being closed fits its command queue
+	TenantMaxRoomQuota   = 16
+	MaxHostedRooms       = 64
+	HostedRoomMaxPlayers = 1000
+	// HostedRoomMaxBots caps the bot population a tenant may ask for
+	HostedRoomMaxBots = 100
+	// Completed minutes of score economy counters kept for GET /admin/economy
+	EconomyHistoryMinutes = 60
+	// Completed minutes of population and tick time kept for GET /admin/history
+	MetricsHistoryMinutes = 24 * 60
+
+	// Highlights index (see highlights.go)
+	HighlightsMax            = 200
+	HighlightMultiKillMin    = 3
+	HighlightMultiKillWindow = 10 * time.Second // max gap between kills in a run
+	HighlightGiantScore      = 1000
+	HighlightEdgeMargin      = 60.0 // px from the boundary that counts as grazing it
+	HighlightEdgeWindow      = 5 * time.Second
+	HighlightEdgeMinScore    = 50 // ignore fresh spawns bouncing off the edge
+
+	// Claimed-name profiles (see profiles.go)
+	ProfileTokenMinLength = 4 // a short PIN is allowed; lockouts slow guessing
+	ProfileTokenMaxLength = 64
+	ProfileHashIterations = 100_000
+	ProfileMaxFailures    = 5 // wrong tokens in a row before a lockout
+	ProfileLockout        = 15 * time.Minute
+	ProfileFlushInterval  = 30 * time.Second
+
+	// Seasons (see seasons.go); SLETHER_SEASON_DAYS overrides the length
+	SeasonLength        = 30 * 24 * time.Hour
+	SeasonCheckInterval = time.Minute
+	SeasonStandingsSize = 100 // standings archived per season
+	SeasonAnnounceFor   = 15 * time.Second
+
+	// Persistent score records (SLETHER_RECORDS, see score_records.go):
+	// finished lives buffered before dropping, how often the boards are
+	// re-read after a change, names per board, and names sent to players
+	RecordsBuffer  = 1024
+	RecordsRefresh = 30 * time.Second
+	RecordsTopN    = 50
+	RecordsMsgSize = 10
+
+	// Ranked ratings (see rating.go)
+	RatingStart   = 1200.0
+	RatingFloor   = 100.0
+	RatingK       = 24.0 // max points per kill at equal size
+	RatingSizeMin = 0.5  // victim/killer length ratio bounds for scaling K
+	RatingSizeMax = 2.0
+
+	// Admin live dashboard stream (see admin_live.go)
+	LiveInterval      = time.Second
+	LiveWriteTimeout  = 5 * time.Second
+	LiveMaxBots       = 500
+	LiveMaxFoodTarget = 4 * TargetFoodCount
+
+	// Adaptive bot AI cadence (see bot_cadence.go): fractions of the tick
+	// budget the mean tick time is compared against each second
+	BotMaxCadence     = 3 // decide at least every 3 ticks
+	BotCadenceRaiseAt = 0.6
+	BotCadenceLowerAt = 0.3
+
+	// Snakes one connection may control at once, its own included (see multi_snake.go)
+	MultiSnakeMax = 4
+
+	// Public stats API (see public_stats.go)
+	PublicStatsRefresh     = 10 * time.Second // cached document rebuild interval
+	PublicStatsSampleEvery = 5 * time.Minute  // players-online history resolution
+	PublicAPIRateLimit     = 60               // /api/ requests per client IP per minute
 )
 
+// FoodLevelTable lists every food level: the value a snake gets for eating
+// it and its relative weight among random ambient spawns (0 = never spawned
+// at random). Keep the damage drop value equal to DeathFoodPerUnit so food
+// dropped by hits is worth the body it came from.
+var FoodLevelTable = []FoodLevelSpec{
+	{Level: FoodLevel1, Value: 1, SpawnWeight: 90},
+	{Level: FoodLevel3, Value: 3, SpawnWeight: 8},
+	{Level: FoodLevel5, Value: 5, SpawnWeight: 2},
+	{Level: FoodLevel10, Value: 10},
+}
+
 // Player colors palette
 var PlayerColors = []string{
 	"#e74c3c", "#3498db", "#2ecc71", "#f39c12", "#9b59b6",
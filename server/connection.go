@@ -2,9 +2,14 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
@@ -14,38 +19,172 @@ import (
 type PlayerInput struct {
 	Angle float64
 	Boost bool
+	Seq   uint32 // client's input sequence number, 0 if it sends none
 }
 
-// Conn manages a single WebSocket player session
+// wsConn is the subset of *websocket.Conn used by Conn, so tests can
+// substitute an in-memory socket
+type wsConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	SetWriteDeadline(t time.Time) error
+	SetReadLimit(limit int64)
+	Close() error
+}
+
+// Conn manages a single WebSocket player session. ID identifies the
+// session; the player's own snake has its own ID, snakeID, which the
+// ConnManager maps back to the session (see multi_snake.go).
 type Conn struct {
 	ID     string
-	Name   string
-	ws     *websocket.Conn
+	// displayName is the name of the last join, nil before one. Set by
+	// the join goroutine and read from the loop, writer and admin
+	// goroutines, so only ever through name().
+	displayName atomic.Pointer[string]
+	// snakeID is the player's own snake for the whole session, across
+	// respawns. Set before the Conn is added to a ConnManager.
+	snakeID string
+	// ip is the client address (see client_ip.go); set before
+	// the Conn is added to a ConnManager
+	ip     string
+	ws     wsConn
 	input  PlayerInput
-	mu     sync.Mutex // protects input and ws writes
+	out    *sendQueue // drained by writeLoop, the only goroutine writing to ws
+	// stateTick is the World.Tick of the last snapshot built for this conn.
+	// Game-loop goroutine only.
+	stateTick uint64
+	// view is what the client holds from its state messages, for deltas
+	// (see state_delta.go). Game-loop goroutine only.
+	view viewCache
+	// inputLag buffers recent inputs for a shadow input-lag sanction.
+	// Game-loop goroutine only.
+	inputLag []PlayerInput
+	// extra holds the snakes controlled beyond the connection's own, slot
+	// i+1 at index i (see multi_snake.go). Guarded by mu.
+	extra    []*snakeSlot
+	extraSeq int
+	// health tracks send latency and backlog (see client_health.go)
+	health connHealth
+	// connectedAt is when the WebSocket was accepted, for session time
+	connectedAt time.Time
+	// join paces joins and respawns and holds what they send (see
+	// join_gate.go); a join's tokens and flag opt-in reach onJoin by value
+	join joinGate
+	// verified is whether a captcha has passed (see captcha.go): set before
+	// Add when exempt, afterwards only under join.mu. Read by the read loop
+	// to pick its frame limit.
+	verified atomic.Bool
+	// country is the client's country from the proxy; set before Add
+	country string
+	// echo is whether the client asked for input echoes (set before Add),
+	// and appliedSeq the sequence number of the input its own snake was
+	// steered with this tick (see input_echo.go). Game-loop goroutine only.
+	echo       bool
+	appliedSeq uint32
+	mu     sync.Mutex // protects input and closed
 	closed bool
 }
 
-// NewConn creates a new connection wrapper
-func NewConn(ws *websocket.Conn) *Conn {
-	return &Conn{
-		ID: uuid.New().String(),
-		ws: ws,
+// playerSnakeSeq numbers player snakes; never reused within a process
+var playerSnakeSeq atomic.Uint64
+
+// NewConn creates a new connection wrapper and starts its writer goroutine
+func NewConn(ws wsConn) *Conn {
+	c := &Conn{
+		ID:          uuid.New().String(),
+		snakeID:     fmt.Sprintf("player-%d", playerSnakeSeq.Add(1)),
+		ws:          ws,
+		out:         newSendQueue(),
+		connectedAt: time.Now(),
 	}
+	go c.writeLoop()
+	return c
 }
 
-// Send serializes msg to JSON and writes it to the WebSocket
+// Send serializes msg to JSON and queues it for the writer at the message's
+// priority (see priorityOf). Never blocks on the network.
 func (c *Conn) Send(msg interface{}) error {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.closed {
-		return nil
+	c.push(priorityOf(msg), data)
+	return nil
+}
+
+// sendEncoded queues an already-encoded message, for payloads shared by many
+// connections (encode once, fan out)
+func (c *Conn) sendEncoded(p sendPriority, data []byte) {
+	c.push(p, data)
+}
+
+// push queues data, skipping cosmetic traffic while the client is in lite
+// mode; a client too far behind on critical messages is dropped
+func (c *Conn) push(p sendPriority, data []byte) {
+	if p == PriorityLow && c.health.lite.Load() {
+		c.health.lowDropped.Add(1)
+		slo.cosmeticDropped.Add(1)
+		return
+	}
+	if _, overflow := c.out.push(p, data); overflow {
+		log.Printf("client %s (%s) left %d critical messages unsent; disconnecting", c.ID, c.name(), SendQueueCriticalMax)
+		slo.slowDisconnects.Add(1)
+	}
+}
+
+// writeLoop writes queued messages until the connection closes. A write
+// error closes the socket, which ends ReadLoop and triggers the disconnect.
+//
+// Every write has a deadline of WriteDeadlineTicks tick periods. A write
+// that misses it still completes, but WriteDeadlineMisses misses in a row
+// disconnect the client with CloseSlow. A write stalled for
+// WriteStallTimeout fails outright and drops the socket, since the
+// connection can't be written to after a timed-out write.
+func (c *Conn) writeLoop() {
+	misses := 0
+	for {
+		data, final, ok := c.out.pop()
+		if !ok {
+			return
+		}
+		if final {
+			// Close handshake: the client's reply ends ReadLoop; don't wait
+			// forever on one that never answers
+			_ = c.ws.SetWriteDeadline(time.Now().Add(CloseHandshakeTimeout))
+			_ = c.ws.WriteMessage(websocket.CloseMessage, data)
+			time.AfterFunc(CloseHandshakeTimeout, c.Close)
+			return
+		}
+		began := time.Now()
+		_ = c.ws.SetWriteDeadline(began.Add(WriteStallTimeout))
+		if err := c.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+			c.health.writeErrors.Add(1)
+			log.Printf("write error for %s: %v", c.ID, err)
+			c.Close()
+			return
+		}
+		took := time.Since(began)
+		c.health.recordWrite(took)
+		if took <= WriteDeadlineTicks*tickDuration() {
+			misses = 0
+			continue
+		}
+		c.health.lateWrites.Add(1)
+		if misses++; misses == WriteDeadlineMisses {
+			log.Printf("client %s (%s) missed %d write deadlines in a row; disconnecting", c.ID, c.name(), misses)
+			slo.slowDisconnects.Add(1)
+			c.Disconnect(CloseSlow, "Connection too slow to keep up")
+		}
 	}
-	return c.ws.WriteMessage(websocket.TextMessage, data)
+}
+
+// name is the player's display name from its last join, "" before one.
+// Safe from any goroutine.
+func (c *Conn) name() string {
+	if n := c.displayName.Load(); n != nil {
+		return *n
+	}
+	return ""
 }
 
 // GetInput returns the current input snapshot
@@ -56,30 +195,58 @@ func (c *Conn) GetInput() PlayerInput {
 }
 
 // setInput updates input under lock
-func (c *Conn) setInput(angle float64, boost bool) {
+func (c *Conn) setInput(angle float64, boost bool, seq uint32) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.input.Angle = angle
 	c.input.Boost = boost
+	c.input.Seq = seq
+}
+
+// Disconnect drops the connection on the server's initiative: an ErrorMsg
+// with code and reason, then a close frame carrying the same code, after any
+// critical messages already queued. Safe from any goroutine.
+func (c *Conn) Disconnect(code int, reason string) {
+	_ = c.Send(ErrorMsg{Type: MsgError, Message: reason, Code: code})
+	c.out.closeAfter(closeFrame(code, reason))
+}
+
+// closeFrame builds a close frame payload, trimming reason to the 123 bytes
+// a control frame leaves for it without splitting a UTF-8 sequence
+func closeFrame(code int, reason string) []byte {
+	const maxReason = 123
+	if len(reason) > maxReason {
+		cut := maxReason
+		for cut > 0 && !utf8.RuneStart(reason[cut]) {
+			cut--
+		}
+		reason = reason[:cut]
+	}
+	return websocket.FormatCloseMessage(code, reason)
 }
 
-// Close marks connection closed
+// Close marks connection closed and stops the writer
 func (c *Conn) Close() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
 	c.closed = true
+	c.out.close()
 	c.ws.Close()
 }
 
 // ConnManager manages all active connections
 type ConnManager struct {
-	mu    sync.RWMutex
-	conns map[string]*Conn
+	mu     sync.RWMutex
+	conns  map[string]*Conn
+	owners map[string]string // snake ID -> controlling conn ID (see multi_snake.go)
 }
 
 // NewConnManager creates an empty connection manager
 func NewConnManager() *ConnManager {
-	return &ConnManager{conns: make(map[string]*Conn)}
+	return &ConnManager{conns: make(map[string]*Conn), owners: make(map[string]string)}
 }
 
 // Add registers a connection
@@ -87,6 +254,7 @@ func (m *ConnManager) Add(c *Conn) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.conns[c.ID] = c
+	m.owners[c.snakeID] = c.ID
 }
 
 // Remove unregisters a connection
@@ -94,6 +262,11 @@ func (m *ConnManager) Remove(id string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.conns, id)
+	for snakeID, owner := range m.owners {
+		if owner == id {
+			delete(m.owners, snakeID)
+		}
+	}
 }
 
 // Get returns a connection by ID
@@ -127,17 +300,27 @@ func (m *ConnManager) Snapshot() []*Conn {
 //   "j" = join, "i" = input, "r" = respawn
 // onJoin is called when a join/respawn message is received.
 // onDisconnect is called when the connection closes.
+// Frames over MaxClientMsgSize close the connection before they are
+// buffered, so a client can't make the server read and unmarshal megabytes.
+// Until a captcha has passed the limit is MaxJoinMsgSize, so a join can
+// carry the provider's token.
 func (c *Conn) ReadLoop(
 	world *World,
-	onJoin func(conn *Conn, name string),
+	onJoin func(conn *Conn, req joinRequest),
 	onDisconnect func(conn *Conn),
 ) {
 	defer func() {
+		c.stopJoins()
 		onDisconnect(c)
 		c.Close()
 	}()
 
+	limit := int64(0)
 	for {
+		if want := c.readLimit(); want != limit {
+			limit = want
+			c.ws.SetReadLimit(limit)
+		}
 		_, raw, err := c.ws.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
@@ -146,23 +329,47 @@ func (c *Conn) ReadLoop(
 			return
 		}
 
-		var msg ClientMessage
-		if err := json.Unmarshal(raw, &msg); err != nil {
-			log.Printf("bad message from %s: %v", c.ID, err)
-			continue
-		}
+		c.handleMessage(raw, onJoin)
+	}
+}
 
-		switch msg.Type {
-		case MsgJoin, MsgRespawn: // "j" or "r"
-			name := msg.Name
-			if name == "" {
-				name = "Player"
-			}
-			c.Name = name
-			onJoin(c, name)
+// readLimit is the largest frame the read loop accepts next
+func (c *Conn) readLimit() int64 {
+	if c.verified.Load() {
+		return MaxClientMsgSize
+	}
+	return MaxJoinMsgSize
+}
 
-		case MsgInput: // "i"
-			c.setInput(msg.Angle, msg.Boost == 1)
+// handleMessage decodes and applies one client message. Malformed or
+// out-of-range messages are logged and dropped — a hostile client must never
+// be able to panic the connection goroutine or feed the game loop a value
+// that wedges it (e.g. a 1e308 angle in the turn-normalization math).
+func (c *Conn) handleMessage(raw []byte, onJoin func(conn *Conn, req joinRequest)) {
+	var msg ClientMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		log.Printf("bad message from %s: %v", c.ID, err)
+		return
+	}
+
+	switch msg.Type {
+	case MsgJoin, MsgRespawn: // "j" or "r"
+		name := sanitizeName(msg.Name)
+		if name == "" {
+			name = DefaultPlayerName
+		}
+		c.requestJoin(joinRequest{name: name, token: msg.Token, captcha: msg.Captcha, flag: msg.Flag == 1}, onJoin)
+
+	case MsgInput: // "i"
+		if math.IsNaN(msg.Angle) || math.IsInf(msg.Angle, 0) {
+			return
+		}
+		// Fold into [-π, π] so downstream angle math stays well-conditioned
+		angle := math.Remainder(msg.Angle, 2*math.Pi)
+		if msg.Slot == 0 {
+			c.setInput(angle, msg.Boost == 1, msg.Seq)
+		} else {
+			c.setSlotInput(msg.Slot, angle, msg.Boost == 1)
 		}
 	}
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"io"
+	"log"
+	"math"
+	"os"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// fuzzSeeds covers malformed JSON, enormous fields, non-finite or huge floats
+// and unexpected field types
+var fuzzSeeds = []string{
+	`{"t":"j","n":"alice"}`,
+	`{"t":"r","n":""}`,
+	`{"t":"i","a":1.57,"b":1}`,
+	`{"t":"i","a":1e308,"b":1}`,
+	`{"t":"i","a":-1e308}`,
+	`{"t":"i","a":NaN}`,
+	`{"t":"i","a":"north"}`,
+	`{"t":5}`,
+	`{"t":"j","n":{"x":1}}`,
+	`{"t":"i","b":99999999999999999999}`,
+	`[1,2,3]`,
+	`null`,
+	`{"t":"j","n":"` + string(make([]byte, 4096)) + `"}`,
+	`{"t":"j","n":"𐏿\u0000"}`,
+	`{`,
+	``,
+}
+
+func quietLogs(tb testing.TB) {
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+// FuzzHandleMessage feeds arbitrary bytes to the per-message handler and
+// checks that whatever survives is safe for the game loop to consume
+func FuzzHandleMessage(f *testing.F) {
+	quietLogs(f)
+	for _, s := range fuzzSeeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		c := NewConn(newMockWS())
+		joined := ""
+		c.handleMessage(raw, func(_ *Conn, req joinRequest) { joined = req.name })
+
+		if n := len(graphemes(joined)); n > MaxNameLength {
+			t.Fatalf("join name has %d characters, limit %d", n, MaxNameLength)
+		}
+		if n := utf8.RuneCountInString(joined); n > MaxNameRunes {
+			t.Fatalf("join name has %d runes, limit %d", n, MaxNameRunes)
+		}
+		if joined != "" && sanitizeName(joined) != joined {
+			t.Fatalf("join name %q isn't stable under sanitizeName", joined)
+		}
+		inp := c.GetInput()
+		if math.IsNaN(inp.Angle) || inp.Angle < -math.Pi || inp.Angle > math.Pi {
+			t.Fatalf("stored angle %v outside [-π, π]", inp.Angle)
+		}
+
+		// The game loop must be able to apply whatever was stored
+		s := NewSnake("fuzz", "fuzz", "#fff")
+		done := make(chan struct{})
+		go func() {
+			s.ApplyInput(inp.Angle, inp.Boost, NewFoodPool())
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("ApplyInput wedged on angle %v", inp.Angle)
+		}
+		if math.IsNaN(s.Angle) || math.IsInf(s.Angle, 0) {
+			t.Fatalf("snake angle became %v", s.Angle)
+		}
+	})
+}
+
+// FuzzReadLoop drives the full read loop with one hostile frame and checks
+// the goroutine still exits cleanly when the socket closes
+func FuzzReadLoop(f *testing.F) {
+	quietLogs(f)
+	for _, s := range fuzzSeeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		world := newEmptyWorld()
+		ws := newMockWS()
+		c := NewConn(ws)
+		ws.inbox <- raw
+
+		exited := make(chan struct{})
+		go func() {
+			c.ReadLoop(world, func(*Conn, joinRequest) {}, func(*Conn) {})
+			close(exited)
+		}()
+		ws.Close()
+		select {
+		case <-exited:
+		case <-time.After(time.Second):
+			t.Fatal("read loop did not exit after close")
+		}
+	})
+}
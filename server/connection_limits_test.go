@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// limitServer runs the real read loop behind a real WebSocket, so frame
+// limits are enforced by gorilla as in production. Joins that pass gate
+// (nil for none) reach onJoin, and the loop's exit closes done.
+func limitServer(t *testing.T, gate *CaptchaGate, onJoin func(*Conn, joinRequest)) (*websocket.Conn, <-chan struct{}) {
+	t.Helper()
+	quietLogs(t)
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		c := NewConn(ws)
+		c.verified.Store(gate.exempt(r))
+		c.ReadLoop(newEmptyWorld(), func(c *Conn, req joinRequest) {
+			if gate.verifyJoin(c, req.captcha) {
+				onJoin(c, req)
+			}
+		}, func(*Conn) {})
+		close(done)
+	}))
+	t.Cleanup(srv.Close)
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, done
+}
+
+func sendJSON(t *testing.T, ws *websocket.Conn, msg ClientMessage) {
+	t.Helper()
+	data, _ := json.Marshal(msg)
+	if err := ws.WriteMessage(websocket.TextMessage, data); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// TestReadLimitClosesOversizedFrame checks that a frame over
+// MaxClientMsgSize ends the connection instead of being read and decoded
+func TestReadLimitClosesOversizedFrame(t *testing.T) {
+	joined := make(chan string, 1)
+	client, done := limitServer(t, nil, func(_ *Conn, req joinRequest) { joined <- req.name })
+
+	sendJSON(t, client, ClientMessage{Type: MsgJoin, Name: strings.Repeat("x", MaxClientMsgSize)})
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("oversized frame did not close the connection")
+	}
+	select {
+	case name := <-joined:
+		t.Fatalf("oversized join ran with name of %d bytes", len(name))
+	default:
+	}
+}
+
+// TestReadLimitTruncatesLongName checks that a long name inside the limit
+// is capped at MaxNameLength before it reaches the join
+func TestReadLimitTruncatesLongName(t *testing.T) {
+	joined := make(chan string, 1)
+	client, _ := limitServer(t, nil, func(_ *Conn, req joinRequest) { joined <- req.name })
+
+	sendJSON(t, client, ClientMessage{Type: MsgJoin, Name: strings.Repeat("y", 200)})
+	select {
+	case name := <-joined:
+		if name != strings.Repeat("y", MaxNameLength) {
+			t.Fatalf("joined as %q, want %d characters", name, MaxNameLength)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("join did not run")
+	}
+}
+
+// TestCaptchaTokenFitsJoinLimit joins with a provider-sized captcha token
+// through the real read limit, then checks the limit drops back to
+// MaxClientMsgSize once the connection is verified
+func TestCaptchaTokenFitsJoinLimit(t *testing.T) {
+	got := make(chan string, 1)
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.PostFormValue("response")
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer provider.Close()
+	captchaProviders["test"] = captchaProvider{verifyURL: provider.URL}
+	defer delete(captchaProviders, "test")
+	gate := &CaptchaGate{provider: "test", siteKey: "site", secret: "secret", client: provider.Client()}
+
+	joined := make(chan string, 1)
+	client, done := limitServer(t, gate, func(_ *Conn, req joinRequest) { joined <- req.name })
+
+	token := strings.Repeat("0.AbC-_", 2048/7+1)[:2048]
+	sendJSON(t, client, ClientMessage{
+		Type:    MsgJoin,
+		Name:    strings.Repeat("名", MaxNameLength),
+		Token:   strings.Repeat("k", 64),
+		Captcha: token,
+	})
+	select {
+	case name := <-joined:
+		if name != strings.Repeat("名", MaxNameLength) {
+			t.Fatalf("joined as %q", name)
+		}
+	case <-done:
+		t.Fatal("join with a 2048-character captcha token closed the connection")
+	case <-time.After(2 * time.Second):
+		t.Fatal("join did not run")
+	}
+	if sent := <-got; sent != token {
+		t.Fatalf("provider got a %d-character token, want %d", len(sent), len(token))
+	}
+
+	// Verified: a frame that size is no longer accepted
+	sendJSON(t, client, ClientMessage{Type: MsgRespawn, Captcha: token})
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("oversized frame after verification did not close the connection")
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// bundledPacks are the example content packs shipped in the binary,
+// selectable by name (file name without .json)
+//
+//go:embed packs/*.json
+var bundledPacks embed.FS
+
+// Scheduled event types in a content pack
+const (
+	PackEventAnnounce    = "announce"     // banner to everyone
+	PackEventGoldenApple = "golden_apple" // spawn the golden apple now unless one is out
+)
+
+// ContentPack is map content in JSON: static features plus a schedule of
+// timed world events, applied when a world is created. Coordinates are
+// relative to the world center, so packs don't depend on where it is.
+type ContentPack struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Obstacles   []PackFeature `json:"obstacles,omitempty"`
+	Zones       []PackFeature `json:"zones,omitempty"`
+	Portals     []PackFeature `json:"portals,omitempty"`
+	Schedule    []PackEvent   `json:"schedule,omitempty"`
+}
+
+// PackFeature is a circle; tx,ty is a portal's destination. A zone with a
+// multiplier is a danger zone: food eaten inside is worth that much more.
+type PackFeature struct {
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	Radius     float64 `json:"r"`
+	TX         float64 `json:"tx,omitempty"`
+	TY         float64 `json:"ty,omitempty"`
+	Multiplier float64 `json:"multiplier,omitempty"`
+}
+
+// PackEvent fires At seconds after the world is created, then every Every
+// seconds if set. Announcements carry Text, Severity and Duration (seconds).
+type PackEvent struct {
+	Type     string  `json:"type"`
+	At       float64 `json:"at"`
+	Every    float64 `json:"every,omitempty"`
+	Text     string  `json:"text,omitempty"`
+	Severity string  `json:"severity,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+}
+
+// scheduledEvent is a validated PackEvent in ticks
+type scheduledEvent struct {
+	at, every uint64
+	announce  *AnnouncementMsg
+	apple     bool
+}
+
+// due reports whether the event fires at tick
+func (e scheduledEvent) due(tick uint64) bool {
+	if tick < e.at {
+		return false
+	}
+	return tick == e.at || (e.every > 0 && (tick-e.at)%e.every == 0)
+}
+
+// loadContentPack resolves spec to a pack: "" means none, a path (containing
+// a slash or ending in .json) is read from disk, anything else names a
+// bundled pack
+func loadContentPack(spec string) (*ContentPack, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	var data []byte
+	var err error
+	if strings.ContainsRune(spec, '/') || strings.HasSuffix(spec, ".json") {
+		data, err = os.ReadFile(spec)
+	} else {
+		data, err = bundledPacks.ReadFile("packs/" + spec + ".json")
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("no bundled pack %q (have %s)", spec, strings.Join(bundledPackNames(), ", "))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := parseContentPack(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", spec, err)
+	}
+	return p, nil
+}
+
+// bundledPackNames lists the packs embedded in the binary
+func bundledPackNames() []string {
+	entries, _ := bundledPacks.ReadDir("packs")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	return names
+}
+
+// parseContentPack decodes and validates a pack. Unknown fields are
+// rejected so a typo in a hand-written pack fails loudly.
+func parseContentPack(data []byte) (*ContentPack, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var p ContentPack
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// validate checks the pack fits the world: features inside the boundary and
+// within size bounds, obstacles leaving most of the map open, portals
+// landing on open ground, events well formed
+func (p *ContentPack) validate() error {
+	if n := utf8.RuneCountInString(strings.TrimSpace(p.Name)); n == 0 || n > PackMaxNameLength {
+		return fmt.Errorf("name must be 1-%d characters", PackMaxNameLength)
+	}
+	for kind, list := range map[string][]PackFeature{"obstacle": p.Obstacles, "zone": p.Zones, "portal": p.Portals} {
+		if len(list) > PackMaxFeatures {
+			return fmt.Errorf("more than %d %ss", PackMaxFeatures, kind)
+		}
+		for i, f := range list {
+			if f.Radius < PackMinFeatureRadius || f.Radius > PackMaxFeatureRadius {
+				return fmt.Errorf("%s %d: radius must be %g-%g", kind, i, PackMinFeatureRadius, PackMaxFeatureRadius)
+			}
+			if math.Hypot(f.X, f.Y)+f.Radius > WorldRadius {
+				return fmt.Errorf("%s %d: not inside the world", kind, i)
+			}
+			if f.Multiplier != 0 && kind != "zone" {
+				return fmt.Errorf("%s %d: only zones have a multiplier", kind, i)
+			}
+			if f.Multiplier != 0 && (f.Multiplier < 1 || f.Multiplier > RiskMaxMultiplier) {
+				return fmt.Errorf("%s %d: multiplier must be 1-%g", kind, i, RiskMaxMultiplier)
+			}
+		}
+	}
+	// Spawn points don't avoid obstacles, so a map walled off by them would
+	// kill most snakes as they arrive. Overlaps count twice, which only errs
+	// toward rejecting.
+	cover := 0.0
+	for _, o := range p.Obstacles {
+		cover += o.Radius * o.Radius
+	}
+	if cover > PackMaxObstacleCover*WorldRadius*WorldRadius {
+		return fmt.Errorf("obstacles cover more than %g%% of the world", PackMaxObstacleCover*100)
+	}
+	// Snakes arrive at a portal's destination and must be able to reach its
+	// entrance, so neither may overlap an obstacle, and entrances may not
+	// overlap each other
+	for i, f := range p.Portals {
+		if math.Hypot(f.TX, f.TY) > WorldRadius-SpawnMargin {
+			return fmt.Errorf("portal %d: destination within %g of the boundary", i, SpawnMargin)
+		}
+		for _, o := range p.Obstacles {
+			if math.Hypot(f.X-o.X, f.Y-o.Y) < f.Radius+o.Radius {
+				return fmt.Errorf("portal %d: entrance overlaps an obstacle", i)
+			}
+			if math.Hypot(f.TX-o.X, f.TY-o.Y) < f.Radius+o.Radius {
+				return fmt.Errorf("portal %d: destination overlaps an obstacle", i)
+			}
+		}
+		for j, g := range p.Portals[:i] {
+			if math.Hypot(f.X-g.X, f.Y-g.Y) < f.Radius+g.Radius {
+				return fmt.Errorf("portal %d: entrance overlaps portal %d", i, j)
+			}
+		}
+	}
+	if len(p.Schedule) > PackMaxEvents {
+		return fmt.Errorf("more than %d scheduled events", PackMaxEvents)
+	}
+	for i, e := range p.Schedule {
+		if _, err := e.compile(); err != nil {
+			return fmt.Errorf("schedule %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// compile validates e and converts it to ticks
+func (e PackEvent) compile() (scheduledEvent, error) {
+	if e.At < 0 {
+		return scheduledEvent{}, errors.New("negative start time")
+	}
+	if e.Every != 0 && e.Every < PackMinEventInterval.Seconds() {
+		return scheduledEvent{}, fmt.Errorf("repeats more often than every %s", PackMinEventInterval)
+	}
+	sec := func(s float64) uint64 { return uint64(ticksFor(time.Duration(s * float64(time.Second)))) }
+	ev := scheduledEvent{at: sec(e.At), every: sec(e.Every)}
+	switch e.Type {
+	case PackEventAnnounce:
+		msg, err := NewAnnouncement(e.Text, e.Severity, time.Duration(e.Duration*float64(time.Second)))
+		if err != nil {
+			return scheduledEvent{}, err
+		}
+		ev.announce = &msg
+	case PackEventGoldenApple:
+		ev.apple = true
+	default:
+		return scheduledEvent{}, fmt.Errorf("unknown event type %q", e.Type)
+	}
+	return ev, nil
+}
+
+// applyPack installs p's features and schedule on a world being created,
+// before anything is spawned in it. p must have passed validate.
+func (w *World) applyPack(p *ContentPack) {
+	abs := func(list []PackFeature) []MapFeature {
+		out := make([]MapFeature, len(list))
+		for i, f := range list {
+			out[i] = MapFeature{
+				X: WorldCenterX + f.X, Y: WorldCenterY + f.Y, Radius: f.Radius,
+				TargetX: WorldCenterX + f.TX, TargetY: WorldCenterY + f.TY,
+				Multiplier: f.Multiplier,
+			}
+		}
+		return out
+	}
+	w.Pack = p.Name
+	w.Map = MapFeatures{Obstacles: abs(p.Obstacles), Zones: abs(p.Zones), Portals: abs(p.Portals)}
+	w.Schedule = w.Schedule[:0]
+	for _, e := range p.Schedule {
+		ev, _ := e.compile()
+		w.Schedule = append(w.Schedule, ev)
+	}
+}
+
+// runSchedule fires the content pack events due this tick. Caller must hold w.mu.Lock.
+func (gl *GameLoop) runSchedule(snap *TickSnapshot) {
+	w := gl.world
+	for _, ev := range w.Schedule {
+		if !ev.due(w.Tick - w.epoch) {
+			continue
+		}
+		if ev.announce != nil {
+			snap.Announcements = append(snap.Announcements, *ev.announce)
+		}
+		if ev.apple && !w.Apple.Active {
+			w.Apple.respawnAt = w.Tick // updateGoldenApple spawns it this tick
+		}
+	}
+}
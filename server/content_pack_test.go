@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestContentPackRejects feeds parseContentPack packs that don't fit the
+// world and checks each is turned away, while the bundled packs load
+func TestContentPackRejects(t *testing.T) {
+	for _, name := range bundledPackNames() {
+		if _, err := loadContentPack(name); err != nil {
+			t.Errorf("bundled pack %s: %v", name, err)
+		}
+	}
+
+	obstacles := func(n int, r float64) []PackFeature {
+		out := make([]PackFeature, n)
+		for i := range out {
+			out[i] = PackFeature{Radius: r}
+		}
+		return out
+	}
+	schedule := make([]PackEvent, PackMaxEvents+1)
+	for i := range schedule {
+		schedule[i] = PackEvent{Type: PackEventGoldenApple, At: float64(i)}
+	}
+	tests := []struct {
+		name string
+		pack ContentPack
+		want string
+	}{
+		{"no name", ContentPack{Name: " "}, "name must be"},
+		{"obstacle past the boundary", ContentPack{Name: "p", Obstacles: []PackFeature{{X: WorldRadius, Radius: 100}}}, "not inside the world"},
+		{"obstacle straddling the boundary", ContentPack{Name: "p", Obstacles: []PackFeature{{Y: -WorldRadius + 50, Radius: 100}}}, "not inside the world"},
+		{"obstacle too small", ContentPack{Name: "p", Obstacles: obstacles(1, PackMinFeatureRadius-1)}, "radius must be"},
+		{"obstacle too large", ContentPack{Name: "p", Obstacles: obstacles(1, PackMaxFeatureRadius+1)}, "radius must be"},
+		{"too many obstacles", ContentPack{Name: "p", Obstacles: obstacles(PackMaxFeatures+1, 100)}, "more than"},
+		{"too many events", ContentPack{Name: "p", Schedule: schedule}, "more than"},
+		{"obstacles blocking the map", ContentPack{Name: "p", Obstacles: []PackFeature{
+			{X: -3000, Radius: 3000}, {X: 3000, Radius: 3000}, {Y: -3000, Radius: 3000}, {Y: 3000, Radius: 3000},
+		}}, "cover more than"},
+		{"one obstacle stacked many times", ContentPack{Name: "p", Obstacles: obstacles(PackMaxFeatures, PackMaxFeatureRadius)}, "cover more than"},
+		{"portal into an obstacle", ContentPack{Name: "p",
+			Obstacles: []PackFeature{{X: 2000, Radius: 500}},
+			Portals:   []PackFeature{{Radius: 100, TX: 2000}},
+		}, "destination overlaps"},
+		{"multiplier off a zone", ContentPack{Name: "p", Obstacles: []PackFeature{{Radius: 100, Multiplier: 2}}}, "only zones"},
+		{"unknown event", ContentPack{Name: "p", Schedule: []PackEvent{{Type: "meteor"}}}, "unknown event type"},
+		{"event repeating too often", ContentPack{Name: "p", Schedule: []PackEvent{{Type: PackEventGoldenApple, Every: 1}}}, "repeats more often"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := json.Marshal(tt.pack)
+			if _, err := parseContentPack(data); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseContentPack = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseContentPack([]byte(`{"name": "p", "obstacle": []}`)); err == nil {
+		t.Error("a misspelled field was accepted")
+	}
+}
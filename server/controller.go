@@ -0,0 +1,113 @@
+package main
+
+import "time"
+
+// Controller is a snake's input source. Each tick the game loop asks every
+// live snake's controller for its steering before anything moves, then
+// moves them all under the same rules (see movement.go). A snake is driven
+// by a PlayerController or BotController according to what it is, unless
+// GameLoop.SetController swaps in another one, so sources can be mixed,
+// swapped and scripted in tests. A snake with neither a player nor a bot
+// behind it is driven by its set controller alone. Decide runs on the game loop goroutine
+// with w.mu held; view is the live view.
+type Controller interface {
+	Decide(view WorldView, snake *Snake) (angle float64, boost bool)
+}
+
+// PlayerController steers with a connection's latest input for one of its
+// snakes (slot 0 is its own, see multi_snake.go), delayed by lagMS for an
+// input-lag sanction
+type PlayerController struct {
+	conn  *Conn
+	slot  int
+	lagMS int
+}
+
+func (p PlayerController) Decide(WorldView, *Snake) (float64, bool) {
+	var inp PlayerInput
+	if p.slot == 0 {
+		inp = p.conn.laggedInput(p.lagMS)
+		p.conn.appliedSeq = echoSeq(p.conn, inp, p.lagMS)
+	} else {
+		inp = p.conn.slotInput(p.slot, p.lagMS)
+	}
+	return inp.Angle, inp.Boost
+}
+
+// BotController runs a bot's AI, its cohort's policy or the heuristic
+// rules, on the manager's cadence and keeps the last steering in between
+// (see bot_cadence.go)
+type BotController struct {
+	bm  *BotManager
+	bot *Bot
+}
+
+func (b BotController) Decide(view WorldView, snake *Snake) (float64, bool) {
+	bm, bot := b.bm, b.bot
+	if bm.due(bot) {
+		began := time.Now()
+		bot.step = bm.cadence
+		if p := bot.cohort.Policy; p != nil {
+			turn, boost := p.Act(observe(view, snake))
+			bot.steerAngle, bot.steerBoost = actionAngle(snake, turn), boost
+		} else {
+			bot.steerAngle, bot.steerBoost = bm.decideBotInput(bot, snake, view)
+		}
+		bm.aiTime += time.Since(began)
+	}
+	return bot.steerAngle, bot.steerBoost
+}
+
+// AutoPilotController steers any snake with the heuristic bot rules every
+// tick, keeping its own AI state, e.g. to stand in for a player
+type AutoPilotController struct {
+	bm    *BotManager
+	state Bot
+}
+
+// NewAutoPilot creates an auto-pilot using bm's rules
+func NewAutoPilot(bm *BotManager) *AutoPilotController {
+	return &AutoPilotController{bm: bm, state: Bot{wanderTicks: randomWanderDuration()}}
+}
+
+func (a *AutoPilotController) Decide(view WorldView, snake *Snake) (float64, bool) {
+	if a.state.ID != snake.ID {
+		a.state = Bot{ID: snake.ID, targetAngle: snake.Angle, wanderTicks: randomWanderDuration(), lastScore: snake.Score}
+	}
+	return a.bm.decideBotInput(&a.state, snake, view)
+}
+
+// ReplayController plays back recorded inputs, one per tick, then holds
+// the last one
+type ReplayController struct {
+	inputs []PlayerInput
+	next   int
+}
+
+// NewReplay creates a controller that replays inputs from the start
+func NewReplay(inputs []PlayerInput) *ReplayController {
+	return &ReplayController{inputs: inputs}
+}
+
+func (r *ReplayController) Decide(_ WorldView, snake *Snake) (float64, bool) {
+	if len(r.inputs) == 0 {
+		return snake.Angle, false
+	}
+	inp := r.inputs[min(r.next, len(r.inputs)-1)]
+	r.next++
+	return inp.Angle, inp.Boost
+}
+
+// SetController makes c drive snake id instead of its own controller until
+// the snake dies or leaves; nil restores its own. Game-loop goroutine only
+// (use World.Post from elsewhere).
+func (gl *GameLoop) SetController(id string, c Controller) {
+	if c == nil {
+		delete(gl.controllers, id)
+		return
+	}
+	if gl.controllers == nil {
+		gl.controllers = make(map[string]Controller)
+	}
+	gl.controllers[id] = c
+}
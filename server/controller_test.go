@@ -0,0 +1,98 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// loggedInputs returns the inputs the world log recorded for snake id, oldest first
+func loggedInputs(l *WorldLog, id string) []PlayerInput {
+	var inputs []PlayerInput
+	for _, t := range l.Dump("", "test").Ticks {
+		for _, e := range t.Entries {
+			if e.Type == WorldLogInput && e.Snake == id {
+				inputs = append(inputs, PlayerInput{Angle: e.Angle, Boost: e.Boost})
+			}
+		}
+	}
+	return inputs
+}
+
+// TestSetControllerSwapsLiveSnake swaps a player's live snake to a replay
+// and to the auto-pilot and back, checking each tick's steering comes from
+// the controller in charge and not from the player's own input
+func TestSetControllerSwapsLiveSnake(t *testing.T) {
+	quietLogs(t)
+	world := newEmptyWorld()
+	world.Log = NewWorldLog(256, t.TempDir())
+	conns := NewConnManager()
+	gl := newGameLoop(world, conns, 0)
+	gl.ambientFood = false
+
+	// Near the boundary, facing out; the player holds that course
+	s := newSnakeAt("p1", "p1", "#ffffff", WorldCenterX+WorldRadius-200, WorldCenterY)
+	s.Angle = 0
+	world.AddSnake(s)
+	c := NewConn(newMockWS())
+	c.ID, c.snakeID = "p1", "p1"
+	c.setInput(0, false, 0)
+	conns.Add(c)
+
+	recorded := []PlayerInput{{Angle: 0.1}, {Angle: 0.25, Boost: true}, {Angle: -0.05}}
+	gl.SetController("p1", NewReplay(recorded))
+	for range len(recorded) + 2 {
+		gl.tick()
+	}
+	got := loggedInputs(world.Log, "p1")
+	for i, want := range recorded {
+		if got[i] != want {
+			t.Fatalf("replay tick %d steered %+v, want %+v", i, got[i], want)
+		}
+	}
+	for _, held := range got[len(recorded):] {
+		if held != recorded[len(recorded)-1] {
+			t.Fatalf("replay past its end steered %+v, want the last input held", held)
+		}
+	}
+
+	gl.SetController("p1", NewAutoPilot(gl.bots))
+	for range 120 {
+		gl.tick()
+	}
+	if !s.Alive {
+		t.Fatal("auto-pilot kept the player's course into the boundary")
+	}
+	auto := loggedInputs(world.Log, "p1")[len(got)]
+	if math.Abs(normalizeAngle(auto.Angle-math.Pi)) > 0.01 {
+		t.Fatalf("auto-pilot steered %.2f at the boundary, want toward the center", auto.Angle)
+	}
+
+	gl.SetController("p1", nil)
+	c.setInput(1.5, false, 0)
+	gl.tick()
+	inputs := loggedInputs(world.Log, "p1")
+	if last := inputs[len(inputs)-1]; last.Angle != 1.5 {
+		t.Fatalf("after restoring, steered %.2f, want the player's 1.50", last.Angle)
+	}
+}
+
+// TestControllerDrivesUnownedSnake checks a snake with no player or bot
+// behind it moves only while a controller is set
+func TestControllerDrivesUnownedSnake(t *testing.T) {
+	quietLogs(t)
+	world := newEmptyWorld()
+	gl := newGameLoop(world, NewConnManager(), 0)
+	gl.ambientFood = false
+
+	s := newSnakeAt("r1", "r1", "#ffffff", WorldCenterX, WorldCenterY)
+	world.AddSnake(s)
+	gl.tick()
+	if s.Head() != s.prevHead || s.Head().X != WorldCenterX {
+		t.Fatal("a snake without a controller moved")
+	}
+	gl.SetController("r1", NewReplay([]PlayerInput{{Angle: s.Angle}}))
+	gl.tick()
+	if moved := math.Hypot(s.Head().X-WorldCenterX, s.Head().Y-WorldCenterY); math.Abs(moved-s.Speed) > 1e-9 {
+		t.Fatalf("replayed snake moved %.2f px, want %.2f", moved, s.Speed)
+	}
+}
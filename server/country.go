@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Country flags are opt-in: a player who ticks "show my country" on the
+// join screen gets the country their connection comes from, as reported by
+// the GeoIP-aware proxy in front of the server (e.g. Cloudflare's
+// CF-IPCountry). The code travels with their snake and leaderboard entry.
+// Bots carry no flag unless BotCountryFlags is set, in which case each gets
+// one of the countries its name's language is spoken in.
+
+// countryHeaderFromEnv reads SLETHER_COUNTRY_HEADER, the request header
+// carrying the client's ISO 3166-1 alpha-2 country; unset disables flags
+func countryHeaderFromEnv() string {
+	return os.Getenv("SLETHER_COUNTRY_HEADER")
+}
+
+// requestCountry is r's country from header, "" if absent or not a real
+// country code (proxies use XX or ZZ for unknown, T1 for Tor)
+func requestCountry(r *http.Request, header string) string {
+	if header == "" {
+		return ""
+	}
+	code := strings.ToUpper(strings.TrimSpace(r.Header.Get(header)))
+	if len(code) != 2 || code == "XX" || code == "ZZ" {
+		return ""
+	}
+	for _, ch := range code {
+		if ch < 'A' || ch > 'Z' {
+			return ""
+		}
+	}
+	return code
+}
+
+// botNameCountries maps each bot name to the countries of every locale
+// that lists it
+var botNameCountries = func() map[string][]string {
+	m := make(map[string][]string)
+	for _, l := range botLocales {
+		for _, name := range l.Names {
+			m[name] = append(m[name], l.Countries...)
+		}
+	}
+	return m
+}()
+
+// botCountry picks a plausible flag for a bot called name, "" unless
+// BotCountryFlags is set. Suffixed duplicates ("Viper 2") count as the base name.
+func botCountry(name string) string {
+	if !BotCountryFlags {
+		return ""
+	}
+	countries := botNameCountries[name]
+	if countries == nil {
+		if i := strings.LastIndexByte(name, ' '); i > 0 {
+			countries = botNameCountries[name[:i]]
+		}
+	}
+	if len(countries) == 0 {
+		return ""
+	}
+	return countries[rand.Intn(len(countries))]
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// deathDropLevels are the levels death drops come in, largest first, and
+// how often each is picked: mostly large items, so a big body doesn't turn
+// into hundreds of crumbs
+var deathDropLevels = []struct{ level, weight int }{
+	{DeathFoodLevel, 6},
+	{FoodLevel3, 3},
+	{FoodLevel1, 1},
+}
+
+// bodyDrops spills share of s's body as food along its whole length, head to
+// tail. Drop points are spaced by exponential gaps averaging DeathFoodPerUnit
+// segments, so a body breaks into clumps and thin stretches rather than an
+// even dotted line. The value of the body behind a point (times share)
+// builds up until it pays for the next item, whose level is drawn from
+// deathDropLevels; a long gap pays for a clump of several. What is left at
+// the tail drops there, largest items first. Items scatter across the body
+// by about its width and along it by a segment or so. The share only thins
+// out what each stretch of body yields; it never shortens the stretch of
+// body that drops food.
+func (s *Snake) bodyDrops(pool *FoodPool, share float64) []*Food {
+	segs := s.Segments
+	if len(segs) == 0 || share <= 0 {
+		return nil
+	}
+	last := float64(len(segs) - 1)
+	food := make([]*Food, 0, int(float64(len(segs))*share)/DeathFoodLevel+1)
+	drop := func(level int, pos float64) float64 {
+		x, y := s.scatterAt(pos)
+		f := pool.newFoodWithLevel(x, y, level, false)
+		if f == nil {
+			return float64(foodLevelSpec(level).Value) // pool exhausted: the share is lost
+		}
+		food = append(food, f)
+		return float64(f.Value)
+	}
+	pos, budget := 0.0, share // the head segment's share
+	next := randomDropLevel()
+	for pos < last {
+		step := min(rand.ExpFloat64()*DeathFoodPerUnit, last-pos)
+		pos += step
+		budget += step * share
+		for budget >= float64(foodLevelSpec(next).Value) {
+			budget -= drop(next, pos)
+			next = randomDropLevel()
+		}
+	}
+	for _, l := range deathDropLevels {
+		for budget >= float64(foodLevelSpec(l.level).Value) {
+			budget -= drop(l.level, last)
+		}
+	}
+	return food
+}
+
+// randomDropLevel draws a death drop level by deathDropLevels weight
+func randomDropLevel() int {
+	total := 0
+	for _, l := range deathDropLevels {
+		total += l.weight
+	}
+	r := rand.Intn(total)
+	for _, l := range deathDropLevels {
+		if r < l.weight {
+			return l.level
+		}
+		r -= l.weight
+	}
+	return FoodLevel1
+}
+
+// scatterAt is a random point around the body at pos, a fractional segment
+// index: jittered along the body, then offset across it by about its width
+func (s *Snake) scatterAt(pos float64) (float64, float64) {
+	segs := s.Segments
+	last := float64(len(segs) - 1)
+	pos = math.Max(0, math.Min(last, pos+rand.NormFloat64()*0.5))
+	i := int(pos)
+	if i >= len(segs)-1 {
+		i = max(len(segs)-2, 0)
+	}
+	a, b := segs[i], segs[min(i+1, len(segs)-1)]
+	t := pos - float64(i)
+	x, y := a.X+(b.X-a.X)*t, a.Y+(b.Y-a.Y)*t
+
+	dx, dy := b.X-a.X, b.Y-a.Y
+	if d := math.Hypot(dx, dy); d > 0 {
+		dx, dy = dx/d, dy/d
+	} else {
+		angle := rand.Float64() * 2 * math.Pi
+		dx, dy = math.Cos(angle), math.Sin(angle)
+	}
+	across := rand.NormFloat64() * s.Width * 0.75
+	x, y = x-dy*across, y+dx*across
+	return clampToCircle(x, y, WorldCenterX, WorldCenterY, WorldRadius)
+}
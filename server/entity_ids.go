@@ -0,0 +1,40 @@
+package main
+
+import "sync"
+
+// EntityIDs maps string snake identities ("player-…", "bot-…" IDs) to
+// compact uint32 wire IDs. UUIDs cost 38 bytes per snake per message; a numeric
+// ID costs at most 10. IDs are never reused within a process lifetime, so a
+// client can't confuse a new snake with one that just left its viewport.
+//
+// Safe for concurrent use — connection goroutines assign IDs on connect while
+// the game loop reads them.
+type EntityIDs struct {
+	mu   sync.Mutex
+	next uint32
+	ids  map[string]uint32
+}
+
+// NewEntityIDs creates an empty mapping table
+func NewEntityIDs() *EntityIDs {
+	return &EntityIDs{ids: make(map[string]uint32)}
+}
+
+// Assign returns the wire ID for key, allocating one on first use
+func (t *EntityIDs) Assign(key string) uint32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if id, ok := t.ids[key]; ok {
+		return id
+	}
+	t.next++
+	t.ids[key] = t.next
+	return t.next
+}
+
+// Release forgets key; a later Assign for the same key gets a new ID
+func (t *EntityIDs) Release(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.ids, key)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Event types in GameEvent.Type
+const (
+	EventJoin      = "join"      // snake spawned (player join/respawn or bot spawn)
+	EventLeave     = "leave"     // player disconnected or bot despawned
+	EventDeath     = "death"     // Other = killer ID, "" for the boundary
+	EventKill      = "kill"      // Other = victim ID
+	EventMilestone = "milestone" // Score crossed one of scoreMilestones
+	EventObjective = "objective" // ate the golden apple
+)
+
+// scoreMilestones are the scores that produce an EventMilestone when crossed
+var scoreMilestones = []int{100, 250, 500, 1000, 2500, 5000, 10000}
+
+// GameEvent is one line of the event stream
+type GameEvent struct {
+	Time      time.Time `json:"time"`
+	Room      string    `json:"room,omitempty"` // bot IDs repeat across rooms
+	Tick      uint64    `json:"tick"`
+	Type      string    `json:"type"`
+	Snake     string    `json:"snake"`  // subject snake ID
+	Entity    uint32    `json:"entity"` // subject's wire ID, as in state messages
+	Name      string    `json:"name,omitempty"`
+	Bot       bool      `json:"bot,omitempty"`
+	Other     string    `json:"other,omitempty"`
+	OtherName string    `json:"other_name,omitempty"`
+	Score     int       `json:"score,omitempty"`
+	Length    int       `json:"length,omitempty"`
+	Streak    int       `json:"streak,omitempty"`    // kill: killer's streak after this kill
+	Mutual    bool      `json:"mutual,omitempty"`    // death, kill: the two snakes killed each other
+	Milestone int       `json:"milestone,omitempty"` // milestone: the score crossed
+	X         float64   `json:"x,omitempty"`         // head position
+	Y         float64   `json:"y,omitempty"`
+}
+
+// snakeEvent starts an event about s: identity, score, length and head
+func snakeEvent(typ string, s *Snake) GameEvent {
+	head := s.Head()
+	return GameEvent{
+		Type:   typ,
+		Snake:  s.ID,
+		Entity: s.NetID,
+		Name:   s.Name,
+		Bot:    strings.HasPrefix(s.ID, "bot-"),
+		Score:  s.Score,
+		Length: len(s.Segments),
+		X:      roundTo1(head.X),
+		Y:      roundTo1(head.Y),
+	}
+}
+
+// EventStream writes GameEvents as newline-delimited JSON to a file or a
+// TCP/Unix socket for offline analytics. Emit never blocks the game loop:
+// events queue in a bounded buffer drained by a writer goroutine, and are
+// dropped (and counted) when it is full or the socket is down. A nil stream
+// — SLETHER_EVENTS unset — turns Emit into a no-op.
+type EventStream struct {
+	events  chan GameEvent
+	dropped atomic.Int64
+	dest    string
+	// open returns the writer; for sockets it redials after a failure
+	open func() (io.WriteCloser, error)
+	// done is closed when the writer exits after Close
+	done chan struct{}
+}
+
+// openEventStream parses SLETHER_EVENTS-style specs: "tcp://host:port",
+// "unix:///path/to.sock" or a file path (appended to). Files are opened
+// now so a bad path fails startup; sockets are dialled by the writer and
+// redialled every EventStreamRetry while the collector is unreachable.
+func openEventStream(spec string) (*EventStream, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	s := &EventStream{events: make(chan GameEvent, EventStreamBuffer), dest: spec, done: make(chan struct{})}
+	switch {
+	case strings.HasPrefix(spec, "tcp://"):
+		addr := strings.TrimPrefix(spec, "tcp://")
+		s.open = func() (io.WriteCloser, error) { return net.DialTimeout("tcp", addr, EventStreamRetry) }
+	case strings.HasPrefix(spec, "unix://"):
+		path := strings.TrimPrefix(spec, "unix://")
+		s.open = func() (io.WriteCloser, error) { return net.DialTimeout("unix", path, EventStreamRetry) }
+	default:
+		f, err := os.OpenFile(spec, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		s.open = func() (io.WriteCloser, error) {
+			if f == nil {
+				return nil, fmt.Errorf("file closed after a write error")
+			}
+			w := f
+			f = nil // a failed file is not reopened
+			return w, nil
+		}
+	}
+	go s.run()
+	log.Printf("event stream: writing to %s", spec)
+	return s, nil
+}
+
+// Emit queues e, stamping its time; safe from any goroutine
+func (s *EventStream) Emit(e GameEvent) {
+	if s == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	select {
+	case s.events <- e:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// run is the writer goroutine: it (re)opens the destination, encodes queued
+// events and flushes whenever the queue runs dry, until Close
+func (s *EventStream) run() {
+	defer close(s.done)
+	for {
+		dst, err := s.open()
+		if err != nil {
+			log.Printf("event stream %s: %v; retrying in %v", s.dest, err, EventStreamRetry)
+			if !s.discardFor(EventStreamRetry) {
+				return
+			}
+			continue
+		}
+		err = s.drain(dst)
+		dst.Close()
+		if err == nil {
+			return
+		}
+		log.Printf("event stream %s: %v", s.dest, err)
+	}
+}
+
+// Close writes out the events still queued, waiting at most timeout, and
+// stops the writer. Nothing may Emit afterwards: call it once the game
+// loops have stopped. A no-op on a nil stream.
+func (s *EventStream) Close(timeout time.Duration) {
+	if s == nil {
+		return
+	}
+	close(s.events)
+	select {
+	case <-s.done:
+	case <-time.After(timeout):
+		log.Printf("event stream %s: %d events not written at shutdown", s.dest, len(s.events))
+	}
+}
+
+// drain writes events to dst until a write fails, or returns nil once the
+// stream is closed and every event is written
+func (s *EventStream) drain(dst io.Writer) error {
+	bw := bufio.NewWriter(dst)
+	enc := json.NewEncoder(bw)
+	for e := range s.events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+		if len(s.events) > 0 {
+			continue
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if n := s.dropped.Swap(0); n > 0 {
+			log.Printf("event stream %s: dropped %d events (buffer full or destination down)", s.dest, n)
+		}
+	}
+	return nil
+}
+
+// discardFor counts queued events as dropped for d, so a dead collector
+// doesn't leave the buffer full of stale events when it comes back.
+// Returns false if the stream was closed meanwhile.
+func (s *EventStream) discardFor(d time.Duration) bool {
+	deadline := time.After(d)
+	for {
+		select {
+		case _, ok := <-s.events:
+			if !ok {
+				return false
+			}
+			s.dropped.Add(1)
+		case <-deadline:
+			return true
+		}
+	}
+}
+
+// emit stamps e with the current tick and sends it to highlight detection,
+// profile stats, score records and the event stream. Caller must hold w.mu.Lock (or run on the loop as a
+// WorldCommand).
+func (w *World) emit(e GameEvent) {
+	e.Tick = w.Tick
+	e.Room = w.Room
+	w.Highlights.observe(e)
+	w.Profiles.observe(e)
+	w.PublicStats.observe(e)
+	w.Records.observe(e)
+	w.Events.Emit(e)
+}
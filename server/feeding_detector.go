@@ -0,0 +1,84 @@
+package main
+
+import "strings"
+
+// feedPair identifies a victim dying repeatedly to the same killer
+type feedPair struct {
+	victimID string
+	killerID string
+}
+
+// feedRecord counts a pair's deaths inside the current window
+type feedRecord struct {
+	VictimName string `json:"victim_name"`
+	KillerName string `json:"killer_name"`
+	Deaths     int    `json:"deaths"`
+	FirstTick  uint64 `json:"first_tick"`
+	LastTick   uint64 `json:"last_tick"`
+	Flagged    bool   `json:"flagged"`
+}
+
+// FeedingDetector spots players who keep dying into the same snake — usually
+// feeding a friend's score. Identities are snake IDs, which for players are
+// the connection ID and so survive respawns. Loop goroutine writes; readers
+// must hold World.mu for reading.
+type FeedingDetector struct {
+	pairs map[feedPair]*feedRecord
+}
+
+// NewFeedingDetector creates an empty detector
+func NewFeedingDetector() *FeedingDetector {
+	return &FeedingDetector{pairs: make(map[feedPair]*feedRecord)}
+}
+
+// RecordDeath notes victim's death to killer at tick and returns the factor
+// to apply to the victim's food drop (1 unless the pair is flagged).
+// Bot victims are ignored — bots can't collude.
+func (d *FeedingDetector) RecordDeath(victim, killer *Snake, tick uint64) float64 {
+	if strings.HasPrefix(victim.ID, "bot-") {
+		return 1
+	}
+	d.prune(tick)
+
+	key := feedPair{victimID: victim.ID, killerID: killer.ID}
+	rec := d.pairs[key]
+	if rec == nil {
+		rec = &feedRecord{FirstTick: tick}
+		d.pairs[key] = rec
+	}
+	rec.VictimName, rec.KillerName = victim.Name, killer.Name
+	rec.Deaths++
+	rec.LastTick = tick
+	if rec.Deaths >= FeedingFlagDeaths {
+		rec.Flagged = true
+		return FeedingDropFactor
+	}
+	return 1
+}
+
+// prune forgets pairs with no death inside the window
+func (d *FeedingDetector) prune(tick uint64) {
+	for key, rec := range d.pairs {
+		if tick-rec.LastTick > uint64(ticksFor(FeedingWindow)) {
+			delete(d.pairs, key)
+		}
+	}
+}
+
+// FeedingFlag is one flagged pair as exposed by the admin API
+type FeedingFlag struct {
+	VictimID string `json:"victim_id"`
+	KillerID string `json:"killer_id"`
+	feedRecord
+}
+
+// Flags returns every currently flagged pair
+func (d *FeedingDetector) Flags() []FeedingFlag {
+	flags := []FeedingFlag{}
+	for key, rec := range d.pairs {
+		if rec.Flagged {
+			flags = append(flags, FeedingFlag{VictimID: key.victimID, KillerID: key.killerID, feedRecord: *rec})
+		}
+	}
+	return flags
+}
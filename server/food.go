@@ -1,21 +1,22 @@
 package main
 
 import (
-	"fmt"
 	"math"
 	"math/rand"
 )
 
 // Food represents a collectible item in the world.
-// Level 1 = common, Level 3 = medium, Level 5 = death drop, Level 10 = rare moving food.
+// Level 1 = common, Level 3 = medium, Level 5 = death drop, Level 10 = rare moving food
+// (values and spawn odds in FoodLevelTable).
 type Food struct {
-	ID       string
-	X        float64
-	Y        float64
-	Value    int
-	Color    string
-	Level    int  // 1, 3, 5, or 10
-	IsMoving bool // true for level-10 rare moving food
+	ID        FoodID
+	X         float64
+	Y         float64
+	Value     int
+	Color     string
+	Level     int    // 1, 3, 5, or 10
+	IsMoving  bool   // true for level-10 rare moving food
+	SpawnTick uint64 // World.Tick when added to the world
 
 	// Moving food fields (only used when IsMoving = true)
 	MoveAngle float64 // radians, current travel direction
@@ -23,48 +24,56 @@ type Food struct {
 	MoveTicks int     // ticks until next random direction change
 }
 
-// NewFood creates a food item at a random position inside the circular world.
-// 90% chance level 1, 10% chance level 3.
-func NewFood() *Food {
-	x, y := randomCirclePoint(WorldCenterX, WorldCenterY, WorldRadius)
-	level := FoodLevel1
-	if rand.Float64() < 0.10 {
-		level = FoodLevel3
-	}
-	return newFoodWithLevel(x, y, level, false)
+// NewFood creates a food item at a random position inside the circular world,
+// its level drawn by FoodLevelTable spawn weight.
+func (p *FoodPool) NewFood() *Food {
+	x, y := randomCirclePoint(WorldCenterX, WorldCenterY, WorldRadius-FoodBoundaryMargin)
+	return p.newAmbientFood(x, y)
+}
+
+// newAmbientFood creates a randomly spawned food item at (x, y), its level
+// drawn by FoodLevelTable spawn weight.
+func (p *FoodPool) newAmbientFood(x, y float64) *Food {
+	return p.newFoodWithLevel(x, y, randomFoodLevel(), false)
 }
 
-// NewFoodAt creates a level-3 food item near a position (used on snake death).
+// NewFoodAt creates a DeathFoodLevel food item near a position (used for damage hits).
 // Scatters ±20px to spread food along the body instead of piling up.
-func NewFoodAt(x, y float64) *Food {
+func (p *FoodPool) NewFoodAt(x, y float64) *Food {
 	scatter := 20.0
 	sx := x + (rand.Float64()*2-1)*scatter
 	sy := y + (rand.Float64()*2-1)*scatter
 	cx, cy := clampToCircle(sx, sy, WorldCenterX, WorldCenterY, WorldRadius)
-	return newFoodWithLevel(cx, cy, FoodLevel3, false)
+	return p.newFoodWithLevel(cx, cy, DeathFoodLevel, false)
 }
 
 // NewMovingFood creates a level-10 moving food at a random position inside the world.
-func NewMovingFood() *Food {
-	x, y := randomCirclePoint(WorldCenterX, WorldCenterY, WorldRadius)
-	f := newFoodWithLevel(x, y, FoodLevel10, true)
+func (p *FoodPool) NewMovingFood() *Food {
+	x, y := randomCirclePoint(WorldCenterX, WorldCenterY, WorldRadius-FoodBoundaryMargin)
+	f := p.newFoodWithLevel(x, y, FoodLevel10, true)
+	if f == nil {
+		return nil
+	}
 	f.MoveAngle = rand.Float64() * 2 * math.Pi
-	f.MoveSpeed = MovingFoodSpeed
-	f.MoveTicks = MovingFoodDirMinTicks + rand.Intn(MovingFoodDirMaxTicks-MovingFoodDirMinTicks)
+	f.MoveSpeed = perTick(MovingFoodSpeed)
+	f.MoveTicks = randomTicks(MovingFoodDirMin, MovingFoodDirMax)
 	return f
 }
 
-// newFoodWithLevel is the internal constructor
-func newFoodWithLevel(x, y float64, level int, isMoving bool) *Food {
-	return &Food{
-		ID:       newFoodID(),
-		X:        x,
-		Y:        y,
-		Value:    level,
-		Color:    foodColorForLevel(level),
-		Level:    level,
-		IsMoving: isMoving,
+// newFoodWithLevel is the internal constructor — structs and IDs come from the pool.
+// Like every constructor here it returns nil when the pool is exhausted.
+func (p *FoodPool) newFoodWithLevel(x, y float64, level int, isMoving bool) *Food {
+	f, ok := p.Acquire()
+	if !ok {
+		return nil
 	}
+	f.X = x
+	f.Y = y
+	f.Value = foodLevelSpec(level).Value
+	f.Color = foodColorForLevel(level)
+	f.Level = level
+	f.IsMoving = isMoving
+	return f
 }
 
 // UpdateMoving advances moving food one tick: moves, bounces off boundary, counts down direction timer.
@@ -102,7 +111,7 @@ func (f *Food) UpdateMoving() {
 	f.MoveTicks--
 	if f.MoveTicks <= 0 {
 		f.MoveAngle = rand.Float64() * 2 * math.Pi
-		f.MoveTicks = MovingFoodDirMinTicks + rand.Intn(MovingFoodDirMaxTicks-MovingFoodDirMinTicks)
+		f.MoveTicks = randomTicks(MovingFoodDirMin, MovingFoodDirMax)
 	}
 }
 
@@ -113,7 +122,7 @@ func (f *Food) ToDTO() FoodDTO {
 		isMovingInt = 1
 	}
 	return FoodDTO{
-		ID:       f.ID,
+		ID:       uint32(f.ID),
 		X:        roundTo1(f.X),
 		Y:        roundTo1(f.Y),
 		Value:    f.Value,
@@ -130,11 +139,40 @@ func (f *Food) DistanceTo(x, y float64) float64 {
 	return math.Sqrt(dx*dx + dy*dy)
 }
 
-var foodCounter int
+// FoodLevelSpec is one row of FoodLevelTable
+type FoodLevelSpec struct {
+	Level       int
+	Value       int
+	SpawnWeight float64 // relative chance among random spawns; 0 = never
+}
 
-func newFoodID() string {
-	foodCounter++
-	return fmt.Sprintf("f%d", foodCounter)
+// foodLevelSpec looks level up in FoodLevelTable; an unlisted level is worth its number
+func foodLevelSpec(level int) FoodLevelSpec {
+	for _, spec := range FoodLevelTable {
+		if spec.Level == level {
+			return spec
+		}
+	}
+	return FoodLevelSpec{Level: level, Value: level}
+}
+
+// randomFoodLevel draws a level for a random spawn by FoodLevelTable weight
+func randomFoodLevel() int {
+	total := 0.0
+	for _, spec := range FoodLevelTable {
+		total += spec.SpawnWeight
+	}
+	r := rand.Float64() * total
+	for _, spec := range FoodLevelTable {
+		if spec.SpawnWeight <= 0 {
+			continue
+		}
+		if r < spec.SpawnWeight {
+			return spec.Level
+		}
+		r -= spec.SpawnWeight
+	}
+	return FoodLevel1
 }
 
 // foodColorForLevel returns a color keyed to food level
@@ -170,25 +208,27 @@ func randomFromSlice(s []string) string {
 
 // NewFoodCluster creates a group of 5-12 food items clustered around a random center point.
 // Cluster radius ~80-150px, making food visually grouped together.
-func NewFoodCluster() []*Food {
+func (p *FoodPool) NewFoodCluster() []*Food {
 	cx, cy := randomCirclePoint(WorldCenterX, WorldCenterY, WorldRadius-200)
+	return p.newFoodClusterAt(cx, cy)
+}
+
+// newFoodClusterAt creates a cluster of 5-12 food items around (cx, cy)
+func (p *FoodPool) newFoodClusterAt(cx, cy float64) []*Food {
 	count := 5 + rand.Intn(8) // 5-12 items per cluster
 	clusterRadius := 80.0 + rand.Float64()*70.0 // 80-150px spread
 
-	foods := make([]*Food, count)
+	foods := make([]*Food, 0, count)
 	for i := 0; i < count; i++ {
 		// Scatter around cluster center
 		angle := rand.Float64() * 2 * math.Pi
 		r := clusterRadius * math.Sqrt(rand.Float64())
 		fx := cx + r*math.Cos(angle)
 		fy := cy + r*math.Sin(angle)
-		fx, fy = clampToCircle(fx, fy, WorldCenterX, WorldCenterY, WorldRadius)
-
-		level := FoodLevel1
-		if rand.Float64() < 0.10 {
-			level = FoodLevel3
+		fx, fy = clampToCircle(fx, fy, WorldCenterX, WorldCenterY, WorldRadius-FoodBoundaryMargin)
+		if f := p.newAmbientFood(fx, fy); f != nil {
+			foods = append(foods, f)
 		}
-		foods[i] = newFoodWithLevel(fx, fy, level, false)
 	}
 	return foods
 }
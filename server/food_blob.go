@@ -0,0 +1,68 @@
+package main
+
+import "math"
+
+// blobBucket accumulates food falling in one FoodBlobCellSize sub-cell
+type blobBucket struct {
+	items      []*Food
+	value      int
+	sumX, sumY float64 // value-weighted position sums
+	top        *Food   // highest-level item, supplies blob color/level
+}
+
+// aggregateFoodCell merges tight piles in one grid cell's entries into blobs.
+// Sub-cells with fewer than FoodBlobMinItems items, and all moving food,
+// are appended to items unchanged. Returns the extended slices.
+func aggregateFoodCell(food map[FoodID]*Food, entries []gridEntry, items []FoodDTO, blobs []FoodBlobDTO) ([]FoodDTO, []FoodBlobDTO) {
+	buckets := map[cellKey]*blobBucket{}
+	var order []cellKey // keep output stable across ticks
+	for _, e := range entries {
+		if e.foodID == 0 {
+			continue
+		}
+		f, ok := food[e.foodID]
+		if !ok {
+			continue
+		}
+		if f.IsMoving {
+			items = append(items, f.ToDTO())
+			continue
+		}
+		k := cellKey{
+			cx: int(math.Floor(f.X / FoodBlobCellSize)),
+			cy: int(math.Floor(f.Y / FoodBlobCellSize)),
+		}
+		b := buckets[k]
+		if b == nil {
+			b = &blobBucket{}
+			buckets[k] = b
+			order = append(order, k)
+		}
+		b.items = append(b.items, f)
+		b.value += f.Value
+		b.sumX += f.X * float64(f.Value)
+		b.sumY += f.Y * float64(f.Value)
+		if b.top == nil || f.Level > b.top.Level {
+			b.top = f
+		}
+	}
+
+	for _, k := range order {
+		b := buckets[k]
+		if len(b.items) < FoodBlobMinItems || b.value <= 0 {
+			for _, f := range b.items {
+				items = append(items, f.ToDTO())
+			}
+			continue
+		}
+		blobs = append(blobs, FoodBlobDTO{
+			X:     roundTo1(b.sumX / float64(b.value)),
+			Y:     roundTo1(b.sumY / float64(b.value)),
+			Value: b.value,
+			Color: b.top.Color,
+			Level: b.top.Level,
+			Count: len(b.items),
+		})
+	}
+	return items, blobs
+}
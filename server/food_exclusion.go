@@ -0,0 +1,42 @@
+package main
+
+import "math"
+
+// foodSpawnBlocked reports whether ambient food must not appear at (x, y):
+// within FoodBoundaryMargin of the boundary (eating it would be suicide), on
+// an obstacle, or under a snake's body (a free meal for nobody in
+// particular). Bodies come from the grid as of the last RebuildGrid.
+// Caller must hold w.mu.Lock.
+func (w *World) foodSpawnBlocked(x, y float64) bool {
+	if math.Hypot(x-WorldCenterX, y-WorldCenterY) > WorldRadius-FoodBoundaryMargin {
+		return true
+	}
+	for _, o := range w.Map.Obstacles {
+		if math.Hypot(x-o.X, y-o.Y) < o.Radius+FoodRadius {
+			return true
+		}
+	}
+	blocked := false
+	w.Grid.ForEachSnakeBodyNear(x, y, SnakeMaxWidth+FoodRadius, "", func(e gridEntry) bool {
+		s := w.Snakes[e.snakeID]
+		if s != nil && math.Hypot(e.x-x, e.y-y) < s.Width+FoodRadius {
+			blocked = true
+		}
+		return !blocked
+	})
+	return blocked
+}
+
+// placeAmbient adds randomly spawned food f unless its spot is excluded, in
+// which case f goes back to the pool. Caller must hold w.mu.Lock.
+func (w *World) placeAmbient(f *Food) bool {
+	if f == nil {
+		return false // pool exhausted
+	}
+	if w.foodSpawnBlocked(f.X, f.Y) {
+		w.FoodPool.Release(f)
+		return false
+	}
+	w.addFood(f)
+	return true
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+)
+
+// FoodID is a compact numeric food identifier.
+// Low foodSlotBits bits = pool slot index, high bits = slot generation.
+// Slot 0 is never handed out, so the zero FoodID means "no food".
+type FoodID uint32
+
+const (
+	foodSlotBits = 20 // up to ~1M live food items
+	foodSlotMask = 1<<foodSlotBits - 1
+	foodGenMask  = 1<<(32-foodSlotBits) - 1
+)
+
+// Slot returns the pool slot index encoded in the ID
+func (id FoodID) Slot() uint32 {
+	return uint32(id) & foodSlotMask
+}
+
+// Gen returns the slot generation encoded in the ID
+func (id FoodID) Gen() uint32 {
+	return uint32(id) >> foodSlotBits
+}
+
+// String formats the ID for logs and the legacy "f123" wire format
+func (id FoodID) String() string {
+	return "f" + strconv.FormatUint(uint64(id), 10)
+}
+
+// FoodPool recycles Food structs and their IDs to cut GC churn at 12.5k+ food.
+// A released slot gets its generation bumped before reuse, so a recycled ID never
+// equals the ID of the food that previously occupied the slot. Released slots
+// are reused oldest first, so the 12-bit generation only wraps after a slot
+// has gone round the whole free queue 4096 times — with the thousands of
+// slots freed in play, long after any client could still hold the old ID.
+// Each world owns a pool, so IDs are unique per world; worlds running side
+// by side never share one. Safe for concurrent use.
+type FoodPool struct {
+	mu    sync.Mutex
+	slots []*Food  // slot index -> Food struct (index 0 unused)
+	gens  []uint32 // slot index -> current generation
+	free  []uint32 // released slot indices ready for reuse, oldest first
+}
+
+// NewFoodPool creates an empty pool
+func NewFoodPool() *FoodPool {
+	return &FoodPool{
+		slots: []*Food{nil},
+		gens:  []uint32{0},
+	}
+}
+
+// Acquire returns a zeroed Food with a fresh ID, reusing the longest-released
+// slot when there is one; false when every slot is live, and the caller
+// skips the spawn
+func (p *FoodPool) Acquire() (*Food, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var slot uint32
+	if len(p.free) > 0 {
+		slot = p.free[0]
+		p.free = p.free[1:]
+	} else {
+		slot = uint32(len(p.slots))
+		if slot > foodSlotMask {
+			return nil, false
+		}
+		p.slots = append(p.slots, &Food{})
+		p.gens = append(p.gens, 0)
+	}
+	f := p.slots[slot]
+	*f = Food{ID: FoodID(p.gens[slot]<<foodSlotBits | slot)}
+	return f, true
+}
+
+// Release returns a food's slot to the pool. The Food must no longer be
+// referenced by the world; its struct will be overwritten on the next Acquire.
+// Stale IDs (already released, or from an older generation) are ignored.
+func (p *FoodPool) Release(f *Food) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	slot := f.ID.Slot()
+	if slot == 0 || int(slot) >= len(p.slots) || p.slots[slot] != f {
+		return
+	}
+	if f.ID.Gen() != p.gens[slot] {
+		return
+	}
+	p.gens[slot] = (p.gens[slot] + 1) & foodGenMask
+	p.free = append(p.free, slot)
+}
+
+// Live returns the number of slots currently handed out
+func (p *FoodPool) Live() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.slots) - 1 - len(p.free)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFoodPoolIDs(t *testing.T) {
+	p := NewFoodPool()
+	seen := make(map[FoodID]bool)
+	var items []*Food
+	for range 1000 {
+		f, ok := p.Acquire()
+		if !ok || f.ID == 0 || seen[f.ID] {
+			t.Fatalf("bad or repeated ID %v", f.ID)
+		}
+		seen[f.ID] = true
+		items = append(items, f)
+	}
+	old := items[10].ID
+	p.Release(items[10])
+	p.Release(items[10]) // stale: ignored
+	p.Release(items[20])
+	f, _ := p.Acquire() // oldest released first
+	if f.ID.Slot() != old.Slot() || f.ID == old {
+		t.Fatalf("reused slot %d should carry a new generation: old %v, new %v", old.Slot(), old, f.ID)
+	}
+	if got := p.Live(); got != 999 {
+		t.Fatalf("Live = %d, want 999", got)
+	}
+}
+
+// TestFoodPoolConcurrent acquires and releases from many goroutines at
+// once; run with -race. IDs live at the same time must stay distinct.
+func TestFoodPoolConcurrent(t *testing.T) {
+	p := NewFoodPool()
+	var mu sync.Mutex
+	live := make(map[FoodID]bool)
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var mine []*Food
+			for i := range 500 {
+				f := p.newFoodWithLevel(0, 0, FoodLevel1, false)
+				mu.Lock()
+				if live[f.ID] {
+					mu.Unlock()
+					t.Errorf("ID %v handed out twice", f.ID)
+					return
+				}
+				live[f.ID] = true
+				mu.Unlock()
+				mine = append(mine, f)
+				if i%2 == 1 {
+					f = mine[0]
+					mine = mine[1:]
+					mu.Lock()
+					delete(live, f.ID)
+					mu.Unlock()
+					p.Release(f)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := p.Live(); got != len(live) {
+		t.Fatalf("Live = %d, want %d", got, len(live))
+	}
+}
+
+// TestWorldsFoodConcurrent runs food churn in two worlds side by side, as
+// rooms will; run with -race. Each world's pool accounts for exactly its
+// own food.
+func TestWorldsFoodConcurrent(t *testing.T) {
+	quietLogs(t)
+	worlds := []*World{newEmptyWorld(), newEmptyWorld()}
+	var wg sync.WaitGroup
+	for _, w := range worlds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				w.mu.Lock()
+				w.AddFood(w.FoodPool.NewFoodCluster())
+				for id := range w.Food {
+					w.RemoveFood(id)
+					break
+				}
+				w.mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	for i, w := range worlds {
+		if live := w.FoodPool.Live(); live != len(w.Food) {
+			t.Errorf("world %d: pool has %d live, world holds %d", i, live, len(w.Food))
+		}
+	}
+}
+
+// TestInitialFoodOnBlockedMap spawns the initial food on a map one obstacle
+// covers entirely: nothing may be placed, and it must return, not spin
+func TestInitialFoodOnBlockedMap(t *testing.T) {
+	w := newEmptyWorld()
+	w.Map.Obstacles = []MapFeature{{X: WorldCenterX, Y: WorldCenterY, Radius: WorldRadius}}
+	w.spawnInitialFood()
+	if len(w.Food) != 0 {
+		t.Fatalf("%d food placed under the obstacle", len(w.Food))
+	}
+	if live := w.FoodPool.Live(); live != 0 {
+		t.Fatalf("%d pool slots not released", live)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// FoodRebalancer picks where ambient food spawns. The world is split into
+// square regions of FoodRegionCells grid cells; each region's share of
+// spawns is its share of the arena's area, boosted when it is short of food
+// and has snakes in it. Popular areas therefore refill while the empty far
+// side no longer hoards food nobody eats, and with no snakes anywhere the
+// spread stays uniform. Game-loop goroutine only.
+type FoodRebalancer struct {
+	n        int       // regions per side
+	size     float64   // region side, px
+	expected []float64 // food a region holds when TargetFoodCount is spread evenly
+	cum      []float64 // cumulative spawn weights by region
+	food     []int     // per-region counts from the last refresh
+	bodies   []int
+	nextTick uint64
+}
+
+// NewFoodRebalancer starts with a uniform spread
+func NewFoodRebalancer() *FoodRebalancer {
+	size := FoodRegionCells * GridCellSize
+	n := int(math.Ceil(2 * WorldRadius / size))
+	r := &FoodRebalancer{
+		n:        n,
+		size:     size,
+		expected: make([]float64, n*n),
+		cum:      make([]float64, n*n),
+		food:     make([]int, n*n),
+		bodies:   make([]int, n*n),
+	}
+	// Area of each region inside the circle, sampled on a fixed lattice
+	const samples = 8
+	inside := 0.0
+	for i := range r.expected {
+		ox, oy := float64(i%n)*size, float64(i/n)*size
+		for sx := 0; sx < samples; sx++ {
+			for sy := 0; sy < samples; sy++ {
+				x := ox + (float64(sx)+0.5)*size/samples
+				y := oy + (float64(sy)+0.5)*size/samples
+				if math.Hypot(x-WorldCenterX, y-WorldCenterY) <= WorldRadius {
+					r.expected[i]++
+				}
+			}
+		}
+		inside += r.expected[i]
+	}
+	sum := 0.0
+	for i := range r.expected {
+		r.expected[i] *= TargetFoodCount / inside
+		sum += r.expected[i]
+		r.cum[i] = sum
+	}
+	return r
+}
+
+// maybeRefresh reweights regions from grid occupancy every
+// FoodRebalanceInterval. Call after RebuildGrid; caller must hold w.mu.Lock.
+func (r *FoodRebalancer) maybeRefresh(tick uint64, g *SpatialGrid) {
+	if !FoodRebalanceEnabled || tick < r.nextTick {
+		return
+	}
+	r.nextTick = tick + uint64(ticksFor(FoodRebalanceInterval))
+	clear(r.food)
+	clear(r.bodies)
+	g.forEachCell(func(k cellKey, entries []gridEntry) {
+		rx, ry := k.cx/FoodRegionCells, k.cy/FoodRegionCells
+		if k.cx < 0 || k.cy < 0 || rx >= r.n || ry >= r.n {
+			return
+		}
+		i := ry*r.n + rx
+		for _, e := range entries {
+			if e.foodID != 0 {
+				r.food[i]++
+			} else {
+				r.bodies[i]++
+			}
+		}
+	})
+	sum := 0.0
+	for i, exp := range r.expected {
+		if exp > 0 {
+			deficit := max(0, 1-float64(r.food[i])/exp)
+			// Peaks at 1 with FoodRebalanceCrowd segments and falls off
+			// either side, so a brawl doesn't become a food fountain
+			x := float64(r.bodies[i]) / FoodRebalanceCrowd
+			activity := 2 * x / (1 + x*x)
+			sum += exp * (1 + FoodRebalanceBias*deficit*activity)
+		}
+		r.cum[i] = sum
+	}
+}
+
+// spawnPoint returns a weighted random point at least margin inside the boundary
+func (r *FoodRebalancer) spawnPoint(margin float64) (float64, float64) {
+	radius := WorldRadius - margin
+	total := r.cum[len(r.cum)-1]
+	for range 8 {
+		v := rand.Float64() * total
+		i := sort.Search(len(r.cum), func(i int) bool { return r.cum[i] > v })
+		if i == len(r.cum) {
+			break
+		}
+		x := (float64(i%r.n) + rand.Float64()) * r.size
+		y := (float64(i/r.n) + rand.Float64()) * r.size
+		if math.Hypot(x-WorldCenterX, y-WorldCenterY) <= radius {
+			return x, y
+		}
+	}
+	// Edge regions are partly outside; after repeated misses, spread evenly
+	return randomCirclePoint(WorldCenterX, WorldCenterY, radius)
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"log"
 	"math"
 	"math/rand"
+	"net/netip"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,97 +15,199 @@ type GameLoop struct {
 	world        *World
 	conns        *ConnManager
 	bots         *BotManager
-	killMap      map[string]string // victimID -> killerName
-	tickCount    int               // total ticks elapsed, used for moving food spawn timing
+	sanctions    map[netip.Prefix]Sanction // active shadow sanctions, snapshotted each tick (usually nil)
+	tickCount    int                       // total ticks elapsed, used for moving food spawn timing
+	sender       *BroadcastPool            // parallel encode+write for per-tick state
+	ambientFood  bool                      // spawn moving food and top up food count each tick
+	lastTick     atomic.Int64              // unix nanos of the last completed tick, read by /readyz
+	catchUpTicks atomic.Int64              // ticks run late to catch up after a stall
+	droppedTicks atomic.Int64              // ticks skipped because the loop fell too far behind
+	// ticksOverBudget, ticksSkipped and ticksCaughtUp count from start, for
+	// the SLO counters (see slo_counters.go); the two above are reset as logged
+	ticksOverBudget atomic.Int64
+	ticksSkipped    atomic.Int64
+	ticksCaughtUp   atomic.Int64
+	watchdog        *SystemdNotifier           // fed every tick; nil outside systemd
+	tickWindow      tickWindow                 // tick durations in the current second
+	tickTiming      atomic.Pointer[TickTiming] // the last complete second, for the live dashboard
+	history         *MetricsHistory            // per-minute aggregates for GET /admin/history
+	controllers     map[string]Controller      // snake ID -> controller overriding its own (see controller.go)
+	stop            chan struct{}              // closed by Stop
+	stopped         chan struct{}              // closed when Run returns
 }
 
 // NewGameLoop creates a game loop bound to world and conn manager.
 // It also creates and pre-populates the BotManager with BotCount initial bots.
 func NewGameLoop(world *World, conns *ConnManager) *GameLoop {
+	return newGameLoop(world, conns, BotCount)
+}
+
+// newGameLoop creates a game loop that maintains botCount bots
+func newGameLoop(world *World, conns *ConnManager, botCount int) *GameLoop {
 	bm := NewBotManager(world)
+	bm.target = botCount
 	// Pre-spawn initial bots before the game loop starts
-	for i := 0; i < BotCount; i++ {
+	for i := 0; i < botCount; i++ {
 		bm.SpawnBot()
 	}
 	return &GameLoop{
-		world:   world,
-		conns:   conns,
-		bots:    bm,
-		killMap: make(map[string]string),
+		world:       world,
+		conns:       conns,
+		bots:        bm,
+		sender:      NewBroadcastPool(BroadcastWorkers),
+		history:     NewMetricsHistory(),
+		ambientFood: true,
+		stop:        make(chan struct{}),
+		stopped:     make(chan struct{}),
 	}
 }
 
-// Run starts the fixed-timestep loop. Blocks until process exits.
+// Run starts the fixed-timestep loop. Blocks until Stop.
+// Ticks are scheduled against an absolute timeline rather than a Ticker, so
+// game speed holds under load: after a stall the loop runs the owed ticks
+// back to back (at most MaxCatchUpTicks) and drops the rest.
 func (gl *GameLoop) Run() {
-	ticker := time.NewTicker(time.Second / TickRate)
-	defer ticker.Stop()
+	step := tickDuration()
 	log.Printf("game loop started at %d ticks/sec", TickRate)
 
-	for range ticker.C {
+	defer close(gl.stopped)
+	next := time.Now()
+	for {
+		select {
+		case <-gl.stop:
+			log.Printf("game loop stopped at tick %d", gl.world.Tick)
+			return
+		default:
+		}
+		now := time.Now()
+		if wait := next.Sub(now); wait > 0 {
+			time.Sleep(wait)
+			continue
+		}
+		if owed := int64(now.Sub(next) / step); owed > MaxCatchUpTicks {
+			dropped := owed - MaxCatchUpTicks
+			gl.droppedTicks.Add(dropped)
+			gl.ticksSkipped.Add(dropped)
+			next = next.Add(time.Duration(dropped) * step)
+		} else if owed > 0 {
+			gl.catchUpTicks.Add(1)
+			gl.ticksCaughtUp.Add(1)
+		}
+		began := time.Now()
 		gl.tick()
+		took := time.Since(began)
+		if took > step {
+			gl.ticksOverBudget.Add(1)
+		}
+		gl.recordTickTime(took)
+		gl.history.record(gl.world.Snapshot(), took)
+		next = next.Add(step)
 	}
 }
 
+// Stop ends Run between two ticks and waits for it to return
+func (gl *GameLoop) Stop() {
+	close(gl.stop)
+	<-gl.stopped
+}
+
+// Ready reports whether the loop has ticked within ReadyMaxTickAge
+func (gl *GameLoop) Ready() bool {
+	last := gl.lastTick.Load()
+	return last != 0 && time.Since(time.Unix(0, last)) < ReadyMaxTickAge
+}
+
 // tick executes a single game update
 func (gl *GameLoop) tick() {
+	defer gl.lastTick.Store(time.Now().UnixNano())
 	gl.tickCount++
 	w := gl.world
-	w.mu.Lock()
+	unlock := w.lock("tick")
+	w.Tick++
+	defer w.Log.dumpOnPanic(w.Room, w.Tick)
+
+	// 0. Apply queued joins, disconnects and admin commands
+	w.applyCommands()
+	gl.sanctions = w.Moderation.Active(time.Now())
 
 	// 1. Update moving food positions (before collision so magnets see updated pos)
 	gl.updateMovingFood()
 
-	// 2a. Update bot AI — bots decide input and move themselves inside Update()
-	boundaryDeaths := map[string]bool{}
-	gl.bots.Update()
-	// Collect any boundary-crossing bot snakes (marked dead by bot.Update)
-	for botID := range gl.bots.bots {
-		if s, ok := w.Snakes[botID]; ok && !s.Alive {
-			boundaryDeaths[botID] = true
-		}
-	}
+	// 2a. Remove retiring bots nobody is watching
+	gl.bots.UpdateRetiring()
 
-	// 2b. Apply player inputs and move player snakes; detect boundary crossings
-	conns := gl.conns.Snapshot()
-	for _, c := range conns {
-		snake, ok := w.Snakes[c.ID]
-		if !ok || !snake.Alive {
-			continue
-		}
-		inp := c.GetInput()
-		if dropped := snake.ApplyInput(inp.Angle, inp.Boost); dropped != nil {
-			w.Food[dropped.ID] = dropped
-		}
-		outOfBounds := snake.Move()
-		if outOfBounds {
-			boundaryDeaths[snake.ID] = true
-		}
-	}
+	// 2b. Ask every snake's controller for input, then move them all;
+	// detect boundary crossings
+	w.updateComeback()
+	movers := gl.gatherInputs()
+	gl.tickWindow.bots += gl.bots.aiTime
+	gl.bots.aiTime = 0
+	boundaryDeaths := gl.moveSnakes(movers)
 
 	// 3. Rebuild spatial grid after movement
 	w.RebuildGrid()
+	w.trackPressure()
+
+	// 3b. Compare player aim with nearby food to spot machine clients
+	gl.watchAim()
 
 	// 4. Collision detection (head-to-body, head-to-head)
-	gl.killMap = make(map[string]string)
 	deaths := gl.detectCollisions()
+	if DamageModelEnabled {
+		gl.applyDamage(deaths)
+	}
 
 	// 5. Merge boundary deaths into deaths map
 	for id := range boundaryDeaths {
 		if _, alreadyDead := deaths[id]; !alreadyDead {
-			deaths[id] = "Boundary"
+			deaths[id] = KillerBoundary
 		}
 	}
 
-	// 6. Process deaths — drop food, record killer names
-	for victimID, killerName := range deaths {
-		snake := w.Snakes[victimID]
-		if snake == nil || !snake.Alive {
+	// 6. Process deaths — credit kills, then drop food and record killer
+	// names. Kills go first so that a killer dying this tick too has them in
+	// its final score and stats (see deathOutcome). This tick's events go
+	// into a fresh snapshot, published at the end of the locked section for
+	// every post-tick consumer.
+	snap := &TickSnapshot{Deaths: make(map[string]DeathMsg)}
+	outcomes := w.resolveDeaths(deaths)
+	for _, o := range outcomes {
+		if o.killer == nil {
 			continue
 		}
-		dropped := snake.DropFood()
-		w.AddFood(dropped)
-		gl.killMap[victimID] = killerName
-		log.Printf("snake %s (%s) died to %s, dropped %d food", snake.Name, victimID, killerName, len(dropped))
+		feed, helper := w.creditKill(o)
+		if o.feedsKill() {
+			snap.KillFeed = append(snap.KillFeed, feed)
+			gl.unmuteKillFeed(snap, len(snap.KillFeed)-1, o.killer, o.victim, helper)
+		}
+		kill := snakeEvent(EventKill, o.killer)
+		kill.Other, kill.OtherName, kill.Streak, kill.Mutual = o.victim.ID, o.victim.Name, feed.Streak, o.mutual
+		w.emit(kill)
+	}
+	for _, o := range outcomes {
+		snake := o.victim
+		killerName, factor := "Boundary", 1.0
+		if o.killer != nil {
+			killerName = o.killer.Name
+			factor = w.Feeding.RecordDeath(snake, o.killer, w.Tick)
+		}
+		head := snake.Head()
+		w.Log.record(WorldLogEntry{Type: WorldLogDeath, Snake: snake.ID, X: head.X, Y: head.Y, Length: len(snake.Segments),
+			Score: snake.Score, Other: o.killerID, Mutual: o.mutual})
+		dropped := w.dropBody(snake, factor)
+		if o.killer != nil && w.Ranked && !o.mutual {
+			w.Profiles.rateKill(o.killer, snake)
+		}
+		// Capture the final score now so the post-tick send needs no extra lock
+		msg := DeathMsg{Type: MsgDeath, Killer: killerName, Score: snake.Score}
+		if o.mutual {
+			msg.Mutual = 1
+		}
+		snap.Deaths[snake.ID] = msg
+		death := snakeEvent(EventDeath, snake)
+		death.Other, death.OtherName, death.Mutual = o.killerID, killerName, o.mutual
+		w.emit(death)
+		log.Printf("snake %s (%s) died to %s, dropped %d food", snake.Name, snake.ID, killerName, len(dropped))
 	}
 
 	// 6b. Notify bot manager of deaths so it can start respawn countdowns
@@ -110,43 +215,110 @@ func (gl *GameLoop) tick() {
 
 	// 7. Apply magnetic food attraction then collect food
 	gl.applyFoodMagnet()
-	gl.collectFood()
+	snap.Ate = gl.collectFood()
+	gl.collectGoldenApple(snap, snap.Ate)
 
-	// 8. Spawn moving food if conditions are met
-	gl.maybeSpawnMovingFood()
+	if gl.ambientFood {
+		// 8. Spawn moving food if conditions are met
+		gl.maybeSpawnMovingFood()
 
-	// 9. Maintain total food count
-	w.MaintainFoodCount()
+		// 9. Maintain total food count
+		w.MaintainFoodCount()
 
-	leaderboard := w.Leaderboard()
+		// 9a. Fire content pack events, then spawn the golden apple when due
+		gl.runSchedule(snap)
+		gl.updateGoldenApple(snap)
+	}
+
+	// 9b. Close the score economy's minute once the clock passes it
+	w.Economy.roll(time.Now())
 
-	w.mu.Unlock()
+	// 9c. Spot boost escapes along the boundary for the highlights index
+	w.observeEdges()
 
-	// 10a. Tick bot respawn countdowns and spawn replacements (acquires lock internally)
+	// 10a. Tick bot respawn countdowns and spawn replacements
 	gl.bots.MaintainBotCount()
 
-	// 10b. Broadcast viewport-culled state to all connected players
-	gl.broadcast(leaderboard)
+	// 10b. Publish the end-of-tick snapshot (leaderboard, counts, events)
+	snap.Players = gl.conns.Count()
+	snap.Cohorts = gl.bots.cohortStats()
+	snap.BotTarget = gl.bots.currentTarget()
+	snap.BotCadence = gl.bots.cadence
+	for id := range gl.bots.bots {
+		if s, ok := w.Snakes[id]; ok && s.Alive {
+			snap.Bots++
+		}
+	}
+	w.updatePublicStats(snap.Players)
+	w.publishSnapshot(snap)
+	w.Log.endTick(w.Tick)
+
+	unlock()
+
+	// 10c. Broadcast viewport-culled state to all connected players
+	gl.broadcast(snap)
+
+	// 11. Send death messages to players left without a live snake
+	gl.sendDeaths(snap)
+	// 11a. Kill feed (kills, streak milestones, shutdowns) goes to everyone
+	if len(snap.KillFeed) > 0 {
+		conns := gl.conns.Snapshot()
+		for i, msg := range snap.KillFeed {
+			data, _ := json.Marshal(msg)
+			for _, c := range conns {
+				if own, ok := snap.OwnKillFeed[c.ID][i]; ok {
+					ownData, _ := json.Marshal(own)
+					c.sendEncoded(PriorityLow, ownData)
+					continue
+				}
+				c.sendEncoded(PriorityLow, data)
+			}
+		}
+	}
 
-	// 11. Send death messages to dead players
-	for victimID, killerName := range gl.killMap {
-		conn, ok := gl.conns.Get(victimID)
-		if !ok {
-			continue
+	// 11b. Golden apple spawns and pickups go to everyone; rare and carry
+	// the location, so never dropped. Scheduled announcements likewise.
+	for _, msg := range snap.Objective {
+		data, _ := json.Marshal(msg)
+		for _, c := range gl.conns.Snapshot() {
+			c.sendEncoded(PriorityCritical, data)
 		}
-		w.mu.RLock()
-		score := 0
-		if s, exists := w.Snakes[victimID]; exists {
-			score = s.Score
+	}
+	for _, msg := range snap.Announcements {
+		gl.conns.Announce(msg, "")
+	}
+
+	// 11c. Tell players what they ate this tick, for score pop animations
+	ate := make(map[*Conn]int, len(snap.Ate))
+	for id, value := range snap.Ate {
+		if conn, ok := gl.conns.Owner(id); ok {
+			ate[conn] += value
 		}
-		w.mu.RUnlock()
+	}
+	for conn, value := range ate {
+		_ = conn.Send(AteMsg{Type: MsgAte, Value: value})
+	}
 
-		_ = conn.Send(DeathMsg{
-			Type:   MsgDeath,
-			Killer: killerName,
-			Score:  score,
-		})
+	// 11d. Personal HUD stats, at a much lower rate than state
+	if gl.tickCount%ticksFor(StatsInterval) == 0 {
+		gl.sendStats()
 	}
+
+	// 11e. Move clients that can't keep up in or out of lite mode
+	gl.checkClients()
+
+	// 12. Periodically log world lock contention per call site and tick timing
+	if gl.tickCount%(LockStatsReportSec*TickRate) == 0 {
+		for _, line := range w.LockStats.Report() {
+			log.Printf("lock stats %s", line)
+		}
+		if late, dropped := gl.catchUpTicks.Swap(0), gl.droppedTicks.Swap(0); late > 0 || dropped > 0 {
+			log.Printf("tick timing: %d late catch-up ticks, %d dropped in the last %ds", late, dropped, LockStatsReportSec)
+		}
+	}
+
+	// 13. Feed the systemd watchdog — a wedged loop stops pinging and gets restarted
+	gl.watchdog.Ping()
 }
 
 // updateMovingFood advances all level-10 moving food items one tick.
@@ -160,10 +332,10 @@ func (gl *GameLoop) updateMovingFood() {
 	}
 }
 
-// maybeSpawnMovingFood spawns a new level-10 moving food every MovingFoodSpawnInterval ticks,
+// maybeSpawnMovingFood spawns a new level-10 moving food every MovingFoodSpawnInterval,
 // if fewer than MovingFoodMaxCount exist. Caller must hold w.mu.Lock.
 func (gl *GameLoop) maybeSpawnMovingFood() {
-	if gl.tickCount%MovingFoodSpawnInterval != 0 {
+	if gl.tickCount%ticksFor(MovingFoodSpawnInterval) != 0 {
 		return
 	}
 	w := gl.world
@@ -177,49 +349,62 @@ func (gl *GameLoop) maybeSpawnMovingFood() {
 	if count >= MovingFoodMaxCount {
 		return
 	}
-	mf := NewMovingFood()
-	w.Food[mf.ID] = mf
+	mf := w.FoodPool.NewMovingFood()
+	if !w.placeAmbient(mf) {
+		return // spot excluded; try again next interval
+	}
 	log.Printf("spawned moving food %s (total moving: %d)", mf.ID, count+1)
 }
 
-// applyFoodMagnet pulls food within MagnetRadius toward each alive snake head.
+// magnetClaim is the snake currently winning one food item (pull or eat)
+type magnetClaim struct {
+	snake *Snake
+	dist  float64
+}
+
+// applyFoodMagnet pulls food within each alive snake's magnet radius toward
+// its head. When several heads reach the same food, only the nearest pulls it
+// that tick (ties go to the lower snake ID) so food doesn't jitter between them.
 // Food within actual eating radius is left for collectFood to handle.
 // Caller must hold w.mu.Lock.
 func (gl *GameLoop) applyFoodMagnet() {
 	w := gl.world
+	claims := make(map[FoodID]magnetClaim)
 	for _, snake := range w.Snakes {
 		if !snake.Alive {
 			continue
 		}
 		head := snake.Head()
-		// Scale magnet radius with snake width (wider snake = bigger attraction zone)
-		magnetR := MagnetRadius * (snake.Width / SnakeBaseWidth)
-		nearFoodIDs := w.Grid.NearbyFood(head.X, head.Y, magnetR)
-		for _, fid := range nearFoodIDs {
+		w.Grid.ForEachFoodNear(head.X, head.Y, snake.MagnetRadius(), func(fid FoodID) bool {
 			food, ok := w.Food[fid]
 			if !ok {
-				continue
-			}
-			dx := head.X - food.X
-			dy := head.Y - food.Y
-			dist := math.Sqrt(dx*dx + dy*dy)
-			// Already within eating radius — collectFood will handle it
-			if dist <= SnakeHeadRadius+FoodRadius {
-				continue
+				return true
 			}
-			// Move food toward head by MagnetSpeed (don't overshoot)
-			moveBy := MagnetSpeed
-			if moveBy > dist {
-				moveBy = dist
+			dist := math.Hypot(head.X-food.X, head.Y-food.Y)
+			cur, claimed := claims[fid]
+			if !claimed || dist < cur.dist || (dist == cur.dist && snake.ID < cur.snake.ID) {
+				claims[fid] = magnetClaim{snake: snake, dist: dist}
 			}
-			food.X += (dx / dist) * moveBy
-			food.Y += (dy / dist) * moveBy
+			return true
+		})
+	}
+
+	for fid, claim := range claims {
+		// Already within eating radius — collectFood will handle it
+		if claim.dist <= SnakeHeadRadius+FoodRadius {
+			continue
 		}
+		food := w.Food[fid]
+		head := claim.snake.Head()
+		// Move food toward head by the snake's magnet speed (don't overshoot)
+		moveBy := math.Min(claim.snake.MagnetSpeed(), claim.dist)
+		food.X += (head.X - food.X) / claim.dist * moveBy
+		food.Y += (head.Y - food.Y) / claim.dist * moveBy
 	}
 }
 
 // detectCollisions checks head-to-body and head-to-head collisions.
-// Returns map of victimID -> killerName.
+// Returns map of victimID -> killerID.
 func (gl *GameLoop) detectCollisions() map[string]string {
 	w := gl.world
 	deaths := map[string]string{}
@@ -236,23 +421,33 @@ func (gl *GameLoop) detectCollisions() map[string]string {
 		if _, dead := deaths[snake.ID]; dead {
 			continue
 		}
-		head := snake.Head()
-
-		// Head vs body of other snakes
-		nearby := w.Grid.NearbySnakeBody(head.X, head.Y, CollisionCheckRadius, snake.ID)
-		for _, entry := range nearby {
-			other := w.Snakes[entry.snakeID]
-			if other == nil || !other.Alive {
-				continue
-			}
-			dist := math.Sqrt(
-				(head.X-entry.x)*(head.X-entry.x) +
-					(head.Y-entry.y)*(head.Y-entry.y),
-			)
-			if dist < SnakeHeadRadius+SnakeBodyRadius {
-				if _, alreadyDead := deaths[snake.ID]; !alreadyDead {
-					deaths[snake.ID] = other.Name
+		// Head vs body of other snakes, at each substep of the head's move so
+		// a boosting head can't pass through a body between ticks
+		n := snake.substeps()
+		for k := 1; k <= n; k++ {
+			head := snake.headAt(float64(k) / float64(n))
+			hit := false
+			w.Grid.ForEachSnakeBodyNear(head.X, head.Y, CollisionCheckRadius, snake.ID, func(entry gridEntry) bool {
+				other := w.Snakes[entry.snakeID]
+				if other == nil || !other.Alive || !snake.CanInteract(other) {
+					return true
+				}
+				dist := math.Sqrt(
+					(head.X-entry.x)*(head.X-entry.x) +
+						(head.Y-entry.y)*(head.Y-entry.y),
+				)
+				if dist < SnakeHeadRadius+SnakeBodyRadius {
+					// First hit decides the outcome — stop scanning
+					if !tryGrazeBounce(snake, entry.x, entry.y) {
+						deaths[snake.ID] = other.ID
+					}
+					hit = true
+					return false
 				}
+				return true
+			})
+			if hit {
+				break
 			}
 		}
 	}
@@ -268,19 +463,25 @@ func (gl *GameLoop) detectCollisions() map[string]string {
 			if _, dead := deaths[b.ID]; dead {
 				continue
 			}
-			ha := a.Head()
-			hb := b.Head()
-			dx := ha.X - hb.X
-			dy := ha.Y - hb.Y
-			dist := math.Sqrt(dx*dx + dy*dy)
-			if dist < SnakeHeadRadius*2 {
+			if !a.CanInteract(b) {
+				continue
+			}
+			// Compare heads at matching substeps so two fast heads can't cross
+			n := max(a.substeps(), b.substeps())
+			for k := 1; k <= n; k++ {
+				frac := float64(k) / float64(n)
+				ha, hb := a.headAt(frac), b.headAt(frac)
+				if math.Hypot(ha.X-hb.X, ha.Y-hb.Y) >= SnakeHeadRadius*2 {
+					continue
+				}
 				// Smaller snake dies; if equal both die
 				if a.Score >= b.Score {
-					deaths[b.ID] = a.Name
+					deaths[b.ID] = a.ID
 				}
 				if b.Score >= a.Score {
-					deaths[a.ID] = b.Name
+					deaths[a.ID] = b.ID
 				}
+				break
 			}
 		}
 	}
@@ -288,71 +489,118 @@ func (gl *GameLoop) detectCollisions() map[string]string {
 	return deaths
 }
 
-// collectFood checks each alive snake head for food within eating radius and consumes it.
-// Caller must hold w.mu.Lock.
-func (gl *GameLoop) collectFood() {
+// collectFood lets each alive snake eat food within eating radius of its head.
+// Food reachable by several heads goes to the nearest one (ties to the lower
+// snake ID), so the outcome doesn't depend on map iteration order.
+// Returns the total value eaten per snake ID, risk zone and comeback
+// bonuses included. Caller must hold w.mu.Lock.
+func (gl *GameLoop) collectFood() map[string]int {
 	w := gl.world
+	claims := make(map[FoodID]magnetClaim)
 	for _, snake := range w.Snakes {
 		if !snake.Alive {
 			continue
 		}
-		head := snake.Head()
-		nearFoodIDs := w.Grid.NearbyFood(head.X, head.Y, SnakeHeadRadius+FoodRadius)
-		for _, fid := range nearFoodIDs {
-			food, ok := w.Food[fid]
-			if !ok {
-				continue
+		// A boosting head eats along its whole move, not just where it ends
+		n := snake.substeps()
+		for k := 1; k <= n; k++ {
+			head := snake.headAt(float64(k) / float64(n))
+			w.Grid.ForEachFoodNear(head.X, head.Y, SnakeHeadRadius+FoodRadius, func(fid FoodID) bool {
+				food, ok := w.Food[fid]
+				if !ok {
+					return true
+				}
+				dist := math.Hypot(head.X-food.X, head.Y-food.Y)
+				cur, claimed := claims[fid]
+				if !claimed || dist < cur.dist || (dist == cur.dist && snake.ID < cur.snake.ID) {
+					claims[fid] = magnetClaim{snake: snake, dist: dist}
+				}
+				return true
+			})
+		}
+	}
+
+	eaten := make(map[string]int)
+	for fid, claim := range claims {
+		food := w.Food[fid]
+		level, worth := food.Level, food.Value
+		risk := w.riskBonus(food.X, food.Y, worth)
+		comeback := w.comebackBonus(claim.snake, worth)
+		w.RemoveFood(fid)
+		credit := func(v int) int { return v }
+		if s, ok := gl.sanctionOf(claim.snake.ID); ok {
+			credit = func(v int) int { return int(float64(v) * s.ScoreFactor) }
+		}
+		w.Economy.ate(level, worth, credit(worth))
+		w.Economy.risk(risk, credit(risk))
+		w.Economy.comeback(comeback, credit(comeback))
+		value := credit(worth) + credit(risk) + credit(comeback)
+		before := claim.snake.Score
+		claim.snake.Grow(value)
+		eaten[claim.snake.ID] += value
+		for _, m := range scoreMilestones {
+			if before < m && claim.snake.Score >= m {
+				e := snakeEvent(EventMilestone, claim.snake)
+				e.Milestone = m
+				w.emit(e)
 			}
-			w.RemoveFood(fid)
-			snake.Grow(food.Value)
 		}
 	}
+	return eaten
 }
 
 // broadcast sends viewport-culled state to each connected player.
-func (gl *GameLoop) broadcast(leaderboard []LeaderboardEntry) {
+// All payloads are built under a single read section, then encoded and
+// written in parallel by the broadcast pool outside the lock. Leaderboard and
+// minimap come from the tick's snapshot, shared by every player.
+func (gl *GameLoop) broadcast(snap *TickSnapshot) {
 	w := gl.world
 	conns := gl.conns.Snapshot()
+	jobs := make([]sendJob, 0, len(conns))
 
-	// Compute minimap dots once for all players
-	w.mu.RLock()
-	minimapDots := w.MinimapSnakes()
-	w.mu.RUnlock()
-
+	boardDue := w.Board.due(w.Tick)
+	unlock := w.rlock("broadcast")
 	for _, c := range conns {
-		w.mu.RLock()
-		snake, hasSnake := w.Snakes[c.ID]
-
-		var cx, cy float64
-		if hasSnake && snake.Alive {
-			head := snake.Head()
-			cx, cy = head.X, head.Y
-		} else {
-			w.mu.RUnlock()
-			_ = c.Send(StateMsg{
+		// Between leaderboard updates the board is left out (null); a
+		// viewer's first state always has it
+		var board, humans []LeaderboardEntry
+		if boardDue || c.stateTick == 0 {
+			board, humans = snap.Leaderboard, snap.HumanBoard
+		}
+		snake, hasSnake := w.followedSnake(c)
+		if !hasSnake {
+			c.view.reset()
+			jobs = append(jobs, sendJob{conn: c, msg: StateMsg{
 				Type:        MsgState,
 				Snakes:      []SnakeDTO{},
 				Food:        []FoodDTO{},
-				Leaderboard: leaderboard,
-			})
+				Leaderboard: board,
+				Humans:      humans,
+			}})
 			continue
 		}
 
-		snakeDTOs := w.SnakesInViewport(cx, cy)
-		foodDTOs := w.FoodInViewport(cx, cy)
-		w.mu.RUnlock()
-
-		msg := StateMsg{
-			Type:        MsgState,
-			Snakes:      snakeDTOs,
-			Food:        foodDTOs,
-			Leaderboard: leaderboard,
-			Minimap:     minimapDots,
+		if c.liteSkip(w.Tick) || c.out.statePending() {
+			continue
+		}
+		since := c.stateTick
+		if since == 0 {
+			since = w.Tick // first snapshot: nothing on screen is "new" to this viewer
 		}
-		if err := c.Send(msg); err != nil {
-			log.Printf("send error to %s: %v", c.ID, err)
+		c.stateTick = w.Tick
+		msg := w.ViewportState(c, snake, since, board, snap.Minimap)
+		msg.Humans = unmuteBoard(humans, w.ownNames(c))
+		if c.health.lite.Load() {
+			msg.Minimap = nil
 		}
+		if snake.ID != c.snakeID {
+			msg.Follow = snake.NetID
+		}
+		jobs = append(jobs, sendJob{conn: c, msg: msg})
 	}
+	unlock()
+
+	gl.sender.SendAll(jobs)
 }
 
 // randIntn is a helper to avoid direct rand.Intn calls in tests
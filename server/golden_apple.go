@@ -0,0 +1,99 @@
+package main
+
+import (
+	"log"
+	"math"
+)
+
+// GoldenApple is the world's single objective. It appears at a random spot
+// announced to every player, so it pulls snakes (bots included) into a
+// brief global hotspot; whoever eats it gets GoldenAppleValue and wears a
+// crown for GoldenAppleCrownFor. The next one spawns a random
+// GoldenAppleRespawnMin–Max later. Game-loop goroutine only.
+type GoldenApple struct {
+	Active    bool
+	X, Y      float64
+	respawnAt uint64 // World.Tick the next apple spawns; 0 = not scheduled yet
+}
+
+// msg describes the apple's current state for clients
+func (a *GoldenApple) msg() ObjectiveMsg {
+	if !a.Active {
+		return ObjectiveMsg{Type: MsgObjective}
+	}
+	return ObjectiveMsg{Type: MsgObjective, Active: 1, X: roundTo1(a.X), Y: roundTo1(a.Y)}
+}
+
+// schedule sets the next spawn a random respawn delay after tick
+func (a *GoldenApple) schedule(tick uint64) {
+	a.respawnAt = tick + uint64(randomTicks(GoldenAppleRespawnMin, GoldenAppleRespawnMax))
+}
+
+// updateGoldenApple spawns the apple when it is due. Caller must hold w.mu.Lock.
+func (gl *GameLoop) updateGoldenApple(snap *TickSnapshot) {
+	w := gl.world
+	a := &w.Apple
+	if !GoldenAppleEnabled || a.Active {
+		return
+	}
+	if a.respawnAt == 0 {
+		a.schedule(w.Tick)
+		return
+	}
+	if w.Tick < a.respawnAt {
+		return
+	}
+	for range 10 {
+		x, y := randomCirclePoint(WorldCenterX, WorldCenterY, WorldRadius-GoldenAppleMargin)
+		if w.foodSpawnBlocked(x, y) {
+			continue
+		}
+		a.Active, a.X, a.Y = true, x, y
+		snap.Objective = append(snap.Objective, a.msg())
+		log.Printf("golden apple spawned at (%.0f, %.0f)", x, y)
+		return
+	}
+	// Every spot tried was excluded; try again next tick
+}
+
+// collectGoldenApple gives the apple to the nearest head within reach (ties
+// to the lower snake ID), adding its value to eaten for the score pop.
+// Caller must hold w.mu.Lock.
+func (gl *GameLoop) collectGoldenApple(snap *TickSnapshot, eaten map[string]int) {
+	w := gl.world
+	a := &w.Apple
+	if !a.Active {
+		return
+	}
+	var eater *Snake
+	best := SnakeHeadRadius + GoldenAppleRadius
+	for _, s := range w.Snakes {
+		if !s.Alive {
+			continue
+		}
+		head := s.Head()
+		d := math.Hypot(head.X-a.X, head.Y-a.Y)
+		if d < best || (d == best && eater != nil && s.ID < eater.ID) {
+			eater, best = s, d
+		}
+	}
+	if eater == nil {
+		return
+	}
+	value := GoldenAppleValue
+	if s, ok := gl.sanctionOf(eater.ID); ok {
+		value = int(float64(value) * s.ScoreFactor)
+	}
+	eater.Grow(value)
+	eater.CrownTicks = ticksFor(GoldenAppleCrownFor)
+	eaten[eater.ID] += value
+	w.Economy.objective(GoldenAppleValue, value)
+	w.emit(snakeEvent(EventObjective, eater))
+
+	a.Active = false
+	a.schedule(w.Tick)
+	msg := a.msg()
+	msg.Eater, msg.Value = eater.Name, value
+	snap.Objective = append(snap.Objective, msg)
+	log.Printf("golden apple eaten by %s (%s)", eater.Name, eater.ID)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+)
+
+// HeadStartConfig is a world's respawn head start: a player respawning
+// starts with Fraction of the score their previous life gained, up to Max,
+// so a long life doesn't end in a full reset. Fraction 0 turns it off,
+// for hardcore rooms. Immutable once the world runs.
+type HeadStartConfig struct {
+	Fraction float64
+	Max      int // score
+}
+
+// defaultHeadStart is a world's head start unless configured
+var defaultHeadStart = HeadStartConfig{Fraction: HeadStartFraction, Max: HeadStartMax}
+
+// headStartFromEnv reads SLETHER_HEAD_START (the fraction, 0-HeadStartMaxFraction;
+// 0 for hardcore) and SLETHER_HEAD_START_MAX (the cap, 0-HeadStartMaxCap)
+func headStartFromEnv() (HeadStartConfig, error) {
+	cfg := defaultHeadStart
+	if v := os.Getenv("SLETHER_HEAD_START"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(f >= 0 && f <= HeadStartMaxFraction) {
+			return cfg, fmt.Errorf("SLETHER_HEAD_START=%q: want 0-%g", v, HeadStartMaxFraction)
+		}
+		cfg.Fraction = f
+	}
+	if v := os.Getenv("SLETHER_HEAD_START_MAX"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > HeadStartMaxCap {
+			return cfg, fmt.Errorf("SLETHER_HEAD_START_MAX=%q: want 0-%d", v, HeadStartMaxCap)
+		}
+		cfg.Max = n
+	}
+	return cfg, nil
+}
+
+// bonus is the head start for a life after one that ended with score
+func (c HeadStartConfig) bonus(score int) int {
+	gained := max(score-SnakeInitSegments, 0)
+	return min(int(math.Round(float64(gained)*c.Fraction)), c.Max)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Highlight types
+const (
+	HighlightMultiKill  = "multi_kill"  // HighlightMultiKillMin+ kills in quick succession
+	HighlightGiantDeath = "giant_death" // a snake of HighlightGiantScore+ died
+	HighlightEdgeEscape = "edge_escape" // boosted clear after grazing the boundary
+)
+
+// Highlight is one notable moment, for streamers and the death screen to
+// link to. Tick and time locate it in any recording of the game.
+type Highlight struct {
+	ID        int       `json:"id"`
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	Tick      uint64    `json:"tick"`
+	Entity    uint32    `json:"entity"` // subject's wire ID, as in state messages
+	Name      string    `json:"name"`
+	Bot       bool      `json:"bot,omitempty"`
+	Score     int       `json:"score"`
+	Kills     int       `json:"kills,omitempty"`      // multi_kill: kills so far in the run
+	Victims   []string  `json:"victims,omitempty"`    // multi_kill: victim names
+	OtherName string    `json:"other_name,omitempty"` // giant_death: killer
+	X         float64   `json:"x"`
+	Y         float64   `json:"y"`
+}
+
+// killRun is a snake's current run of kills, each within
+// HighlightMultiKillWindow of the previous
+type killRun struct {
+	last    uint64
+	victims []string
+	hl      *Highlight // recorded once the run is long enough
+}
+
+// Highlights detects highlight moments from game events and boundary
+// passes and keeps the newest HighlightsMax. Detection state is game-loop
+// only; the index has its own lock for API readers.
+type Highlights struct {
+	runs  map[string]*killRun // snake ID -> kill run
+	edges map[string]uint64   // snake ID -> tick it boosted within HighlightEdgeMargin
+
+	mu     sync.Mutex
+	recent []*Highlight // oldest first
+	nextID int
+}
+
+// NewHighlights creates an empty index
+func NewHighlights() *Highlights {
+	return &Highlights{runs: make(map[string]*killRun), edges: make(map[string]uint64)}
+}
+
+// add indexes h, evicting the oldest beyond HighlightsMax
+func (h *Highlights) add(hl *Highlight) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextID++
+	hl.ID = h.nextID
+	h.recent = append(h.recent, hl)
+	if len(h.recent) > HighlightsMax {
+		h.recent = h.recent[len(h.recent)-HighlightsMax:]
+	}
+}
+
+// observe looks at an emitted event for kills and deaths worth keeping.
+// Game-loop goroutine only.
+func (h *Highlights) observe(e GameEvent) {
+	if h == nil {
+		return
+	}
+	switch e.Type {
+	case EventKill:
+		run := h.runs[e.Snake]
+		if run == nil || e.Tick-run.last > uint64(ticksFor(HighlightMultiKillWindow)) {
+			run = &killRun{}
+			h.runs[e.Snake] = run
+		}
+		run.last = e.Tick
+		run.victims = append(run.victims, e.OtherName)
+		if len(run.victims) < HighlightMultiKillMin {
+			return
+		}
+		if run.hl == nil {
+			run.hl = highlightFrom(HighlightMultiKill, e)
+			run.hl.Kills, run.hl.Victims = len(run.victims), append([]string(nil), run.victims...)
+			h.add(run.hl)
+			return
+		}
+		// Extend the recorded run in place
+		h.mu.Lock()
+		run.hl.Kills, run.hl.Victims = len(run.victims), append(run.hl.Victims, e.OtherName)
+		run.hl.Score = e.Score
+		h.mu.Unlock()
+	case EventDeath:
+		delete(h.runs, e.Snake)
+		delete(h.edges, e.Snake)
+		if e.Score >= HighlightGiantScore {
+			hl := highlightFrom(HighlightGiantDeath, e)
+			hl.OtherName = e.OtherName
+			h.add(hl)
+		}
+	case EventLeave:
+		delete(h.runs, e.Snake)
+		delete(h.edges, e.Snake)
+	}
+}
+
+// highlightFrom starts a highlight about e's subject
+func highlightFrom(typ string, e GameEvent) *Highlight {
+	t := e.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return &Highlight{
+		Type: typ, Time: t, Tick: e.Tick,
+		Entity: e.Entity, Name: e.Name, Bot: e.Bot, Score: e.Score,
+		X: e.X, Y: e.Y,
+	}
+}
+
+// observeEdges records a boundary escape when a snake that boosted within
+// HighlightEdgeMargin of the boundary gets back past three times that
+// margin within HighlightEdgeWindow. Call after deaths are resolved;
+// caller must hold w.mu.Lock.
+func (w *World) observeEdges() {
+	h := w.Highlights
+	window := uint64(ticksFor(HighlightEdgeWindow))
+	for id, since := range h.edges {
+		if w.Tick-since > window {
+			delete(h.edges, id) // hugged the edge too long to count as an escape
+		}
+	}
+	for _, s := range w.Snakes {
+		if !s.Alive || s.Score < HighlightEdgeMinScore {
+			continue
+		}
+		head := s.Head()
+		gap := WorldRadius - math.Hypot(head.X-WorldCenterX, head.Y-WorldCenterY)
+		since, grazed := h.edges[s.ID]
+		switch {
+		case s.BoostActive && gap < HighlightEdgeMargin && !grazed:
+			h.edges[s.ID] = w.Tick
+		case grazed && gap > 3*HighlightEdgeMargin:
+			delete(h.edges, s.ID)
+			e := snakeEvent(HighlightEdgeEscape, s)
+			e.Tick = since
+			h.add(highlightFrom(HighlightEdgeEscape, e))
+		}
+	}
+}
+
+// List returns up to limit highlights, newest first, optionally only those
+// of type typ and about entity (0 for any)
+func (h *Highlights) List(typ string, entity uint32, limit int) []Highlight {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := []Highlight{}
+	for i := len(h.recent) - 1; i >= 0 && len(out) < limit; i-- {
+		hl := h.recent[i]
+		if (typ == "" || hl.Type == typ) && (entity == 0 || hl.Entity == entity) {
+			c := *hl
+			c.Victims = append([]string(nil), hl.Victims...)
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// handleHighlights serves the highlights index: GET /api/highlights with
+// optional ?type=, ?entity= (a player's own snake, by the entity ID from
+// welcome, for the death screen) and ?limit= (default 20, at most
+// HighlightsMax)
+func handleHighlights(world *World) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit := 20
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+				return
+			}
+			limit = min(n, HighlightsMax)
+		}
+		var entity uint32
+		if v := q.Get("entity"); v != "" {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid entity"})
+				return
+			}
+			entity = uint32(n)
+		}
+		list := world.Highlights.List(q.Get("type"), entity, limit)
+		writePublicJSON(w, 5*time.Second, map[string]any{"highlights": list})
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Hosted rooms are rooms a tenant creates with its key, within the key's
+// quotas (see tenant_keys.go): at most MaxRooms of them at once, holding
+// at most MaxPlayers players together. The tenant picks a few settings
+// (RoomConfig); everything else is the server's. A hosted room keeps its
+// stats to itself: it has its own profile store, and feeds neither the
+// all-time records nor the seasons, which belong to the server's rooms.
+// Players reach it only by name (?room=), never by automatic placement.
+// Hosted rooms are recreated on restart and closed when their key is
+// revoked.
+
+// RoomConfig is what a tenant may set on a room it creates; unknown
+// fields are refused. Zero values keep the server's setting.
+type RoomConfig struct {
+	// Pack is a bundled or uploaded content pack ID
+	Pack string `json:"pack,omitempty"`
+	// Ranked turns rating kills between claimed names on or off
+	Ranked *bool `json:"ranked,omitempty"`
+	// Lang is the community language (see community.go)
+	Lang string `json:"lang,omitempty"`
+	// Bots is the bot population to maintain, 0..HostedRoomMaxBots
+	Bots *int `json:"bots,omitempty"`
+	// MaxPlayers is the room's capacity, 1..HostedRoomMaxPlayers (0 for
+	// the most)
+	MaxPlayers int `json:"max_players,omitempty"`
+}
+
+// validate checks every field is in range; whether the pack exists is
+// checked when the room is built
+func (c RoomConfig) validate() error {
+	if strings.ContainsAny(c.Pack, `/\`) || strings.HasSuffix(c.Pack, ".json") {
+		return errors.New("pack must be a bundled or uploaded pack ID")
+	}
+	if c.Lang != "" && findBotLocale(c.Lang) == nil {
+		return fmt.Errorf("lang %q: no bot names in that language", c.Lang)
+	}
+	if c.Bots != nil && (*c.Bots < 0 || *c.Bots > HostedRoomMaxBots) {
+		return fmt.Errorf("bots must be 0-%d", HostedRoomMaxBots)
+	}
+	if c.MaxPlayers < 0 || c.MaxPlayers > HostedRoomMaxPlayers {
+		return fmt.Errorf("max_players must be 1-%d", HostedRoomMaxPlayers)
+	}
+	return nil
+}
+
+// Errors from RoomManager.host and Destroy
+var (
+	errRoomExists = errors.New("room already exists")
+	errRoomFixed  = errors.New("only hosted rooms can be closed")
+)
+
+// resolvePack finds the pack a hosted room is built from: the server's
+// own for "", else a bundled or uploaded one
+func (m *RoomManager) resolvePack(id string) (*ContentPack, error) {
+	if id == "" {
+		return m.pack, nil
+	}
+	return m.Rooms()[0].World.Packs.Resolve(id)
+}
+
+// host builds the room h describes, created with key, and adds it; it
+// starts at once if the manager is running
+func (m *RoomManager) host(h HostedRoom, key TenantKey) (*Room, error) {
+	pack, err := m.resolvePack(h.Config.Pack)
+	if err != nil {
+		return nil, err
+	}
+	w := newRoomWorld(m.Rooms()[0].World, pack)
+	w.Profiles, w.Seasons, w.Records = newProfileStore(), nil, nil
+	if h.Config.Ranked != nil {
+		w.Ranked = *h.Config.Ranked
+	}
+	if h.Config.Lang != "" {
+		w.Lang = h.Config.Lang
+	}
+	room := NewRoom(h.Name, w)
+	room.Tenant, room.Key, room.quota = h.Tenant, h.Key, key.MaxPlayers
+	room.Capacity = HostedRoomMaxPlayers
+	if h.Config.MaxPlayers > 0 {
+		room.Capacity = h.Config.MaxPlayers
+	}
+	if m.cohorts != nil {
+		room.Loop.bots.setCohorts(m.cohorts)
+	}
+	if h.Config.Bots != nil {
+		room.Loop.bots.target = *h.Config.Bots
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, taken := m.byName[h.Name]; taken {
+		return nil, errRoomExists
+	}
+	m.rooms = append(m.rooms, room)
+	m.byName[h.Name] = room
+	if m.running {
+		go room.Loop.Run()
+		m.hooks.emit(RoomOpened, room)
+	}
+	log.Printf("room %s opened for tenant %s", h.Name, h.Tenant)
+	return room, nil
+}
+
+// restoreHosted recreates the hosted rooms kept with keys, skipping any
+// that can no longer be built (their pack deleted, say). Call before Run.
+func (m *RoomManager) restoreHosted(keys *TenantKeys) {
+	rooms, owners := keys.Hosted()
+	for i, h := range rooms {
+		if _, err := m.host(h, owners[i]); err != nil {
+			log.Printf("hosted room %s: %v", h.Name, err)
+		}
+	}
+}
+
+// Destroy closes the hosted room name like a shutdown closes the server:
+// the loop stops between two ticks, live players' lives end as if they
+// left, and every connection is closed with CloseRoomClosed. Their leave
+// commands go to a loop that no longer runs; the room's capacity is well
+// under CommandQueueSize, so they all fit without blocking.
+func (m *RoomManager) Destroy(name string) error {
+	m.mu.Lock()
+	r, ok := m.byName[name]
+	if !ok {
+		m.mu.Unlock()
+		return errNoSuchRoom
+	}
+	if r.Tenant == "" {
+		m.mu.Unlock()
+		return errRoomFixed
+	}
+	delete(m.byName, name)
+	m.rooms = slices.DeleteFunc(m.rooms, func(other *Room) bool { return other == r })
+	running := m.running
+	m.mu.Unlock()
+
+	r.closed.Store(true)
+	if running {
+		r.Loop.Stop()
+		r.Loop.endSessions()
+	}
+	for _, c := range r.Conns.Snapshot() {
+		c.Disconnect(CloseRoomClosed, "This room has closed.")
+	}
+	m.hooks.emit(RoomClosed, r)
+	log.Printf("room %s closed", name)
+	return nil
+}
+
+// handleCreateRoom creates a room for the calling tenant key, within its
+// quotas, from an optional RoomConfig body
+func (a *AdminAPI) handleCreateRoom(w http.ResponseWriter, r *http.Request) {
+	if a.tenant == nil {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "rooms are created with a tenant key"})
+		return
+	}
+	var cfg RoomConfig
+	if r.ContentLength != 0 {
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid room config: " + err.Error()})
+			return
+		}
+	}
+	if err := cfg.validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if _, err := a.rooms.resolvePack(cfg.Pack); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	h := HostedRoom{
+		Name:    "t-" + strings.ToLower(rand.Text()[:10]),
+		Tenant:  a.tenant.Tenant,
+		Key:     a.tenant.ID,
+		Config:  cfg,
+		Created: time.Now().UTC(),
+	}
+	if err := a.keys.Host(*a.tenant, h); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errRoomQuota):
+			status = http.StatusForbidden
+		case errors.Is(err, errHostedFull):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, errNoSuchKey):
+			status = http.StatusUnauthorized
+		}
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	room, err := a.rooms.host(h, *a.tenant)
+	if err != nil {
+		if uerr := a.keys.Unhost(h.Name); uerr != nil {
+			log.Printf("admin: forget room %s: %v", h.Name, uerr)
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	a.record(r, "room.create", h)
+	writeJSON(w, http.StatusCreated, map[string]any{"room": room.Name, "capacity": room.Capacity, "config": cfg})
+}
+
+// handleDeleteRoom closes one of the caller's hosted rooms; the operator
+// may close any hosted room, but not the server's own
+func (a *AdminAPI) handleDeleteRoom(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	room, ok := a.rooms.Get(name)
+	if !ok || !a.owns(room) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such room"})
+		return
+	}
+	if room.Tenant == "" {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": errRoomFixed.Error()})
+		return
+	}
+	if err := a.keys.Unhost(name); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := a.rooms.Destroy(name); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such room"})
+		return
+	}
+	a.record(r, "room.close", map[string]string{"room": name})
+	writeJSON(w, http.StatusOK, map[string]string{"closed": name})
+}
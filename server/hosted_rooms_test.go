@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// adminCall sends one admin API request with bearer token and decodes the
+// JSON answer into out (if not nil), returning the status
+func adminCall(t *testing.T, mux *http.ServeMux, method, path, token, body string, out any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: %v (%s)", method, path, err, rec.Body)
+		}
+	}
+	return rec.Code
+}
+
+// TestHostedRooms creates rooms with tenant keys through the admin API and
+// checks the room and player quotas, the config whitelist, isolation
+// between tenants, closing, and recreation from the key file
+func TestHostedRooms(t *testing.T) {
+	quietLogs(t)
+	keysPath := filepath.Join(t.TempDir(), "keys.json")
+	t.Setenv("SLETHER_ADMIN_TOKEN", "op")
+	t.Setenv("SLETHER_AUDIT_LOG", "")
+	t.Setenv("SLETHER_TENANT_KEYS", keysPath)
+
+	rooms := NewRoomManager(NewRoom("main", newEmptyWorld()))
+	api := newAdminAPI(rooms)
+	mux := http.NewServeMux()
+	api.register(mux)
+
+	issue := func(tenant string, maxRooms, maxPlayers int) string {
+		var resp struct {
+			Secret string `json:"secret"`
+		}
+		body := `{"tenant":"` + tenant + `","max_rooms":` + strconv.Itoa(maxRooms) + `,"max_players":` + strconv.Itoa(maxPlayers) + `}`
+		if code := adminCall(t, mux, "POST", "/admin/keys", "op", body, &resp); code != http.StatusCreated {
+			t.Fatalf("issue key for %s: status %d", tenant, code)
+		}
+		return resp.Secret
+	}
+	acme, other := issue("acme", 1, 2), issue("other", 1, 0)
+
+	type created struct {
+		Room     string `json:"room"`
+		Capacity int    `json:"capacity"`
+	}
+	var room created
+	if code := adminCall(t, mux, "POST", "/admin/rooms", "op", `{}`, nil); code != http.StatusForbidden {
+		t.Fatalf("operator created a room: status %d", code)
+	}
+	if code := adminCall(t, mux, "POST", "/admin/rooms", acme, `{"tick_rate":60}`, nil); code != http.StatusBadRequest {
+		t.Fatalf("room with a non-whitelisted setting: status %d", code)
+	}
+	if code := adminCall(t, mux, "POST", "/admin/rooms", acme, `{"pack":"../../etc/passwd"}`, nil); code != http.StatusBadRequest {
+		t.Fatalf("room with a pack path: status %d", code)
+	}
+	if code := adminCall(t, mux, "POST", "/admin/rooms", acme, `{"max_players":5,"bots":3,"lang":"ja"}`, &room); code != http.StatusCreated {
+		t.Fatalf("create room: status %d", code)
+	}
+	if room.Capacity != 5 {
+		t.Fatalf("capacity %d, want 5", room.Capacity)
+	}
+	if code := adminCall(t, mux, "POST", "/admin/rooms", acme, `{}`, nil); code != http.StatusForbidden {
+		t.Fatalf("room over the key's quota: status %d", code)
+	}
+	r, ok := rooms.Get(room.Room)
+	if !ok || r.Tenant != "acme" || r.World.Lang != "ja" || r.Loop.bots.target != 3 {
+		t.Fatalf("hosted room not built from its config: %+v", r)
+	}
+	if r.World.Records != nil || r.World.Profiles == rooms.Rooms()[0].World.Profiles {
+		t.Fatal("hosted room shares the server's stats")
+	}
+
+	// Player quota across the key's rooms, and no automatic placement
+	for range 2 {
+		r.Conns.Add(NewConn(newMockWS()))
+	}
+	if _, err := rooms.Assign(room.Room); !errors.Is(err, errRoomsFull) {
+		t.Fatalf("join over the key's player quota: %v", err)
+	}
+	if got, _ := rooms.Assign(""); got == r {
+		t.Fatal("automatic placement picked a hosted room")
+	}
+
+	// Other tenants can't see or close it
+	var list struct {
+		Rooms []adminRoom `json:"rooms"`
+	}
+	adminCall(t, mux, "GET", "/admin/rooms", other, "", &list)
+	if len(list.Rooms) != 0 {
+		t.Fatalf("other tenant sees rooms %+v", list.Rooms)
+	}
+	if code := adminCall(t, mux, "DELETE", "/admin/rooms/"+room.Room, other, "", nil); code != http.StatusNotFound {
+		t.Fatalf("other tenant closed the room: status %d", code)
+	}
+	if code := adminCall(t, mux, "DELETE", "/admin/rooms/main", acme, "", nil); code != http.StatusNotFound {
+		t.Fatalf("tenant reached the server's room: status %d", code)
+	}
+
+	// Kept across a restart
+	restarted := NewRoomManager(NewRoom("main", newEmptyWorld()))
+	newAdminAPI(restarted)
+	if r2, ok := restarted.Get(room.Room); !ok || r2.Capacity != 5 || r2.quota != 2 {
+		t.Fatalf("hosted room not recreated from %s", keysPath)
+	}
+
+	// Closing frees the quota
+	if code := adminCall(t, mux, "DELETE", "/admin/rooms/"+room.Room, acme, "", nil); code != http.StatusOK {
+		t.Fatalf("close room: status %d", code)
+	}
+	if _, ok := rooms.Get(room.Room); ok || !r.closed.Load() {
+		t.Fatal("closed room still listed")
+	}
+	if code := adminCall(t, mux, "POST", "/admin/rooms", acme, "", &room); code != http.StatusCreated {
+		t.Fatalf("create after close: status %d", code)
+	}
+}
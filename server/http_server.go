@@ -0,0 +1,209 @@
+package main
+
+import (
+	"compress/gzip"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// middleware wraps a handler with cross-cutting HTTP behavior
+type middleware func(http.Handler) http.Handler
+
+// chain applies mws so the first one listed is the outermost
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// newHTTPServer builds the HTTP server; admin may be nil (API disabled).
+// extraSources are origins the page may also load from (a captcha widget);
+// proxies are the reverse proxies whose X-Forwarded-For is believed.
+// The WebSocket routes (game and admin live) are mounted bare — middleware
+// response wrappers would get in the way of the connection hijack and the
+// per-message compression already handles their payloads.
+func newHTTPServer(ws http.Handler, rooms *RoomManager, admin *AdminAPI, extraSources string, proxies trustedProxies) *http.Server {
+	site := http.NewServeMux()
+	site.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeText(w, http.StatusOK, "ok")
+	})
+	site.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !rooms.Ready() {
+			writeText(w, http.StatusServiceUnavailable, "game loop not ticking")
+			return
+		}
+		writeText(w, http.StatusOK, "ready")
+	})
+	static, clientVersion := staticHandler()
+	site.HandleFunc("GET /api/client-version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"version": clientVersion})
+	})
+	// Community-facing APIs, polled by other sites, are rate limited per IP.
+	// Stats and highlights are per room (?room=); profiles, seasons and
+	// records are shared by every room.
+	limiter := newAPIRateLimiter(proxies)
+	world := rooms.Rooms()[0].World
+	site.HandleFunc("GET /api/stats", limiter.limit(rooms.perRoom(handlePublicStats)))
+	site.HandleFunc("GET /api/highlights", limiter.limit(rooms.perRoom(handleHighlights)))
+	site.HandleFunc("GET /api/profile/{name}", limiter.limit(handleProfile(world)))
+	site.HandleFunc("GET /api/seasons", limiter.limit(handleSeasons(world)))
+	site.HandleFunc("GET /api/seasons/{n}", limiter.limit(handleSeason(world)))
+	site.HandleFunc("GET /api/records", limiter.limit(handleRecords(world)))
+	if admin != nil {
+		admin.register(site)
+	}
+	site.Handle("/", static)
+
+	root := http.NewServeMux()
+	root.Handle(WebSocketPath, ws)
+	if admin != nil {
+		root.HandleFunc("GET "+AdminPathPrefix+"live", admin.authLive(admin.handleLive))
+	}
+	root.Handle("/", chain(site, withRequestLog, withSecurityHeaders(extraSources), withGzip))
+
+	return &http.Server{
+		Handler:           root,
+		ReadHeaderTimeout: HTTPReadHeaderTimeout,
+		ReadTimeout:       HTTPReadTimeout,
+		WriteTimeout:      HTTPWriteTimeout,
+		IdleTimeout:       HTTPIdleTimeout,
+	}
+}
+
+// writeText writes a short plain-text response
+func writeText(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body + "\n"))
+}
+
+// statusRecorder captures the status code and body size for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// withRequestLog logs one line per request. Health probes are skipped so
+// orchestrator polling doesn't drown the log.
+func withRequestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		log.Printf("http %s %s %d %dB %s", r.Method, r.URL.Path, rec.status, rec.bytes, time.Since(start).Round(time.Microsecond))
+	})
+}
+
+// withSecurityHeaders sets conservative browser hardening headers. The CSP
+// allows same-origin scripts only (no inline) plus WebSocket connects, and
+// scripts, frames, styles and fetches from extra (space-separated origins).
+func withSecurityHeaders(extra string) middleware {
+	if extra != "" {
+		extra = " " + extra
+	}
+	csp := "default-src 'self'; script-src 'self'" + extra + "; style-src 'self' 'unsafe-inline'" + extra + "; " +
+		"img-src 'self' data:; connect-src 'self' ws: wss:" + extra + "; frame-src 'self'" + extra + "; frame-ancestors 'none'"
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			h.Set("Content-Security-Policy", csp)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+var gzipWriters = sync.Pool{New: func() any {
+	zw, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+	return zw
+}}
+
+// gzipResponseWriter compresses the body unless the handler already encoded
+// it (the embedded client ships pre-compressed) or it isn't text
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	zw      *gzip.Writer
+	decided bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if !g.decided {
+		g.decided = true
+		h := g.Header()
+		if status == http.StatusOK && h.Get("Content-Encoding") == "" && isCompressibleType(h.Get("Content-Type")) {
+			h.Set("Content-Encoding", "gzip")
+			h.Del("Content-Length")
+			h.Add("Vary", "Accept-Encoding")
+			g.zw = gzipWriters.Get().(*gzip.Writer)
+			g.zw.Reset(g.ResponseWriter)
+		}
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !g.decided {
+		if g.Header().Get("Content-Type") == "" {
+			g.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.zw != nil {
+		return g.zw.Write(b)
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+func (g *gzipResponseWriter) close() {
+	if g.zw != nil {
+		g.zw.Close()
+		gzipWriters.Put(g.zw)
+	}
+}
+
+// isCompressibleType reports whether a Content-Type is worth gzipping
+func isCompressibleType(ct string) bool {
+	return strings.HasPrefix(ct, "text/") ||
+		strings.Contains(ct, "javascript") ||
+		strings.Contains(ct, "json") ||
+		strings.Contains(ct, "svg")
+}
+
+// withGzip compresses text responses for clients that accept gzip
+func withGzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
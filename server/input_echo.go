@@ -0,0 +1,38 @@
+package main
+
+import "math"
+
+// Input echo: a client that connects with ?echo=1 gets an InputEchoMsg
+// every tick its own snake moves, saying which of its inputs the server
+// applied (by the sequence number the client put on it) and where that
+// left the snake's heading after the turn-rate clamp. It settles "my snake
+// didn't turn" reports with data: an input that never shows up was lost or
+// superseded before the tick, one that shows up with a different heading
+// was clamped. It also gives client-side prediction the acknowledged
+// sequence number to reconcile from. The same numbers go into the world
+// log (see world_log.go). Off by default, since it adds a message per tick.
+
+// echoSeq is the sequence number to report for inp, the input a player's
+// own snake is steered with this tick. Under a shadow input lag (see
+// moderation.go) that is the newest input's number rather than the delayed
+// one, so the echo can't give the sanction away.
+func echoSeq(c *Conn, inp PlayerInput, lagMS int) uint32 {
+	if lagMS > 0 {
+		return c.GetInput().Seq
+	}
+	return inp.Seq
+}
+
+// inputEcho reports m, a player's own snake after steering, for tick
+func inputEcho(tick uint64, m *mover) InputEchoMsg {
+	msg := InputEchoMsg{
+		Type:    MsgEcho,
+		Tick:    tick,
+		Seq:     m.seq,
+		Heading: math.Round(normalizeAngle(m.snake.Angle)*1000) / 1000,
+	}
+	if m.snake.BoostActive {
+		msg.Boost = 1
+	}
+	return msg
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// joinGate paces a connection's joins and respawns to one per JoinCooldown.
+// A join inside the cooldown isn't dropped, which could strand a client on
+// the death screen; it is held and runs when the cooldown ends, and any
+// further joins meanwhile only replace its name and token. A client
+// spamming "j" therefore costs one profile check and one queued spawn per
+// cooldown, not one per message.
+//
+// Everything a join sends stays here, under mu, and reaches onJoin as a
+// copy, so no other goroutine can see a join half applied; only the
+// display name is published, through Conn.name.
+type joinGate struct {
+	mu      sync.Mutex  // held across onJoin, so joins run one at a time and in order
+	last    time.Time   // when the last join ran
+	pending joinRequest // latest requested join, run by timer
+	timer   *time.Timer // pending deferred join, nil if none
+	stopped bool        // set by stop; no join runs afterwards
+
+	// profileName, profileToken and profileKey cache the last profile
+	// check (see joinProfile)
+	profileName, profileToken, profileKey string
+}
+
+// joinRequest is what a join or respawn message asks for
+type joinRequest struct {
+	name    string
+	token   string // profile token, "" for none
+	captcha string // widget token; sticky, a join without one reuses the last
+	flag    bool   // show the player's country flag
+}
+
+// requestJoin runs req now, or when the cooldown ends
+func (c *Conn) requestJoin(req joinRequest, onJoin func(conn *Conn, req joinRequest)) {
+	g := &c.join
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return
+	}
+	if req.captcha == "" {
+		req.captcha = g.pending.captcha
+	}
+	g.pending = req
+	if g.timer != nil {
+		return // the pending join picks up this name
+	}
+	wait := JoinCooldown - time.Since(g.last)
+	if wait <= 0 {
+		c.runJoin(onJoin)
+		return
+	}
+	g.timer = time.AfterFunc(wait, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.timer = nil
+		if !g.stopped {
+			c.runJoin(onJoin)
+		}
+	})
+}
+
+// runJoin applies the latest requested join; caller holds c.join.mu
+func (c *Conn) runJoin(onJoin func(conn *Conn, req joinRequest)) {
+	g := &c.join
+	g.last = time.Now()
+	name := g.pending.name
+	c.displayName.Store(&name)
+	onJoin(c, g.pending)
+}
+
+// stopJoins cancels any pending join. Called before the disconnect is
+// queued, so a deferred join can't respawn the snake after it is removed.
+func (c *Conn) stopJoins() {
+	g := &c.join
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stopped = true
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+}
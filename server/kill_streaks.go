@@ -0,0 +1,86 @@
+package main
+
+// KillerBoundary is the killer ID recorded for deaths with no killing snake
+const KillerBoundary = ""
+
+// streakMilestones are kill streaks announced to everyone in the kill feed
+var streakMilestones = map[int]bool{3: true, 5: true, 10: true, 15: true, 25: true}
+
+// creditKill records o's kill (plus an assist for whoever was crowding the
+// victim), pays a shutdown bonus if the victim was on a streak and the
+// killer lives on, and returns the kill feed entry (see deathOutcome for
+// same-tick deaths), with the snake credited with the assist (nil for
+// none). Streaks are kills in the current life: a respawn creates a fresh
+// Snake. Caller must hold w.mu.Lock.
+func (w *World) creditKill(o deathOutcome) (KillFeedMsg, *Snake) {
+	killer, victim := o.killer, o.victim
+	killer.Kills++
+	msg := KillFeedMsg{
+		Type:   MsgKill,
+		Killer: killer.Name,
+		Victim: victim.Name,
+		Streak: killer.Kills,
+	}
+	if o.mutual {
+		msg.Mutual = 1
+	}
+	helper := w.assistFor(victim, killer.ID)
+	if helper != nil {
+		helper.Assists++
+		msg.Assist = helper.Name
+	}
+	if streakMilestones[killer.Kills] {
+		msg.Milestone = 1
+	}
+	if o.streak >= ShutdownMinStreak && !o.killerDies {
+		bonus := ShutdownBonusPerKill * o.streak
+		killer.Grow(bonus)
+		w.Economy.bonus(bonus)
+		msg.Shutdown = o.streak
+		msg.Bonus = bonus
+	}
+	return msg, helper
+}
+
+// trackPressure records, for every alive snake, which other heads are close
+// enough to be crowding it this tick. Call after RebuildGrid. Caller must hold w.mu.Lock.
+func (w *World) trackPressure() {
+	for _, s := range w.Snakes {
+		if !s.Alive {
+			continue
+		}
+		head := s.Head()
+		w.Grid.ForEachSnakeBodyNear(head.X, head.Y, AssistRadius, s.ID, func(e gridEntry) bool {
+			if e.segIdx == 0 {
+				if s.pressure == nil {
+					s.pressure = make(map[string]uint64)
+				}
+				s.pressure[e.snakeID] = w.Tick
+			}
+			return true
+		})
+		for id, tick := range s.pressure {
+			if w.Tick-tick > uint64(ticksFor(AssistWindow)) {
+				delete(s.pressure, id)
+			}
+		}
+	}
+}
+
+// assistFor returns the snake with the most recent pressure on victim within
+// the assist window, excluding the killer; nil if none
+func (w *World) assistFor(victim *Snake, killerID string) *Snake {
+	var best *Snake
+	var bestTick uint64
+	for id, tick := range victim.pressure {
+		if id == killerID || w.Tick-tick > uint64(ticksFor(AssistWindow)) {
+			continue
+		}
+		s := w.Snakes[id]
+		if s == nil || (best != nil && (tick < bestTick || (tick == bestTick && id > best.ID))) {
+			continue
+		}
+		best, bestTick = s, tick
+	}
+	return best
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// LeaderboardConfig is a world's leaderboard settings. Competitive worlds
+// often want a longer, humans-only board; busy ones can send it less often
+// than every tick to save bandwidth. Immutable once the world runs.
+type LeaderboardConfig struct {
+	Size     int           // entries shown
+	Interval time.Duration // between updates in state messages; 0 = every tick
+	Bots     bool          // whether bots can appear on the board
+}
+
+// defaultLeaderboard is the board every world had before it was configurable
+var defaultLeaderboard = LeaderboardConfig{Size: LeaderboardSize, Bots: true}
+
+// leaderboardFromEnv reads SLETHER_LEADERBOARD_SIZE (1-LeaderboardMaxSize),
+// SLETHER_LEADERBOARD_INTERVAL (a duration such as 500ms, at most
+// LeaderboardMaxInterval) and SLETHER_LEADERBOARD_BOTS (false for a
+// humans-only board)
+func leaderboardFromEnv() (LeaderboardConfig, error) {
+	cfg := defaultLeaderboard
+	if v := os.Getenv("SLETHER_LEADERBOARD_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > LeaderboardMaxSize {
+			return cfg, fmt.Errorf("SLETHER_LEADERBOARD_SIZE=%q: want 1-%d", v, LeaderboardMaxSize)
+		}
+		cfg.Size = n
+	}
+	if v := os.Getenv("SLETHER_LEADERBOARD_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 || d > LeaderboardMaxInterval {
+			return cfg, fmt.Errorf("SLETHER_LEADERBOARD_INTERVAL=%q: want a duration up to %v", v, LeaderboardMaxInterval)
+		}
+		cfg.Interval = d
+	}
+	if v := os.Getenv("SLETHER_LEADERBOARD_BOTS"); v != "" {
+		bots, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("SLETHER_LEADERBOARD_BOTS=%q: want true or false", v)
+		}
+		cfg.Bots = bots
+	}
+	return cfg, nil
+}
+
+// due reports whether state messages at tick carry the leaderboard
+func (c LeaderboardConfig) due(tick uint64) bool {
+	return tick%uint64(ticksFor(c.Interval)) == 0
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// openListeners returns the sockets the HTTP server serves on:
+//   - any passed in by systemd socket activation (LISTEN_PID/LISTEN_FDS), then
+//   - each address in SLETHER_LISTEN, a comma-separated list of "host:port"
+//     or "unix:/path/to.sock" entries.
+//
+// With neither set it listens on ServerPort. A stale Unix socket file left by
+// a crashed run is removed first; it is unlinked again when its listener closes.
+func openListeners() ([]net.Listener, error) {
+	listeners, err := activatedListeners()
+	if err != nil {
+		return nil, err
+	}
+	spec := os.Getenv("SLETHER_LISTEN")
+	if spec == "" && len(listeners) == 0 {
+		spec = ServerPort
+	}
+	for _, addr := range strings.Split(spec, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		l, err := listenAddr(addr)
+		if err != nil {
+			closeAll(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listenAddr opens one SLETHER_LISTEN entry
+func listenAddr(addr string) (net.Listener, error) {
+	path, isUnix := strings.CutPrefix(addr, "unix:")
+	if !isUnix {
+		return net.Listen("tcp", addr)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("remove stale socket %s: %w", path, err)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Group-writable so a reverse proxy in the service's group can connect
+	if err := os.Chmod(path, 0o660); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// activatedListeners adopts sockets passed by systemd (fds 3, 4, ...) when
+// LISTEN_PID names this process
+func activatedListeners() ([]net.Listener, error) {
+	if pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID")); pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	// Not inherited by anything we might exec
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, n)
+	for fd := 3; fd < 3+n; fd++ {
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		l, err := net.FileListener(f)
+		f.Close() // FileListener dups the descriptor
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("socket activation fd %d: %w", fd, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+func closeAll(listeners []net.Listener) {
+	for _, l := range listeners {
+		l.Close()
+	}
+}
+
+// serveAll serves srv on every listener. Each listener runs independently:
+// one failing is logged and the rest keep serving. Returns once all have
+// stopped, with the first unexpected error (nil after srv.Shutdown/Close).
+func serveAll(srv *http.Server, listeners []net.Listener) error {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		log.Printf("server listening on %s %s", l.Addr().Network(), l.Addr())
+		go func() {
+			err := srv.Serve(l)
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			} else {
+				log.Printf("listener %s stopped: %v", l.Addr(), err)
+			}
+			errs <- err
+		}()
+	}
+	var first error
+	for range listeners {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// lockSiteStats accumulates wait/hold timings for one world.mu call site
+type lockSiteStats struct {
+	count     int64
+	waitTotal time.Duration
+	waitMax   time.Duration
+	holdTotal time.Duration
+	holdMax   time.Duration
+}
+
+// LockStats records how long each call site waits for and holds world.mu,
+// so contention between the tick and connection goroutines is measurable
+// instead of guessed. Reset on every Report.
+type LockStats struct {
+	mu    sync.Mutex
+	sites map[string]*lockSiteStats
+}
+
+// NewLockStats creates an empty stats table
+func NewLockStats() *LockStats {
+	return &LockStats{sites: make(map[string]*lockSiteStats)}
+}
+
+func (ls *LockStats) record(site string, wait, hold time.Duration) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	st := ls.sites[site]
+	if st == nil {
+		st = &lockSiteStats{}
+		ls.sites[site] = st
+	}
+	st.count++
+	st.waitTotal += wait
+	st.holdTotal += hold
+	if wait > st.waitMax {
+		st.waitMax = wait
+	}
+	if hold > st.holdMax {
+		st.holdMax = hold
+	}
+}
+
+// Report returns one summary line per call site (sorted by name) and resets the counters
+func (ls *LockStats) Report() []string {
+	ls.mu.Lock()
+	sites := ls.sites
+	ls.sites = make(map[string]*lockSiteStats)
+	ls.mu.Unlock()
+
+	names := make([]string, 0, len(sites))
+	for name := range sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		st := sites[name]
+		lines = append(lines, fmt.Sprintf("%s: n=%d wait avg=%v max=%v hold avg=%v max=%v",
+			name, st.count,
+			st.waitTotal/time.Duration(st.count), st.waitMax,
+			st.holdTotal/time.Duration(st.count), st.holdMax))
+	}
+	return lines
+}
+
+// lock acquires the world write lock and returns the matching unlock func,
+// recording wait and hold time under site
+func (w *World) lock(site string) (unlock func()) {
+	start := time.Now()
+	w.mu.Lock()
+	acquired := time.Now()
+	return func() {
+		w.mu.Unlock()
+		w.LockStats.record(site, acquired.Sub(start), time.Since(acquired))
+	}
+}
+
+// rlock acquires the world read lock and returns the matching unlock func,
+// recording wait and hold time under site
+func (w *World) rlock(site string) (unlock func()) {
+	start := time.Now()
+	w.mu.RLock()
+	acquired := time.Now()
+	return func() {
+		w.mu.RUnlock()
+		w.LockStats.record(site, acquired.Sub(start), time.Since(acquired))
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MachinePolicy is what happens to a connection flagged as a machine client
+type MachinePolicy string
+
+const (
+	MachinePolicyOff      MachinePolicy = "off"      // don't watch at all
+	MachinePolicyFlag     MachinePolicy = "flag"     // list and log only
+	MachinePolicyRestrict MachinePolicy = "restrict" // shadow sanction, per-IP cap
+	MachinePolicyKick     MachinePolicy = "kick"     // disconnect
+)
+
+// machinePolicyFromEnv reads SLETHER_MACHINE_POLICY (default restrict)
+func machinePolicyFromEnv() (MachinePolicy, error) {
+	switch p := MachinePolicy(os.Getenv("SLETHER_MACHINE_POLICY")); p {
+	case "":
+		return MachinePolicyRestrict, nil
+	case MachinePolicyOff, MachinePolicyFlag, MachinePolicyRestrict, MachinePolicyKick:
+		return p, nil
+	default:
+		return "", fmt.Errorf("SLETHER_MACHINE_POLICY=%q: want off, flag, restrict or kick", p)
+	}
+}
+
+// aimTrack is one player's aim record for the current window
+type aimTrack struct {
+	target  FoodID // food the input was last aimed at, 0 if none
+	lostAt  uint64 // tick that target vanished while aimed at; 0 if not waiting
+	samples int    // ticks with food in range
+	hits    int    // of those, ticks aimed exactly at a food item
+	// Retargets: after the aimed-at food goes, ticks until the input is
+	// aimed at another one
+	reactions, reactionTicks int
+	flag                     *MachineFlag // set once flagged, for the session
+}
+
+// MachineFlag is one flagged connection as exposed by the admin API
+type MachineFlag struct {
+	ID         string    `json:"id"`
+	Snake      string    `json:"snake"`
+	Name       string    `json:"name"`
+	IP         string    `json:"ip"`
+	AimRatio   float64   `json:"aim_ratio"`   // share of ticks aimed dead on a food item
+	ReactionMS float64   `json:"reaction_ms"` // mean time to retarget after eating
+	FlaggedAt  time.Time `json:"flagged_at"`
+	Action     string    `json:"action"` // what the policy did: flagged, restricted or kicked
+}
+
+// MachineDetector spots headless clients farming food. The real client
+// steers towards the mouse, which people point loosely and move after
+// what they see. A farming script instead aims its input dead on a food
+// item tick after tick, and the moment that item is eaten swings onto the
+// next one faster than anyone can react. Each tick the detector compares
+// every live player's input angle with the bearing of food within
+// MachineAimRadius; over each MachineWindow it flags a player whose aim is
+// exact at least MachineAimRatio of the time and who retargets within
+// MachineReactionMax on average. Both must hold, so a sharp human or a
+// laggy script alone isn't enough. Game-loop goroutine writes; readers
+// must hold World.rlock.
+type MachineDetector struct {
+	Policy      MachinePolicy
+	tracks      map[string]*aimTrack // by connection ID
+	windowStart uint64
+}
+
+// NewMachineDetector creates an empty detector applying policy
+func NewMachineDetector(policy MachinePolicy) *MachineDetector {
+	return &MachineDetector{Policy: policy, tracks: make(map[string]*aimTrack)}
+}
+
+// watchAim samples every player's aim for this tick and, at the end of
+// each window, flags machine clients. Call after RebuildGrid; caller must
+// hold w.mu.Lock.
+func (gl *GameLoop) watchAim() {
+	w := gl.world
+	d := w.Machines
+	if d.Policy == MachinePolicyOff {
+		return
+	}
+	conns := gl.conns.Snapshot()
+	for _, c := range conns {
+		s := w.Snakes[c.snakeID]
+		if s == nil || !s.Alive || strings.HasPrefix(s.ID, "bot-") {
+			continue
+		}
+		t := d.tracks[c.ID]
+		if t == nil {
+			t = &aimTrack{}
+			d.tracks[c.ID] = t
+		}
+		if t.flag == nil {
+			w.sampleAim(t, s, c.GetInput().Angle)
+		}
+	}
+
+	if w.Tick-d.windowStart < uint64(ticksFor(MachineWindow)) {
+		return
+	}
+	d.windowStart = w.Tick
+	live := make(map[string]bool, len(conns))
+	for _, c := range conns {
+		live[c.ID] = true
+		if t := d.tracks[c.ID]; t != nil && t.flag == nil {
+			gl.judgeAim(c, t)
+		}
+	}
+	for id := range d.tracks {
+		if !live[id] {
+			delete(d.tracks, id)
+		}
+	}
+}
+
+// sampleAim records whether angle points dead on a food item near s
+func (w *World) sampleAim(t *aimTrack, s *Snake, angle float64) {
+	head := s.Head()
+	if t.target != 0 && w.Food[t.target] == nil {
+		if t.lostAt == 0 {
+			t.lostAt = w.Tick
+		}
+		t.target = 0
+	}
+	var aimed FoodID
+	best := MachineAimTolerance
+	found := false
+	w.Grid.ForEachFoodNear(head.X, head.Y, MachineAimRadius, func(id FoodID) bool {
+		f := w.Food[id]
+		if f == nil {
+			return true
+		}
+		found = true
+		if off := math.Abs(normalizeAngle(math.Atan2(f.Y-head.Y, f.X-head.X) - angle)); off <= best {
+			aimed, best = id, off
+		}
+		return true
+	})
+	if !found {
+		t.lostAt = 0 // nothing to retarget to; don't time it
+		return
+	}
+	t.samples++
+	if aimed == 0 {
+		if t.lostAt != 0 && w.Tick-t.lostAt > uint64(ticksFor(MachineReactionCap)) {
+			t.lostAt = 0
+		}
+		return
+	}
+	t.hits++
+	if t.lostAt != 0 {
+		t.reactions++
+		t.reactionTicks += int(w.Tick - t.lostAt)
+		t.lostAt = 0
+	}
+	t.target = aimed
+}
+
+// judgeAim flags c if the window's aim looks machine-made, applies the
+// policy, and starts a fresh window either way
+func (gl *GameLoop) judgeAim(c *Conn, t *aimTrack) {
+	samples, hits, reactions, reactionTicks := t.samples, t.hits, t.reactions, t.reactionTicks
+	*t = aimTrack{target: t.target}
+	if samples < MachineMinSamples || reactions < MachineMinReactions {
+		return
+	}
+	ratio := float64(hits) / float64(samples)
+	reaction := time.Duration(reactionTicks) * tickDuration() / time.Duration(reactions)
+	if ratio < MachineAimRatio || reaction > MachineReactionMax {
+		return
+	}
+	t.flag = &MachineFlag{
+		ID: c.ID, Snake: c.snakeID, Name: c.name(), IP: c.ip,
+		AimRatio:   ratio,
+		ReactionMS: float64(reaction) / float64(time.Millisecond),
+		FlaggedAt:  time.Now(),
+		Action:     "flagged",
+	}
+	w := gl.world
+	switch w.Machines.Policy {
+	case MachinePolicyRestrict:
+		if w.Machines.flaggedFrom(c.ip) > MachineMaxPerIP {
+			t.flag.Action = "kicked"
+			c.Disconnect(CloseKicked, "Too many automated clients from your network")
+			break
+		}
+		// Keyed by address like any sanction, so reconnecting keeps it;
+		// without a usable address the flag is all there is
+		network, err := parseBanTarget(c.ip)
+		if err != nil {
+			break
+		}
+		t.flag.Action = "restricted"
+		w.Moderation.Set(network, Sanction{
+			InputLagMS:  MachineInputLagMS,
+			ScoreFactor: MachineScoreFactor,
+			Expires:     time.Now().Add(ModerationMaxDuration),
+		})
+	case MachinePolicyKick:
+		t.flag.Action = "kicked"
+		c.Disconnect(CloseKicked, "Automated clients aren't allowed")
+	}
+	log.Printf("machine client: %s (%s, %s) aims dead on food %.0f%% of the time and retargets in %v; %s",
+		c.ID, c.name(), c.ip, 100*ratio, reaction.Round(time.Millisecond), t.flag.Action)
+}
+
+// flaggedFrom counts flagged connections from ip
+func (d *MachineDetector) flaggedFrom(ip string) int {
+	n := 0
+	for _, t := range d.tracks {
+		if t.flag != nil && t.flag.IP == ip {
+			n++
+		}
+	}
+	return n
+}
+
+// Flags returns every flagged connection still connected, newest first
+func (d *MachineDetector) Flags() []MachineFlag {
+	flags := []MachineFlag{}
+	for _, t := range d.tracks {
+		if t.flag != nil {
+			flags = append(flags, *t.flag)
+		}
+	}
+	slices.SortFunc(flags, func(a, b MachineFlag) int { return b.FlaggedAt.Compare(a.FlaggedAt) })
+	return flags
+}
+
+// handleMachines lists flagged machine clients, with counts per IP
+func (a *AdminAPI) handleMachines(w http.ResponseWriter, r *http.Request) {
+	unlock := a.world.rlock("admin.machines")
+	policy := a.world.Machines.Policy
+	flags := a.world.Machines.Flags()
+	unlock()
+	byIP := make(map[string]int)
+	for _, f := range flags {
+		byIP[f.IP]++
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"policy":  policy,
+		"flagged": len(flags),
+		"by_ip":   byIP,
+		"clients": flags,
+	})
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
-	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -60,101 +62,362 @@ var upgrader = websocket.Upgrader{
 	EnableCompression: true,
 }
 
-// sendErrorAndClose sends an error message via WebSocket then closes the connection
-func sendErrorAndClose(ws *websocket.Conn, msg string) {
-	data, _ := json.Marshal(ErrorMsg{Type: MsgError, Message: msg})
+// sendErrorAndClose rejects a socket that never became a Conn: an error
+// message, then a close frame with the same Close* code
+func sendErrorAndClose(ws *websocket.Conn, code int, msg string) {
+	data, _ := json.Marshal(ErrorMsg{Type: MsgError, Message: msg, Code: code})
 	_ = ws.WriteMessage(websocket.TextMessage, data)
+	_ = ws.WriteControl(websocket.CloseMessage, closeFrame(code, msg), time.Now().Add(CloseHandshakeTimeout))
 	ws.Close()
 }
 
-func main() {
-	world := NewWorld()
-	conns := NewConnManager()
-	loop := NewGameLoop(world, conns)
+// postJoin queues spawning (or respawning) c's snake for the next tick.
+// Joins and disconnects never mutate the world from the connection goroutine.
+func postJoin(world *World, c *Conn, req joinRequest) {
+	name := checkImpersonation(world, c, req.name)
+	profile := joinProfile(world, c, name, req.token)
+	snake := NewSnake(c.snakeID, name, randomColor())
+	if s, ok := sanctionFor(world.Moderation.Active(time.Now()), c.ip); ok && s.Mute {
+		snake.Name, snake.mutedName = DefaultPlayerName, name
+	}
+	if req.flag {
+		snake.Country = c.country
+	}
+	err := world.Post(func(w *World) {
+		// Drop old snake if reconnecting / respawning; a respawn after a
+		// death gets a head start from the life that ended, kept on the
+		// dead snake under the player's snake ID
+		if old, exists := w.Snakes[c.snakeID]; exists {
+			if old.Alive {
+				w.dropBody(old, 1)
+			} else if n := w.HeadStart.bonus(old.Score); n > 0 {
+				snake.Grow(n) // released over the first ticks, like any growth
+			}
+		}
+		w.removeExtraSnakes(c)
+		w.AddSnake(snake)
+		w.emit(snakeEvent(EventJoin, snake))
+		w.Profiles.startLife(c.snakeID, profile)
+		// Backfill: send the new viewport now instead of leaving the client
+		// on an empty world until the next broadcast
+		snap := w.Snapshot()
+		c.view.reset()
+		msg := w.ViewportState(c, snake, w.Tick, snap.Leaderboard, snap.Minimap)
+		msg.Humans = unmuteBoard(snap.HumanBoard, w.ownNames(c))
+		_ = c.Send(msg)
+		// Commands run before this tick's spawns; let those pop in next broadcast
+		c.stateTick = w.Tick - 1
+	})
+	if err != nil {
+		log.Printf("join refused for %s (session %s): %v", name, c.ID, err)
+		c.Disconnect(CloseServerFull, "Server busy. Please try again.")
+		return
+	}
+	log.Printf("snake joined: %s (%s, session %s)", name, c.snakeID, c.ID)
+}
 
-	// WebSocket handler
-	http.HandleFunc(WebSocketPath, func(w http.ResponseWriter, r *http.Request) {
-		// Extract client IP (handle X-Forwarded-For for reverse proxies)
-		ip := r.Header.Get("X-Forwarded-For")
-		if ip == "" {
-			ip, _, _ = net.SplitHostPort(r.RemoteAddr)
+// postDisconnect unregisters c and queues removal of its snake for the next
+// tick of the loop, which closes stopped when it ends
+func postDisconnect(world *World, conns *ConnManager, c *Conn, stopped <-chan struct{}) {
+	conns.Remove(c.ID)
+	leave := func(w *World) {
+		if snake, exists := w.Snakes[c.snakeID]; exists {
+			w.emit(snakeEvent(EventLeave, snake))
+			if snake.Alive {
+				w.dropBody(snake, 1)
+			}
+			w.RemoveSnake(c.snakeID)
 		}
+		w.removeExtraSnakes(c)
+		// Never-joined sessions still hold the wire ID assigned at welcome
+		w.EntityIDs.Release(c.snakeID)
+	}
+	// A leave can't be refused or the snake would linger; this goroutine
+	// is done with the connection, so it can wait for the queue to drain.
+	// A stopped loop never drains it, and has no world left to linger in.
+	for world.Post(leave) != nil {
+		select {
+		case <-stopped:
+			log.Printf("player disconnected: %s (loop stopped)", c.ID)
+			return
+		case <-time.After(tickDuration()):
+		}
+	}
+	log.Printf("player disconnected: %s", c.ID)
+}
+
+// wsHandler upgrades a request to a player connection in the room it asks
+// for with ?room=, or the least-full room, and runs its read loop
+func wsHandler(rooms *RoomManager, captcha *CaptchaGate, countryHeader string, proxies trustedProxies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Client IP, from X-Forwarded-For only behind a trusted proxy
+		ip := proxies.clientIP(r)
+
+		// The client marks automatic retries, for the reconnect SLO counters
+		reconnect := r.URL.Query().Get("reconnect") == "1"
 
 		ws, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			log.Printf("ws upgrade error: %v", err)
+			slo.reject(rejectUpgrade, reconnect)
 			return
 		}
 
-		// Check limits after upgrade so client can receive error messages
-		if conns.Count() >= MaxPlayers {
-			sendErrorAndClose(ws, "Server full. Please try again later.")
+		// Check bans and limits after upgrade so client can receive error messages
+		// The peer itself counts too, so a banned proxy is refused outright
+		bans := rooms.Rooms()[0].World.Bans
+		_, banned := bans.Banned(ip)
+		if _, peerBanned := bans.Banned(peerIP(r)); banned || peerBanned {
+			log.Printf("refused banned address %s (peer %s)", ip, peerIP(r))
+			slo.reject(rejectBanned, reconnect)
+			sendErrorAndClose(ws, CloseBanned, "You are banned from this server.")
 			return
 		}
+		room, err := rooms.Assign(r.URL.Query().Get("room"))
+		if errors.Is(err, errNoSuchRoom) {
+			slo.reject(rejectNoSuchRoom, reconnect)
+			sendErrorAndClose(ws, CloseNoSuchRoom, "No such room.")
+			return
+		} else if err != nil {
+			slo.reject(rejectServerFull, reconnect)
+			sendErrorAndClose(ws, CloseServerFull, "Server full. Please try again later.")
+			return
+		}
+		slo.accept(reconnect)
+		world, conns := room.World, room.Conns
 
 
 		// Enable per-message write compression at best-speed level
 		ws.EnableWriteCompression(true)
 
 		conn := NewConn(ws)
+		conn.ip = ip
+		conn.country = requestCountry(r, countryHeader)
+		conn.verified.Store(captcha.exempt(r))
+		conn.echo = r.URL.Query().Get("echo") == "1"
 		conns.Add(conn)
-		log.Printf("player connected: %s", conn.ID)
+		rooms.joined(room)
+		if room.closed.Load() {
+			// Placed as the room was being destroyed, after its
+			// connections were closed (see RoomManager.Destroy)
+			conn.Disconnect(CloseRoomClosed, "This room has closed.")
+		}
+		log.Printf("player connected: %s (room %s)", conn.ID, room.Name)
 
 		// Send welcome immediately so client knows its ID and world dimensions
-		_ = conn.Send(WelcomeMsg{
+		welcome := WelcomeMsg{
 			Type:        MsgWelcome,
 			ID:          conn.ID,
+			EntityID:    world.EntityIDs.Assign(conn.snakeID),
 			WorldRadius: WorldRadius,
 			Color:       randomColor(),
-		})
-
-		onJoin := func(c *Conn, name string) {
-			world.mu.Lock()
-			// Drop old snake if reconnecting / respawning
-			if old, exists := world.Snakes[c.ID]; exists {
-				if old.Alive {
-					dropped := old.DropFood()
-					world.AddFood(dropped)
-				}
-			}
-			color := randomColor()
-			snake := NewSnake(c.ID, name, color)
-			world.AddSnake(snake)
-			world.mu.Unlock()
-			log.Printf("snake joined: %s (%s)", name, c.ID)
+			TickRate:    TickRate,
+			Rules:       world.rules(),
+			Lang:        world.Lang,
+		}
+		if !conn.verified.Load() {
+			welcome.Captcha = captcha.dto()
+		}
+		_ = conn.Send(welcome)
+		// Static layout follows once, keeping per-tick state lean
+		_ = conn.Send(world.MapMsg())
+		if motd := world.MOTD.Get(); !motd.empty() {
+			_ = conn.Send(motd.Msg())
+		}
+		if boards := world.Records.Boards(); boards != nil {
+			_ = conn.Send(boards.Msg())
+		}
+		if apple := world.Snapshot().Apple; apple != nil {
+			_ = conn.Send(*apple)
 		}
 
-		onDisconnect := func(c *Conn) {
-			conns.Remove(c.ID)
-			world.mu.Lock()
-			if snake, exists := world.Snakes[c.ID]; exists {
-				if snake.Alive {
-					dropped := snake.DropFood()
-					world.AddFood(dropped)
-				}
-				world.RemoveSnake(c.ID)
+		onJoin := func(c *Conn, req joinRequest) {
+			if captcha.verifyJoin(c, req.captcha) {
+				postJoin(world, c, req)
 			}
-			world.mu.Unlock()
-			log.Printf("player disconnected: %s", c.ID)
+		}
+		onDisconnect := func(c *Conn) {
+			postDisconnect(world, conns, c, room.Loop.stopped)
+			rooms.left(room)
 		}
 
 		// Blocking read loop — runs until client disconnects
 		conn.ReadLoop(world, onJoin, onDisconnect)
-	})
+	}
+}
 
-	// Serve static client files
-	staticDir := StaticDir
-	if env := os.Getenv("SLETHER_STATIC_DIR"); env != "" {
-		staticDir = env
+func main() {
+	if err := configureTickRate(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	// Replay export turns a world log dump into frames instead of serving
+	if path := os.Getenv("SLETHER_REPLAY"); path != "" {
+		if err := exportReplay(path, os.Stdout); err != nil {
+			log.Fatalf("replay: %v", err)
+		}
+		return
 	}
-	fs := http.FileServer(http.Dir(staticDir))
-	http.Handle("/", fs)
+	cohorts, err := botCohortsFromEnv()
+	if err != nil {
+		log.Fatalf("bot cohorts: %v", err)
+	}
+	// Headless training mode replaces the game server entirely
+	if addr := os.Getenv("SLETHER_TRAIN_LISTEN"); addr != "" {
+		log.Fatal(serveTraining(addr, cohorts))
+	}
+	httpsTLS, err := tlsFromEnv()
+	if err != nil {
+		log.Fatalf("tls: %v", err)
+	}
+	listeners, err := openListeners()
+	if err != nil {
+		log.Fatalf("listen: %v", err)
+	}
+	var redirect *http.Server
+	if httpsTLS != nil {
+		listeners = httpsTLS.wrap(listeners)
+		if addr := os.Getenv("SLETHER_HTTP_REDIRECT"); addr != "" {
+			if redirect, err = httpsTLS.serveRedirect(addr, listeners); err != nil {
+				log.Fatalf("listen: %v", err)
+			}
+		}
+	}
+	packs, err := openPackLibrary(os.Getenv("SLETHER_PACK_DIR"))
+	if err != nil {
+		log.Fatalf("pack library: %v", err)
+	}
+	pack, err := packs.Resolve(os.Getenv("SLETHER_CONTENT_PACK"))
+	if err != nil {
+		log.Fatalf("content pack: %v", err)
+	}
+	world := NewWorldFromPack(pack)
+	world.Packs = packs
+	if pack != nil {
+		log.Printf("content pack %q: %d obstacles, %d zones, %d portals, %d scheduled events",
+			pack.Name, len(pack.Obstacles), len(pack.Zones), len(pack.Portals), len(pack.Schedule))
+	}
+	if world.MOTD, err = loadMOTD(os.Getenv("SLETHER_MOTD")); err != nil {
+		log.Fatalf("motd: %v", err)
+	}
+	if world.Profiles, err = openProfileStore(os.Getenv("SLETHER_PROFILES")); err != nil {
+		log.Fatalf("profiles: %v", err)
+	}
+	world.Ranked = rankedFromEnv()
+	if world.Board, err = leaderboardFromEnv(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	if world.Lang, err = communityFromEnv(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	if world.Comeback, err = comebackFromEnv(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	if world.HeadStart, err = headStartFromEnv(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	if world.BotHunt, err = botHuntFromEnv(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	if world.Machines.Policy, err = machinePolicyFromEnv(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	seasonLength, err := seasonLengthFromEnv()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	if world.Seasons, err = openSeasons(os.Getenv("SLETHER_SEASONS"), seasonLength, world.Profiles); err != nil {
+		log.Fatalf("seasons: %v", err)
+	}
+	if world.Records, err = openScoreRecords(os.Getenv("SLETHER_RECORDS")); err != nil {
+		log.Fatalf("records: %v", err)
+	}
+	if world.Events, err = openEventStream(os.Getenv("SLETHER_EVENTS")); err != nil {
+		log.Fatalf("event stream: %v", err)
+	}
+	if world.Log, err = worldLogFromEnv(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	roomCount, err := roomsFromEnv()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	list := make([]*Room, roomCount)
+	for i := range list {
+		w := world
+		if i > 0 {
+			w = newRoomWorld(world, pack)
+		}
+		list[i] = NewRoom(roomName(i), w)
+		list[i].Loop.bots.setCohorts(cohorts)
+	}
+	rooms := NewRoomManager(list...)
+	rooms.pack, rooms.cohorts = pack, cohorts
+	if rooms.hooks, err = roomWebhookFromEnv(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	notifier := newSystemdNotifier()
+	// The default room's loop feeds the watchdog; /readyz covers every room
+	list[0].Loop.watchdog = notifier
+
+	captcha, err := captchaFromEnv()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	proxies, err := trustedProxiesFromEnv()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	srv := newHTTPServer(wsHandler(rooms, captcha, countryHeaderFromEnv(), proxies), rooms, newAdminAPI(rooms), captcha.cspSources(), proxies)
+
+	// Start the game loops in background
+	rooms.Run()
+	go world.Seasons.run(rooms)
+	go world.Records.run(rooms)
+
+	// READY once every loop has ticked; the listeners are already bound
+	go func() {
+		for !rooms.Ready() {
+			time.Sleep(10 * time.Millisecond)
+		}
+		notifier.Ready()
+	}()
 
-	// Start game loop in background
-	go loop.Run()
+	// Re-read the MOTD file on SIGHUP and push it to everyone connected;
+	// certificate files are re-read too, for renewals
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			if httpsTLS != nil {
+				if err := httpsTLS.reload(); err != nil {
+					log.Printf("tls reload: %v", err)
+				}
+			}
+			if err := world.MOTD.Reload(); err != nil {
+				log.Printf("motd reload: %v", err)
+				continue
+			}
+			log.Printf("motd reloaded")
+			for _, r := range rooms.Rooms() {
+				r.Conns.BroadcastMOTD(world.MOTD.Get())
+			}
+		}
+	}()
+
+	// Shut down gracefully on SIGINT/SIGTERM (see shutdown.go)
+	done := make(chan struct{})
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		log.Printf("received %v, shutting down", <-sig)
+		notifier.Stopping()
+		gracefulShutdown(sig, []*http.Server{srv, redirect}, rooms, world)
+		close(done)
+	}()
 
-	log.Printf("server listening on %s (circular world r=%.0f)", ServerPort, WorldRadius)
-	if err := http.ListenAndServe(ServerPort, nil); err != nil {
+	log.Printf("circular world r=%.0f, %d room(s)", WorldRadius, roomCount)
+	if err := serveAll(srv, listeners); err != nil {
 		log.Fatalf("server error: %v", err)
 	}
+	<-done
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// metricsWriter emits the Prometheus text exposition format
+type metricsWriter struct {
+	w io.Writer
+}
+
+// family writes the HELP and TYPE header for a metric
+func (m metricsWriter) family(name, typ, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes one value; labels alternate name, value
+func (m metricsWriter) sample(name string, value any, labels ...string) {
+	if len(labels) == 0 {
+		fmt.Fprintf(m.w, "%s %v\n", name, value)
+		return
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+	fmt.Fprintf(m.w, "%s{%s} %v\n", name, strings.Join(pairs, ","), value)
+}
+
+// handleMetrics serves counts, the score economy and the SLO counters for
+// Prometheus scraping (configure the scrape with the admin bearer token)
+func (a *AdminAPI) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	snap := a.world.Snapshot()
+	unlock := a.world.rlock("admin.metrics")
+	eco := a.world.Economy.Report().Total
+	machines := len(a.world.Machines.Flags())
+	unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	m := metricsWriter{w}
+
+	m.family("slether_tick", "counter", "Simulation ticks completed.")
+	m.sample("slether_tick", snap.Tick)
+	m.family("slether_players", "gauge", "Connected player sessions.")
+	m.sample("slether_players", snap.Players)
+	m.family("slether_snakes_alive", "gauge", "Alive snakes, players and bots.")
+	m.sample("slether_snakes_alive", snap.AliveSnakes)
+	m.family("slether_bots", "gauge", "Alive bot snakes.")
+	m.sample("slether_bots", snap.Bots)
+	m.family("slether_bot_ai_cadence", "gauge", "Ticks between bot AI decisions; above 1 when ticks run long.")
+	m.sample("slether_bot_ai_cadence", snap.BotCadence)
+	m.family("slether_food", "gauge", "Food items in the world.")
+	m.sample("slether_food", snap.Food)
+	lite := 0
+	for _, c := range a.conns.Snapshot() {
+		if c.health.lite.Load() {
+			lite++
+		}
+	}
+	m.family("slether_clients_lite", "gauge", "Connections in lite mode because they can't keep up.")
+	m.sample("slether_clients_lite", lite)
+	m.family("slether_machine_clients", "gauge", "Connected players flagged as machine clients.")
+	m.sample("slether_machine_clients", machines)
+
+	m.family("slether_score_created_total", "counter", "Score credited to snakes, by source.")
+	m.sample("slether_score_created_total", eco.Spawn, "source", "spawn")
+	m.sample("slether_score_created_total", eco.Created-eco.Spawn-eco.Bonus-eco.Objective-eco.Risk-eco.Comeback, "source", "food")
+	m.sample("slether_score_created_total", eco.Bonus, "source", "shutdown_bonus")
+	m.sample("slether_score_created_total", eco.Objective, "source", "golden_apple")
+	m.sample("slether_score_created_total", eco.Risk, "source", "risk_zone")
+	m.sample("slether_score_created_total", eco.Comeback, "source", "comeback")
+	m.family("slether_score_eaten_total", "counter", "Food value credited to snakes, by food level.")
+	for _, level := range slices.Sorted(maps.Keys(eco.Eaten)) {
+		m.sample("slether_score_eaten_total", eco.Eaten[level], "level", fmt.Sprint(level))
+	}
+	m.family("slether_score_destroyed_total", "counter", "Score taken from snakes, by cause.")
+	m.sample("slether_score_destroyed_total", eco.Boost, "cause", "boost")
+	m.sample("slether_score_destroyed_total", eco.Damage, "cause", "damage")
+	m.sample("slether_score_destroyed_total", eco.Death, "cause", "death")
+	m.sample("slether_score_destroyed_total", eco.Despawn, "cause", "despawn")
+	m.family("slether_score_dropped_total", "counter", "Destroyed score returned to the world as food, by cause.")
+	m.sample("slether_score_dropped_total", eco.BoostDropped, "cause", "boost")
+	m.sample("slether_score_dropped_total", eco.DamageDropped, "cause", "damage")
+	m.sample("slether_score_dropped_total", eco.DeathDropped, "cause", "death")
+	m.family("slether_food_withheld_total", "counter", "Food value eaten but not credited because of sanctions.")
+	m.sample("slether_food_withheld_total", eco.Withheld)
+
+	writeSLO(m, a.rooms)
+}
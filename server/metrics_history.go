@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MinuteMetrics aggregates one wall clock minute of ticks. Population
+// fields are means over the minute's ticks.
+type MinuteMetrics struct {
+	Time       time.Time `json:"time"` // start of the minute
+	Ticks      int       `json:"ticks"`
+	TickMeanMS float64   `json:"tick_mean_ms"`
+	TickP95MS  float64   `json:"tick_p95_ms"`
+	TickMaxMS  float64   `json:"tick_max_ms"`
+	Players    float64   `json:"players"`
+	PlayersMax int       `json:"players_max"`
+	Snakes     float64   `json:"snakes"` // alive, players and bots
+	Bots       float64   `json:"bots"`
+	Food       float64   `json:"food"`
+	Kills      int       `json:"kills"`
+	Deaths     int       `json:"deaths"` // kills plus boundary and obstacle deaths
+}
+
+// minuteAccum collects the minute in progress; game-loop goroutine only
+type minuteAccum struct {
+	start                       time.Time
+	durations                   []time.Duration
+	players, snakes, bots, food int
+	playersMax                  int
+	kills, deaths               int
+}
+
+// MetricsHistory keeps per-minute aggregates of population, tick time and
+// kills for MetricsHistoryMinutes, so deployments without a metrics stack
+// still have history to tune against. It is fed from the game loop after
+// each tick; readers take its own lock.
+type MetricsHistory struct {
+	cur minuteAccum
+
+	mu      sync.Mutex
+	minutes []MinuteMetrics // completed, oldest first
+}
+
+// NewMetricsHistory starts with the current minute
+func NewMetricsHistory() *MetricsHistory {
+	return &MetricsHistory{cur: minuteAccum{
+		start:     time.Now().Truncate(time.Minute),
+		durations: make([]time.Duration, 0, int(time.Minute/tickDuration())),
+	}}
+}
+
+// record adds one tick: its end-of-tick snapshot and how long it took.
+// Game-loop goroutine only.
+func (h *MetricsHistory) record(snap *TickSnapshot, d time.Duration) {
+	if minute := snap.Time.Truncate(time.Minute); minute.After(h.cur.start) {
+		h.roll(minute)
+	}
+	c := &h.cur
+	c.durations = append(c.durations, d)
+	c.players += snap.Players
+	c.snakes += snap.AliveSnakes
+	c.bots += snap.Bots
+	c.food += snap.Food
+	c.playersMax = max(c.playersMax, snap.Players)
+	c.kills += len(snap.KillFeed)
+	c.deaths += len(snap.Deaths)
+}
+
+// roll closes the minute in progress and starts the one at minute
+func (h *MetricsHistory) roll(minute time.Time) {
+	c := &h.cur
+	if n := len(c.durations); n > 0 {
+		ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+		var sum time.Duration
+		for _, d := range c.durations {
+			sum += d
+		}
+		slices.Sort(c.durations)
+		m := MinuteMetrics{
+			Time:       c.start,
+			Ticks:      n,
+			TickMeanMS: ms(sum / time.Duration(n)),
+			TickP95MS:  ms(c.durations[(n*95-1)/100]),
+			TickMaxMS:  ms(c.durations[n-1]),
+			Players:    float64(c.players) / float64(n),
+			PlayersMax: c.playersMax,
+			Snakes:     float64(c.snakes) / float64(n),
+			Bots:       float64(c.bots) / float64(n),
+			Food:       float64(c.food) / float64(n),
+			Kills:      c.kills,
+			Deaths:     c.deaths,
+		}
+		h.mu.Lock()
+		h.minutes = append(h.minutes, m)
+		if len(h.minutes) > MetricsHistoryMinutes {
+			h.minutes = h.minutes[len(h.minutes)-MetricsHistoryMinutes:]
+		}
+		h.mu.Unlock()
+	}
+	*c = minuteAccum{start: minute, durations: c.durations[:0]}
+}
+
+// Since returns completed minutes starting at or after since, oldest first
+func (h *MetricsHistory) Since(since time.Time) []MinuteMetrics {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i, _ := slices.BinarySearchFunc(h.minutes, since, func(m MinuteMetrics, t time.Time) int {
+		return m.Time.Compare(t)
+	})
+	return append([]MinuteMetrics{}, h.minutes[i:]...)
+}
+
+// handleHistory returns per-minute aggregates as a flat JSON array, oldest
+// first, for a Grafana JSON datasource or a quick script. ?from= (RFC 3339 or
+// unix seconds) or ?minutes= limits it to recent history; the default is
+// everything kept.
+func (a *AdminAPI) handleHistory(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	q := r.URL.Query()
+	if v := q.Get("from"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			since = t
+		} else if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+			since = time.Unix(sec, 0)
+		} else {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from must be RFC 3339 or unix seconds"})
+			return
+		}
+	} else if v := q.Get("minutes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "minutes must be a positive integer"})
+			return
+		}
+		since = time.Now().Truncate(time.Minute).Add(-time.Duration(n) * time.Minute)
+	}
+	writeJSON(w, http.StatusOK, a.loop.history.Since(since))
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Sanction is a set of shadow penalties on one player. They degrade the
+// experience quietly instead of an obvious kick or ban.
+type Sanction struct {
+	// Mute hides the player's name from everyone but themselves: others see
+	// DefaultPlayerName on the snake, the leaderboard and the kill feed.
+	// Applied from the player's next join or respawn.
+	Mute bool `json:"mute"`
+	// InputLagMS delays the player's steering/boost input (0..ModerationMaxInputLag)
+	InputLagMS int `json:"input_lag_ms"`
+	// ScoreFactor scales food value the player eats (0..1; 0 or 1 = unchanged)
+	ScoreFactor float64   `json:"score_factor"`
+	Expires     time.Time `json:"expires"`
+}
+
+// Moderation holds active sanctions keyed by network, like BanList (a
+// single address is a /32 or /128), so reconnecting doesn't shed one.
+// Safe for concurrent use — the admin API writes, the game loop reads a
+// snapshot once per tick.
+type Moderation struct {
+	mu    sync.Mutex
+	byNet map[netip.Prefix]Sanction
+}
+
+// NewModeration creates an empty sanction table
+func NewModeration() *Moderation {
+	return &Moderation{byNet: make(map[netip.Prefix]Sanction)}
+}
+
+// Set replaces network's sanction, clamping values into range
+func (m *Moderation) Set(network netip.Prefix, s Sanction) Sanction {
+	s.InputLagMS = max(0, min(s.InputLagMS, int(ModerationMaxInputLag/time.Millisecond)))
+	if s.ScoreFactor <= 0 || s.ScoreFactor > 1 {
+		s.ScoreFactor = 1
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byNet[network] = s
+	return s
+}
+
+// Clear lifts network's sanction; returns false if there was none
+func (m *Moderation) Clear(network netip.Prefix) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.byNet[network]
+	delete(m.byNet, network)
+	return ok
+}
+
+// Active returns unexpired sanctions, dropping expired ones. Returns nil when
+// there are none so the per-tick check stays cheap.
+func (m *Moderation) Active(now time.Time) map[netip.Prefix]Sanction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var active map[netip.Prefix]Sanction
+	for network, s := range m.byNet {
+		if now.After(s.Expires) {
+			delete(m.byNet, network)
+			continue
+		}
+		if active == nil {
+			active = make(map[netip.Prefix]Sanction, len(m.byNet))
+		}
+		active[network] = s
+	}
+	return active
+}
+
+// sanctionFor returns the sanction in active covering a client address (see
+// client_ip.go), the narrowest network's when several do; an empty or
+// unparsable address is never sanctioned
+func sanctionFor(active map[netip.Prefix]Sanction, ip string) (Sanction, bool) {
+	if len(active) == 0 {
+		return Sanction{}, false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return Sanction{}, false
+	}
+	addr = addr.Unmap()
+	var found Sanction
+	bits := -1
+	for network, s := range active {
+		if network.Bits() > bits && network.Contains(addr) {
+			found, bits = s, network.Bits()
+		}
+	}
+	return found, bits >= 0
+}
+
+// laggedInput returns the input the loop should apply this tick: the live
+// input, or with lagMS > 0 the one from that long ago. Game-loop goroutine only.
+func (c *Conn) laggedInput(lagMS int) PlayerInput {
+	return delayInput(&c.inputLag, c.GetInput(), lagMS)
+}
+
+// delayInput pushes inp into buf and returns the input from lagMS ago
+func delayInput(buf *[]PlayerInput, inp PlayerInput, lagMS int) PlayerInput {
+	if lagMS <= 0 {
+		*buf = (*buf)[:0]
+		return inp
+	}
+	lag := ticksFor(time.Duration(lagMS) * time.Millisecond)
+	*buf = append(*buf, inp)
+	if n := len(*buf) - (lag + 1); n > 0 {
+		*buf = append((*buf)[:0], (*buf)[n:]...)
+	}
+	return (*buf)[0]
+}
+
+// ownNames maps the wire IDs of c's snakes to c's own name if c is
+// shadow-muted, nil if not. Extra snakes are granted under the main
+// snake's name and dropped when it rejoins, so it speaks for them all.
+// Caller must hold w.mu.
+func (w *World) ownNames(c *Conn) map[uint32]string {
+	if s, ok := w.Snakes[c.snakeID]; !ok || s.mutedName == "" {
+		return nil
+	}
+	own := make(map[uint32]string)
+	for _, id := range c.SnakeIDs() {
+		if s, ok := w.Snakes[id]; ok && s.mutedName != "" {
+			own[s.NetID] = s.mutedName
+		}
+	}
+	return own
+}
+
+// unmuteBoard returns board with the rows of own's snakes named as their
+// player sees them; board is shared between viewers, so it's copied first
+func unmuteBoard(board []LeaderboardEntry, own map[uint32]string) []LeaderboardEntry {
+	var out []LeaderboardEntry
+	for i, e := range board {
+		if name, ok := own[e.ID]; ok {
+			if out == nil {
+				out = slices.Clone(board)
+			}
+			out[i].Name = name
+		}
+	}
+	if out == nil {
+		return board
+	}
+	return out
+}
+
+// unmuteKillFeed records how the players of shadow-muted snakes named in
+// kill feed entry i (killer, victim and helper, nil for no assist) see it:
+// with their own name. Caller must hold w.mu.
+func (gl *GameLoop) unmuteKillFeed(snap *TickSnapshot, i int, killer, victim, helper *Snake) {
+	for _, s := range []*Snake{killer, victim, helper} {
+		if s == nil || s.mutedName == "" {
+			continue
+		}
+		c, ok := gl.conns.Owner(s.ID)
+		if !ok {
+			continue
+		}
+		own, ok := snap.OwnKillFeed[c.ID][i]
+		if !ok {
+			own = snap.KillFeed[i]
+		}
+		switch s {
+		case killer:
+			own.Killer = s.mutedName
+		case victim:
+			own.Victim = s.mutedName
+		default:
+			own.Assist = s.mutedName
+		}
+		if snap.OwnKillFeed == nil {
+			snap.OwnKillFeed = make(map[string]map[int]KillFeedMsg)
+		}
+		if snap.OwnKillFeed[c.ID] == nil {
+			snap.OwnKillFeed[c.ID] = make(map[int]KillFeedMsg)
+		}
+		snap.OwnKillFeed[c.ID][i] = own
+	}
+}
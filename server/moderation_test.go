@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSanctionFollowsAddress sets a sanction on a network and a narrower
+// one on an address in it, then checks reconnecting players keep theirs and
+// a shadow-muted player's name is hidden from everyone but themselves
+func TestSanctionFollowsAddress(t *testing.T) {
+	quietLogs(t)
+	world := newEmptyWorld()
+	conns := NewConnManager()
+	gl := newGameLoop(world, conns, 0)
+	gl.ambientFood = false
+
+	expires := time.Now().Add(time.Hour)
+	network, _ := parseBanTarget("203.0.113.0/24")
+	world.Moderation.Set(network, Sanction{InputLagMS: 500, Expires: expires})
+	host, _ := parseBanTarget("203.0.113.7")
+	world.Moderation.Set(host, Sanction{Mute: true, ScoreFactor: 0.5, Expires: expires})
+
+	join := func(id, ip, name string) *Conn {
+		c := NewConn(newMockWS())
+		c.ID, c.snakeID, c.ip = id, id+"-snake", ip
+		conns.Add(c)
+		postJoin(world, c, joinRequest{name: name})
+		gl.tick()
+		return c
+	}
+	muted := join("s1", "203.0.113.7", "Alice")
+	other := join("s2", "203.0.113.9", "Bob")
+	clean := join("s3", "198.51.100.1", "Carol")
+
+	tests := []struct {
+		c    *Conn
+		want Sanction
+		ok   bool
+	}{
+		{muted, Sanction{Mute: true, ScoreFactor: 0.5, Expires: expires}, true},
+		{other, Sanction{InputLagMS: 500, ScoreFactor: 1, Expires: expires}, true},
+		{clean, Sanction{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := gl.sanctionOf(tt.c.snakeID)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: sanction %+v, %v; want %+v, %v", tt.c.ip, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// A new session from the same address is still sanctioned
+	conns.Remove(muted.ID)
+	again := join("s4", "203.0.113.7", "Alice")
+	if s, ok := gl.sanctionOf(again.snakeID); !ok || !s.Mute {
+		t.Fatalf("reconnect shed the sanction: %+v, %v", s, ok)
+	}
+
+	snake := world.Snakes[again.snakeID]
+	if snake.Name != DefaultPlayerName {
+		t.Fatalf("muted snake is named %q for everyone, want %q", snake.Name, DefaultPlayerName)
+	}
+	board := world.Snapshot().Leaderboard
+	named := func(board []LeaderboardEntry, name string) bool {
+		for _, e := range board {
+			if e.ID == snake.NetID {
+				return e.Name == name
+			}
+		}
+		return false
+	}
+	if !named(unmuteBoard(board, world.ownNames(again)), "Alice") {
+		t.Error("muted player doesn't see their own name on the leaderboard")
+	}
+	if !named(unmuteBoard(board, world.ownNames(clean)), DefaultPlayerName) || !named(board, DefaultPlayerName) {
+		t.Error("another player sees the muted name on the leaderboard")
+	}
+	again.view.reset() // a keyframe, whose entries carry names
+	msg := world.ViewportState(again, snake, world.Tick, board, nil)
+	for _, dto := range msg.Snakes {
+		if dto.ID == snake.NetID && dto.Name != "Alice" {
+			t.Errorf("muted player sees their own snake as %q", dto.Name)
+		}
+	}
+
+	snap := &TickSnapshot{KillFeed: []KillFeedMsg{{Type: MsgKill, Killer: snake.Name, Victim: "Bob"}}}
+	gl.unmuteKillFeed(snap, 0, snake, world.Snakes[other.snakeID], nil)
+	if own := snap.OwnKillFeed[again.ID][0]; own.Killer != "Alice" || own.Victim != "Bob" {
+		t.Errorf("muted player's kill feed entry %+v, want their own name", own)
+	}
+	if _, ok := snap.OwnKillFeed[other.ID]; ok {
+		t.Error("the victim got a kill feed entry with the muted name")
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// MOTD is the operator's branding and rules text shown on the join screen:
+// server name, region, mode, free text and community links. It is the JSON
+// format of the SLETHER_MOTD file and of the admin API.
+type MOTD struct {
+	Name   string     `json:"name"`
+	Region string     `json:"region,omitempty"`
+	Mode   string     `json:"mode,omitempty"`
+	Text   string     `json:"text,omitempty"`
+	Links  []MOTDLink `json:"links,omitempty"`
+}
+
+// MOTDLink is one community link (Discord, rules page, ...)
+type MOTDLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// validate checks lengths and that links are plain http(s) URLs, since
+// clients render them as clickable
+func (m MOTD) validate() error {
+	for field, v := range map[string]string{"name": m.Name, "region": m.Region, "mode": m.Mode} {
+		if utf8.RuneCountInString(v) > MOTDMaxFieldLength {
+			return fmt.Errorf("%s longer than %d characters", field, MOTDMaxFieldLength)
+		}
+	}
+	if utf8.RuneCountInString(m.Text) > MOTDMaxTextLength {
+		return fmt.Errorf("text longer than %d characters", MOTDMaxTextLength)
+	}
+	if len(m.Links) > MOTDMaxLinks {
+		return fmt.Errorf("more than %d links", MOTDMaxLinks)
+	}
+	for _, l := range m.Links {
+		if strings.TrimSpace(l.Label) == "" || utf8.RuneCountInString(l.Label) > MOTDMaxFieldLength {
+			return fmt.Errorf("link label must be 1-%d characters", MOTDMaxFieldLength)
+		}
+		u, err := url.Parse(l.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("link %q: want an absolute http(s) URL", l.Label)
+		}
+	}
+	return nil
+}
+
+// empty reports whether there is nothing to show
+func (m MOTD) empty() bool {
+	return m.Name == "" && m.Region == "" && m.Mode == "" && m.Text == "" && len(m.Links) == 0
+}
+
+// Msg converts the MOTD to its wire form
+func (m MOTD) Msg() MotdMsg {
+	msg := MotdMsg{Type: MsgMOTD, Name: m.Name, Region: m.Region, Mode: m.Mode, Text: m.Text}
+	for _, l := range m.Links {
+		msg.Links = append(msg.Links, MotdLinkDTO{Label: l.Label, URL: l.URL})
+	}
+	return msg
+}
+
+// MOTDStore holds the current MOTD, optionally backed by a JSON file that
+// Reload re-reads (SIGHUP) and Set writes back, so admin edits survive a
+// restart. Safe for concurrent use.
+type MOTDStore struct {
+	mu   sync.Mutex
+	path string // "" = memory only
+	cur  MOTD
+}
+
+// loadMOTD reads path if set. A missing file starts empty and is created on
+// the first Set; a malformed one is an error.
+func loadMOTD(path string) (*MOTDStore, error) {
+	s := &MOTDStore{path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the current MOTD
+func (s *MOTDStore) Get() MOTD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cur
+}
+
+// Reload re-reads the backing file; the current MOTD is kept on error
+func (s *MOTDStore) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var m MOTD
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("%s: %w", s.path, err)
+	}
+	if err := m.validate(); err != nil {
+		return fmt.Errorf("%s: %w", s.path, err)
+	}
+	s.mu.Lock()
+	s.cur = m
+	s.mu.Unlock()
+	return nil
+}
+
+// Set validates and installs m, writing it to the backing file first (via a
+// temp file and rename, so a crash never leaves half a file)
+func (s *MOTDStore) Set(m MOTD) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path != "" {
+		data, _ := json.MarshalIndent(m, "", "  ")
+		tmp, err := os.CreateTemp(filepath.Dir(s.path), ".motd-*")
+		if err != nil {
+			return err
+		}
+		_, err = tmp.Write(append(data, '\n'))
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), s.path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	s.cur = m
+	return nil
+}
+
+// BroadcastMOTD sends m to every connected player, e.g. after an edit
+func (m *ConnManager) BroadcastMOTD(motd MOTD) {
+	msg := motd.Msg()
+	for _, c := range m.Snapshot() {
+		_ = c.Send(msg)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// mover is a live snake and the steering it applies this tick. For a
+// player's own snake, seq is the input's sequence number and echo the
+// connection, if it asked for input echoes (see input_echo.go).
+type mover struct {
+	snake *Snake
+	angle float64
+	boost bool
+	seq   uint32
+	echo  *Conn
+}
+
+// gatherInputs asks every live snake's controller for this tick's steering
+// before anything moves (see controller.go). A snake with neither a bot nor
+// a player behind it moves only while it has a controller set. Snakes are
+// ordered by ID so movement, and the boost food it drops, doesn't depend on
+// map order.
+// Caller must hold w.mu.Lock.
+func (gl *GameLoop) gatherInputs() []mover {
+	w := gl.world
+	for id := range gl.controllers {
+		if s, ok := w.Snakes[id]; !ok || !s.Alive {
+			delete(gl.controllers, id)
+		}
+	}
+	view := w.LiveView()
+	movers := make([]mover, 0, len(w.Snakes))
+	overridden := 0
+	add := func(s *Snake, c Controller) {
+		if o, ok := gl.controllers[s.ID]; ok {
+			c, overridden = o, overridden+1
+		}
+		angle, boost := c.Decide(view, s)
+		movers = append(movers, mover{snake: s, angle: angle, boost: boost})
+	}
+	for id, bot := range gl.bots.bots {
+		if s, ok := w.Snakes[id]; ok && s.Alive {
+			add(s, BotController{gl.bots, bot})
+		}
+	}
+	for _, c := range gl.conns.Snapshot() {
+		sanction, _ := sanctionFor(gl.sanctions, c.ip)
+		lag := sanction.InputLagMS
+		for slot, id := range c.SnakeIDs() {
+			if s, ok := w.Snakes[id]; ok && s.Alive {
+				add(s, PlayerController{c, slot, lag})
+				if _, scripted := gl.controllers[id]; slot == 0 && !scripted {
+					m := &movers[len(movers)-1]
+					m.seq = c.appliedSeq
+					if c.echo {
+						m.echo = c
+					}
+				}
+			}
+		}
+	}
+	if overridden < len(gl.controllers) {
+		driven := make(map[*Snake]bool, len(movers))
+		for _, m := range movers {
+			driven[m.snake] = true
+		}
+		for id := range gl.controllers {
+			if s := w.Snakes[id]; !driven[s] {
+				add(s, nil)
+			}
+		}
+	}
+	slices.SortFunc(movers, func(a, b mover) int { return strings.Compare(a.snake.ID, b.snake.ID) })
+	return movers
+}
+
+// moveSnakes steers and moves every mover under the same rules and returns
+// the IDs of those that crossed the boundary. They stay alive until the
+// loop processes deaths, so bots and players die there the same way.
+// Caller must hold w.mu.Lock.
+func (gl *GameLoop) moveSnakes(movers []mover) map[string]bool {
+	boundaryDeaths := map[string]bool{}
+	for _, m := range movers {
+		gl.world.steer(m.snake, m.angle, m.boost)
+		gl.world.comebackSpeed(m.snake)
+		if m.snake.Move() {
+			boundaryDeaths[m.snake.ID] = true
+		}
+		head := m.snake.Head()
+		gl.world.Log.record(WorldLogEntry{Type: WorldLogInput, Snake: m.snake.ID, Angle: m.angle, Heading: m.snake.Angle, Boost: m.boost, Seq: m.seq,
+			X: head.X, Y: head.Y, Length: len(m.snake.Segments)})
+		if m.echo != nil {
+			_ = m.echo.Send(inputEcho(gl.world.Tick, &m))
+		}
+	}
+	return boundaryDeaths
+}
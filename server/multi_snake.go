@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// A connection can control more than its own snake for special modes, e.g.
+// a hydra power-up or a co-op minigame. Its own snake is slot 0; extra
+// snakes are slots 1 and up. The ConnManager maps every snake ID back to
+// its connection, since snake and session IDs differ. Input messages
+// name the slot they steer ("s"); an extra snake that has had no input of
+// its own mirrors slot 0, so clients that only know one snake still steer
+// every head. Extra snakes last until they die or the player respawns or
+// leaves. The viewport follows the player's own snake while it lives, then
+// the first live extra one, and the death screen only shows once none are
+// left.
+
+// snakeSlot is one extra snake a connection controls
+type snakeSlot struct {
+	id       string
+	input    PlayerInput
+	steered  bool          // has had input of its own
+	inputLag []PlayerInput // game-loop goroutine only
+}
+
+var (
+	errNoSnake   = errors.New("player has no live snake")
+	errSlotsFull = fmt.Errorf("player already controls %d snakes", MultiSnakeMax)
+)
+
+// SnakeIDs lists the snakes c controls, its own first
+func (c *Conn) SnakeIDs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]string, 0, 1+len(c.extra))
+	ids = append(ids, c.snakeID)
+	for _, s := range c.extra {
+		ids = append(ids, s.id)
+	}
+	return ids
+}
+
+// setSlotInput steers extra snake slot; out-of-range slots are ignored
+func (c *Conn) setSlotInput(slot int, angle float64, boost bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if slot < 1 || slot > len(c.extra) {
+		return
+	}
+	s := c.extra[slot-1]
+	s.input, s.steered = PlayerInput{Angle: angle, Boost: boost}, true
+}
+
+// slotInput returns extra snake slot's input, delayed by lagMS, or the
+// primary input while it has none of its own. Game-loop goroutine only.
+func (c *Conn) slotInput(slot, lagMS int) PlayerInput {
+	c.mu.Lock()
+	s := c.extra[slot-1]
+	inp := s.input
+	if !s.steered {
+		inp = c.input
+	}
+	c.mu.Unlock()
+	return delayInput(&s.inputLag, inp, lagMS)
+}
+
+// takeExtra detaches every extra snake from c and returns their IDs
+func (c *Conn) takeExtra() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]string, len(c.extra))
+	for i, s := range c.extra {
+		ids[i] = s.id
+	}
+	c.extra = nil
+	return ids
+}
+
+// attach records that conn connID controls snake snakeID
+func (m *ConnManager) attach(snakeID, connID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.owners[snakeID] = connID
+}
+
+// Owner returns the connection controlling snake id, its own or an extra one
+func (m *ConnManager) Owner(id string) (*Conn, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, ok := m.conns[m.owners[id]]
+	return c, ok
+}
+
+// grantSnake spawns an extra snake for c in the next tick, named and
+// colored like its own. Blocks until the loop has applied it; returns the
+// new snake's slot.
+func grantSnake(world *World, conns *ConnManager, c *Conn) (int, error) {
+	type result struct {
+		slot int
+		err  error
+	}
+	done := make(chan result, 1)
+	err := world.Post(func(w *World) {
+		own, ok := w.Snakes[c.snakeID]
+		if !ok || !own.Alive {
+			done <- result{err: errNoSnake}
+			return
+		}
+		c.mu.Lock()
+		if 1+len(c.extra) >= MultiSnakeMax {
+			c.mu.Unlock()
+			done <- result{err: errSlotsFull}
+			return
+		}
+		c.extraSeq++
+		id := fmt.Sprintf("%s/%d", c.snakeID, c.extraSeq)
+		c.extra = append(c.extra, &snakeSlot{id: id})
+		slot := len(c.extra)
+		c.mu.Unlock()
+
+		conns.attach(id, c.ID)
+		snake := NewSnake(id, own.Name, own.Color)
+		snake.mutedName = own.mutedName
+		w.AddSnake(snake)
+		w.emit(snakeEvent(EventJoin, snake))
+		log.Printf("snake %s (%s) granted extra snake %s", own.Name, c.ID, id)
+		done <- result{slot: slot}
+	})
+	if err != nil {
+		return 0, err
+	}
+	r := <-done
+	return r.slot, r.err
+}
+
+// handleGrantSnake gives a player an extra snake to steer, for trying out
+// multi-snake modes
+func (a *AdminAPI) handleGrantSnake(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	c, ok := a.conns.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such player"})
+		return
+	}
+	slot, err := grantSnake(a.world, a.conns, c)
+	if errors.Is(err, errCommandQueueFull) {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		return
+	} else if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	a.record(r, "player.grant_snake", map[string]any{"id": id, "slot": slot})
+	writeJSON(w, http.StatusOK, map[string]int{"slot": slot})
+}
+
+// removeExtraSnakes drops c's extra snakes from the world, scattering any
+// live body as food. Caller must hold w.mu.Lock.
+func (w *World) removeExtraSnakes(c *Conn) {
+	for _, id := range c.takeExtra() {
+		snake, ok := w.Snakes[id]
+		if !ok {
+			continue
+		}
+		w.emit(snakeEvent(EventLeave, snake))
+		if snake.Alive {
+			w.dropBody(snake, 1)
+		}
+		w.RemoveSnake(id)
+	}
+}
+
+// followedSnake is the snake c's viewport follows: its own while alive,
+// else the first live extra one. Caller must hold at least w.rlock.
+func (w *World) followedSnake(c *Conn) (*Snake, bool) {
+	for _, id := range c.SnakeIDs() {
+		if s, ok := w.Snakes[id]; ok && s.Alive {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// sendDeaths tells players whose last live snake died this tick. Run after
+// the tick's lock is released.
+func (gl *GameLoop) sendDeaths(snap *TickSnapshot) {
+	if len(snap.Deaths) == 0 {
+		return
+	}
+	w := gl.world
+	msgs := make(map[*Conn]DeathMsg)
+	unlock := w.rlock("sendDeaths")
+	for victimID, msg := range snap.Deaths {
+		c, ok := gl.conns.Owner(victimID)
+		if !ok {
+			continue
+		}
+		if _, alive := w.followedSnake(c); !alive {
+			msgs[c] = msg
+		}
+	}
+	unlock()
+	for c, msg := range msgs {
+		_ = c.Send(msg)
+	}
+}
+
+// sanctionOf returns the active sanction on the player controlling snake
+// id, if any. Game-loop goroutine only.
+func (gl *GameLoop) sanctionOf(id string) (Sanction, bool) {
+	if len(gl.sanctions) == 0 {
+		return Sanction{}, false
+	}
+	c, ok := gl.conns.Owner(id)
+	if !ok {
+		return Sanction{}, false
+	}
+	return sanctionFor(gl.sanctions, c.ip)
+}
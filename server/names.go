@@ -0,0 +1,202 @@
+package main
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Player names may be in any script, but only as visible text. sanitizeName
+// strips control, format (bidi overrides, zero-width characters),
+// private-use and replacement characters, folds whitespace runs to one
+// space, drops combining marks with no base and caps the marks stacked on
+// one character, then keeps the first MaxNameLength user-perceived
+// characters (grapheme clusters, so an accented letter or a flag emoji
+// counts once). On top of that, a new name that looks like someone on the
+// leaderboard without being the same name is refused (see impersonates).
+
+const (
+	zwnj = '\u200c' // kept: needed by Persian and Indic scripts
+	zwj  = '\u200d' // kept: joins emoji sequences
+)
+
+// sanitizeName returns the visible, length-capped form of a join name; it
+// may be "" when nothing visible is left
+func sanitizeName(raw string) string {
+	var b strings.Builder
+	space := true // drop leading spaces
+	for _, r := range raw {
+		switch {
+		case r == zwnj || r == zwj:
+		case unicode.IsSpace(r) || unicode.Is(unicode.Zs, r):
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		case r == utf8.RuneError || !unicode.IsGraphic(r) || unicode.Is(unicode.Co, r):
+			continue // controls, bidi and other format characters, private use
+		}
+		b.WriteRune(r)
+		space = false
+	}
+
+	var out strings.Builder
+	n, runes := 0, 0
+	for _, g := range graphemes(strings.TrimSpace(b.String())) {
+		first, _ := utf8.DecodeRuneInString(g)
+		if isMark(first) || first == zwj || first == zwnj {
+			continue // a mark or joiner with nothing to attach to
+		}
+		g = capMarks(g)
+		runes += utf8.RuneCountInString(g)
+		if n == MaxNameLength || runes > MaxNameRunes {
+			break
+		}
+		out.WriteString(g)
+		n++
+	}
+	return strings.TrimRight(strings.TrimSpace(out.String()), string([]rune{zwj, zwnj}))
+}
+
+// isMark reports combining marks, which draw on the preceding character
+func isMark(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Mc, unicode.Me)
+}
+
+// extendsCluster reports runes that belong to the grapheme before them:
+// marks, joiners, variation selectors, emoji skin tones and tag characters
+func extendsCluster(r rune) bool {
+	return isMark(r) || r == zwj || r == zwnj ||
+		unicode.Is(unicode.Variation_Selector, r) ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) ||
+		(r >= 0xe0020 && r <= 0xe007f)
+}
+
+func isRegionalIndicator(r rune) bool { return r >= 0x1f1e6 && r <= 0x1f1ff }
+
+// graphemes splits s into user-perceived characters. It covers what names
+// need (marks, emoji ZWJ sequences, flags) rather than all of UAX #29.
+func graphemes(s string) []string {
+	var out []string
+	start := 0
+	var prev rune = -1
+	regional := 0 // regional indicators in the current cluster
+	for i, r := range s {
+		joined := prev == zwj && unicode.Is(unicode.So, r)
+		pair := isRegionalIndicator(r) && regional == 1
+		if i > 0 && !extendsCluster(r) && !joined && !pair {
+			out = append(out, s[start:i])
+			start, regional = i, 0
+		}
+		if isRegionalIndicator(r) {
+			regional++
+		}
+		prev = r
+	}
+	if start < len(s) {
+		out = append(out, s[start:])
+	}
+	return out
+}
+
+// capMarks keeps at most MaxNameMarks combining marks on one grapheme
+func capMarks(g string) string {
+	marks := 0
+	return strings.Map(func(r rune) rune {
+		if isMark(r) {
+			if marks++; marks > MaxNameMarks {
+				return -1
+			}
+		}
+		return r
+	}, g)
+}
+
+// confusables maps look-alike letters to the Latin letter they imitate.
+// Far from the full Unicode table; it covers the Cyrillic and Greek
+// homoglyphs and digit swaps impersonators actually reach for.
+var confusables = map[rune]string{
+	// Cyrillic
+	'а': "a", 'в': "b", 'е': "e", 'ё': "e", 'һ': "h", 'і': "i", 'ї': "i", 'ј': "j", 'к': "k",
+	'м': "m", 'н': "h", 'о': "o", 'р': "p", 'с': "c", 'т': "t", 'у': "y", 'х': "x",
+	'ѕ': "s", 'ԁ': "d", 'ԛ': "q", 'ԝ': "w", 'ү': "y", 'ɡ': "g",
+	// Greek
+	'α': "a", 'β': "b", 'ε': "e", 'η': "n", 'ι': "i", 'κ': "k", 'ν': "v", 'ο': "o",
+	'ρ': "p", 'τ': "t", 'υ': "u", 'χ': "x", 'ω': "w",
+	// Digits and look-alike Latin
+	'0': "o", '1': "l", '3': "e", '5': "s", '|': "l", 'ı': "i", 'ł': "l",
+}
+
+// nameSkeleton reduces name to a form where look-alikes collide: case and
+// width folded, marks, joiners, spaces and punctuation dropped, homoglyphs
+// mapped to Latin, and "rn" and "vv" read as "m" and "w"
+func nameSkeleton(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r >= 0xff01 && r <= 0xff5e {
+			r -= 0xff01 - '!' // fullwidth ASCII
+		}
+		r = unicode.ToLower(r)
+		if sk, ok := confusables[r]; ok {
+			b.WriteString(sk)
+			continue
+		}
+		if isMark(r) || extendsCluster(r) || unicode.IsSpace(r) || unicode.IsPunct(r) {
+			continue
+		}
+		if folded := foldAccent(r); folded != 0 {
+			r = folded
+		}
+		b.WriteRune(r)
+	}
+	s := b.String()
+	s = strings.ReplaceAll(s, "rn", "m")
+	s = strings.ReplaceAll(s, "vv", "w")
+	s = strings.ReplaceAll(s, "l", "i") // l, I and 1 are one skeleton
+	return s
+}
+
+// foldAccent maps precomposed Latin letters with diacritics to their base
+// letter (é → e), 0 for anything else
+func foldAccent(r rune) rune {
+	const from = "àáâãäåāăąçćĉċčďđèéêëēĕėęěĝğġģĥħìíîïĩīĭįĵķĺļľŀñńņňòóôõöøōŏőŕŗřśŝşšţťŧùúûüũūŭůűųŵýÿŷźżž"
+	const to = "aaaaaaaaacccccddeeeeeeeeegggghhiiiiiiiijkllllnnnnooooooooorrrsssstttuuuuuuuuuuwyyyzzz"
+	if r < 0xc0 || r > 0x17f {
+		return 0
+	}
+	if i := strings.IndexRune(from, r); i >= 0 {
+		return rune(to[utf8.RuneCountInString(from[:i])])
+	}
+	return 0
+}
+
+// impersonates returns the leaderboard name that name imitates: one that
+// looks the same once reduced to a skeleton but isn't the same name.
+// Identical names are allowed, as they always have been.
+func impersonates(name string, board []LeaderboardEntry) (string, bool) {
+	sk := nameSkeleton(name)
+	if sk == "" {
+		return "", false
+	}
+	for _, e := range board {
+		if e.Name != name && nameSkeleton(e.Name) == sk {
+			return e.Name, true
+		}
+	}
+	return "", false
+}
+
+// checkImpersonation returns name, or DefaultPlayerName with a notice to c
+// when name imitates someone on the current leaderboard
+func checkImpersonation(world *World, c *Conn, name string) string {
+	other, ok := impersonates(name, world.Snapshot().Leaderboard)
+	if !ok {
+		return name
+	}
+	if msg, err := NewAnnouncement(localize(world.Lang, textLookalike, other, DefaultPlayerName), SeverityWarn, 6*time.Second); err == nil {
+		_ = c.Send(msg)
+	}
+	return DefaultPlayerName
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeName(t *testing.T) {
+	zalgo := "Z" + strings.Repeat("\u0336\u0317", 8) + "algo"
+	tests := []struct {
+		name, raw, want string
+	}{
+		{"plain", "Alice", "Alice"},
+		{"whitespace folded", "  Bob \t the \u3000Snake  ", "Bob the Snake"},
+		{"controls", "a\x00b\x1b[31mc", "ab[31mc"},
+		{"zalgo marks capped", zalgo, "Z\u0336\u0317\u0336algo"},
+		{"marks without a base", "\u0301\u0301abc", "abc"},
+		{"rtl override", "\u202egnp.exe", "gnp.exe"},
+		{"bidi isolates and embeddings", "ab\u2067cd\u2069\u202aef\u202c", "abcdef"},
+		{"zero-width space", "a\u200bb\ufeffc", "abc"},
+		{"private use", "a\ue000b", "ab"},
+		{"invalid utf-8", "a\xffb", "ab"},
+		{"nothing visible", "\u202e\u200b\u2066", ""},
+		{"zwnj kept inside a word", "می\u200cخواهم", "می\u200cخواهم"},
+		{"trailing joiner dropped", "ab\u200d", "ab"},
+		{"flags count once", strings.Repeat("🇫🇷", 25), strings.Repeat("🇫🇷", MaxNameLength)},
+		{"lone regional indicator", "🇫🇷🇩", "🇫🇷🇩"},
+		{"emoji zwj sequence kept whole", "👨\u200d👩\u200d👧 fam", "👨\u200d👩\u200d👧 fam"},
+		{"length capped", strings.Repeat("a", 25), strings.Repeat("a", MaxNameLength)},
+		{"code points capped", strings.Repeat("e\u0301\u0302\u0303", 20), strings.Repeat("e\u0301\u0302\u0303", MaxNameRunes/4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeName(tt.raw); got != tt.want {
+				t.Errorf("sanitizeName(%+q) = %+q, want %+q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImpersonates(t *testing.T) {
+	board := []LeaderboardEntry{{Name: "Alice"}, {Name: "mike"}, {Name: "🇫🇷 Lou"}}
+	tests := []struct {
+		name, join, want string
+	}{
+		{"same name is allowed", "Alice", ""},
+		{"unrelated", "Bob", ""},
+		{"cyrillic a", "Аlice", "Alice"},
+		{"greek omicron and iota", "Αlιce", "Alice"},
+		{"capital i for l", "AIice", "Alice"},
+		{"digit one for l", "A1ice", "Alice"},
+		{"case", "ALICE", "Alice"},
+		{"fullwidth", "Ａｌｉｃｅ", "Alice"},
+		{"accent", "Alicé", "Alice"},
+		{"combining mark", "Alic\u0301e", "Alice"},
+		{"spaces and punctuation", "A.l i-c_e", "Alice"},
+		{"zero-width joiner", "Al\u200dice", "Alice"},
+		{"rn for m", "rnike", "mike"},
+		{"same flag, digit for o", "🇫🇷 L0u", "🇫🇷 Lou"},
+		{"another flag is another name", "🇩🇪 Lou", ""},
+		{"punctuation only", "!!!", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := impersonates(tt.join, board)
+			if ok != (tt.want != "") || got != tt.want {
+				t.Errorf("impersonates(%q) = %q, %v; want %q", tt.join, got, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Observation layout: a fixed-length, heading-relative feature vector shared
+// by the training environment and learned bot policies, so a policy trained
+// against TrainingEnv sees exactly what it will see as a bot. Positions are
+// rotated into the snake's frame (+x straight ahead, +y to its right) and
+// scaled by ObsRadius; anything missing is zero-padded.
+const (
+	ObsFood    = 8 // nearest food items: x, y, value/FoodLevel10
+	ObsSnakes  = 4 // nearest other heads: x, y, sin/cos of relative heading, length ratio
+	ObsSectors = 8 // danger rays: nearest body in each 45° sector, 1 = none within ObsRadius
+
+	obsSelfLen   = 5 // length/100, boosting, boundary distance, sin/cos toward center
+	obsFoodLen   = 3
+	obsSnakeLen  = 5
+	ObsFeatures  = obsSelfLen + ObsFood*obsFoodLen + ObsSnakes*obsSnakeLen + ObsSectors
+	obsFoodStart = obsSelfLen
+	obsSnakeOff  = obsFoodStart + ObsFood*obsFoodLen
+	obsSectorOff = obsSnakeOff + ObsSnakes*obsSnakeLen
+)
+
+// observe encodes what s can see through view as ObsFeatures floats
+func observe(view WorldView, s *Snake) []float64 {
+	f := make([]float64, ObsFeatures)
+	head := s.Head()
+	cos, sin := math.Cos(-s.Angle), math.Sin(-s.Angle)
+	// local rotates a world offset into the heading frame, scaled to ObsRadius
+	local := func(x, y float64) (float64, float64) {
+		dx, dy := x-head.X, y-head.Y
+		return (dx*cos - dy*sin) / ObsRadius, (dx*sin + dy*cos) / ObsRadius
+	}
+
+	// Self
+	f[0] = float64(len(s.Segments)) / 100
+	if s.BoostActive {
+		f[1] = 1
+	}
+	dc := math.Hypot(head.X-WorldCenterX, head.Y-WorldCenterY)
+	f[2] = (WorldRadius - dc) / WorldRadius
+	toCenter := math.Atan2(WorldCenterY-head.Y, WorldCenterX-head.X) - s.Angle
+	f[3], f[4] = math.Sin(toCenter), math.Cos(toCenter)
+
+	// Nearest food
+	var food []FoodView
+	view.NearbyFood(head.X, head.Y, ObsRadius, func(fv FoodView) bool {
+		food = append(food, fv)
+		return true
+	})
+	sort.Slice(food, func(i, j int) bool {
+		return math.Hypot(food[i].X-head.X, food[i].Y-head.Y) < math.Hypot(food[j].X-head.X, food[j].Y-head.Y)
+	})
+	for i, fv := range food[:min(len(food), ObsFood)] {
+		o := obsFoodStart + i*obsFoodLen
+		f[o], f[o+1] = local(fv.X, fv.Y)
+		f[o+2] = float64(fv.Value) / FoodLevel10
+	}
+
+	// Nearest other heads we can collide with
+	self := snakeViewOf(s)
+	var others []SnakeView
+	view.NearbySnakes(head.X, head.Y, ObsRadius, func(o SnakeView) bool {
+		if o.ID != s.ID && self.CanInteract(o) {
+			others = append(others, o)
+		}
+		return true
+	})
+	sort.Slice(others, func(i, j int) bool {
+		return math.Hypot(others[i].X-head.X, others[i].Y-head.Y) < math.Hypot(others[j].X-head.X, others[j].Y-head.Y)
+	})
+	for i, o := range others[:min(len(others), ObsSnakes)] {
+		off := obsSnakeOff + i*obsSnakeLen
+		f[off], f[off+1] = local(o.X, o.Y)
+		f[off+2], f[off+3] = math.Sin(o.Angle-s.Angle), math.Cos(o.Angle-s.Angle)
+		f[off+4] = float64(o.Length) / float64(len(s.Segments))
+	}
+
+	// Danger rays: nearest interacting body segment per sector
+	for i := range ObsSectors {
+		f[obsSectorOff+i] = 1
+	}
+	view.NearbyBodies(head.X, head.Y, ObsRadius, s.ID, func(b BodyView) bool {
+		if !layersInteract(self.Layer, b.Layer) {
+			return true
+		}
+		a := normalizeAngle(math.Atan2(b.Y-head.Y, b.X-head.X) - s.Angle) // [-π, π]
+		sector := int((a+math.Pi)/(2*math.Pi)*ObsSectors) % ObsSectors
+		if d := math.Hypot(b.X-head.X, b.Y-head.Y) / ObsRadius; d < f[obsSectorOff+sector] {
+			f[obsSectorOff+sector] = d
+		}
+		return true
+	})
+	return f
+}
+
+// actionAngle turns a policy/agent action into the absolute steering angle
+// ApplyInput takes: turn in [-1, 1] is a fraction of a right angle off the
+// current heading, positive to the right
+func actionAngle(s *Snake, turn float64) float64 {
+	return s.Angle + math.Max(-1, math.Min(1, turn))*math.Pi/2
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// PackLibrary holds content packs uploaded through the admin API (from map
+// editors), optionally backed by a directory of <id>.json files so uploads
+// survive a restart. Together with the bundled packs it is the catalog a
+// world's pack is chosen from. Safe for concurrent use.
+type PackLibrary struct {
+	mu    sync.Mutex
+	dir   string // "" = memory only
+	packs map[string]*ContentPack
+}
+
+// errPackStore wraps failures writing an upload to the library directory,
+// as opposed to the pack being rejected
+var errPackStore = errors.New("could not save pack")
+
+// PackSummary describes one pack in the catalog
+type PackSummary struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Bundled     bool   `json:"bundled"`
+	Obstacles   int    `json:"obstacles"`
+	Zones       int    `json:"zones"`
+	Portals     int    `json:"portals"`
+	Events      int    `json:"events"`
+}
+
+func (p *ContentPack) summary(id string, bundled bool) PackSummary {
+	return PackSummary{
+		ID: id, Name: p.Name, Description: p.Description, Bundled: bundled,
+		Obstacles: len(p.Obstacles), Zones: len(p.Zones), Portals: len(p.Portals), Events: len(p.Schedule),
+	}
+}
+
+// packID derives a pack's ID from its name: lower case, runs of anything
+// but letters and digits become one dash
+func packID(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// openPackLibrary loads every pack in dir if set, creating it if missing.
+// A pack that fails validation is an error, like a bad SLETHER_MOTD.
+func openPackLibrary(dir string) (*PackLibrary, error) {
+	l := &PackLibrary{dir: dir, packs: make(map[string]*ContentPack)}
+	if dir == "" {
+		return l, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		p, err := parseContentPack(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		l.packs[strings.TrimSuffix(filepath.Base(f), ".json")] = p
+	}
+	return l, nil
+}
+
+// Resolve finds the pack for spec: an uploaded pack ID first, then
+// anything loadContentPack accepts
+func (l *PackLibrary) Resolve(spec string) (*ContentPack, error) {
+	if p, ok := l.Get(spec); ok {
+		return p, nil
+	}
+	return loadContentPack(spec)
+}
+
+// Get returns the uploaded pack with id
+func (l *PackLibrary) Get(id string) (*ContentPack, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.packs[id]
+	return p, ok
+}
+
+// Put validates p and stores it under the ID derived from its name,
+// replacing an earlier upload with the same ID. It is written to the
+// backing directory first (via a temp file and rename). Bundled pack
+// names are reserved.
+func (l *PackLibrary) Put(p *ContentPack) (string, error) {
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+	id := packID(p.Name)
+	if id == "" {
+		return "", errors.New("name needs at least one letter or digit")
+	}
+	if slices.Contains(bundledPackNames(), id) {
+		return "", fmt.Errorf("%q is a bundled pack", id)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.packs) >= PackLibraryMax {
+		if _, replacing := l.packs[id]; !replacing {
+			return "", fmt.Errorf("library full (%d packs)", PackLibraryMax)
+		}
+	}
+	if l.dir != "" {
+		data, _ := json.MarshalIndent(p, "", "  ")
+		tmp, err := os.CreateTemp(l.dir, ".pack-*")
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", errPackStore, err)
+		}
+		_, err = tmp.Write(append(data, '\n'))
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), filepath.Join(l.dir, id+".json"))
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return "", fmt.Errorf("%w: %v", errPackStore, err)
+		}
+	}
+	l.packs[id] = p
+	return id, nil
+}
+
+// List summarizes the bundled packs followed by the uploaded ones, each by ID
+func (l *PackLibrary) List() []PackSummary {
+	var out []PackSummary
+	for _, id := range bundledPackNames() {
+		if p, err := loadContentPack(id); err == nil {
+			out = append(out, p.summary(id, true))
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, id := range slices.Sorted(maps.Keys(l.packs)) {
+		out = append(out, l.packs[id].summary(id, false))
+	}
+	return out
+}
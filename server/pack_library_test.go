@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPackUpload uploads packs through the admin API and checks a good one
+// is stored and survives a reopen, and oversized, invalid and reserved ones
+// are refused without touching the library
+func TestPackUpload(t *testing.T) {
+	quietLogs(t)
+	t.Setenv("SLETHER_ADMIN_TOKEN", "op")
+	t.Setenv("SLETHER_AUDIT_LOG", "")
+	dir := t.TempDir()
+	world := newEmptyWorld()
+	var err error
+	if world.Packs, err = openPackLibrary(dir); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	newAdminAPI(NewRoomManager(NewRoom("main", world))).register(mux)
+	upload := func(body string) int {
+		return adminCall(t, mux, "POST", AdminPathPrefix+"packs", "op", body, nil)
+	}
+
+	if code := upload(`{"name": "Ring Road", "obstacles": [{"x": 0, "y": 0, "r": 800}]}`); code != http.StatusCreated {
+		t.Fatalf("good pack: status %d", code)
+	}
+	huge, _ := json.Marshal(ContentPack{Name: "huge", Description: strings.Repeat("x", PackUploadMaxBytes)})
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"oversized", string(huge), http.StatusRequestEntityTooLarge},
+		{"obstacle out of range", `{"name": "far", "obstacles": [{"x": 20000, "y": 0, "r": 100}]}`, http.StatusBadRequest},
+		{"map blocked", `{"name": "wall", "obstacles": [` + strings.Repeat(`{"x": 0, "y": 0, "r": 3000},`, 5) + `{"x": 0, "y": 0, "r": 3000}]}`, http.StatusBadRequest},
+		{"not json", `name: wall`, http.StatusBadRequest},
+		{"bundled name", `{"name": "Pillars"}`, http.StatusBadRequest},
+		{"no letters in the name", `{"name": "!!!"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := upload(tt.body); code != tt.want {
+				t.Errorf("status %d, want %d", code, tt.want)
+			}
+		})
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 || filepath.Base(files[0]) != "ring-road.json" {
+		t.Fatalf("library directory holds %v, want only ring-road.json", files)
+	}
+	again, err := openPackLibrary(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := again.Get("ring-road"); !ok || len(p.Obstacles) != 1 {
+		t.Fatalf("reopened library has %+v, %v", p, ok)
+	}
+
+	// A bad pack dropped into the directory by hand fails the reopen
+	os.WriteFile(filepath.Join(dir, "wall.json"), []byte(`{"name": "wall", "obstacles": [{"x": 0, "y": 0, "r": 1}]}`), 0o644)
+	if _, err := openPackLibrary(dir); err == nil {
+		t.Error("library opened with an invalid pack in its directory")
+	}
+}
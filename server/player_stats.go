@@ -0,0 +1,87 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Effect bits in StatsMsg.Effects
+const (
+	EffectBoost        = 1 << iota // boosting right now
+	EffectInvulnerable             // invulnerable after a damage-model hit
+	EffectMagnet                   // magnet power-up active
+	EffectTiny                     // phase layer tiny
+	EffectGiant                    // phase layer giant
+	EffectCrown                    // crowned by the golden apple
+	EffectComeback                 // shorter than the median: comeback bonus (see comeback.go)
+)
+
+// effectsOf packs the snake's current effects into StatsMsg.Effects bits.
+// Caller must hold at least w.rlock.
+func (w *World) effectsOf(s *Snake) int {
+	f := 0
+	if s.BoostActive {
+		f |= EffectBoost
+	}
+	if s.InvulnTicks > 0 {
+		f |= EffectInvulnerable
+	}
+	if s.MagnetBonus > 0 {
+		f |= EffectMagnet
+	}
+	if s.CrownTicks > 0 {
+		f |= EffectCrown
+	}
+	if w.inComeback(s) {
+		f |= EffectComeback
+	}
+	switch s.Layer() {
+	case LayerTiny:
+		f |= EffectTiny
+	case LayerGiant:
+		f |= EffectGiant
+	}
+	return f
+}
+
+// sendStats sends every player with a live snake its StatsMsg. Rank counts
+// all alive snakes, bots included, so it matches the leaderboard.
+func (gl *GameLoop) sendStats() {
+	w := gl.world
+	conns := gl.conns.Snapshot()
+	now := time.Now()
+	msgs := make(map[*Conn]StatsMsg, len(conns))
+
+	unlock := w.rlock("sendStats")
+	scores := make([]int, 0, len(w.Snakes))
+	for _, s := range w.Snakes {
+		if s.Alive {
+			scores = append(scores, s.Score)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(scores)))
+	for _, c := range conns {
+		s, ok := w.followedSnake(c)
+		if !ok {
+			continue
+		}
+		// Rank = 1 + number of strictly higher scores; ties share a rank
+		higher := sort.Search(len(scores), func(i int) bool { return scores[i] <= s.Score })
+		msgs[c] = StatsMsg{
+			Type:    MsgStats,
+			Score:   s.Score,
+			Rank:    higher + 1,
+			Of:      len(scores),
+			Length:  len(s.Segments),
+			Kills:   s.Kills,
+			Assists: s.Assists,
+			Session: int(now.Sub(c.connectedAt) / time.Second),
+			Effects: w.effectsOf(s),
+		}
+	}
+	unlock()
+
+	for c, msg := range msgs {
+		_ = c.Send(msg)
+	}
+}
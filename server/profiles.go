@@ -0,0 +1,316 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Profile is the persistent stat record of a claimed name. Players opt in
+// by joining with a token (a PIN or passphrase); the first join with a
+// token claims the name and later sessions with the same name and token
+// add to the record.
+type Profile struct {
+	Name      string    `json:"name"`
+	Salt      []byte    `json:"salt"`
+	Hash      []byte    `json:"hash"` // PBKDF2-SHA256 of the token
+	Claimed   time.Time `json:"claimed"`
+	LastSeen  time.Time `json:"last_seen"`
+	Lives     int       `json:"lives"`
+	Kills     int       `json:"kills"`
+	PlaySec   float64   `json:"play_sec"`
+	BestScore int       `json:"best_score"`
+	Rating    float64   `json:"rating,omitempty"` // Elo, from ranked worlds (see rating.go)
+	// Season holds this season's share of the stats (see seasons.go)
+	Season ProfileSeason `json:"season"`
+
+	failures    int       // consecutive wrong tokens
+	lockedUntil time.Time // set after ProfileMaxFailures
+}
+
+// PublicProfile is what GET /api/profile/{name} shows
+type PublicProfile struct {
+	Name      string    `json:"name"`
+	Since     time.Time `json:"since"`
+	LastSeen  time.Time `json:"last_seen"`
+	Lives     int       `json:"lives"`
+	Kills     int       `json:"kills"`
+	PlaySec   int       `json:"play_sec"`
+	BestScore int       `json:"best_score"`
+	Rating    int       `json:"rating,omitempty"` // omitted until rated in a ranked world
+}
+
+// Outcomes of ProfileStore.Authenticate
+var (
+	errProfileToken  = errors.New("wrong token for this name")
+	errProfileLocked = errors.New("too many wrong tokens, try again later")
+)
+
+// profileLife is a claimed snake's life in progress
+type profileLife struct {
+	key   string
+	start time.Time
+	kills int
+}
+
+// ProfileStore holds claimed names and their stats, optionally backed by a
+// JSON file (SLETHER_PROFILES) that a background flush rewrites when
+// something changed, so the game loop never waits on disk. Lives in
+// progress are tracked from game events like Highlights. Safe for
+// concurrent use; a leaf lock.
+type ProfileStore struct {
+	mu    sync.Mutex
+	path  string              // "" = memory only
+	byKey map[string]*Profile // profileKey(name) -> profile
+	dirty bool
+
+	lives map[string]profileLife // snake ID -> life; written by every room's loop, guarded by mu
+
+	season int // current season number, set by Seasons
+}
+
+// profileKey folds case and surrounding space so "Ana" and "ana " are the same claim
+func profileKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// hashProfileToken derives the stored hash of token
+func hashProfileToken(token string, salt []byte) []byte {
+	key, _ := pbkdf2.Key(sha256.New, token, salt, ProfileHashIterations, 32)
+	return key
+}
+
+// openProfileStore loads path if set (a missing file starts empty) and
+// starts the flush goroutine
+func openProfileStore(path string) (*ProfileStore, error) {
+	s := newProfileStore()
+	s.path = path
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		var list []*Profile
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, p := range list {
+			s.byKey[profileKey(p.Name)] = p
+		}
+	}
+	go func() {
+		for range time.Tick(ProfileFlushInterval) {
+			if err := s.Flush(); err != nil {
+				log.Printf("profiles: %v", err)
+			}
+		}
+	}()
+	return s, nil
+}
+
+func newProfileStore() *ProfileStore {
+	return &ProfileStore{byKey: make(map[string]*Profile), lives: make(map[string]profileLife)}
+}
+
+// Authenticate checks token against name's claim, claiming an unclaimed
+// name. Returns the profile key to accrue the session to and whether the
+// name was just claimed. Runs on connection goroutines: the hash is
+// deliberately slow, so it is computed outside the lock.
+func (s *ProfileStore) Authenticate(name, token string) (key string, claimed bool, err error) {
+	key = profileKey(name)
+	if n := utf8.RuneCountInString(token); n < ProfileTokenMinLength || n > ProfileTokenMaxLength {
+		return "", false, fmt.Errorf("token must be %d-%d characters", ProfileTokenMinLength, ProfileTokenMaxLength)
+	}
+	if key == "" || key == profileKey(DefaultPlayerName) {
+		return "", false, errors.New("pick a name to claim")
+	}
+	s.mu.Lock()
+	p, ok := s.byKey[key]
+	var salt []byte
+	if ok {
+		if time.Now().Before(p.lockedUntil) {
+			s.mu.Unlock()
+			return "", false, errProfileLocked
+		}
+		salt = p.Salt
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		salt = make([]byte, 16)
+		rand.Read(salt)
+	}
+	hash := hashProfileToken(token, salt)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok = s.byKey[key]; !ok {
+		now := time.Now()
+		s.byKey[key] = &Profile{Name: strings.TrimSpace(name), Salt: salt, Hash: hash, Claimed: now, LastSeen: now}
+		s.dirty = true
+		return key, true, nil
+	}
+	if subtle.ConstantTimeCompare(hash, p.Hash) != 1 {
+		// A claim racing ours won with another salt, or the token is wrong
+		p.failures++
+		if p.failures >= ProfileMaxFailures {
+			p.failures, p.lockedUntil = 0, time.Now().Add(ProfileLockout)
+		}
+		return "", false, errProfileToken
+	}
+	p.failures = 0
+	return key, false, nil
+}
+
+// startLife begins accruing snakeID's life to the profile key
+func (s *ProfileStore) startLife(snakeID, key string) {
+	if key != "" {
+		s.mu.Lock()
+		s.lives[snakeID] = profileLife{key: key, start: time.Now()}
+		s.mu.Unlock()
+	}
+}
+
+// observe counts kills of claimed snakes and books their lives when they
+// die or leave
+func (s *ProfileStore) observe(e GameEvent) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	life, ok := s.lives[e.Snake]
+	if !ok {
+		return
+	}
+	switch e.Type {
+	case EventKill:
+		life.kills++
+		s.lives[e.Snake] = life
+	case EventDeath, EventLeave:
+		delete(s.lives, e.Snake)
+		now := time.Now()
+		if p, ok := s.byKey[life.key]; ok {
+			p.Lives++
+			p.Kills += life.kills
+			p.PlaySec += now.Sub(life.start).Seconds()
+			p.BestScore = max(p.BestScore, e.Score)
+			p.LastSeen = now
+			ps := p.seasonStats(s.season)
+			ps.Lives++
+			ps.Kills += life.kills
+			ps.PlaySec += now.Sub(life.start).Seconds()
+			ps.BestScore = max(ps.BestScore, e.Score)
+			s.dirty = true
+		}
+	}
+}
+
+// Get returns the public view of name's profile
+func (s *ProfileStore) Get(name string) (PublicProfile, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.byKey[profileKey(name)]
+	if !ok {
+		return PublicProfile{}, false
+	}
+	return PublicProfile{
+		Name: p.Name, Since: p.Claimed, LastSeen: p.LastSeen,
+		Lives: p.Lives, Kills: p.Kills, PlaySec: int(p.PlaySec), BestScore: p.BestScore,
+		Rating: int(math.Round(p.Rating)),
+	}, true
+}
+
+// Flush writes the store to its file if anything changed (via a temp file
+// and rename, so a crash never leaves half a file)
+func (s *ProfileStore) Flush() error {
+	s.mu.Lock()
+	if s.path == "" || !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	list := make([]*Profile, 0, len(s.byKey))
+	for _, p := range s.byKey {
+		c := *p
+		list = append(list, &c)
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	data, _ := json.Marshal(list)
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".profiles-*")
+	if err == nil {
+		_, err = tmp.Write(data)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), s.path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
+		s.mu.Lock()
+		s.dirty = true // retry next flush
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// handleProfile serves GET /api/profile/{name}
+func handleProfile(world *World) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := world.Profiles.Get(r.PathValue("name"))
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such profile"})
+			return
+		}
+		writePublicJSON(w, 30*time.Second, p)
+	}
+}
+
+// joinProfile checks the token c joined with and returns the profile key
+// the new life accrues to, "" for none. The check is cached per name and
+// token so respawns don't pay for the hash; the player hears the outcome
+// when it changes. Runs under c.join.mu, which guards the cache.
+func joinProfile(world *World, c *Conn, name, token string) string {
+	if token == "" {
+		return ""
+	}
+	g := &c.join
+	if name == g.profileName && token == g.profileToken {
+		return g.profileKey
+	}
+	key, claimed, err := world.Profiles.Authenticate(name, token)
+	g.profileName, g.profileToken, g.profileKey = name, token, key
+	text, severity := localize(world.Lang, textWelcomeBack, name), SeverityInfo
+	switch {
+	case err != nil:
+		text, severity = localize(world.Lang, textNotRecorded, err), SeverityWarn
+	case claimed:
+		text = localize(world.Lang, textClaimed, name)
+	}
+	if msg, err := NewAnnouncement(text, severity, 6*time.Second); err == nil {
+		_ = c.Send(msg)
+	}
+	return key
+}
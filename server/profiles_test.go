@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestProfileClaim claims a name and checks later joins with the right and
+// wrong tokens, the lockout after ProfileMaxFailures and what can't be claimed
+func TestProfileClaim(t *testing.T) {
+	s := newProfileStore()
+	key, claimed, err := s.Authenticate("Ana", "1234")
+	if err != nil || !claimed || key != "ana" {
+		t.Fatalf("claim = %q, %v, %v; want ana, claimed", key, claimed, err)
+	}
+	if key, claimed, err = s.Authenticate(" ana ", "1234"); err != nil || claimed || key != "ana" {
+		t.Fatalf("rejoin = %q, %v, %v; want the same claim", key, claimed, err)
+	}
+
+	tests := []struct {
+		name, token string
+		want        error // nil: any error
+	}{
+		{"Ana", "4321", errProfileToken},
+		{"Ana", "123", nil},
+		{"Bo", "12345678901234567890123456789012345678901234567890123456789012345", nil},
+		{DefaultPlayerName, "1234", nil},
+		{"  ", "1234", nil},
+	}
+	for _, tt := range tests {
+		_, _, err := s.Authenticate(tt.name, tt.token)
+		if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+			t.Errorf("Authenticate(%q, %q) = %v, want %v", tt.name, tt.token, err, tt.want)
+		}
+	}
+
+	for range ProfileMaxFailures {
+		s.Authenticate("Ana", "0000")
+	}
+	if _, _, err := s.Authenticate("Ana", "1234"); !errors.Is(err, errProfileLocked) {
+		t.Fatalf("right token during the lockout = %v, want %v", err, errProfileLocked)
+	}
+	if _, claimed, err := s.Authenticate("Bo", "5555"); err != nil || !claimed {
+		t.Fatalf("another name was locked out too: %v", err)
+	}
+}
+
+// TestProfilePersistence books a life to a claimed name, flushes the store
+// and reopens it: the stats and the claim survive, and only the right token
+// still opens it
+func TestProfilePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	s, err := openProfileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _, err := s.Authenticate("Ana", "pass phrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.startLife("snake-1", key)
+	s.observe(GameEvent{Type: EventKill, Snake: "snake-1"})
+	s.observe(GameEvent{Type: EventKill, Snake: "snake-1"})
+	s.observe(GameEvent{Type: EventDeath, Snake: "snake-1", Score: 420})
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := openProfileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, ok := again.Get("ana")
+	if !ok || p.Name != "Ana" || p.Lives != 1 || p.Kills != 2 || p.BestScore != 420 {
+		t.Fatalf("reloaded profile %+v, %v; want Ana with 1 life, 2 kills, best 420", p, ok)
+	}
+	if _, claimed, err := again.Authenticate("Ana", "pass phrase"); err != nil || claimed {
+		t.Fatalf("right token after reload = %v, claimed %v", err, claimed)
+	}
+	if _, _, err := again.Authenticate("Ana", "pass phrasE"); !errors.Is(err, errProfileToken) {
+		t.Fatalf("wrong token after reload = %v, want %v", err, errProfileToken)
+	}
+}
+
+// TestProfileVerifyTiming checks a wrong token isn't turned away sooner
+// than the right one is let in, whether it is wrong from its first
+// character or only its last: every check pays for the full hash before a
+// constant-time compare. The fastest of a few rounds each keeps scheduling
+// noise out.
+func TestProfileVerifyTiming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("hashes tokens repeatedly")
+	}
+	s := newProfileStore()
+	const token = "correct horse battery staple"
+	if _, _, err := s.Authenticate("Ana", token); err != nil {
+		t.Fatal(err)
+	}
+	fastest := map[string]time.Duration{}
+	for range 5 {
+		// The right token last in each round resets the failure count
+		for _, try := range []struct{ label, token string }{
+			{"first", "Xorrect horse battery staple"},
+			{"last", "correct horse battery staplX"},
+			{"right", token},
+		} {
+			began := time.Now()
+			s.Authenticate("Ana", try.token)
+			if took := time.Since(began); fastest[try.label] == 0 || took < fastest[try.label] {
+				fastest[try.label] = took
+			}
+		}
+	}
+	for _, label := range []string{"first", "last"} {
+		if fastest[label] < fastest["right"]/2 {
+			t.Errorf("token wrong at its %s character rejected in %v, the right one takes %v", label, fastest[label], fastest["right"])
+		}
+	}
+}
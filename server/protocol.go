@@ -1,87 +1,219 @@
 package main
 
+//go:generate go run ./cmd/protogen
+
 // Protocol uses single-character JSON keys to minimize wire size.
 // All x,y coordinates are rounded to 1 decimal place.
 //
 // Message type constants (value of "t" field):
 //   Client → Server:
 //     "j" = join    {"t":"j","n":"PlayerName"}
-//     "i" = input   {"t":"i","a":1.57,"b":1}   (a=angle radians, b=boost 0/1)
+//     "i" = input   {"t":"i","a":1.57,"b":1,"q":57}   (a=angle radians, b=boost 0/1, q=sequence number)
 //     "r" = respawn {"t":"r","n":"PlayerName"}
 //   Server → Client:
-//     "w" = welcome {"t":"w","i":"id","r":10500,"c":"#color"}  (r=world radius)
-//     "s" = state   {"t":"s","s":[snakes],"f":[food],"l":[leaderboard]}
+//     "w" = welcome {"t":"w","i":"uuid","e":7,"r":10500,"c":"#color","h":20,"u":{rules}}  (e=entity ID, r=world radius, h=tick rate, u=physics)
+//     "s" = state   {"t":"s","s":[snakes],"f":[food],"b":[blobs],"l":[leaderboard]}
 //     "d" = death   {"t":"d","k":"KillerName","p":score}
+//     "m" = map     {"t":"m","x":10500,"y":10500,"r":10500,"k":0.0076,"o":[..],"z":[..],"p":[..]}
+//                   sent once after welcome; static layout kept out of per-tick state
+//     "a" = ate     {"t":"a","v":6}   (v=food value eaten this tick, for score pops)
+//     "k" = kill    {"t":"k","k":"Killer","v":"Victim","n":3,"m":1,"x":4,"p":20}  (kill feed, sent to all)
+//     "n" = announce {"t":"n","m":"text","s":"warn","d":10000}  (banner; s=info|warn|alert, d=ms)
+//     "o" = motd    {"t":"o","n":"EU #1","g":"eu-west","o":"ffa","m":"Be nice","l":[{"n":"Discord","u":"https://.."}]}
+//                   sent after map when the operator set one, and again when it changes
+//     "p" = stats   {"t":"p","p":120,"r":3,"n":57,"l":64,"k":2,"x":1,"s":340,"f":1}  (own HUD, ~1 Hz)
+//     "g" = objective {"t":"g","s":1,"x":1.0,"y":2.0} / {"t":"g","s":0,"n":"Eater","p":150}
+//                   golden apple spawned at x,y / eaten; sent to all, and on join while one is out
+//     "x" = shutdown {"t":"x","s":5}  (server shutting down: connection closes with 4002 in s seconds)
+//     "h" = records {"t":"h","a":[{"n":"name","p":score}],"d":[..]}  (all-time and today's best, kept across restarts)
+//                   sent after map when records are on, and again when they change
+//     "q" = echo    {"t":"q","k":1234,"q":57,"a":1.571,"b":1}  (input applied to own snake this tick)
+//                   only to clients that connected with ?echo=1
+//
+// Entity IDs ("i" in snakes, food, leaderboard) are compact uint32 values mapped
+// server-side; the session UUID only appears in the welcome message.
 //
-// SnakeDTO: {"i":"id","n":"name","s":[[x,y],...],"c":"#color","p":score}
-// FoodDTO:  {"i":"id","x":1.0,"y":2.0,"v":1,"c":"#f00","l":1,"m":0}
-//   l=level (1/3/5/10), m=isMoving (0/1)
-// LeaderboardEntry: {"i":"id","n":"name","p":score}
+// SnakeDTO: {"i":7,"n":"name","s":[[x,y],...],"c":"#color","p":score,"y":layer}
+//   y=phase layer (1=tiny, 2=giant, omitted=normal); tiny and giant never collide
+//   h=1 while recently hit and invulnerable (damage model), omitted otherwise
+//   k=1 while crowned for eating the golden apple, omitted otherwise
+// FoodDTO:  {"i":1048577,"x":1.0,"y":2.0,"v":1,"c":"#f00","l":1,"m":0,"n":1}
+//   l=level (1/3/5/10), m=isMoving (0/1), n=new since this client's last state (omitted if not)
+// FoodBlobDTO: {"x":1.0,"y":2.0,"v":24,"c":"#f00","l":3,"n":8}
+//   merged pile of n food items far from the viewer; split back into items as the viewer nears
+// LeaderboardEntry: {"i":7,"n":"name","p":score}
 
 // Message type identifiers — single-char for compact protocol
 const (
-	MsgJoin    = "j"
-	MsgInput   = "i"
-	MsgRespawn = "r"
-	MsgWelcome = "w"
-	MsgState   = "s"
-	MsgDeath   = "d"
-	MsgError   = "e"
+	MsgJoin      = "j"
+	MsgInput     = "i"
+	MsgRespawn   = "r"
+	MsgWelcome   = "w"
+	MsgState     = "s"
+	MsgDeath     = "d"
+	MsgError     = "e"
+	MsgMap       = "m"
+	MsgAte       = "a"
+	MsgKill      = "k"
+	MsgAnnounce  = "n"
+	MsgStats     = "p"
+	MsgMOTD      = "o"
+	MsgObjective = "g"
+	MsgShutdown  = "x"
+	MsgRecords   = "h"
+	MsgEcho      = "q"
+)
+
+// Application WebSocket close codes (RFC 6455 leaves 4000–4999 to
+// applications). Every server-initiated disconnect sends one in the close
+// frame, after an ErrorMsg carrying the same code. 40xx: try again later,
+// reconnecting automatically is fine. 41xx: permanent for this session,
+// don't reconnect without the player's say-so.
+const (
+	CloseServerFull  = 4000
+	CloseRateLimited = 4001
+	CloseMaintenance = 4002 // server restarting or shutting down
+	CloseIdle        = 4003 // AFK for too long
+	CloseSlow        = 4004 // connection couldn't keep up with sends
+	CloseCaptcha     = 4005 // join verification failed; reconnect for a fresh widget
+	CloseKicked      = 4100
+	CloseBanned      = 4101
+	CloseNoSuchRoom  = 4102 // the room asked for with ?room= doesn't exist
+	CloseRoomClosed  = 4103 // the room was closed by its owner
 )
 
 // ClientMessage is the base incoming message from the browser.
 // Uses single-char keys matching the compact protocol.
-//   {"t":"j","n":"name"}          join / respawn
-//   {"t":"i","a":1.57,"b":1}      input (a=angle, b=boost)
+//   {"t":"j","n":"name","k":"1234"} join / respawn (k = optional profile token, f = 1 to show country flag)
+//   {"t":"i","a":1.57,"b":1,"q":57} input (a=angle, b=boost, q=optional sequence number)
+//   {"t":"i","a":1.57,"s":1}        input for an extra snake (s=slot, see multi_snake.go)
 type ClientMessage struct {
 	Type  string  `json:"t"`
 	Name  string  `json:"n,omitempty"`
+	Token string  `json:"k,omitempty"` // claims the name / accrues to its profile (see profiles.go)
 	Angle float64 `json:"a,omitempty"`
 	Boost int     `json:"b,omitempty"` // 0 or 1 (client sends int, not bool)
+	Slot  int     `json:"s,omitempty"` // snake an input steers: 0 = own, 1+ = extra (see multi_snake.go)
+	Seq   uint32  `json:"q,omitempty"` // input sequence number, echoed back on request (see input_echo.go)
+	// Captcha is the widget token proving the first join is a person (see captcha.go)
+	Captcha string `json:"c,omitempty"`
+	// Flag is 1 to show the player's country with their name (see country.go)
+	Flag int `json:"f,omitempty"`
 }
 
 // WelcomeMsg is sent to a player immediately on WebSocket connect.
+// i = session UUID, e = entity ID the player's snake uses in state messages
 // r = world radius (circular map, center is always WorldCenterX/Y = 10500,10500)
-// {"t":"w","i":"uuid","r":10500,"c":"#hexcolor"}
+// h = server tick rate in Hz (state messages arrive at this rate)
+// u = physics rules for client-side prediction and rendering
+// v = captcha widget to complete before the first join, when required
+// {"t":"w","i":"uuid","e":7,"r":10500,"c":"#hexcolor","h":20,"u":{..}}
 type WelcomeMsg struct {
-	Type        string  `json:"t"`
-	ID          string  `json:"i"`
-	WorldRadius float64 `json:"r"`
-	Color       string  `json:"c"`
+	Type        string   `json:"t"`
+	ID          string   `json:"i"`
+	EntityID    uint32   `json:"e"`
+	WorldRadius float64  `json:"r"`
+	Color       string   `json:"c"`
+	TickRate    int      `json:"h"`
+	Rules       RulesDTO `json:"u"`
+	// Captcha is set when the first join must carry a widget token
+	Captcha *CaptchaDTO `json:"v,omitempty"`
+	// Lang is the world's community language (BCP 47), for the MOTD and
+	// server announcements; absent for an international world
+	Lang string `json:"l,omitempty"`
+}
+
+// CaptchaDTO names the captcha widget to render before joining:
+// p = provider ("turnstile" or "hcaptcha"), k = public site key
+type CaptchaDTO struct {
+	Provider string `json:"p"`
+	SiteKey  string `json:"k"`
+}
+
+// RulesDTO carries the movement and sizing constants the server simulates
+// with, so clients predict and draw with the same numbers. Rates are per
+// second; turn rate at n segments is tr / (1 + n*tf). Food eaten within rb
+// px of the boundary is worth rm times its value (absent when disabled).
+// {"v":60,"vb":100,"sp":8,"tr":3.6,"tf":0.001,"hr":10,"br":8,"w":10,"wm":28,"mr":16,"mv":60,"fr":5,"rb":1000,"rm":2}
+type RulesDTO struct {
+	Speed          float64 `json:"v"`  // px/s
+	BoostSpeed     float64 `json:"vb"` // px/s
+	SegmentSpacing float64 `json:"sp"` // px of head travel between body segments
+	TurnRate       float64 `json:"tr"` // rad/s at minimum size
+	TurnScale      float64 `json:"tf"` // turn penalty per segment
+	HeadRadius     float64 `json:"hr"`
+	BodyRadius     float64 `json:"br"`
+	BaseWidth      float64 `json:"w"`  // starting visual radius
+	MaxWidth       float64 `json:"wm"` // visual radius cap
+	MagnetRadius   float64 `json:"mr"` // at base width; scales with width
+	MagnetSpeed    float64 `json:"mv"` // px/s
+	FoodRadius     float64 `json:"fr"`
+	RiskBand       float64 `json:"rb,omitempty"` // px inside the boundary
+	RiskMultiplier float64 `json:"rm,omitempty"`
+	// Comeback rule: snakes shorter than the median get cs more base speed
+	// and cf more food value (fractions; absent when off)
+	ComebackSpeed float64 `json:"cs,omitempty"`
+	ComebackFood  float64 `json:"cf,omitempty"`
 }
 
 // SnakeDTO is the compact snake for per-tick state updates.
 // Segments are encoded as flat [x,y] float64 pairs to save bytes vs {"x":..,"y":..} objects.
-// {"i":"id","n":"name","s":[[x,y],[x,y]],"c":"#color","p":score}
+// {"i":7,"n":"name","s":[[x,y],[x,y]],"c":"#color","p":score}
+// In a delta state a snake the viewer already holds comes as a delta entry
+// instead: no s, n, c or f; the new head and the segments laid since, which
+// replace the held head, plus the new length.
+// {"i":7,"a":[[x,y]],"z":120,"p":score}
 type SnakeDTO struct {
-	ID       string       `json:"i"`
-	Name     string       `json:"n"`
-	Segments [][2]float64 `json:"s"`
-	Color    string       `json:"c"`
+	ID       uint32       `json:"i"`
+	Name     string       `json:"n,omitempty"` // omitted in delta entries: unchanged
+	Country  string       `json:"f,omitempty"` // opted-in country flag, ISO 3166-1 alpha-2
+	Segments [][2]float64 `json:"s,omitempty"` // whole body; omitted in delta entries
+	Added    [][2]float64 `json:"a,omitempty"` // delta entries: the new head, then segments laid since the last state
+	Length   int          `json:"z,omitempty"` // delta entries: body length after adding a and trimming the tail
+	Color    string       `json:"c,omitempty"` // omitted in delta entries: unchanged
 	Score    int          `json:"p"`
 	Boosting int          `json:"b,omitempty"` // 1 if boosting, omitted if not
-	Width    float64      `json:"w"`           // visual radius
+	Width    float64      `json:"w"`           // visual radius, eased server-side (no popping)
+	Layer    int          `json:"y,omitempty"` // phase layer hint: 1=tiny, 2=giant, omitted if normal
+	Hit      int          `json:"h,omitempty"` // 1 while invulnerable after a damage-model hit
+	Crown    int          `json:"k,omitempty"` // 1 while crowned by the golden apple
 }
 
 // FoodDTO is the compact food item for per-tick state updates.
 // l = level (1/3/5/10), m = isMoving (0 or 1 integer for JSON compactness)
-// {"i":"id","x":1.0,"y":2.0,"v":1,"c":"#f00","l":1,"m":0}
+// {"i":1048577,"x":1.0,"y":2.0,"v":1,"c":"#f00","l":1,"m":0}
 type FoodDTO struct {
-	ID       string  `json:"i"`
+	ID       uint32  `json:"i"`
 	X        float64 `json:"x"`
 	Y        float64 `json:"y"`
 	Value    int     `json:"v"`
 	Color    string  `json:"c"`
 	Level    int     `json:"l"`
-	IsMoving int     `json:"m"` // 0 or 1
+	IsMoving int     `json:"m"`           // 0 or 1
+	New      int     `json:"n,omitempty"` // 1 the first snapshot after it spawned (pop-in hint)
+}
+
+// FoodBlobDTO is an aggregated pile of food sent instead of its items when far from the viewer.
+// v = combined value, l = highest level in the pile, n = item count.
+// {"x":1.0,"y":2.0,"v":24,"c":"#f00","l":3,"n":8}
+type FoodBlobDTO struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Value int     `json:"v"`
+	Color string  `json:"c"`
+	Level int     `json:"l"`
+	Count int     `json:"n"`
 }
 
 // LeaderboardEntry is a single leaderboard row.
-// {"i":"id","n":"name","p":score}
+// {"i":7,"n":"name","p":score}
 type LeaderboardEntry struct {
-	ID    string `json:"i"`
-	Name  string `json:"n"`
-	Score int    `json:"p"`
+	ID     uint32 `json:"i"`
+	Name   string `json:"n"`
+	Score  int    `json:"p"`
+	Rating int    `json:"r,omitempty"` // ranked worlds: the player's profile rating
+	// Country is the snake's opted-in flag, ISO 3166-1 alpha-2
+	Country string `json:"f,omitempty"`
 }
 
 // MinimapSnake is a downsampled snake for the minimap — only includes snakes visible at minimap scale.
@@ -92,28 +224,191 @@ type MinimapSnake struct {
 	Width    float64      `json:"w"`
 }
 
-// StateMsg is the per-tick state update sent to each client.
-// {"t":"s","s":[snakes],"f":[food],"l":[leaderboard],"m":[minimap dots]}
+// StateMsg is the per-tick state update sent to each client. l is null
+// between leaderboard updates when the world sends them less often than
+// every tick; keep the last one. u, sent with l, ranks human players only
+// while bots are on l. A delta state (d=1, see state_delta.go)
+// lists every snake in view but only the food and blobs that changed: f
+// holds food that entered or moved, r the food IDs that left, and b is null
+// when the blobs are unchanged. Apply it to the previous state.
+// {"t":"s","s":[snakes],"f":[food],"b":[blobs],"l":[leaderboard],"m":[minimap dots],"g":3}
 type StateMsg struct {
 	Type        string             `json:"t"`
 	Snakes      []SnakeDTO         `json:"s"`
 	Food        []FoodDTO          `json:"f"`
+	Blobs       []FoodBlobDTO      `json:"b"` // null: none, or unchanged in a delta
 	Leaderboard []LeaderboardEntry `json:"l"`
+	Humans      []LeaderboardEntry `json:"u,omitempty"` // with l: humans-only board, absent when l has no bots
 	Minimap     []MinimapSnake      `json:"m,omitempty"`
+	Pending     int                `json:"g,omitempty"` // viewer's own pending growth (segments still to add)
+	Follow      uint32             `json:"y,omitempty"` // entity the viewport follows when not the player's own snake
+	Delta       int                `json:"d,omitempty"` // 1 if this state only carries changes since the previous one
+	Removed     []uint32           `json:"r,omitempty"` // deltas: food IDs gone from view since the previous state
 }
 
 // DeathMsg is sent to a player when their snake dies.
-// k = killer name (or "Boundary"), p = final score
+// k = killer name (or "Boundary"), p = final score,
+// m = 1 if the killer died to the player's snake in the same tick
 // {"t":"d","k":"KillerName","p":42}
 type DeathMsg struct {
 	Type   string `json:"t"`
 	Killer string `json:"k"`
 	Score  int    `json:"p"`
+	Mutual int    `json:"m,omitempty"`
+}
+
+// MapMsg describes the static world, sent once right after welcome so the
+// client can pre-render the background.
+// x,y = world center, r = boundary radius, k = minimap px per world unit
+// o = obstacles, z = zones, p = portals (omitted when the map has none)
+type MapMsg struct {
+	Type         string          `json:"t"`
+	CenterX      float64         `json:"x"`
+	CenterY      float64         `json:"y"`
+	Radius       float64         `json:"r"`
+	MinimapScale float64         `json:"k"`
+	Obstacles    []MapFeatureDTO `json:"o,omitempty"`
+	Zones        []MapFeatureDTO `json:"z,omitempty"`
+	Portals      []MapFeatureDTO `json:"p,omitempty"`
+}
+
+// MapFeatureDTO is a circular map feature; tx,ty is a portal's destination,
+// m a danger zone's score multiplier for food eaten inside.
+// {"x":1.0,"y":2.0,"r":300,"tx":5.0,"ty":6.0}
+type MapFeatureDTO struct {
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	Radius  float64 `json:"r"`
+	TargetX float64 `json:"tx,omitempty"`
+	TargetY float64 `json:"ty,omitempty"`
+	Multiplier float64 `json:"m,omitempty"`
+}
+
+// AteMsg tells a player how much food value their snake ate this tick.
+// {"t":"a","v":6}
+type AteMsg struct {
+	Type  string `json:"t"`
+	Value int    `json:"v"`
+}
+
+// KillFeedMsg announces a kill to every player.
+// n = killer's streak after this kill, m = 1 if n is a milestone,
+// x = victim's streak ended by this kill (shutdown), p = shutdown bonus value,
+// a = name of the snake credited with an assist, u = 1 for a mutual kill
+// (killer and victim took each other out; one entry for the pair)
+// {"t":"k","k":"Killer","v":"Victim","n":3,"m":1,"x":4,"p":20}
+type KillFeedMsg struct {
+	Type      string `json:"t"`
+	Killer    string `json:"k"`
+	Victim    string `json:"v"`
+	Streak    int    `json:"n"`
+	Milestone int    `json:"m,omitempty"`
+	Shutdown  int    `json:"x,omitempty"`
+	Bonus     int    `json:"p,omitempty"`
+	Assist    string `json:"a,omitempty"`
+	Mutual    int    `json:"u,omitempty"`
+}
+
+// ObjectiveMsg reports the golden apple to every player.
+// s = 1 while it is out at x,y; s = 0 once eaten, by n for p score
+// {"t":"g","s":1,"x":1.0,"y":2.0}
+type ObjectiveMsg struct {
+	Type   string  `json:"t"`
+	Active int     `json:"s"`
+	X      float64 `json:"x,omitempty"`
+	Y      float64 `json:"y,omitempty"`
+	Eater  string  `json:"n,omitempty"`
+	Value  int     `json:"p,omitempty"`
+}
+
+// AnnouncementMsg shows a banner to the player for d milliseconds.
+// s = severity: "info", "warn" or "alert"
+// {"t":"n","m":"Server restarting in 5 minutes","s":"warn","d":10000}
+type AnnouncementMsg struct {
+	Type     string `json:"t"`
+	Text     string `json:"m"`
+	Severity string `json:"s"`
+	Duration int    `json:"d"`
+}
+
+// ShutdownMsg warns every player that the server is shutting down: the
+// game runs on for s seconds, then the connection closes with
+// CloseMaintenance. Sent once, to all.
+// {"t":"x","s":5}
+type ShutdownMsg struct {
+	Type    string `json:"t"`
+	Seconds int    `json:"s"`
+}
+
+// RecordsMsg is the persistent high-score boards: a = all-time, d = today
+// (UTC), best first, each name once at its best final score.
+// {"t":"h","a":[{"n":"name","p":4200}],"d":[{"n":"name","p":900}]}
+type RecordsMsg struct {
+	Type    string        `json:"t"`
+	AllTime []RecordEntry `json:"a"`
+	Today   []RecordEntry `json:"d"`
 }
 
-// ErrorMsg is sent when the server rejects a connection (rate limit, full, etc).
-// {"t":"e","m":"message"}
+// RecordEntry is one line of a records board: n = name, p = score
+type RecordEntry struct {
+	Name  string `json:"n"`
+	Score int    `json:"p"`
+}
+
+// InputEchoMsg reports the input the server applied to the player's own
+// snake in tick k, sent each tick to clients that connected with ?echo=1:
+// q = sequence number of the input used (0 before the first numbered
+// input), a = heading after the turn-rate clamp, b = 1 if boosting
+// {"t":"q","k":1234,"q":57,"a":1.571,"b":1}
+type InputEchoMsg struct {
+	Type    string  `json:"t"`
+	Tick    uint64  `json:"k"`
+	Seq     uint32  `json:"q"`
+	Heading float64 `json:"a"`
+	Boost   int     `json:"b,omitempty"`
+}
+
+// ErrorMsg is sent when the server rejects or drops a connection (rate limit,
+// full, etc). c = the Close* code the close frame that follows will carry.
+// {"t":"e","m":"message","c":4000}
 type ErrorMsg struct {
 	Type    string `json:"t"`
 	Message string `json:"m"`
+	Code    int    `json:"c,omitempty"`
+}
+
+// StatsMsg is the owning player's HUD data, sent about once a second apart
+// from the per-tick state. p=score, r=rank among n alive snakes, l=length,
+// k=kills and x=assists this life, s=seconds since connect, f=effect bits
+// (EffectBoost, EffectInvulnerable, EffectMagnet, EffectTiny, EffectGiant,
+// EffectCrown, EffectComeback).
+// {"t":"p","p":120,"r":3,"n":57,"l":64,"k":2,"x":1,"s":340,"f":1}
+type StatsMsg struct {
+	Type    string `json:"t"`
+	Score   int    `json:"p"`
+	Rank    int    `json:"r"`
+	Of      int    `json:"n"`
+	Length  int    `json:"l"`
+	Kills   int    `json:"k"`
+	Assists int    `json:"x"`
+	Session int    `json:"s"`
+	Effects int    `json:"f"`
+}
+
+// MotdMsg is the operator's message of the day for the join screen.
+// n = server name, g = region, o = mode, m = rules/free text, l = links
+// {"t":"o","n":"EU #1","g":"eu-west","o":"ffa","m":"Be nice","l":[{"n":"Discord","u":"https://.."}]}
+type MotdMsg struct {
+	Type   string        `json:"t"`
+	Name   string        `json:"n,omitempty"`
+	Region string        `json:"g,omitempty"`
+	Mode   string        `json:"o,omitempty"`
+	Text   string        `json:"m,omitempty"`
+	Links  []MotdLinkDTO `json:"l,omitempty"`
+}
+
+// MotdLinkDTO is one community link: n = label, u = absolute http(s) URL
+type MotdLinkDTO struct {
+	Label string `json:"n"`
+	URL   string `json:"u"`
 }
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PlayersSample is the online player count at one moment
+type PlayersSample struct {
+	Time    time.Time `json:"time"`
+	Players int       `json:"players"`
+}
+
+// BiggestSnake is the highest score reached today
+type BiggestSnake struct {
+	Name  string    `json:"name"`
+	Score int       `json:"score"`
+	Bot   bool      `json:"bot,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// PublicStatsDoc is the GET /api/stats body. "Today" is the UTC day.
+type PublicStatsDoc struct {
+	Updated        time.Time       `json:"updated"`
+	Players        int             `json:"players"`
+	PlayersHistory []PlayersSample `json:"players_history"` // last 24 h, oldest first
+	KillsToday     int             `json:"kills_today"`
+	Biggest        *BiggestSnake   `json:"biggest_today,omitempty"`
+	AvgLifespanSec float64         `json:"avg_lifespan_sec"` // player lives ended today
+	LivesToday     int             `json:"lives_today"`
+}
+
+// publicStatsCache is an encoded PublicStatsDoc and its ETag
+type publicStatsCache struct {
+	body []byte
+	etag string
+}
+
+// PublicStats keeps community-facing aggregates up to date from game events
+// and the tick, and republishes them every PublicStatsRefresh as a cached,
+// pre-encoded document, so serving /api/stats never touches the world.
+// Accumulation is game-loop only; the cache is swapped atomically.
+type PublicStats struct {
+	day         time.Time // UTC midnight the daily counters started
+	kills       int
+	lives       int
+	lifeTotal   time.Duration
+	biggest     BiggestSnake         // Score 0 until anyone scores today
+	born        map[string]time.Time // player snake ID -> spawn time
+	history     []PlayersSample
+	nextSample  time.Time
+	nextPublish time.Time
+
+	mu    sync.Mutex
+	cache *publicStatsCache
+}
+
+// NewPublicStats starts today's counters from zero
+func NewPublicStats() *PublicStats {
+	return &PublicStats{born: make(map[string]time.Time)}
+}
+
+// rollDay resets the daily counters once now is on a new UTC day
+func (p *PublicStats) rollDay(now time.Time) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	if day.Equal(p.day) {
+		return
+	}
+	p.day, p.kills, p.lives, p.lifeTotal, p.biggest = day, 0, 0, 0, BiggestSnake{}
+}
+
+// observe counts kills and player lifespans. Game-loop goroutine only.
+func (p *PublicStats) observe(e GameEvent) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.rollDay(now)
+	switch e.Type {
+	case EventJoin:
+		if !e.Bot {
+			p.born[e.Snake] = now
+		}
+	case EventKill:
+		p.kills++
+	case EventDeath, EventLeave:
+		if t, ok := p.born[e.Snake]; ok {
+			delete(p.born, e.Snake)
+			p.lives++
+			p.lifeTotal += now.Sub(t)
+		}
+	}
+}
+
+// updatePublicStats tracks the biggest snake, samples the player count and
+// republishes the document when due. Caller must hold w.mu.Lock.
+func (w *World) updatePublicStats(players int) {
+	p := w.PublicStats
+	now := time.Now()
+	p.rollDay(now)
+	for _, s := range w.Snakes {
+		if s.Alive && s.Score > p.biggest.Score {
+			p.biggest = BiggestSnake{Name: s.Name, Score: s.Score, Bot: strings.HasPrefix(s.ID, "bot-"), Time: now}
+		}
+	}
+	if !now.Before(p.nextSample) {
+		p.nextSample = now.Add(PublicStatsSampleEvery)
+		p.history = append(p.history, PlayersSample{Time: now, Players: players})
+		if keep := int(24 * time.Hour / PublicStatsSampleEvery); len(p.history) > keep {
+			p.history = p.history[len(p.history)-keep:]
+		}
+	}
+	if now.Before(p.nextPublish) {
+		return
+	}
+	p.nextPublish = now.Add(PublicStatsRefresh)
+	doc := PublicStatsDoc{
+		Updated:        now,
+		Players:        players,
+		PlayersHistory: p.history,
+		KillsToday:     p.kills,
+		LivesToday:     p.lives,
+	}
+	if p.biggest.Score > 0 {
+		b := p.biggest
+		doc.Biggest = &b
+	}
+	if p.lives > 0 {
+		doc.AvgLifespanSec = (p.lifeTotal / time.Duration(p.lives)).Seconds()
+	}
+	body, _ := json.Marshal(doc)
+	sum := sha256.Sum256(body)
+	p.mu.Lock()
+	p.cache = &publicStatsCache{body: body, etag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+	p.mu.Unlock()
+}
+
+// handlePublicStats serves GET /api/stats from the cached document, with an
+// ETag so widgets polling it mostly get 304s, and open CORS so any site can
+// embed it
+func handlePublicStats(world *World) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := world.PublicStats
+		p.mu.Lock()
+		c := p.cache
+		p.mu.Unlock()
+		if c == nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "stats not ready"})
+			return
+		}
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", "*")
+		h.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(PublicStatsRefresh.Seconds())))
+		h.Set("ETag", c.etag)
+		if r.Header.Get("If-None-Match") == c.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		h.Set("Content-Type", "application/json")
+		_, _ = w.Write(c.body)
+	}
+}
+
+// apiRateLimiter allows each client IP PublicAPIRateLimit requests per
+// minute on the public /api/ endpoints community sites poll
+type apiRateLimiter struct {
+	proxies trustedProxies // see client_ip.go
+	mu      sync.Mutex
+	window  time.Time
+	counts  map[string]int
+}
+
+func newAPIRateLimiter(proxies trustedProxies) *apiRateLimiter {
+	return &apiRateLimiter{proxies: proxies, counts: make(map[string]int)}
+}
+
+// allow counts a request from ip in the current minute
+func (l *apiRateLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now := time.Now().Truncate(time.Minute); !now.Equal(l.window) {
+		l.window = now
+		clear(l.counts)
+	}
+	l.counts[ip]++
+	return l.counts[ip] <= PublicAPIRateLimit
+}
+
+// limit wraps a public handler with the per-IP limit
+func (l *apiRateLimiter) limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !l.allow(l.proxies.clientIP(r)) {
+			w.Header().Set("Retry-After", strconv.Itoa(60-time.Now().Second()))
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate limited"})
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+	"os"
+	"strconv"
+)
+
+// rankedFromEnv reads SLETHER_RANKED: a ranked world rates kills between
+// claimed names (see rateKill) and shows ratings on the leaderboard
+func rankedFromEnv() bool {
+	ranked, _ := strconv.ParseBool(os.Getenv("SLETHER_RANKED"))
+	return ranked
+}
+
+// rating is p's Elo rating; profiles claimed before ratings existed start fresh
+func (p *Profile) rating() float64 {
+	if p.Rating == 0 {
+		return RatingStart
+	}
+	return p.Rating
+}
+
+// eloDelta is the rating the killer gains and the victim loses. The usual
+// Elo expectation sets the base (an upset moves ratings more), scaled by
+// the victim's length relative to the killer's, so picking off a much
+// smaller snake earns little and bringing down a giant earns more.
+func eloDelta(killerRating, victimRating float64, killerLen, victimLen int) float64 {
+	expected := 1 / (1 + math.Pow(10, (victimRating-killerRating)/400))
+	size := float64(victimLen) / float64(max(killerLen, 1))
+	size = max(RatingSizeMin, min(RatingSizeMax, size))
+	return RatingK * size * (1 - expected)
+}
+
+// rateKill moves ratings for killer killing victim in a ranked world.
+// Snakes without a claimed profile (guests, bots) count as RatingStart and
+// keep no rating. Call before the victim's death is emitted, while its
+// life is still tracked.
+func (s *ProfileStore) rateKill(killer, victim *Snake) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kLife, kRated := s.lives[killer.ID]
+	vLife, vRated := s.lives[victim.ID]
+	if !kRated && !vRated {
+		return
+	}
+	kp, vp := s.byKey[kLife.key], s.byKey[vLife.key]
+	if kp == vp {
+		return // one profile playing two sessions can't farm itself
+	}
+	kr, vr := RatingStart, RatingStart
+	if kp != nil {
+		kr = kp.rating()
+	}
+	if vp != nil {
+		vr = vp.rating()
+	}
+	delta := eloDelta(kr, vr, len(killer.Segments), len(victim.Segments))
+	if kp != nil {
+		kp.Rating = kr + delta
+	}
+	if vp != nil {
+		vp.Rating = max(RatingFloor, vr-delta)
+	}
+	s.dirty = true
+}
+
+// ratingOf is the rating of the profile snakeID plays for, if any
+func (s *ProfileStore) ratingOf(snakeID string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	life, ok := s.lives[snakeID]
+	if !ok {
+		return 0, false
+	}
+	p, ok := s.byKey[life.key]
+	if !ok {
+		return 0, false
+	}
+	return int(math.Round(p.rating())), true
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Replay export plays a world log dump (see world_log.go) back headlessly
+// and writes one frame of entity JSON per logged tick, e.g. to render a
+// highlight video of a big kill. Each snake is driven by a ReplayController
+// fed the inputs the log recorded for it (see controller.go) and moves
+// under the game's own rules. The log keeps no food and can't re-run
+// collisions, so deaths and removals come from the log, and after each move
+// a snake's heading, head and length are set to the logged ones so it
+// can't drift.
+
+// ReplayFrame is one tick of an exported replay
+type ReplayFrame struct {
+	Tick   uint64          `json:"tick"`
+	Snakes []ReplaySnake   `json:"snakes"`
+	Deaths []WorldLogEntry `json:"deaths,omitempty"` // as logged this tick
+}
+
+// ReplaySnake is one live snake in a frame
+type ReplaySnake struct {
+	ID       string       `json:"id"`
+	Angle    float64      `json:"angle"`
+	Boost    bool         `json:"boost,omitempty"`
+	Segments [][2]float64 `json:"s"` // head first, as in SnakeDTO
+}
+
+// replayStart locates a log entry: tick index, then entry index
+type replayStart [2]int
+
+// replayLives finds where each snake's life starts in d and the inputs
+// logged for it until it dies or is removed. A life starts at a spawn, or
+// at the first input of a snake already alive when the log begins.
+func replayLives(d WorldLogDump) map[replayStart][]PlayerInput {
+	lives := make(map[replayStart][]PlayerInput)
+	open := make(map[string]replayStart)
+	for ti, t := range d.Ticks {
+		for ei, e := range t.Entries {
+			switch e.Type {
+			case WorldLogSpawn:
+				open[e.Snake] = replayStart{ti, ei}
+				lives[open[e.Snake]] = nil
+			case WorldLogInput:
+				start, ok := open[e.Snake]
+				if !ok {
+					start = replayStart{ti, ei}
+					open[e.Snake] = start
+				}
+				lives[start] = append(lives[start], PlayerInput{Angle: e.Angle, Boost: e.Boost, Seq: e.Seq})
+			case WorldLogDeath, WorldLogRemove:
+				delete(open, e.Snake)
+			}
+		}
+	}
+	return lives
+}
+
+// replayWorldLog plays d back, passing each tick's frame to emit in order.
+// Each tick replays the log's own order: entries before the tick's inputs
+// (joins, and bots spawned before the loop started), the move, then the
+// entries after it.
+func replayWorldLog(d WorldLogDump, emit func(ReplayFrame) error) error {
+	world := newEmptyWorld()
+	gl := newGameLoop(world, NewConnManager(), 0)
+	gl.ambientFood = false
+	lives := replayLives(d)
+
+	start := func(at replayStart, e WorldLogEntry) {
+		inputs := lives[at]
+		s := newSnakeAt(e.Snake, e.Snake, "#ffffff", e.X, e.Y)
+		if e.Type == WorldLogInput {
+			s.Angle = e.Heading
+		}
+		// The log has no body: lay it straight behind the head
+		s.Segments = make([]Point, max(e.Length, SnakeMinSegments))
+		for i := range s.Segments {
+			s.Segments[i] = Point{
+				X: e.X - float64(i)*SnakeSegmentSpacing*math.Cos(s.Angle),
+				Y: e.Y - float64(i)*SnakeSegmentSpacing*math.Sin(s.Angle),
+			}
+		}
+		s.prevHead = s.Segments[0]
+		s.recomputeBounds()
+		world.AddSnake(s)
+		gl.SetController(s.ID, NewReplay(inputs))
+	}
+	for ti, t := range d.Ticks {
+		unlock := world.lock("replay")
+		world.Tick = t.Tick
+		frame := ReplayFrame{Tick: t.Tick, Snakes: []ReplaySnake{}}
+		apply := func(ei int, e WorldLogEntry) {
+			switch e.Type {
+			case WorldLogSpawn:
+				start(replayStart{ti, ei}, e)
+			case WorldLogInput:
+				if s, ok := world.Snakes[e.Snake]; ok {
+					pinToLog(s, e)
+				}
+			case WorldLogDeath:
+				frame.Deaths = append(frame.Deaths, e)
+				world.RemoveSnake(e.Snake)
+			case WorldLogRemove:
+				world.RemoveSnake(e.Snake)
+			}
+		}
+		moved := slices.IndexFunc(t.Entries, func(e WorldLogEntry) bool { return e.Type == WorldLogInput })
+		if moved < 0 {
+			moved = len(t.Entries)
+		}
+		for ei, e := range t.Entries[:moved] {
+			apply(ei, e)
+		}
+		for ei := moved; ei < len(t.Entries); ei++ {
+			if e := t.Entries[ei]; e.Type == WorldLogInput && world.Snakes[e.Snake] == nil {
+				start(replayStart{ti, ei}, e)
+			}
+		}
+		gl.moveSnakes(gl.gatherInputs())
+		for ei := moved; ei < len(t.Entries); ei++ {
+			apply(ei, t.Entries[ei])
+		}
+		for _, s := range world.Snakes {
+			if s.Alive {
+				frame.Snakes = append(frame.Snakes, ReplaySnake{ID: s.ID, Angle: math.Round(s.Angle*1000) / 1000, Boost: s.BoostActive, Segments: s.ToDTO(0).Segments})
+			}
+		}
+		unlock()
+		slices.SortFunc(frame.Snakes, func(a, b ReplaySnake) int { return strings.Compare(a.ID, b.ID) })
+		if err := emit(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pinToLog sets s's heading, head and length to the ones logged with its
+// input. Missing length is grown back as Move lays segments, like eaten food.
+func pinToLog(s *Snake, e WorldLogEntry) {
+	s.Angle = e.Heading
+	s.Segments[0] = Point{X: e.X, Y: e.Y}
+	if e.Length > 0 {
+		if len(s.Segments) > e.Length {
+			s.Segments = s.Segments[:e.Length]
+		}
+		s.PendingGrowth = e.Length - len(s.Segments)
+	}
+	s.recomputeBounds()
+}
+
+// exportReplay reads the world log dump at path and writes its frames to
+// out as NDJSON, one frame per line. The dump's tick rate, if recorded,
+// replaces the configured one so snakes move as they did.
+func exportReplay(path string, out io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var d WorldLogDump
+	if err := json.Unmarshal(data, &d); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if d.TickRate != 0 {
+		if d.TickRate < MinTickRate || d.TickRate > MaxTickRate {
+			return fmt.Errorf("%s: tick rate %d out of range", path, d.TickRate)
+		}
+		TickRate = d.TickRate
+	}
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	if err := replayWorldLog(d, func(f ReplayFrame) error { return enc.Encode(f) }); err != nil {
+		return err
+	}
+	return w.Flush()
+}
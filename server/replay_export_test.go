@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReplayExportFollowsLog records a game with bots and a player steering
+// into the boundary, replays its world log and checks every frame has the
+// snakes that were alive at that tick, heading and placed as they were, and
+// the player's death
+func TestReplayExportFollowsLog(t *testing.T) {
+	quietLogs(t)
+	world := newEmptyWorld()
+	world.Log = NewWorldLog(200, t.TempDir())
+	conns := NewConnManager()
+	gl := newGameLoop(world, conns, 3)
+
+	// Alive before the log begins: its life starts at its first input
+	for range 5 {
+		gl.tick()
+	}
+	world.Log = NewWorldLog(200, t.TempDir())
+	s := newSnakeAt("p1", "p1", "#ffffff", WorldCenterX+WorldRadius-40, WorldCenterY)
+	world.AddSnake(s)
+	c := NewConn(newMockWS())
+	c.ID, c.snakeID = "p1", "p1"
+	c.setInput(0.3, true, 0)
+	conns.Add(c)
+
+	type pose struct {
+		head  [2]float64
+		angle float64
+	}
+	var live []map[string]pose
+	for range 40 {
+		gl.tick()
+		poses := map[string]pose{}
+		for id, s := range world.Snakes {
+			if s.Alive {
+				poses[id] = pose{roundPoint(s.Head()), s.Angle}
+			}
+		}
+		live = append(live, poses)
+	}
+	if s.Alive {
+		t.Fatal("player never reached the boundary")
+	}
+
+	dump := world.Log.Dump("main", "test")
+	data, _ := json.Marshal(dump)
+	path := filepath.Join(t.TempDir(), "dump.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := exportReplay(path, &out); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	dec := json.NewDecoder(&out)
+	died := false
+	for i := 0; dec.More(); i++ {
+		var f ReplayFrame
+		if err := dec.Decode(&f); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if f.Tick != dump.Ticks[i].Tick {
+			t.Fatalf("frame %d is tick %d, want %d", i, f.Tick, dump.Ticks[i].Tick)
+		}
+		want := live[i]
+		if len(f.Snakes) != len(want) {
+			t.Fatalf("tick %d: %d snakes, want %d", f.Tick, len(f.Snakes), len(want))
+		}
+		for _, rs := range f.Snakes {
+			p, ok := want[rs.ID]
+			if !ok {
+				t.Fatalf("tick %d: %s in the replay but not alive", f.Tick, rs.ID)
+			}
+			if rs.Segments[0] != p.head {
+				t.Fatalf("tick %d: %s head at %v, want %v", f.Tick, rs.ID, rs.Segments[0], p.head)
+			}
+			if math.Abs(normalizeAngle(rs.Angle-p.angle)) > 0.01 {
+				t.Fatalf("tick %d: %s heading %.3f, want %.3f", f.Tick, rs.ID, rs.Angle, p.angle)
+			}
+		}
+		for _, d := range f.Deaths {
+			died = died || (d.Snake == "p1" && d.Other == "")
+		}
+	}
+	if !died {
+		t.Fatal("replay has no boundary death for the player")
+	}
+}
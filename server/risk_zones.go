@@ -0,0 +1,25 @@
+package main
+
+import "math"
+
+// riskMultiplier is what food eaten at x,y is worth relative to its value:
+// RiskBandMultiplier within RiskBandWidth of the boundary, a danger zone's
+// own multiplier inside it, the highest where they overlap, and 1 elsewhere.
+// The map is fixed at world construction, so no lock is needed.
+func (w *World) riskMultiplier(x, y float64) float64 {
+	m := 1.0
+	if math.Hypot(x-WorldCenterX, y-WorldCenterY) > WorldRadius-RiskBandWidth {
+		m = RiskBandMultiplier
+	}
+	for _, z := range w.Map.Zones {
+		if z.Multiplier > m && math.Hypot(x-z.X, y-z.Y) <= z.Radius {
+			m = z.Multiplier
+		}
+	}
+	return m
+}
+
+// riskBonus is the extra value food worth value earns for being eaten at x,y
+func (w *World) riskBonus(x, y float64, value int) int {
+	return int(math.Round(float64(value) * (w.riskMultiplier(x, y) - 1)))
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Room is one independent game instance: a world, the game loop simulating
+// it (with its bots) and the connections playing in it. Rooms share nothing
+// that is simulated; only the persistent stores (profiles, seasons, MOTD,
+// content packs, sanctions, bans and the event stream) are common to all of
+// them, see newRoomWorld. Rooms created by tenants keep their own profiles
+// and no records or seasons (see hosted_rooms.go).
+type Room struct {
+	Name  string
+	World *World
+	Loop  *GameLoop
+	Conns *ConnManager
+	// Capacity is the most players the room takes: MaxPlayers, or less for
+	// a hosted room (immutable)
+	Capacity int
+	// Tenant and Key name the tenant that created the room and the key it
+	// used, "" for the server's own rooms; quota is that key's MaxPlayers
+	// (all immutable, see hosted_rooms.go)
+	Tenant, Key string
+	quota       int
+	// closed is set once the room is being destroyed, for connections
+	// placed in it just before
+	closed atomic.Bool
+
+	// occupancy serializes the room's full/not-full webhook transitions;
+	// full is whether room_full was the last of them (see room_webhooks.go)
+	occupancy sync.Mutex
+	full      bool
+}
+
+// NewRoom wraps world in a room with its own game loop and connections
+func NewRoom(name string, world *World) *Room {
+	world.Room = name
+	conns := NewConnManager()
+	return &Room{Name: name, World: world, Loop: NewGameLoop(world, conns), Conns: conns, Capacity: MaxPlayers}
+}
+
+// newRoomWorld builds another room's world like base: the same content pack
+// and settings, sharing base's persistent stores
+func newRoomWorld(base *World, pack *ContentPack) *World {
+	w := NewWorldFromPack(pack)
+	w.Moderation, w.Bans, w.MOTD, w.Packs = base.Moderation, base.Bans, base.MOTD, base.Packs
+	w.Profiles, w.Seasons, w.Records, w.Events = base.Profiles, base.Seasons, base.Records, base.Events
+	w.Ranked, w.Board, w.Lang = base.Ranked, base.Board, base.Lang
+	w.Comeback, w.HeadStart, w.BotHunt = base.Comeback, base.HeadStart, base.BotHunt
+	w.Log = base.Log.fresh()
+	w.Machines.Policy = base.Machines.Policy
+	return w
+}
+
+// roomName is the name of the i-th room (from 0): "main", then "room-2"...
+func roomName(i int) string {
+	if i == 0 {
+		return "main"
+	}
+	return fmt.Sprintf("room-%d", i+1)
+}
+
+// roomsFromEnv reads SLETHER_ROOMS, the number of rooms to run (1-MaxRooms)
+func roomsFromEnv() (int, error) {
+	v := os.Getenv("SLETHER_ROOMS")
+	if v == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > MaxRooms {
+		return 0, fmt.Errorf("SLETHER_ROOMS=%q: want 1-%d", v, MaxRooms)
+	}
+	return n, nil
+}
+
+// RoomManager holds the server's rooms and places new connections in them.
+// The server's own rooms are fixed at startup; tenants add and remove
+// hosted rooms while it runs (see hosted_rooms.go), so the set is guarded
+// by mu. Lifecycle changes go to hooks (see room_webhooks.go), nil when no
+// webhook is configured.
+type RoomManager struct {
+	mu      sync.RWMutex
+	rooms   []*Room
+	byName  map[string]*Room
+	running bool // Run was called; rooms added later start at once
+	hooks   *RoomWebhook
+	// pack and cohorts are what hosted rooms are built with by default,
+	// like the server's own rooms; set before Run
+	pack    *ContentPack
+	cohorts []*BotCohort
+}
+
+// NewRoomManager manages rooms; the first one is the default room for the
+// admin API and public endpoints
+func NewRoomManager(rooms ...*Room) *RoomManager {
+	m := &RoomManager{rooms: rooms, byName: make(map[string]*Room, len(rooms))}
+	for _, r := range rooms {
+		m.byName[r.Name] = r
+	}
+	return m
+}
+
+// Rooms lists every room, the default one first
+func (m *RoomManager) Rooms() []*Room {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.rooms)
+}
+
+// Get finds a room by name
+func (m *RoomManager) Get(name string) (*Room, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.byName[name]
+	return r, ok
+}
+
+// Run starts every room's game loop
+func (m *RoomManager) Run() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running = true
+	for _, r := range m.rooms {
+		go r.Loop.Run()
+		m.hooks.emit(RoomOpened, r)
+	}
+}
+
+// Ready reports whether every room's game loop is ticking
+func (m *RoomManager) Ready() bool {
+	for _, r := range m.Rooms() {
+		if !r.Loop.Ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// Errors from RoomManager.Assign
+var (
+	errNoSuchRoom = errors.New("no such room")
+	errRoomsFull  = errors.New("server full")
+)
+
+// Assign picks the room for a new connection: the room named want, or the
+// least-full of the server's own rooms when want is "" — hosted rooms are
+// only joined by name. A hosted room also counts against its key's player
+// quota. Like the single-world check before it, the count isn't reserved,
+// so simultaneous connections may overshoot a limit by a few.
+func (m *RoomManager) Assign(want string) (*Room, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if want != "" {
+		r, ok := m.byName[want]
+		if !ok {
+			return nil, errNoSuchRoom
+		}
+		if r.Conns.Count() >= r.Capacity || m.overQuota(r) {
+			return nil, errRoomsFull
+		}
+		return r, nil
+	}
+	var room *Room
+	best := MaxPlayers
+	for _, r := range m.rooms {
+		if r.Tenant != "" {
+			continue
+		}
+		if n := r.Conns.Count(); n < best && n < r.Capacity {
+			room, best = r, n
+		}
+	}
+	if room == nil {
+		return nil, errRoomsFull
+	}
+	return room, nil
+}
+
+// overQuota reports whether the rooms created with r's key already hold
+// the key's MaxPlayers; caller holds mu
+func (m *RoomManager) overQuota(r *Room) bool {
+	if r.Key == "" || r.quota == 0 {
+		return false
+	}
+	n := 0
+	for _, other := range m.rooms {
+		if other.Key == r.Key {
+			n += other.Conns.Count()
+		}
+	}
+	return n >= r.quota
+}
+
+// findConn finds a connection by ID in whichever room it plays
+func (m *RoomManager) findConn(id string) (*Conn, bool) {
+	for _, r := range m.Rooms() {
+		if c, ok := r.Conns.Get(id); ok {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// fromRequest resolves the ?room= query parameter, the default room when absent
+func (m *RoomManager) fromRequest(r *http.Request) (*Room, bool) {
+	name := r.URL.Query().Get("room")
+	if name == "" {
+		return m.Rooms()[0], true
+	}
+	return m.Get(name)
+}
+
+// perRoom serves each room with its own handler built by h, picked by the
+// ?room= query parameter. The handler is built per request, as rooms come
+// and go.
+func (m *RoomManager) perRoom(h func(*World) http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		room, ok := m.fromRequest(r)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such room"})
+			return
+		}
+		h(room.World)(w, r)
+	}
+}
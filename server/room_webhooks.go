@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Room event types in RoomEvent.Type
+const (
+	RoomOpened  = "room_open"     // the room's game loop started
+	RoomClosed  = "room_close"    // the room stopped: at shutdown, or a hosted room closed
+	RoomFull    = "room_full"     // a join took the room to its capacity
+	RoomNotFull = "room_not_full" // a full room dropped below its capacity
+	RoomEmpty   = "room_empty"    // the last player left
+)
+
+// RoomEvent is one room lifecycle webhook body. Players is the room's
+// occupancy right after the event; simultaneous joins may report it a
+// little out of order, so trackers should take the latest by Time.
+type RoomEvent struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Room     string    `json:"room"`
+	Mode     string    `json:"mode"` // "ranked" or "casual"
+	Pack     string    `json:"pack,omitempty"`
+	Players  int       `json:"players"`
+	Capacity int       `json:"capacity"`
+	Tenant   string    `json:"tenant,omitempty"` // hosted rooms only
+}
+
+// RoomWebhook POSTs RoomEvents as JSON to SLETHER_ROOM_WEBHOOK, so a lobby
+// or matchmaking service can track rooms without polling. Like the event
+// stream it never blocks a caller: events queue in a bounded buffer
+// drained by a sender goroutine, one request each, and are dropped (and
+// counted) when the buffer is full; a failed delivery is logged, not
+// retried. With SLETHER_ROOM_WEBHOOK_SECRET set, each request carries
+// X-Slether-Signature: sha256=<hex HMAC of the body>. A nil webhook — the
+// URL unset — turns every method into a no-op.
+type RoomWebhook struct {
+	url     string
+	secret  []byte
+	client  *http.Client
+	events  chan RoomEvent
+	dropped atomic.Int64
+	// mu guards closing events: a room can still empty after Close
+	mu     sync.Mutex
+	closed bool
+	// done is closed when the sender exits after Close
+	done chan struct{}
+}
+
+// roomWebhookFromEnv reads SLETHER_ROOM_WEBHOOK (an http or https URL) and
+// SLETHER_ROOM_WEBHOOK_SECRET; nil when the URL is unset
+func roomWebhookFromEnv() (*RoomWebhook, error) {
+	v := os.Getenv("SLETHER_ROOM_WEBHOOK")
+	if v == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("SLETHER_ROOM_WEBHOOK=%q: want an http or https URL", v)
+	}
+	h := &RoomWebhook{
+		url:    v,
+		secret: []byte(os.Getenv("SLETHER_ROOM_WEBHOOK_SECRET")),
+		client: &http.Client{Timeout: RoomWebhookTimeout},
+		events: make(chan RoomEvent, RoomWebhookBuffer),
+		done:   make(chan struct{}),
+	}
+	go h.run()
+	log.Printf("room webhook: posting to %s", u.Redacted())
+	return h, nil
+}
+
+// emit queues an event of typ about room; safe from any goroutine
+func (h *RoomWebhook) emit(typ string, room *Room) {
+	if h == nil {
+		return
+	}
+	e := RoomEvent{
+		Time:     time.Now(),
+		Type:     typ,
+		Room:     room.Name,
+		Mode:     "casual",
+		Pack:     room.World.Pack,
+		Players:  room.Conns.Count(),
+		Capacity: room.Capacity,
+		Tenant:   room.Tenant,
+	}
+	if room.World.Ranked {
+		e.Mode = "ranked"
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	select {
+	case h.events <- e:
+	default:
+		h.dropped.Add(1)
+	}
+}
+
+// run is the sender goroutine: it posts queued events in order until Close
+func (h *RoomWebhook) run() {
+	defer close(h.done)
+	for e := range h.events {
+		if err := h.post(e); err != nil {
+			log.Printf("room webhook: %s %s: %v", e.Type, e.Room, err)
+		}
+		if n := h.dropped.Swap(0); n > 0 {
+			log.Printf("room webhook: dropped %d events (buffer full)", n)
+		}
+	}
+}
+
+// post delivers one event
+func (h *RoomWebhook) post(e RoomEvent) error {
+	body, _ := json.Marshal(e)
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(h.secret) > 0 {
+		mac := hmac.New(sha256.New, h.secret)
+		mac.Write(body)
+		req.Header.Set("X-Slether-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+// Close sends the events still queued, waiting at most timeout, and stops
+// the sender; later events are ignored. A no-op on a nil webhook.
+func (h *RoomWebhook) Close(timeout time.Duration) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.closed = true
+	close(h.events)
+	h.mu.Unlock()
+	select {
+	case <-h.done:
+	case <-time.After(timeout):
+		log.Printf("room webhook: %d events not sent at shutdown", len(h.events))
+	}
+}
+
+// joined reports room filling up, once, when a connection placed in it
+// takes it to its capacity
+func (m *RoomManager) joined(room *Room) {
+	room.occupancy.Lock()
+	defer room.occupancy.Unlock()
+	if !room.full && room.Conns.Count() >= room.Capacity {
+		room.full = true
+		m.hooks.emit(RoomFull, room)
+	}
+}
+
+// left reports a full room opening up again, and the room emptying, after
+// a connection has gone from it
+func (m *RoomManager) left(room *Room) {
+	room.occupancy.Lock()
+	defer room.occupancy.Unlock()
+	n := room.Conns.Count()
+	if room.full && n < room.Capacity {
+		room.full = false
+		m.hooks.emit(RoomNotFull, room)
+	}
+	if n == 0 {
+		m.hooks.emit(RoomEmpty, room)
+	}
+}
+
+// Close reports every room closed and sends the webhook's remaining
+// events, waiting at most timeout. Call it once the loops have stopped and
+// the players are gone.
+func (m *RoomManager) Close(timeout time.Duration) {
+	for _, r := range m.Rooms() {
+		m.hooks.emit(RoomClosed, r)
+	}
+	m.hooks.Close(timeout)
+}
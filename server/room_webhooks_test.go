@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRoomWebhook runs a room against a slow webhook endpoint and checks
+// the room's transitions neither wait on it nor get lost: open, full, not
+// full and close arrive in order, signed, with the occupancy at the time
+func TestRoomWebhook(t *testing.T) {
+	quietLogs(t)
+	const secret = "s3cret"
+	release := make(chan struct{})
+	got := make(chan RoomEvent, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if sig := r.Header.Get("X-Slether-Signature"); sig != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			t.Errorf("bad signature %q", sig)
+		}
+		var e RoomEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Errorf("body %s: %v", body, err)
+		}
+		<-release // every delivery waits until the room has done its part
+		got <- e
+	}))
+	defer srv.Close()
+	t.Setenv("SLETHER_ROOM_WEBHOOK", srv.URL)
+	t.Setenv("SLETHER_ROOM_WEBHOOK_SECRET", secret)
+
+	room := NewRoom("main", newEmptyWorld())
+	room.Capacity = 2
+	rooms := NewRoomManager(room)
+	var err error
+	if rooms.hooks, err = roomWebhookFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	began := time.Now()
+	rooms.Run()
+	a, b := NewConn(newMockWS()), NewConn(newMockWS())
+	for _, c := range []*Conn{a, b} {
+		room.Conns.Add(c)
+		rooms.joined(room)
+	}
+	room.Conns.Remove(a.ID)
+	rooms.left(room)
+	took := time.Since(began)
+	room.Loop.Stop()
+
+	close(release)
+	if took > RoomWebhookTimeout/10 {
+		t.Fatalf("room transitions took %v behind a stalled webhook", took)
+	}
+	rooms.Close(time.Second)
+	want := []struct {
+		typ     string
+		players int
+	}{{RoomOpened, 0}, {RoomFull, 2}, {RoomNotFull, 1}, {RoomClosed, 1}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		e := <-got
+		if e.Type != w.typ || e.Players != w.players || e.Room != "main" || e.Capacity != 2 {
+			t.Errorf("event %d = %+v, want %s with %d players", i, e, w.typ, w.players)
+		}
+	}
+}
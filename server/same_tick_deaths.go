@@ -0,0 +1,60 @@
+package main
+
+import (
+	"maps"
+	"slices"
+)
+
+// deathOutcome is how one snake died this tick. Every death in a tick is
+// simultaneous, judged on where the heads ended up after all snakes moved:
+//
+//   - A death is attributed to the snake whose head or body it hit, even if
+//     that snake dies in the same tick (a chain death): its body was there
+//     when the head arrived.
+//   - A kill by a snake that dies in the same tick still counts towards its
+//     kills, the kill feed and its profile, but pays no shutdown bonus: there
+//     is no life left to grow.
+//   - Two snakes that killed each other (a head-on hit between equals, or
+//     each head into the other's body) made a mutual kill. Both death
+//     messages say so, the kill feed gets one entry for the pair, and ratings
+//     don't move.
+type deathOutcome struct {
+	victim   *Snake
+	killerID string // KillerBoundary for the boundary
+	killer   *Snake // nil for the boundary
+	// killerDies is set when the killer dies this tick too
+	killerDies bool
+	// mutual is set when the killer died to the victim this tick
+	mutual bool
+	// streak is the victim's kill streak when the tick began, before any of
+	// this tick's kills were credited
+	streak int
+}
+
+// resolveDeaths turns this tick's victim -> killer map into outcomes,
+// ordered by victim ID so that crediting them doesn't depend on map order.
+// Victims already dead are skipped. Caller must hold w.mu.Lock.
+func (w *World) resolveDeaths(deaths map[string]string) []deathOutcome {
+	out := make([]deathOutcome, 0, len(deaths))
+	for _, id := range slices.Sorted(maps.Keys(deaths)) {
+		victim := w.Snakes[id]
+		if victim == nil || !victim.Alive {
+			continue
+		}
+		o := deathOutcome{victim: victim, killerID: deaths[id], streak: victim.Kills}
+		if killer := w.Snakes[o.killerID]; killer != nil {
+			o.killer = killer
+			killedBy, dies := deaths[o.killerID]
+			o.killerDies = dies
+			o.mutual = dies && killedBy == id
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+// feedsKill reports whether o's kill gets its own kill feed entry: mutual
+// kills share one, carried by the pair's lower victim ID
+func (o deathOutcome) feedsKill() bool {
+	return o.killer != nil && (!o.mutual || o.killer.ID > o.victim.ID)
+}
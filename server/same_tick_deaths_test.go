@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io"
+	"log"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestMutualKill(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	r := newScenarioRun(scenario{snakes: []snakeFixture{
+		{ID: "A", X: WorldCenterX - 39, Y: WorldCenterY, Angle: 0},
+		{ID: "B", X: WorldCenterX + 39, Y: WorldCenterY, Angle: math.Pi},
+	}})
+	var snap *TickSnapshot
+	for range 15 {
+		r.loop.tick()
+		if snap = r.world.Snapshot(); len(snap.Deaths) > 0 {
+			break
+		}
+	}
+	for victim, killer := range map[string]string{"A": "B", "B": "A"} {
+		msg, ok := snap.Deaths[victim]
+		if !ok || msg.Killer != killer || msg.Mutual != 1 {
+			t.Errorf("%s death = %+v, want a mutual kill by %s", victim, msg, killer)
+		}
+		if kills := r.world.Snakes[victim].Kills; kills != 1 {
+			t.Errorf("%s kills = %d, want 1", victim, kills)
+		}
+	}
+	if len(snap.KillFeed) != 1 || snap.KillFeed[0].Mutual != 1 {
+		t.Fatalf("kill feed = %+v, want one mutual entry", snap.KillFeed)
+	}
+}
+
+// TestChainDeath has C run into A's body in the tick A runs into B's: C
+// still dies to A, and A's kill counts, but only B, who lives on, gets a
+// shutdown bonus, for A's streak as the tick began
+func TestChainDeath(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	// Map iteration order varies between runs; the outcome must not
+	for range 20 {
+		r := newScenarioRun(scenario{snakes: []snakeFixture{
+			// B's body runs up through the center, across A's path; C's head
+			// is just below A's body, which reaches 69px left of the center
+			{ID: "B", X: WorldCenterX, Y: WorldCenterY + 100, Angle: math.Pi / 2, Length: 50},
+			{ID: "A", X: WorldCenterX - 12, Y: WorldCenterY, Angle: 0, Length: 20},
+			{ID: "C", X: WorldCenterX - 40, Y: WorldCenterY + 10, Angle: -math.Pi / 2, Length: 20},
+		}})
+		r.world.Snakes["A"].Kills = ShutdownMinStreak
+		r.world.Snakes["C"].Kills = ShutdownMinStreak
+		scoreB := r.world.Snakes["B"].Score
+		r.loop.tick()
+		snap := r.world.Snapshot()
+
+		for victim, killer := range map[string]string{"A": "B", "C": "A"} {
+			if msg, ok := snap.Deaths[victim]; !ok || msg.Killer != killer || msg.Mutual != 0 {
+				t.Fatalf("%s death = %+v, want killed by %s", victim, msg, killer)
+			}
+		}
+		if _, dead := snap.Deaths["B"]; dead {
+			t.Fatal("B died")
+		}
+		if kills := r.world.Snakes["A"].Kills; kills != ShutdownMinStreak+1 {
+			t.Fatalf("A kills = %d, want %d", kills, ShutdownMinStreak+1)
+		}
+		feed := map[string]KillFeedMsg{}
+		for _, msg := range snap.KillFeed {
+			feed[msg.Killer] = msg
+		}
+		if len(snap.KillFeed) != 2 || feed["A"].Victim != "C" || feed["B"].Victim != "A" {
+			t.Fatalf("kill feed = %+v, want A ⚔ C and B ⚔ A", snap.KillFeed)
+		}
+		if feed["A"].Shutdown != 0 {
+			t.Fatalf("A was paid a shutdown bonus while dying: %+v", feed["A"])
+		}
+		if got := feed["B"]; got.Shutdown != ShutdownMinStreak || got.Bonus != ShutdownBonusPerKill*ShutdownMinStreak {
+			t.Fatalf("B's shutdown = %+v, want A's streak of %d as the tick began", got, ShutdownMinStreak)
+		}
+		if score := r.world.Snakes["B"].Score; score < scoreB+ShutdownBonusPerKill*ShutdownMinStreak {
+			t.Fatalf("B score = %d from %d, want the shutdown bonus added", score, scoreB)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"io"
+	"log"
+	"math"
+	"os"
+	"testing"
+)
+
+// snakeFixture places a player-controlled snake. Segments trail straight
+// behind the head, opposite Angle. Angle/Boost are also the constant input.
+type snakeFixture struct {
+	ID     string
+	X, Y   float64
+	Angle  float64
+	Length int  // segments; defaults to SnakeInitSegments
+	Boost  bool // held for the whole scenario
+}
+
+// foodFixture places one static food item
+type foodFixture struct {
+	X, Y  float64
+	Level int
+}
+
+// scenario is a declarative simulation fixture plus expected outcomes
+type scenario struct {
+	name   string
+	snakes []snakeFixture
+	food   []foodFixture
+	ticks  int
+
+	wantDeaths   map[string]string // victim ID -> killer name; unlisted snakes must survive
+	wantScores   map[string]int    // snake ID -> score after the run
+	wantLengths  map[string]int    // snake ID -> segment count after the run
+	wantFoodLeft int               // -1 to skip
+}
+
+// scenarioRun holds a world built from a fixture with no bots and no ambient food
+type scenarioRun struct {
+	world  *World
+	loop   *GameLoop
+	deaths map[string]string
+}
+
+func newScenarioRun(sc scenario) *scenarioRun {
+	world := newEmptyWorld()
+	conns := NewConnManager()
+	gl := newGameLoop(world, conns, 0)
+	gl.ambientFood = false
+
+	for _, fx := range sc.snakes {
+		length := fx.Length
+		if length == 0 {
+			length = SnakeInitSegments
+		}
+		s := NewSnake(fx.ID, fx.ID, "#ffffff")
+		s.Angle = fx.Angle
+		s.Segments = make([]Point, length)
+		for i := range s.Segments {
+			s.Segments[i] = Point{
+				X: fx.X - float64(i)*SnakeSegmentSpacing*math.Cos(fx.Angle),
+				Y: fx.Y - float64(i)*SnakeSegmentSpacing*math.Sin(fx.Angle),
+			}
+		}
+		s.Score = length
+		s.prevHead = s.Segments[0]
+		s.recomputeBounds()
+		world.AddSnake(s)
+
+		c := NewConn(newMockWS())
+		c.ID, c.snakeID = fx.ID, fx.ID
+		c.setInput(fx.Angle, fx.Boost, 0)
+		conns.Add(c)
+	}
+	for _, ff := range sc.food {
+		world.AddFood([]*Food{world.FoodPool.newFoodWithLevel(ff.X, ff.Y, ff.Level, false)})
+	}
+	return &scenarioRun{world: world, loop: gl, deaths: map[string]string{}}
+}
+
+// run advances n ticks, collecting every death and its killer
+func (r *scenarioRun) run(n int) {
+	for i := 0; i < n; i++ {
+		r.loop.tick()
+		for victim, msg := range r.world.Snapshot().Deaths {
+			r.deaths[victim] = msg.Killer
+		}
+	}
+}
+
+func (sc scenario) check(t *testing.T, r *scenarioRun) {
+	t.Helper()
+	for victim, killer := range sc.wantDeaths {
+		got, dead := r.deaths[victim]
+		if !dead {
+			t.Errorf("%s survived, want killed by %q", victim, killer)
+		} else if got != killer {
+			t.Errorf("%s killed by %q, want %q", victim, got, killer)
+		}
+	}
+	for victim, killer := range r.deaths {
+		if _, expected := sc.wantDeaths[victim]; !expected {
+			t.Errorf("%s unexpectedly killed by %q", victim, killer)
+		}
+	}
+	for id, want := range sc.wantScores {
+		if got := r.world.Snakes[id].Score; got != want {
+			t.Errorf("%s score = %d, want %d", id, got, want)
+		}
+	}
+	for id, want := range sc.wantLengths {
+		if got := len(r.world.Snakes[id].Segments); got != want {
+			t.Errorf("%s length = %d, want %d", id, got, want)
+		}
+	}
+	if sc.wantFoodLeft >= 0 && len(r.world.Food) != sc.wantFoodLeft {
+		t.Errorf("food left = %d, want %d", len(r.world.Food), sc.wantFoodLeft)
+	}
+}
+
+// Golden scenarios — update deliberately when collision, growth or boost rules change
+var goldenScenarios = []scenario{
+	{
+		// A reaches B's line after 20 ticks, by when B's 40 segments reach
+		// from 40px to 157px above the center: A crosses mid-body
+		name: "head into perpendicular body dies",
+		snakes: []snakeFixture{
+			{ID: "A", X: WorldCenterX - 60, Y: WorldCenterY - 130, Angle: 0},
+			{ID: "B", X: WorldCenterX, Y: WorldCenterY - 100, Angle: math.Pi / 2, Length: 40},
+		},
+		ticks:        30,
+		wantDeaths:   map[string]string{"A": "B"},
+		wantFoodLeft: -1,
+	},
+	{
+		// A runs alongside B's tail at B's pace, its head just out of reach
+		// of B's body (head plus body radius): a graze, not a hit
+		name: "grazing alongside a body survives",
+		snakes: []snakeFixture{
+			{ID: "A", X: WorldCenterX - 40, Y: WorldCenterY + SnakeHeadRadius + SnakeBodyRadius + 2, Angle: 0},
+			{ID: "B", X: WorldCenterX, Y: WorldCenterY, Angle: 0, Length: 40},
+		},
+		ticks:        30,
+		wantFoodLeft: -1,
+	},
+	{
+		name: "clipping the edge of a body dies",
+		snakes: []snakeFixture{
+			{ID: "A", X: WorldCenterX - 40, Y: WorldCenterY + SnakeHeadRadius + SnakeBodyRadius - 2, Angle: 0},
+			{ID: "B", X: WorldCenterX, Y: WorldCenterY, Angle: 0, Length: 40},
+		},
+		ticks:        30,
+		wantDeaths:   map[string]string{"A": "B"},
+		wantFoodLeft: -1,
+	},
+	{
+		// Heads close 6px/tick from 78px apart and meet at 18px — inside the
+		// head-to-head radius but before either head reaches the other's
+		// first body segment (which trails at most SnakeSegmentSpacing behind its head)
+		name: "head-on: bigger snake wins",
+		snakes: []snakeFixture{
+			{ID: "A", X: WorldCenterX - 39, Y: WorldCenterY, Angle: 0, Length: 20},
+			{ID: "B", X: WorldCenterX + 39, Y: WorldCenterY, Angle: math.Pi},
+		},
+		ticks:        15,
+		wantDeaths:   map[string]string{"B": "A"},
+		wantFoodLeft: -1,
+	},
+	{
+		name: "head-on: equal snakes both die",
+		snakes: []snakeFixture{
+			{ID: "A", X: WorldCenterX - 39, Y: WorldCenterY, Angle: 0},
+			{ID: "B", X: WorldCenterX + 39, Y: WorldCenterY, Angle: math.Pi},
+		},
+		ticks:        15,
+		wantDeaths:   map[string]string{"A": "B", "B": "A"},
+		wantFoodLeft: -1,
+	},
+	{
+		name: "eats food ahead and scores its value",
+		snakes: []snakeFixture{
+			{ID: "A", X: WorldCenterX, Y: WorldCenterY, Angle: 0},
+		},
+		food: []foodFixture{
+			{X: WorldCenterX + 30, Y: WorldCenterY, Level: FoodLevel3},
+			{X: WorldCenterX + 60, Y: WorldCenterY, Level: FoodLevel1},
+		},
+		ticks:        30,
+		wantScores:   map[string]int{"A": SnakeInitSegments + FoodLevel3 + FoodLevel1},
+		wantLengths:  map[string]int{"A": SnakeInitSegments + FoodLevel3 + FoodLevel1},
+		wantFoodLeft: 0,
+	},
+	{
+		name: "crossing the boundary kills",
+		snakes: []snakeFixture{
+			{ID: "A", X: WorldCenterX + WorldRadius - 10, Y: WorldCenterY, Angle: 0},
+		},
+		ticks:        10,
+		wantDeaths:   map[string]string{"A": "Boundary"},
+		wantFoodLeft: -1,
+	},
+	{
+		name: "boost costs one segment every SnakeBoostCostEvery",
+		snakes: []snakeFixture{
+			{ID: "A", X: WorldCenterX, Y: WorldCenterY, Angle: 0, Boost: true},
+		},
+		ticks:        3 * ticksFor(SnakeBoostCostEvery),
+		wantScores:   map[string]int{"A": SnakeInitSegments - 3},
+		wantLengths:  map[string]int{"A": SnakeInitSegments - 3},
+		wantFoodLeft: -1,
+	},
+}
+
+func TestGoldenScenarios(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, sc := range goldenScenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			r := newScenarioRun(sc)
+			r.run(sc.ticks)
+			sc.check(t, r)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"maps"
+	"time"
+)
+
+// EconomyCounts tallies score entering and leaving snakes over a span of
+// time. Score is created when a snake spawns, eats (food, with any risk
+// zone and comeback bonuses, or the golden apple) or collects a shutdown bonus, and destroyed when it boosts, takes a
+// damage hit, dies or is removed alive. Part of destroyed score comes back
+// as food (the *Dropped fields); the rest is the sink. Created minus
+// destroyed is the change in the total score of live snakes.
+type EconomyCounts struct {
+	Start time.Time `json:"start"`
+	// Created
+	Spawn     int         `json:"spawn"`
+	Eaten     map[int]int `json:"eaten"`     // food level -> value credited
+	Bonus     int         `json:"bonus"`     // shutdown bonuses
+	Objective int         `json:"objective"` // golden apples
+	Risk      int         `json:"risk"`      // extra value of food eaten in risk zones
+	Comeback  int         `json:"comeback"`  // extra value of food eaten by small snakes
+	// Destroyed, and the part of it dropped back into the world as food
+	Boost         int `json:"boost"`
+	BoostDropped  int `json:"boost_dropped"`
+	Damage        int `json:"damage"`
+	DamageDropped int `json:"damage_dropped"`
+	Death         int `json:"death"` // score of snakes when they died
+	DeathDropped  int `json:"death_dropped"`
+	Despawn       int `json:"despawn"` // retired bots removed without dying
+	// Withheld is value eaten but not credited (score-factor sanctions)
+	Withheld int `json:"withheld"`
+	// Totals, filled in by ScoreEconomy.Report
+	Created   int `json:"created"`
+	Destroyed int `json:"destroyed"`
+	Sink      int `json:"sink"` // destroyed and not returned as food
+}
+
+func newEconomyCounts(start time.Time) EconomyCounts {
+	return EconomyCounts{Start: start, Eaten: make(map[int]int)}
+}
+
+// add merges o into c
+func (c *EconomyCounts) add(o *EconomyCounts) {
+	c.Spawn += o.Spawn
+	for level, v := range o.Eaten {
+		c.Eaten[level] += v
+	}
+	c.Bonus += o.Bonus
+	c.Objective += o.Objective
+	c.Risk += o.Risk
+	c.Comeback += o.Comeback
+	c.Boost += o.Boost
+	c.BoostDropped += o.BoostDropped
+	c.Damage += o.Damage
+	c.DamageDropped += o.DamageDropped
+	c.Death += o.Death
+	c.DeathDropped += o.DeathDropped
+	c.Despawn += o.Despawn
+	c.Withheld += o.Withheld
+}
+
+// settled returns a copy (with its own Eaten map) with the totals filled in
+func (c EconomyCounts) settled() EconomyCounts {
+	c.Eaten = maps.Clone(c.Eaten)
+	c.Created = c.Spawn + c.Bonus + c.Objective + c.Risk + c.Comeback
+	for _, v := range c.Eaten {
+		c.Created += v
+	}
+	c.Destroyed = c.Boost + c.Damage + c.Death + c.Despawn
+	c.Sink = c.Destroyed - c.BoostDropped - c.DamageDropped - c.DeathDropped
+	return c
+}
+
+// ScoreEconomy audits where score comes from and where it goes, per wall
+// clock minute, so drop ratios and boost costs can be tuned from data.
+// Game-loop goroutine only; readers take World.rlock.
+type ScoreEconomy struct {
+	cur     EconomyCounts
+	history []EconomyCounts // completed minutes, oldest first
+	total   EconomyCounts   // every completed minute since start
+}
+
+// NewScoreEconomy starts counting from now
+func NewScoreEconomy() *ScoreEconomy {
+	start := time.Now().Truncate(time.Minute)
+	return &ScoreEconomy{cur: newEconomyCounts(start), total: newEconomyCounts(start)}
+}
+
+// roll closes the current minute once now has moved past it
+func (e *ScoreEconomy) roll(now time.Time) {
+	minute := now.Truncate(time.Minute)
+	if !minute.After(e.cur.Start) {
+		return
+	}
+	e.total.add(&e.cur)
+	e.history = append(e.history, e.cur)
+	if len(e.history) > EconomyHistoryMinutes {
+		e.history = e.history[len(e.history)-EconomyHistoryMinutes:]
+	}
+	e.cur = newEconomyCounts(minute)
+}
+
+// EconomyReport is the audit as of one moment: completed minutes (oldest
+// first), the minute in progress, and totals since start including it
+type EconomyReport struct {
+	Minutes []EconomyCounts `json:"minutes"`
+	Current EconomyCounts   `json:"current"`
+	Total   EconomyCounts   `json:"total"`
+}
+
+// Report copies the counters out; caller must hold at least w.rlock
+func (e *ScoreEconomy) Report() EconomyReport {
+	r := EconomyReport{Minutes: make([]EconomyCounts, len(e.history))}
+	for i, m := range e.history {
+		r.Minutes[i] = m.settled()
+	}
+	r.Current = e.cur.settled()
+	total := e.total.settled()
+	total.add(&r.Current)
+	r.Total = total.settled()
+	return r
+}
+
+func (e *ScoreEconomy) spawned(score int) { e.cur.Spawn += score }
+func (e *ScoreEconomy) bonus(score int)   { e.cur.Bonus += score }
+
+// objective books a golden apple worth value, of which credited reached the score
+func (e *ScoreEconomy) objective(value, credited int) {
+	e.cur.Objective += credited
+	e.cur.Withheld += value - credited
+}
+
+// ate books food of level worth value, of which credited reached the score
+func (e *ScoreEconomy) ate(level, value, credited int) {
+	e.cur.Eaten[level] += credited
+	e.cur.Withheld += value - credited
+}
+
+// risk books a risk zone bonus worth value, of which credited reached the score
+func (e *ScoreEconomy) risk(value, credited int) {
+	e.cur.Risk += credited
+	e.cur.Withheld += value - credited
+}
+
+// comeback books a comeback bonus worth value, of which credited reached the score
+func (e *ScoreEconomy) comeback(value, credited int) {
+	e.cur.Comeback += credited
+	e.cur.Withheld += value - credited
+}
+
+func (e *ScoreEconomy) boosted(cost int, dropped *Food) {
+	e.cur.Boost += cost
+	if dropped != nil {
+		e.cur.BoostDropped += dropped.Value
+	}
+}
+
+func (e *ScoreEconomy) damaged(cost int, dropped []*Food) {
+	e.cur.Damage += cost
+	e.cur.DamageDropped += foodValue(dropped)
+}
+
+func (e *ScoreEconomy) died(score int, dropped []*Food) {
+	e.cur.Death += score
+	e.cur.DeathDropped += foodValue(dropped)
+}
+
+func (e *ScoreEconomy) despawned(score int) { e.cur.Despawn += score }
+
+// foodValue sums the value of items
+func foodValue(items []*Food) int {
+	v := 0
+	for _, f := range items {
+		v += f.Value
+	}
+	return v
+}
+
+// steer applies input to s, dropping any boost food into the world and
+// booking the boost cost. Caller must hold w.mu.Lock.
+func (w *World) steer(s *Snake, angle float64, boost bool) {
+	before := s.Score
+	dropped := s.ApplyInput(angle, boost, w.FoodPool)
+	if dropped != nil {
+		w.addFood(dropped)
+	}
+	w.Economy.boosted(before-s.Score, dropped)
+}
+
+// dropBody kills s and scatters its body as food, worth factor of the usual
+// drop when below 1, booking its score against the death sink. Returns the
+// food dropped. Caller must hold w.mu.Lock.
+func (w *World) dropBody(s *Snake, factor float64) []*Food {
+	dropped := s.DropFood(w.FoodPool, factor)
+	w.AddFood(dropped)
+	w.Economy.died(s.Score, dropped)
+	return dropped
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// scoreRecordsSchema creates the records tables: every human life that
+// scored, and each name's best. The best table keeps the all-time board a
+// plain index scan however long the history grows.
+const scoreRecordsSchema = `
+CREATE TABLE IF NOT EXISTS scores (
+	id    INTEGER PRIMARY KEY,
+	name  TEXT    NOT NULL,
+	score INTEGER NOT NULL,
+	room  TEXT    NOT NULL,
+	ended INTEGER NOT NULL -- unix seconds
+);
+CREATE INDEX IF NOT EXISTS scores_ended ON scores (ended);
+CREATE TABLE IF NOT EXISTS best (
+	name  TEXT    PRIMARY KEY,
+	score INTEGER NOT NULL,
+	room  TEXT    NOT NULL,
+	ended INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS best_score ON best (score DESC);
+`
+
+// ScoreRecord is one line of a records board: a name's best final score
+type ScoreRecord struct {
+	Name  string    `json:"name"`
+	Score int       `json:"score"`
+	Room  string    `json:"room,omitempty"`
+	Time  time.Time `json:"time"` // when that life ended
+}
+
+// RecordBoards is the GET /api/records body. "Today" is the UTC day.
+type RecordBoards struct {
+	Updated time.Time     `json:"updated"`
+	AllTime []ScoreRecord `json:"all_time"`
+	Today   []ScoreRecord `json:"today"`
+}
+
+// Msg is the boards as sent to players, cut to RecordsMsgSize entries
+func (b *RecordBoards) Msg() RecordsMsg {
+	entries := func(recs []ScoreRecord) []RecordEntry {
+		out := make([]RecordEntry, 0, min(len(recs), RecordsMsgSize))
+		for _, r := range recs[:min(len(recs), RecordsMsgSize)] {
+			out = append(out, RecordEntry{Name: r.Name, Score: r.Score})
+		}
+		return out
+	}
+	return RecordsMsg{Type: MsgRecords, AllTime: entries(b.AllTime), Today: entries(b.Today)}
+}
+
+// ScoreRecords keeps every human player's final score — on death, or on
+// leaving while alive — in a SQLite database, so the best scores survive
+// restarts, unlike the live leaderboard. Writes never block the game loop:
+// finished lives queue in a bounded buffer drained by a writer goroutine,
+// which also re-reads the all-time and daily boards every RecordsRefresh
+// after a change and pushes them to every player. Shared by all rooms.
+// Nil when SLETHER_RECORDS is unset; every method is a no-op on nil.
+type ScoreRecords struct {
+	db      *sql.DB
+	path    string
+	queue   chan ScoreRecord
+	dropped atomic.Int64
+	boards  atomic.Pointer[RecordBoards]
+	// done is closed when the writer exits after Close
+	done chan struct{}
+
+	mu   sync.Mutex
+	live map[string]bool // player snake IDs spawned and not yet recorded
+}
+
+// openScoreRecords opens (creating if needed) the SQLite database at path
+// and reads the current boards; an empty path disables records
+func openScoreRecords(path string) (*ScoreRecords, error) {
+	if path == "" {
+		return nil, nil
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// One connection: the writer goroutine is the only user, and SQLite
+	// takes one writer at a time anyway
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{"PRAGMA journal_mode = WAL", "PRAGMA busy_timeout = 5000", scoreRecordsSchema} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	r := &ScoreRecords{
+		db:    db,
+		path:  path,
+		queue: make(chan ScoreRecord, RecordsBuffer),
+		done:  make(chan struct{}),
+		live:  make(map[string]bool),
+	}
+	boards, err := r.read(time.Now())
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.boards.Store(boards)
+	log.Printf("records: %s, all-time best %d", path, topScore(boards.AllTime))
+	return r, nil
+}
+
+// topScore is the first record's score, 0 for an empty board
+func topScore(recs []ScoreRecord) int {
+	if len(recs) == 0 {
+		return 0
+	}
+	return recs[0].Score
+}
+
+// observe queues the final score of every human life that ends. A dead
+// player's snake is kept until it respawns or leaves, so a life is
+// recorded once: at death, or at leave if still alive.
+func (r *ScoreRecords) observe(e GameEvent) {
+	if r == nil || e.Bot {
+		return
+	}
+	r.mu.Lock()
+	switch e.Type {
+	case EventJoin:
+		r.live[e.Snake] = true
+		r.mu.Unlock()
+		return
+	case EventDeath, EventLeave:
+		if !r.live[e.Snake] {
+			r.mu.Unlock()
+			return
+		}
+		delete(r.live, e.Snake)
+	default:
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Unlock()
+	if e.Score <= 0 {
+		return
+	}
+	select {
+	case r.queue <- ScoreRecord{Name: e.Name, Score: e.Score, Room: e.Room, Time: time.Now()}:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Boards returns the boards as last read; nil on nil records
+func (r *ScoreRecords) Boards() *RecordBoards {
+	if r == nil {
+		return nil
+	}
+	return r.boards.Load()
+}
+
+// run is the writer goroutine: it inserts queued scores and, every
+// RecordsRefresh, re-reads the boards if a score was written or the UTC
+// day rolled over, sending them to every room when they changed. Returns
+// once Close has been called and the queue is written.
+func (r *ScoreRecords) run(rooms *RoomManager) {
+	if r == nil {
+		return
+	}
+	defer close(r.done)
+	defer r.db.Close()
+	refresh := time.NewTicker(RecordsRefresh)
+	defer refresh.Stop()
+	dirty := false
+	for {
+		select {
+		case rec, ok := <-r.queue:
+			if !ok {
+				return
+			}
+			if err := r.insert(rec); err != nil {
+				log.Printf("records: %v", err)
+				continue
+			}
+			dirty = true
+		case now := <-refresh.C:
+			if n := r.dropped.Swap(0); n > 0 {
+				log.Printf("records: dropped %d scores (buffer full)", n)
+			}
+			old := r.boards.Load()
+			if !dirty && utcDay(now).Equal(utcDay(old.Updated)) {
+				continue
+			}
+			boards, err := r.read(now)
+			if err != nil {
+				log.Printf("records: %v", err)
+				continue
+			}
+			dirty = false
+			r.boards.Store(boards)
+			if msg := boards.Msg(); !msg.equal(old.Msg()) {
+				for _, room := range rooms.Rooms() {
+					if room.Tenant != "" {
+						continue // hosted rooms keep no records
+					}
+					for _, c := range room.Conns.Snapshot() {
+						_ = c.Send(msg)
+					}
+				}
+			}
+		}
+	}
+}
+
+// insert writes one finished life and raises its name's best
+func (r *ScoreRecords) insert(rec ScoreRecord) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	ended := rec.Time.Unix()
+	if _, err := tx.Exec(`INSERT INTO scores (name, score, room, ended) VALUES (?, ?, ?, ?)`,
+		rec.Name, rec.Score, rec.Room, ended); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO best (name, score, room, ended) VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET score = excluded.score, room = excluded.room, ended = excluded.ended
+		WHERE excluded.score > best.score`,
+		rec.Name, rec.Score, rec.Room, ended); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// read queries the top RecordsTopN names all-time and since UTC midnight,
+// each name once at its best
+func (r *ScoreRecords) read(now time.Time) (*RecordBoards, error) {
+	allTime, err := r.query(`SELECT name, score, room, ended FROM best ORDER BY score DESC, ended LIMIT ?`, RecordsTopN)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite takes the bare columns from the row holding the MAX
+	today, err := r.query(`SELECT name, MAX(score), room, ended FROM scores WHERE ended >= ?
+		GROUP BY name ORDER BY 2 DESC, ended LIMIT ?`, utcDay(now).Unix(), RecordsTopN)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordBoards{Updated: now, AllTime: allTime, Today: today}, nil
+}
+
+// query runs a board query returning name, score, room, ended rows
+func (r *ScoreRecords) query(q string, args ...any) ([]ScoreRecord, error) {
+	rows, err := r.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	recs := []ScoreRecord{}
+	for rows.Next() {
+		var rec ScoreRecord
+		var ended int64
+		if err := rows.Scan(&rec.Name, &rec.Score, &rec.Room, &ended); err != nil {
+			return nil, err
+		}
+		rec.Time = time.Unix(ended, 0).UTC()
+		recs = append(recs, rec)
+	}
+	return recs, rows.Err()
+}
+
+// Close writes out the scores still queued, waiting at most timeout, and
+// closes the database. Nothing may end a life afterwards: call it once the
+// game loops have stopped. A no-op on nil records.
+func (r *ScoreRecords) Close(timeout time.Duration) {
+	if r == nil {
+		return
+	}
+	close(r.queue)
+	select {
+	case <-r.done:
+	case <-time.After(timeout):
+		log.Printf("records %s: %d scores not written at shutdown", r.path, len(r.queue))
+	}
+}
+
+// utcDay is UTC midnight of t's day
+func utcDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// equal reports whether m and o list the same entries
+func (m RecordsMsg) equal(o RecordsMsg) bool {
+	return slices.Equal(m.AllTime, o.AllTime) && slices.Equal(m.Today, o.Today)
+}
+
+// handleRecords serves GET /api/records: the all-time and daily boards
+func handleRecords(world *World) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		boards := world.Records.Boards()
+		if boards == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "records are disabled"})
+			return
+		}
+		writePublicJSON(w, RecordsRefresh, boards)
+	}
+}
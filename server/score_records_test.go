@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestScoreRecords records each life once — a dead snake's later leave is
+// not a second life — and ranks names by their best score
+func TestScoreRecords(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	r, err := openScoreRecords(filepath.Join(t.TempDir(), "records.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	life := func(id, name string, score int, end string) {
+		r.observe(GameEvent{Type: EventJoin, Snake: id, Name: name})
+		r.observe(GameEvent{Type: end, Snake: id, Name: name, Score: score})
+	}
+	life("a1", "Ann", 300, EventDeath)
+	r.observe(GameEvent{Type: EventLeave, Snake: "a1", Name: "Ann", Score: 300})
+	life("a2", "Ann", 120, EventLeave)
+	life("b1", "Ben", 500, EventDeath)
+	life("c1", "Cat", 900, EventDeath)
+	r.observe(GameEvent{Type: EventJoin, Snake: "bot-2", Name: "Bot", Bot: true})
+	r.observe(GameEvent{Type: EventDeath, Snake: "bot-2", Name: "Bot", Bot: true, Score: 2000})
+
+	rows := 0
+	for len(r.queue) > 0 {
+		if err := r.insert(<-r.queue); err != nil {
+			t.Fatal(err)
+		}
+		rows++
+	}
+	if rows != 4 {
+		t.Errorf("recorded %d lives, want 4", rows)
+	}
+	boards, err := r.read(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RecordEntry{{"Cat", 900}, {"Ben", 500}, {"Ann", 300}}
+	for name, got := range map[string][]RecordEntry{"all-time": boards.Msg().AllTime, "today": boards.Msg().Today} {
+		if !slices.Equal(got, want) {
+			t.Errorf("%s board = %+v, want %+v", name, got, want)
+		}
+	}
+	r.db.Close()
+}
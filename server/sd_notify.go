@@ -0,0 +1,71 @@
+package main
+
+import (
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SystemdNotifier speaks the sd_notify protocol to the service manager:
+// READY once serving, WATCHDOG pings from the game loop (so a wedged loop
+// gets the service restarted) and STOPPING on shutdown. A nil notifier —
+// not running under systemd — turns every method into a no-op.
+type SystemdNotifier struct {
+	conn     *net.UnixConn
+	watchdog time.Duration // ping interval, 0 if the watchdog is off
+	lastPing time.Time     // game-loop goroutine only
+}
+
+// newSystemdNotifier returns nil when NOTIFY_SOCKET is unset
+func newSystemdNotifier() *SystemdNotifier {
+	path := os.Getenv("NOTIFY_SOCKET")
+	if path == "" {
+		return nil
+	}
+	if path[0] == '@' {
+		path = "\x00" + path[1:] // abstract namespace
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		log.Printf("sd_notify: %v", err)
+		return nil
+	}
+	n := &SystemdNotifier{conn: conn}
+	// WATCHDOG_USEC is the timeout; ping at half of it as systemd recommends
+	if usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64); err == nil && usec > 0 {
+		if pid := os.Getenv("WATCHDOG_PID"); pid == "" || pid == strconv.Itoa(os.Getpid()) {
+			n.watchdog = time.Duration(usec) * time.Microsecond / 2
+		}
+	}
+	return n
+}
+
+// notify sends one state string, e.g. "READY=1"
+func (n *SystemdNotifier) notify(state string) {
+	if n == nil {
+		return
+	}
+	if _, err := n.conn.Write([]byte(state)); err != nil {
+		log.Printf("sd_notify %q: %v", state, err)
+	}
+}
+
+// Ready reports that startup finished
+func (n *SystemdNotifier) Ready() { n.notify("READY=1") }
+
+// Stopping reports that shutdown began
+func (n *SystemdNotifier) Stopping() { n.notify("STOPPING=1") }
+
+// Ping sends a watchdog keep-alive if one is due. Called every tick by the
+// game loop; game-loop goroutine only.
+func (n *SystemdNotifier) Ping() {
+	if n == nil || n.watchdog == 0 {
+		return
+	}
+	if now := time.Now(); now.Sub(n.lastPing) >= n.watchdog {
+		n.lastPing = now
+		n.notify("WATCHDOG=1")
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ProfileSeason is a profile's stats for season No. Stats from an earlier
+// season are stale rather than erased, so a rollover never has to rewrite
+// every profile before it is safe on disk.
+type ProfileSeason struct {
+	No        int     `json:"no"`
+	Lives     int     `json:"lives"`
+	Kills     int     `json:"kills"`
+	PlaySec   float64 `json:"play_sec"`
+	BestScore int     `json:"best_score"`
+}
+
+// seasonStats returns p's stats for season no, starting them afresh if they
+// are from an earlier season. Caller must hold the store's lock.
+func (p *Profile) seasonStats(no int) *ProfileSeason {
+	if p.Season.No != no {
+		p.Season = ProfileSeason{No: no}
+	}
+	return &p.Season
+}
+
+// SeasonStanding is one row of a season's final (or live) standings
+type SeasonStanding struct {
+	Rank      int    `json:"rank"`
+	Name      string `json:"name"`
+	BestScore int    `json:"best_score"`
+	Kills     int    `json:"kills"`
+	Lives     int    `json:"lives"`
+	PlaySec   int    `json:"play_sec"`
+	Rating    int    `json:"rating,omitempty"`
+}
+
+// seasonStandings ranks profiles that played season no by best score, then
+// kills, keeping the top n
+func (s *ProfileStore) seasonStandings(no, n int) []SeasonStanding {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []SeasonStanding{}
+	for _, p := range s.byKey {
+		if p.Season.No != no || p.Season.Lives == 0 {
+			continue
+		}
+		rows = append(rows, SeasonStanding{
+			Name: p.Name, BestScore: p.Season.BestScore, Kills: p.Season.Kills,
+			Lives: p.Season.Lives, PlaySec: int(p.Season.PlaySec), Rating: int(math.Round(p.Rating)),
+		})
+	}
+	slices.SortFunc(rows, func(a, b SeasonStanding) int {
+		return cmp.Or(b.BestScore-a.BestScore, b.Kills-a.Kills, cmp.Compare(a.Name, b.Name))
+	})
+	if len(rows) > n {
+		rows = rows[:n]
+	}
+	for i := range rows {
+		rows[i].Rank = i + 1
+	}
+	return rows
+}
+
+// setSeason switches the season profile stats accrue to
+func (s *ProfileStore) setSeason(no int) {
+	s.mu.Lock()
+	s.season = no
+	s.dirty = true
+	s.mu.Unlock()
+}
+
+// SeasonInfo identifies a season
+type SeasonInfo struct {
+	Number int       `json:"number"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// SeasonRecord is an archived season with its final standings
+type SeasonRecord struct {
+	SeasonInfo
+	Standings []SeasonStanding `json:"standings"`
+}
+
+// Seasons rolls the profile stats over every season length: the ending
+// season's standings are archived (to SLETHER_SEASONS if set), seasonal
+// stats start from zero and everyone online is told. Safe for concurrent use.
+type Seasons struct {
+	mu       sync.Mutex
+	path     string // "" = memory only
+	length   time.Duration
+	profiles *ProfileStore
+	current  SeasonInfo
+	archive  []SeasonRecord // oldest first
+}
+
+// seasonsFile is the SLETHER_SEASONS format
+type seasonsFile struct {
+	Current SeasonInfo     `json:"current"`
+	Archive []SeasonRecord `json:"archive"`
+}
+
+// seasonLengthFromEnv reads SLETHER_SEASON_DAYS (default SeasonLength)
+func seasonLengthFromEnv() (time.Duration, error) {
+	v := os.Getenv("SLETHER_SEASON_DAYS")
+	if v == "" {
+		return SeasonLength, nil
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 1 || days > 365 {
+		return 0, fmt.Errorf("SLETHER_SEASON_DAYS=%q: want 1-365", v)
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// openSeasons loads the season state from path if set, or starts season 1
+// now, and points profiles at the current season
+func openSeasons(path string, length time.Duration, profiles *ProfileStore) (*Seasons, error) {
+	s := &Seasons{path: path, length: length, profiles: profiles}
+	var f seasonsFile
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(data, &f); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	if f.Current.Number == 0 {
+		now := time.Now()
+		f.Current = SeasonInfo{Number: 1, Start: now, End: now.Add(length)}
+	}
+	s.current, s.archive = f.Current, f.Archive
+	profiles.setSeason(s.current.Number)
+	return s, s.save()
+}
+
+// save writes the season state to its file (via a temp file and rename).
+// Caller must hold s.mu or own s exclusively.
+func (s *Seasons) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, _ := json.MarshalIndent(seasonsFile{Current: s.current, Archive: s.archive}, "", "  ")
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".seasons-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// run checks for the end of the season every SeasonCheckInterval
+func (s *Seasons) run(rooms *RoomManager) {
+	for range time.Tick(SeasonCheckInterval) {
+		s.maybeRoll(time.Now(), rooms)
+	}
+}
+
+// maybeRoll archives every season that has ended by now (several, if the
+// server was down across a rollover) and announces the last one in every
+// room, in the room's language
+func (s *Seasons) maybeRoll(now time.Time, rooms *RoomManager) {
+	s.mu.Lock()
+	var ended *SeasonRecord
+	for !now.Before(s.current.End) {
+		rec := SeasonRecord{
+			SeasonInfo: s.current,
+			Standings:  s.profiles.seasonStandings(s.current.Number, SeasonStandingsSize),
+		}
+		s.archive = append(s.archive, rec)
+		ended = &s.archive[len(s.archive)-1]
+		s.current = SeasonInfo{Number: rec.Number + 1, Start: rec.End, End: rec.End.Add(s.length)}
+		s.profiles.setSeason(s.current.Number)
+	}
+	if ended == nil {
+		s.mu.Unlock()
+		return
+	}
+	if err := s.save(); err != nil {
+		log.Printf("seasons: %v", err)
+	}
+	rec, next := *ended, s.current.Number
+	s.mu.Unlock()
+	text := func(lang string) string {
+		if len(rec.Standings) > 0 {
+			top := rec.Standings[0]
+			return localize(lang, textSeasonWon, rec.Number, top.Name, top.BestScore, next)
+		}
+		return localize(lang, textSeasonOver, rec.Number, next)
+	}
+	log.Printf("seasons: %s", text(""))
+	for _, r := range rooms.Rooms() {
+		if r.Tenant != "" {
+			continue // hosted rooms have no seasons
+		}
+		if msg, err := NewAnnouncement(text(r.World.Lang), SeverityInfo, SeasonAnnounceFor); err == nil {
+			r.Conns.Announce(msg, "")
+		}
+	}
+}
+
+// handleSeasons serves GET /api/seasons: the current season and the
+// archived ones, without standings
+func handleSeasons(world *World) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := world.Seasons
+		s.mu.Lock()
+		past := make([]SeasonInfo, len(s.archive))
+		for i, rec := range s.archive {
+			past[len(past)-1-i] = rec.SeasonInfo // newest first
+		}
+		cur := s.current
+		s.mu.Unlock()
+		writePublicJSON(w, time.Minute, map[string]any{"current": cur, "past": past})
+	}
+}
+
+// handleSeason serves GET /api/seasons/{n}: final standings of a past
+// season, or live standings of the current one
+func handleSeason(world *World) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(r.PathValue("n"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid season number"})
+			return
+		}
+		s := world.Seasons
+		s.mu.Lock()
+		cur := s.current
+		var rec *SeasonRecord
+		for i := range s.archive {
+			if s.archive[i].Number == n {
+				rec = &s.archive[i]
+			}
+		}
+		s.mu.Unlock()
+		switch {
+		case n == cur.Number:
+			rec = &SeasonRecord{SeasonInfo: cur, Standings: world.Profiles.seasonStandings(n, SeasonStandingsSize)}
+		case rec == nil:
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such season"})
+			return
+		}
+		writePublicJSON(w, time.Minute, rec)
+	}
+}
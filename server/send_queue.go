@@ -0,0 +1,183 @@
+package main
+
+import "sync"
+
+// sendPriority decides how the per-connection writer treats a queued message
+type sendPriority int
+
+const (
+	// PriorityCritical messages (welcome, death, error) are never dropped;
+	// a client with SendQueueCriticalMax of them unsent is disconnected
+	PriorityCritical sendPriority = iota
+	// PriorityState snapshots coalesce — only the newest unsent one is kept
+	PriorityState
+	// PriorityLow traffic (cosmetic messages such as the kill feed) is
+	// dropped once SendQueueLowMax messages are already waiting
+	PriorityLow
+)
+
+// priorityOf classifies an outgoing message. Critical is opt-in: a new
+// message kind is low priority until listed here, so it can't grow a slow
+// client's queue without bound.
+func priorityOf(msg interface{}) sendPriority {
+	switch msg.(type) {
+	case StateMsg, *StateMsg:
+		return PriorityState
+	case WelcomeMsg, MapMsg, DeathMsg, ErrorMsg, ShutdownMsg, AnnouncementMsg, ObjectiveMsg, MotdMsg, RecordsMsg:
+		return PriorityCritical
+	default:
+		// AteMsg, KillFeedMsg and StatsMsg are cosmetic (score itself
+		// arrives in state); a gap in InputEchoMsg's k shows a dropped echo
+		return PriorityLow
+	}
+}
+
+// sendQueue is a connection's outbound buffer. A slow client therefore costs
+// at most one pending snapshot and a bounded number of other messages,
+// instead of blocking the broadcaster or growing without bound.
+type sendQueue struct {
+	mu       sync.Mutex
+	critical [][]byte
+	state    []byte // newest pending snapshot, nil if none
+	low      [][]byte
+	closed   bool
+	final    []byte        // close frame payload written once critical drains, see closeAfter
+	wake     chan struct{} // capacity 1: signals the writer that work is queued
+	// superseded counts snapshots replaced before the writer took them, a
+	// sign the client can't keep up (see client_health.go)
+	superseded int64
+}
+
+func newSendQueue() *sendQueue {
+	return &sendQueue{wake: make(chan struct{}, 1)}
+}
+
+// push queues data at priority p; accepted is false if it was dropped.
+// Overflow is true when it found SendQueueCriticalMax critical messages
+// already waiting: the client isn't reading, so everything pending is
+// discarded and the queue closes with CloseSlow, as closeAfter would.
+func (q *sendQueue) push(p sendPriority, data []byte) (accepted, overflow bool) {
+	q.mu.Lock()
+	if q.closed || q.final != nil {
+		q.mu.Unlock()
+		return false, false
+	}
+	accepted = true
+	switch p {
+	case PriorityCritical:
+		if len(q.critical) >= SendQueueCriticalMax {
+			accepted, overflow = false, true
+			q.critical, q.state, q.low = nil, nil, nil
+			q.final = closeFrame(CloseSlow, "Connection too slow to keep up")
+		} else {
+			q.critical = append(q.critical, data)
+		}
+	case PriorityState:
+		if q.state != nil {
+			q.superseded++
+			slo.statesDropped.Add(1)
+		}
+		q.state = data // coalesce: an older unsent snapshot is obsolete
+	case PriorityLow:
+		if len(q.low) >= SendQueueLowMax {
+			accepted = false
+			slo.cosmeticDropped.Add(1)
+		} else {
+			q.low = append(q.low, data)
+		}
+	}
+	q.mu.Unlock()
+
+	if accepted || overflow {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+	return accepted, overflow
+}
+
+// pop returns the next message to write: critical first, then the pending
+// snapshot, then low-priority traffic. Blocks until one is available;
+// ok is false once the queue is closed. final is true for the close frame
+// payload set by closeAfter, always the last thing popped.
+func (q *sendQueue) pop() (data []byte, final, ok bool) {
+	for {
+		q.mu.Lock()
+		switch {
+		case q.closed:
+			q.mu.Unlock()
+			return nil, false, false
+		case len(q.critical) > 0:
+			data = q.critical[0]
+			q.critical[0] = nil
+			q.critical = q.critical[1:]
+		case q.state != nil:
+			data, q.state = q.state, nil
+		case len(q.low) > 0:
+			data = q.low[0]
+			q.low[0] = nil
+			q.low = q.low[1:]
+		case q.final != nil:
+			data, final = q.final, true
+			q.closed = true
+		}
+		q.mu.Unlock()
+		if data != nil {
+			return data, final, true
+		}
+		<-q.wake
+	}
+}
+
+// closeAfter stops accepting messages, discards pending state and
+// low-priority traffic, and has pop hand out payload as the final close
+// frame once the critical messages already queued are written
+func (q *sendQueue) closeAfter(payload []byte) {
+	q.mu.Lock()
+	if q.closed || q.final != nil {
+		q.mu.Unlock()
+		return
+	}
+	q.final = payload
+	q.state, q.low = nil, nil
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// statePending reports whether a snapshot is still waiting for the writer.
+// Delta states can't replace one another (see state_delta.go), so the
+// broadcaster skips a viewer for the tick instead; the skip counts as
+// superseded.
+func (q *sendQueue) statePending() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.state == nil {
+		return false
+	}
+	q.superseded++
+	slo.statesDropped.Add(1)
+	return true
+}
+
+// supersededCount returns how many snapshots were replaced unsent so far
+func (q *sendQueue) supersededCount() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.superseded
+}
+
+// close discards anything pending and releases the writer
+func (q *sendQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.critical, q.state, q.low = nil, nil, nil
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
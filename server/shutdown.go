@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// gracefulShutdown takes the server down on SIGINT/SIGTERM without players
+// seeing a socket error: new connections and HTTP requests are refused,
+// every player gets a ShutdownMsg and the game runs on for
+// ShutdownCountdown (a second signal cuts it short), then the game loops
+// stop between two ticks, live players' lives end as if they left, so
+// profiles and the event stream record them, and every WebSocket is closed
+// with CloseMaintenance. Profiles, score records and queued events are
+// written out last.
+// Returns once it is safe to exit.
+func gracefulShutdown(sig <-chan os.Signal, servers []*http.Server, rooms *RoomManager, world *World) {
+	ctx, cancel := context.WithTimeout(context.Background(), HTTPShutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		if srv != nil {
+			_ = srv.Shutdown(ctx)
+		}
+	}
+
+	msg := ShutdownMsg{Type: MsgShutdown, Seconds: int(ShutdownCountdown / time.Second)}
+	players := 0
+	for _, r := range rooms.Rooms() {
+		for _, c := range r.Conns.Snapshot() {
+			_ = c.Send(msg)
+			players++
+		}
+	}
+	if players > 0 {
+		log.Printf("shutdown: warned %d players, closing in %v", players, ShutdownCountdown)
+		select {
+		case <-time.After(ShutdownCountdown):
+		case s := <-sig:
+			log.Printf("received %v, skipping the countdown", s)
+		}
+	}
+
+	for _, r := range rooms.Rooms() {
+		r.Loop.Stop()
+		r.Loop.endSessions()
+		for _, c := range r.Conns.Snapshot() {
+			c.Disconnect(CloseMaintenance, "Server shutting down")
+		}
+	}
+	// Give the close frames a moment to go out and be answered
+	deadline := time.Now().Add(CloseHandshakeTimeout)
+	for time.Now().Before(deadline) && connected(rooms) > 0 {
+		time.Sleep(50 * time.Millisecond)
+	}
+	rooms.Close(HTTPShutdownTimeout)
+
+	if err := world.Profiles.Flush(); err != nil {
+		log.Printf("profiles: %v", err)
+	}
+	world.Records.Close(HTTPShutdownTimeout)
+	world.Events.Close(HTTPShutdownTimeout)
+	log.Printf("shutdown complete")
+}
+
+// endSessions ends every connected player's live snakes as if the player
+// left, once the loop has stopped
+func (gl *GameLoop) endSessions() {
+	w := gl.world
+	unlock := w.lock("shutdown")
+	defer unlock()
+	for _, c := range gl.conns.Snapshot() {
+		for _, id := range c.SnakeIDs() {
+			if s, ok := w.Snakes[id]; ok && s.Alive {
+				w.emit(snakeEvent(EventLeave, s))
+			}
+		}
+	}
+}
+
+// connected counts the connections still open across rooms
+func connected(rooms *RoomManager) int {
+	n := 0
+	for _, r := range rooms.Rooms() {
+		n += r.Conns.Count()
+	}
+	return n
+}
@@ -0,0 +1,79 @@
+package main
+
+import "sync/atomic"
+
+// Reasons a connection is turned away, indexes into sloCounters.rejected
+const (
+	rejectUpgrade    = iota // the WebSocket handshake failed
+	rejectBanned            // CloseBanned
+	rejectServerFull        // CloseServerFull
+	rejectNoSuchRoom        // CloseNoSuchRoom
+	rejectCaptcha           // CloseCaptcha: join verification failed
+	rejectReasons
+)
+
+// rejectReasonLabels are the reason labels of slether_slo_connections_rejected_total
+var rejectReasonLabels = [rejectReasons]string{"upgrade", "banned", "server_full", "no_such_room", "captcha"}
+
+// sloCounters counts what players notice going wrong, for alerting
+// straight on player impact rather than inferring it from gauges: state
+// updates and cosmetic messages a connection never got, connections turned
+// away or dropped for being too slow, and how automatic reconnects fare.
+// The counters only go up, from process start, and are shared by every
+// room; ticks over budget are counted per room by the game loop. Served by
+// GET /admin/metrics.
+type sloCounters struct {
+	statesDropped     atomic.Int64 // state snapshots replaced or skipped before they were sent
+	cosmeticDropped   atomic.Int64 // low-priority messages dropped: queue full or lite mode
+	rejected          [rejectReasons]atomic.Int64
+	slowDisconnects   atomic.Int64 // connections dropped with CloseSlow
+	reconnects        atomic.Int64 // reconnects accepted
+	reconnectFailures atomic.Int64 // reconnects turned away
+}
+
+// slo is the process's SLO counters
+var slo sloCounters
+
+// reject counts a connection turned away for reason; reconnect is whether
+// the client marked it as an automatic reconnect (?reconnect=1)
+func (s *sloCounters) reject(reason int, reconnect bool) {
+	s.rejected[reason].Add(1)
+	if reconnect {
+		s.reconnectFailures.Add(1)
+	}
+}
+
+// accept counts a connection that got through the handshake and checks
+func (s *sloCounters) accept(reconnect bool) {
+	if reconnect {
+		s.reconnects.Add(1)
+	}
+}
+
+// writeSLO writes the SLO counters, ticks over budget for every room
+func writeSLO(m metricsWriter, rooms *RoomManager) {
+	m.family("slether_slo_ticks_over_budget_total", "counter", "Ticks that took longer than one tick interval, by room.")
+	for _, r := range rooms.Rooms() {
+		m.sample("slether_slo_ticks_over_budget_total", r.Loop.ticksOverBudget.Load(), "room", r.Name)
+	}
+	m.family("slether_slo_ticks_skipped_total", "counter", "Ticks skipped because the loop fell too far behind, by room.")
+	for _, r := range rooms.Rooms() {
+		m.sample("slether_slo_ticks_skipped_total", r.Loop.ticksSkipped.Load(), "room", r.Name)
+	}
+	m.family("slether_slo_ticks_caught_up_total", "counter", "Ticks run late, back to back, to catch up after a stall, by room.")
+	for _, r := range rooms.Rooms() {
+		m.sample("slether_slo_ticks_caught_up_total", r.Loop.ticksCaughtUp.Load(), "room", r.Name)
+	}
+	m.family("slether_slo_broadcasts_dropped_total", "counter", "Messages a connection never got, by kind: replaced or skipped state updates, dropped cosmetic messages.")
+	m.sample("slether_slo_broadcasts_dropped_total", slo.statesDropped.Load(), "kind", "state")
+	m.sample("slether_slo_broadcasts_dropped_total", slo.cosmeticDropped.Load(), "kind", "cosmetic")
+	m.family("slether_slo_connections_rejected_total", "counter", "Connections turned away, by reason.")
+	for i, reason := range rejectReasonLabels {
+		m.sample("slether_slo_connections_rejected_total", slo.rejected[i].Load(), "reason", reason)
+	}
+	m.family("slether_slo_slow_disconnects_total", "counter", "Connections dropped for missing write deadlines or a full critical queue.")
+	m.sample("slether_slo_slow_disconnects_total", slo.slowDisconnects.Load())
+	m.family("slether_slo_reconnects_total", "counter", "Automatic client reconnects, by result.")
+	m.sample("slether_slo_reconnects_total", slo.reconnects.Load(), "result", "success")
+	m.sample("slether_slo_reconnects_total", slo.reconnectFailures.Load(), "result", "failure")
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"math"
 	"math/rand"
+	"slices"
 )
 
 // Point is a 2D coordinate
@@ -14,7 +15,9 @@ type Point struct {
 // Snake represents a player's snake in the world
 type Snake struct {
 	ID          string
+	NetID       uint32 // compact wire ID, assigned by World.AddSnake
 	Name        string
+	Country     string  // ISO 3166-1 alpha-2 flag shown with the name, "" for none (see country.go)
 	Segments    []Point // index 0 = head
 	Angle       float64 // radians, direction of movement
 	Speed       float64
@@ -24,17 +27,56 @@ type Snake struct {
 	BoostActive bool
 	BoostTicks  int     // ticks spent boosting this cycle
 	Width       float64 // visual width (radius), starts at SnakeBaseWidth
+	// ShownWidth is the width reported to clients: it eases toward Width in
+	// Move so growth and boost drops don't pop the drawn thickness
+	ShownWidth  float64
+	MagnetBonus float64 // extra magnet strength from power-ups; 0 = none, 1 = double
+	// PendingGrowth is eaten length not yet added to the body; Move releases
+	// one segment per segment laid so growth is smooth instead of popping at
+	// the tail
+	PendingGrowth int
+	// Laid counts the body segments Move has laid behind the head, so state
+	// deltas can send a viewer only the segments added since its last state
+	Laid uint64
+	// InvulnTicks counts down after a damage-model hit; collisions are ignored while > 0
+	InvulnTicks int
+	// CrownTicks counts down the golden apple crown (cosmetic aura)
+	CrownTicks int
+	// Kills counts kills this life — the current kill streak
+	Kills int
+	// Assists counts assists this life (see recordPressure)
+	Assists int
+	// mutedName is a shadow-muted player's own name, shown only to them;
+	// Name is DefaultPlayerName for everyone else (see moderation.go)
+	mutedName string
+
+	// pressure maps other snake IDs to the last World.Tick their head was
+	// within AssistRadius of this head; pruned to AssistWindow
+	pressure map[string]uint64
+
+	// travel is how far the head has moved since the newest body segment
+	// was laid, always under SnakeSegmentSpacing
+	travel   float64
+	prevHead Point // the head before this tick's Move, for headAt
+
+	bounds      Rect // cached segment bounding box, see snake_bounds.go
+	boundsDirty bool // a trimmed segment may have shrunk bounds
 }
 
 // NewSnake creates a snake at a random position inside the circular world,
 // keeping SpawnMargin px away from the boundary.
 func NewSnake(id, name, color string) *Snake {
-	spawnRadius := WorldRadius - SpawnMargin
-	r := spawnRadius * math.Sqrt(rand.Float64())
-	spawnAngle := rand.Float64() * 2 * math.Pi
-	x := WorldCenterX + r*math.Cos(spawnAngle)
-	y := WorldCenterY + r*math.Sin(spawnAngle)
+	x, y := randomSpawnPoint()
+	return newSnakeAt(id, name, color, x, y)
+}
 
+// randomSpawnPoint is a uniform point at least SpawnMargin inside the boundary
+func randomSpawnPoint() (float64, float64) {
+	return randomCirclePoint(WorldCenterX, WorldCenterY, WorldRadius-SpawnMargin)
+}
+
+// newSnakeAt creates a snake with its head at (x,y) facing a random direction
+func newSnakeAt(id, name, color string, x, y float64) *Snake {
 	angle := rand.Float64() * 2 * math.Pi
 
 	segments := make([]Point, SnakeInitSegments)
@@ -45,17 +87,65 @@ func NewSnake(id, name, color string) *Snake {
 		}
 	}
 
-	return &Snake{
-		ID:       id,
-		Name:     name,
-		Segments: segments,
-		Angle:    angle,
-		Speed:    SnakeNormalSpeed,
-		Score:    SnakeInitSegments,
-		Color:    color,
-		Alive:    true,
-		Width:    SnakeBaseWidth,
+	s := &Snake{
+		ID:         id,
+		Name:       name,
+		Segments:   segments,
+		Angle:      angle,
+		Speed:      perTick(SnakeNormalSpeed),
+		Score:      SnakeInitSegments,
+		Color:      color,
+		Alive:      true,
+		Width:      SnakeBaseWidth,
+		ShownWidth: SnakeBaseWidth,
+		prevHead:   segments[0],
 	}
+	s.recomputeBounds()
+	return s
+}
+
+// Phase layer identifiers (see PhaseLayersEnabled)
+const (
+	LayerNormal = 0
+	LayerTiny   = 1
+	LayerGiant  = 2
+)
+
+// Layer returns the snake's phase layer based on its current length.
+// Always LayerNormal when phase layers are disabled.
+func (s *Snake) Layer() int {
+	if !PhaseLayersEnabled {
+		return LayerNormal
+	}
+	n := len(s.Segments)
+	if n <= PhaseTinyMaxLength {
+		return LayerTiny
+	}
+	if n >= PhaseGiantMinLength {
+		return LayerGiant
+	}
+	return LayerNormal
+}
+
+// CanInteract reports whether two snakes can collide with each other.
+// Tiny and giant snakes phase through each other; every other pair interacts.
+func (s *Snake) CanInteract(other *Snake) bool {
+	return layersInteract(s.Layer(), other.Layer())
+}
+
+// layersInteract reports whether snakes in phase layers a and b collide
+func layersInteract(a, b int) bool {
+	return !(a == LayerTiny && b == LayerGiant) && !(a == LayerGiant && b == LayerTiny)
+}
+
+// MagnetRadius is how far this snake pulls food: grows with width and MagnetBonus
+func (s *Snake) MagnetRadius() float64 {
+	return MagnetRadius * (s.Width / SnakeBaseWidth) * (1 + s.MagnetBonus)
+}
+
+// MagnetSpeed is how many px per tick this snake pulls food
+func (s *Snake) MagnetSpeed() float64 {
+	return perTick(MagnetSpeed) * (1 + s.MagnetBonus)
 }
 
 // Head returns the head segment of the snake
@@ -63,37 +153,97 @@ func (s *Snake) Head() Point {
 	return s.Segments[0]
 }
 
-// Move advances the snake one tick in its current direction.
+// substeps is how many increments this tick's head movement is checked in:
+// BoostSubsteps while boosting (fast heads can otherwise skip past thin
+// bodies and food between ticks), else 1
+func (s *Snake) substeps() int {
+	if s.BoostActive && BoostSubsteps > 1 {
+		return BoostSubsteps
+	}
+	return 1
+}
+
+// headAt interpolates the head between its previous and current position;
+// frac 0 is where it started this tick, 1 is Head()
+func (s *Snake) headAt(frac float64) Point {
+	head := s.Head()
+	if frac >= 1 {
+		return head
+	}
+	prev := s.prevHead
+	return Point{X: prev.X + (head.X-prev.X)*frac, Y: prev.Y + (head.Y-prev.Y)*frac}
+}
+
+// Move advances the snake one tick in its current direction, laying a body
+// segment behind the head every SnakeSegmentSpacing px of travel, so body
+// density depends on distance covered and not on the tick rate.
 // Returns true if the snake crossed the circular boundary (caller should kill it).
 func (s *Snake) Move() bool {
 	head := s.Head()
 
-	newX := head.X + s.Speed*math.Cos(s.Angle)
-	newY := head.Y + s.Speed*math.Sin(s.Angle)
+	if s.InvulnTicks > 0 {
+		s.InvulnTicks--
+	}
+	if s.CrownTicks > 0 {
+		s.CrownTicks--
+	}
+	s.easeWidth()
+
+	cos, sin := math.Cos(s.Angle), math.Sin(s.Angle)
+	newX := head.X + s.Speed*cos
+	newY := head.Y + s.Speed*sin
 
 	// Check circular boundary — boundary crossing = death
 	dx := newX - WorldCenterX
 	dy := newY - WorldCenterY
 	outOfBounds := (dx*dx + dy*dy) > WorldRadius*WorldRadius
 
-	newHead := Point{X: newX, Y: newY}
+	// The old head needn't leave the bounds: it lies on the path from the
+	// newest segment to the new head, so the box stays a close superset
+	s.prevHead = head
+	s.Segments[0] = Point{X: newX, Y: newY}
+	s.boundsAddHead(s.Segments[0])
 
-	// Shift segments: prepend new head, drop last
-	s.Segments = append([]Point{newHead}, s.Segments[:len(s.Segments)-1]...)
+	// Lay a segment each time the head has covered the spacing, where the
+	// head was at that point, and drop the tail — unless growth is pending,
+	// in which case the tail stays and the body gains a segment
+	s.travel += s.Speed
+	for s.travel >= SnakeSegmentSpacing {
+		s.travel -= SnakeSegmentSpacing
+		seg := Point{X: newX - s.travel*cos, Y: newY - s.travel*sin}
+		if s.PendingGrowth > 0 {
+			s.PendingGrowth--
+			s.Segments = slices.Insert(s.Segments, 1, seg)
+		} else {
+			s.boundsRemove(s.Segments[len(s.Segments)-1])
+			copy(s.Segments[2:], s.Segments[1:len(s.Segments)-1])
+			s.Segments[1] = seg
+		}
+		s.Laid++
+	}
 
 	return outOfBounds
 }
 
-// Grow adds segments at the tail and increases width with diminishing returns.
+// easeWidth moves ShownWidth a tick's share of the way toward Width, closing
+// the gap with a time constant of SnakeWidthEase
+func (s *Snake) easeWidth() {
+	gap := s.Width - s.ShownWidth
+	if math.Abs(gap) < 0.05 {
+		s.ShownWidth = s.Width
+		return
+	}
+	s.ShownWidth += gap / float64(ticksFor(SnakeWidthEase))
+}
+
+// Grow queues amount segments of pending growth (released one per segment
+// laid by Move), credits the score immediately and increases width with diminishing returns.
 // Width gain = foodValue / totalSegments (longer snake → less width gain per food).
 func (s *Snake) Grow(amount int) {
-	tail := s.Segments[len(s.Segments)-1]
-	for i := 0; i < amount; i++ {
-		s.Segments = append(s.Segments, tail)
-	}
+	s.PendingGrowth += amount
 	s.Score += amount
 	// Width grows proportionally: 4 * food_value / total_length (4x multiplier for visible growth)
-	widthGain := 4.0 * float64(amount) / float64(len(s.Segments))
+	widthGain := 4.0 * float64(amount) / float64(len(s.Segments)+s.PendingGrowth)
 	s.Width += widthGain
 	if s.Width > SnakeMaxWidth {
 		s.Width = SnakeMaxWidth
@@ -103,19 +253,12 @@ func (s *Snake) Grow(amount int) {
 // ApplyInput updates the snake's angle and boost state from client input.
 // Turn rate is limited based on snake size — bigger snakes must arc wider to reverse.
 // Returns level-3 food dropped from tail when boosting (nil if none dropped).
-func (s *Snake) ApplyInput(angle float64, boost bool) *Food {
+func (s *Snake) ApplyInput(angle float64, boost bool, pool *FoodPool) *Food {
 	// Calculate max turn rate for this snake's size
-	maxTurn := SnakeMaxTurnRate / (1.0 + float64(len(s.Segments))*SnakeTurnScaleFactor)
+	maxTurn := perTick(SnakeMaxTurnRate) / (1.0 + float64(len(s.Segments))*SnakeTurnScaleFactor)
 
 	// Calculate shortest angular difference (handles wrapping around -π/π)
-	diff := angle - s.Angle
-	// Normalize to [-π, π]
-	for diff > math.Pi {
-		diff -= 2 * math.Pi
-	}
-	for diff < -math.Pi {
-		diff += 2 * math.Pi
-	}
+	diff := normalizeAngle(angle - s.Angle)
 	// Clamp to max turn rate
 	if diff > maxTurn {
 		diff = maxTurn
@@ -127,12 +270,20 @@ func (s *Snake) ApplyInput(angle float64, boost bool) *Food {
 	s.BoostActive = boost
 
 	if boost {
-		s.Speed = SnakeBoostSpeed
+		s.Speed = perTick(SnakeBoostSpeed)
 		s.BoostTicks++
-		// Lose a segment every N boost ticks to "cost" boost
-		if s.BoostTicks%SnakeBoostCostTicks == 0 && len(s.Segments) > SnakeMinSegments {
+		// Lose a segment every SnakeBoostCostEvery of boosting to "cost" boost
+		costTicks := ticksFor(SnakeBoostCostEvery)
+		if s.BoostTicks%costTicks == 0 && s.PendingGrowth > 0 {
+			// Pay from growth not yet on the body before trimming the tail
+			s.PendingGrowth--
+			s.Score--
+			return nil
+		}
+		if s.BoostTicks%costTicks == 0 && len(s.Segments) > SnakeMinSegments {
 			tail := s.Segments[len(s.Segments)-1]
 			s.Segments = s.Segments[:len(s.Segments)-1]
+			s.boundsRemove(tail)
 			s.Score--
 			// Shrink width proportionally when losing segments
 			widthLoss := 4.0 / float64(len(s.Segments)+1)
@@ -142,35 +293,60 @@ func (s *Snake) ApplyInput(angle float64, boost bool) *Food {
 			}
 			// Only drop food 30% of the time (70% pure cost)
 			if rand.Float64() < 0.3 {
-				f := newFoodWithLevel(tail.X, tail.Y, FoodLevel3, false)
-				f.Color = s.Color
+				f := pool.newFoodWithLevel(tail.X, tail.Y, FoodLevel3, false)
+				if f != nil {
+					f.Color = s.Color
+				}
 				return f
 			}
 			return nil
 		}
 	} else {
-		s.Speed = SnakeNormalSpeed
+		s.Speed = perTick(SnakeNormalSpeed)
 		s.BoostTicks = 0
 	}
 	return nil
 }
 
-// DropFood converts the snake body into food items and marks it dead.
-// Only drops 70% of body segments as food to act as a score sink.
-func (s *Snake) DropFood() []*Food {
-	s.Alive = false
-	totalDrops := len(s.Segments) / DeathFoodPerUnit
-	dropCount := int(float64(totalDrops) * 0.6)
-	food := make([]*Food, 0, dropCount+1)
-	for i, seg := range s.Segments {
+// TakeHit removes n segments of length for a damage-model hit, consuming
+// pending growth first, and starts post-hit invulnerability. Returns food
+// dropped where removed tail segments were, and false (without changing the
+// snake) if the hit would leave SnakeMinSegments or fewer — i.e. it is lethal.
+func (s *Snake) TakeHit(n int, pool *FoodPool) ([]*Food, bool) {
+	fromPending := min(n, s.PendingGrowth)
+	fromBody := n - fromPending
+	if len(s.Segments)-fromBody <= SnakeMinSegments {
+		return nil, false
+	}
+	s.PendingGrowth -= fromPending
+
+	var dropped []*Food
+	tail := s.Segments[len(s.Segments)-fromBody:]
+	for i, seg := range tail {
+		s.boundsRemove(seg)
 		if i%DeathFoodPerUnit == 0 {
-			if len(food) >= dropCount {
-				break
+			if f := pool.NewFoodAt(seg.X, seg.Y); f != nil {
+				dropped = append(dropped, f)
 			}
-			food = append(food, NewFoodAt(seg.X, seg.Y))
 		}
 	}
-	return food
+	s.Segments = s.Segments[:len(s.Segments)-fromBody]
+	s.Score -= n
+	// Shrink width the same way boosting does, per segment lost
+	s.Width -= 4.0 * float64(n) / float64(len(s.Segments)+n)
+	if s.Width < SnakeBaseWidth {
+		s.Width = SnakeBaseWidth
+	}
+	s.InvulnTicks = ticksFor(DamageInvulnFor)
+	return dropped, true
+}
+
+// DropFood converts the snake body into food items and marks it dead.
+// The whole body drops food, worth DeathDropShare of it times factor; the
+// rest is a score sink (see bodyDrops).
+func (s *Snake) DropFood(pool *FoodPool, factor float64) []*Food {
+	s.Alive = false
+	return s.bodyDrops(pool, DeathDropShare*factor)
 }
 
 // ToDTO converts snake to serializable form, trimming segments to maxSegs.
@@ -190,13 +366,47 @@ func (s *Snake) ToDTO(maxSegs int) SnakeDTO {
 	if s.BoostActive {
 		boostInt = 1
 	}
+	hitInt := 0
+	if s.InvulnTicks > 0 {
+		hitInt = 1
+	}
+	crownInt := 0
+	if s.CrownTicks > 0 {
+		crownInt = 1
+	}
 	return SnakeDTO{
-		ID:       s.ID,
+		ID:       s.NetID,
 		Name:     s.Name,
+		Country:  s.Country,
 		Segments: pairs,
 		Score:    s.Score,
 		Color:    s.Color,
 		Boosting: boostInt,
-		Width:    roundTo1(s.Width),
+		Width:    roundTo1(s.ShownWidth),
+		Layer:    s.Layer(),
+		Hit:      hitInt,
+		Crown:    crownInt,
+	}
+}
+
+// physicsRules reports the simulation constants sent to clients in the welcome
+func physicsRules() RulesDTO {
+	rules := RulesDTO{
+		Speed:          SnakeNormalSpeed,
+		BoostSpeed:     SnakeBoostSpeed,
+		SegmentSpacing: SnakeSegmentSpacing,
+		TurnRate:       SnakeMaxTurnRate,
+		TurnScale:      SnakeTurnScaleFactor,
+		HeadRadius:     SnakeHeadRadius,
+		BodyRadius:     SnakeBodyRadius,
+		BaseWidth:      SnakeBaseWidth,
+		MaxWidth:       SnakeMaxWidth,
+		MagnetRadius:   MagnetRadius,
+		MagnetSpeed:    MagnetSpeed,
+		FoodRadius:     FoodRadius,
+	}
+	if RiskBandMultiplier != 1 {
+		rules.RiskBand, rules.RiskMultiplier = RiskBandWidth, RiskBandMultiplier
 	}
+	return rules
 }
@@ -0,0 +1,86 @@
+package main
+
+// Rect is an axis-aligned bounding box
+type Rect struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// Intersects reports whether two boxes overlap (touching edges count)
+func (r Rect) Intersects(o Rect) bool {
+	return r.MinX <= o.MaxX && r.MaxX >= o.MinX && r.MinY <= o.MaxY && r.MaxY >= o.MinY
+}
+
+// Contains reports whether o lies entirely inside r
+func (r Rect) Contains(o Rect) bool {
+	return o.MinX >= r.MinX && o.MaxX <= r.MaxX && o.MinY >= r.MinY && o.MaxY <= r.MaxY
+}
+
+// ContainsPoint reports whether (x,y) lies inside r
+func (r Rect) ContainsPoint(x, y float64) bool {
+	return x >= r.MinX && x <= r.MaxX && y >= r.MinY && y <= r.MaxY
+}
+
+// extend grows r to include (x,y)
+func (r *Rect) extend(x, y float64) {
+	if x < r.MinX {
+		r.MinX = x
+	}
+	if x > r.MaxX {
+		r.MaxX = x
+	}
+	if y < r.MinY {
+		r.MinY = y
+	}
+	if y > r.MaxY {
+		r.MaxY = y
+	}
+}
+
+// onEdge reports whether (x,y) touches the box boundary — removing such a
+// point may shrink the box
+func (r Rect) onEdge(x, y float64) bool {
+	return x <= r.MinX || x >= r.MaxX || y <= r.MinY || y >= r.MaxY
+}
+
+// Bounds returns the cached bounding box of the snake's segments.
+// It may be slightly too large if the tail was trimmed since the last
+// refreshBounds; World.RebuildGrid refreshes every alive snake each tick.
+func (s *Snake) Bounds() Rect {
+	return s.bounds
+}
+
+// recomputeBounds rebuilds the bounding box from scratch (O(segments))
+func (s *Snake) recomputeBounds() {
+	if len(s.Segments) == 0 {
+		s.bounds = Rect{}
+		s.boundsDirty = false
+		return
+	}
+	h := s.Segments[0]
+	b := Rect{MinX: h.X, MaxX: h.X, MinY: h.Y, MaxY: h.Y}
+	for _, p := range s.Segments[1:] {
+		b.extend(p.X, p.Y)
+	}
+	s.bounds = b
+	s.boundsDirty = false
+}
+
+// refreshBounds recomputes the box only if a trimmed segment may have shrunk it.
+// Called once per tick after movement (caller must hold world mu.Lock).
+func (s *Snake) refreshBounds() {
+	if s.boundsDirty {
+		s.recomputeBounds()
+	}
+}
+
+// boundsAddHead extends the box for a newly prepended head
+func (s *Snake) boundsAddHead(p Point) {
+	s.bounds.extend(p.X, p.Y)
+}
+
+// boundsRemove marks the box dirty if a removed point sat on its edge
+func (s *Snake) boundsRemove(p Point) {
+	if s.bounds.onEdge(p.X, p.Y) {
+		s.boundsDirty = true
+	}
+}
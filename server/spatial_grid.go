@@ -9,7 +9,7 @@ type cellKey struct {
 
 // gridEntry holds a reference to food or snake segment in a cell
 type gridEntry struct {
-	foodID  string
+	foodID  FoodID // 0 = not food
 	snakeID string
 	segIdx  int
 	x, y    float64
@@ -29,9 +29,12 @@ func NewSpatialGrid(cellSize float64) *SpatialGrid {
 	}
 }
 
-// Clear resets all cells
+// Clear resets all cells, keeping each cell's backing array for reuse
+// so the per-tick rebuild doesn't reallocate thousands of slices
 func (g *SpatialGrid) Clear() {
-	g.cells = make(map[cellKey][]gridEntry)
+	for k, entries := range g.cells {
+		g.cells[k] = entries[:0]
+	}
 }
 
 func (g *SpatialGrid) keyFor(x, y float64) cellKey {
@@ -62,9 +65,19 @@ func (g *SpatialGrid) InsertSnakeBody(s *Snake) {
 	}
 }
 
-// NearbyFood returns food IDs within radius of (x,y)
-func (g *SpatialGrid) NearbyFood(x, y, radius float64) []string {
-	results := []string{}
+// forEachCell calls fn with every non-empty cell's entries
+func (g *SpatialGrid) forEachCell(fn func(k cellKey, entries []gridEntry)) {
+	for k, entries := range g.cells {
+		if len(entries) > 0 {
+			fn(k, entries)
+		}
+	}
+}
+
+// ForEachFoodNear calls fn for every food entry within radius of (x,y).
+// Iteration stops early when fn returns false. Allocation-free — prefer this
+// over NearbyFood on hot paths.
+func (g *SpatialGrid) ForEachFoodNear(x, y, radius float64, fn func(id FoodID) bool) {
 	minCX := int(math.Floor((x - radius) / g.cellSize))
 	maxCX := int(math.Floor((x + radius) / g.cellSize))
 	minCY := int(math.Floor((y - radius) / g.cellSize))
@@ -74,24 +87,23 @@ func (g *SpatialGrid) NearbyFood(x, y, radius float64) []string {
 	for cx := minCX; cx <= maxCX; cx++ {
 		for cy := minCY; cy <= maxCY; cy++ {
 			for _, e := range g.cells[cellKey{cx, cy}] {
-				if e.foodID == "" {
+				if e.foodID == 0 {
 					continue
 				}
 				dx := e.x - x
 				dy := e.y - y
-				if dx*dx+dy*dy <= r2 {
-					results = append(results, e.foodID)
+				if dx*dx+dy*dy <= r2 && !fn(e.foodID) {
+					return
 				}
 			}
 		}
 	}
-	return results
 }
 
-// NearbySnakeBody returns (snakeID, segIdx) pairs within radius of (x,y),
-// excluding the snake identified by excludeID
-func (g *SpatialGrid) NearbySnakeBody(x, y, radius float64, excludeID string) []gridEntry {
-	results := []gridEntry{}
+// ForEachSnakeBodyNear calls fn for every snake body entry within radius of (x,y),
+// excluding the snake identified by excludeID. Iteration stops early when fn
+// returns false. Allocation-free — prefer this over NearbySnakeBody on hot paths.
+func (g *SpatialGrid) ForEachSnakeBodyNear(x, y, radius float64, excludeID string, fn func(e gridEntry) bool) {
 	minCX := int(math.Floor((x - radius) / g.cellSize))
 	maxCX := int(math.Floor((x + radius) / g.cellSize))
 	minCY := int(math.Floor((y - radius) / g.cellSize))
@@ -106,36 +118,69 @@ func (g *SpatialGrid) NearbySnakeBody(x, y, radius float64, excludeID string) []
 				}
 				dx := e.x - x
 				dy := e.y - y
-				if dx*dx+dy*dy <= r2 {
-					results = append(results, e)
+				if dx*dx+dy*dy <= r2 && !fn(e) {
+					return
 				}
 			}
 		}
 	}
+}
+
+// NearbyFood returns food IDs within radius of (x,y)
+func (g *SpatialGrid) NearbyFood(x, y, radius float64) []FoodID {
+	results := []FoodID{}
+	g.ForEachFoodNear(x, y, radius, func(id FoodID) bool {
+		results = append(results, id)
+		return true
+	})
+	return results
+}
+
+// NearbySnakeBody returns (snakeID, segIdx) pairs within radius of (x,y),
+// excluding the snake identified by excludeID
+func (g *SpatialGrid) NearbySnakeBody(x, y, radius float64, excludeID string) []gridEntry {
+	results := []gridEntry{}
+	g.ForEachSnakeBodyNear(x, y, radius, excludeID, func(e gridEntry) bool {
+		results = append(results, e)
+		return true
+	})
 	return results
 }
 
-// FoodInViewport returns food items that fall within the given viewport rectangle
-func (g *SpatialGrid) FoodInViewport(food map[string]*Food, vx, vy, vw, vh float64) []FoodDTO {
+// FoodInViewport returns food items that fall within the given viewport rectangle.
+// Cells farther than FoodBlobMinDist from the viewer (vcx,vcy) have tight piles
+// merged into blobs (see aggregateFoodCell); nearby cells are always sent item by item.
+func (g *SpatialGrid) FoodInViewport(food map[FoodID]*Food, vx, vy, vw, vh, vcx, vcy float64) ([]FoodDTO, []FoodBlobDTO) {
 	result := []FoodDTO{}
+	var blobs []FoodBlobDTO
 	minCX := int(math.Floor(vx / g.cellSize))
 	maxCX := int(math.Floor((vx + vw) / g.cellSize))
 	minCY := int(math.Floor(vy / g.cellSize))
 	maxCY := int(math.Floor((vy + vh) / g.cellSize))
 
-	seen := map[string]bool{}
+	blobDist2 := FoodBlobMinDist * FoodBlobMinDist
 	for cx := minCX; cx <= maxCX; cx++ {
 		for cy := minCY; cy <= maxCY; cy++ {
-			for _, e := range g.cells[cellKey{cx, cy}] {
-				if e.foodID == "" || seen[e.foodID] {
+			entries := g.cells[cellKey{cx, cy}]
+			if FoodBlobEnabled && len(entries) >= FoodBlobMinItems {
+				// Distance from viewer to cell center decides whether to aggregate
+				dx := (float64(cx)+0.5)*g.cellSize - vcx
+				dy := (float64(cy)+0.5)*g.cellSize - vcy
+				if dx*dx+dy*dy > blobDist2 {
+					result, blobs = aggregateFoodCell(food, entries, result, blobs)
+					continue
+				}
+			}
+			// Each food is inserted into exactly one cell, so no dedup set is needed
+			for _, e := range entries {
+				if e.foodID == 0 {
 					continue
 				}
 				if f, ok := food[e.foodID]; ok {
-					seen[e.foodID] = true
 					result = append(result, f.ToDTO())
 				}
 			}
 		}
 	}
-	return result
+	return result, blobs
 }
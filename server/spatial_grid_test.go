@@ -0,0 +1,123 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// newBenchGrid fills a grid with n food items and a few long snakes around the world center
+func newBenchGrid(n int) (*SpatialGrid, map[FoodID]*Food) {
+	g := NewSpatialGrid(GridCellSize)
+	food := make(map[FoodID]*Food, n)
+	pool := NewFoodPool()
+	for i := 0; i < n; i++ {
+		f := pool.NewFood()
+		food[f.ID] = f
+		g.InsertFood(f)
+	}
+	for i := 0; i < 50; i++ {
+		s := NewSnake("bench-"+string(rune('a'+i%26)), "bench", "#fff")
+		s.Grow(200)
+		g.InsertSnakeBody(s)
+	}
+	return g, food
+}
+
+func TestForEachFoodNearMatchesNearbyFood(t *testing.T) {
+	g, _ := newBenchGrid(5000)
+	for i := 0; i < 100; i++ {
+		x, y := randomCirclePoint(WorldCenterX, WorldCenterY, WorldRadius)
+		want := g.NearbyFood(x, y, BotFoodSeekRadius)
+		got := 0
+		g.ForEachFoodNear(x, y, BotFoodSeekRadius, func(FoodID) bool {
+			got++
+			return true
+		})
+		if got != len(want) {
+			t.Fatalf("ForEachFoodNear visited %d items, NearbyFood returned %d", got, len(want))
+		}
+	}
+}
+
+func TestForEachSnakeBodyNearStopsEarly(t *testing.T) {
+	g := NewSpatialGrid(GridCellSize)
+	s := NewSnake("s1", "s1", "#fff")
+	g.InsertSnakeBody(s)
+	head := s.Head()
+	visits := 0
+	g.ForEachSnakeBodyNear(head.X, head.Y, 500, "", func(gridEntry) bool {
+		visits++
+		return false
+	})
+	if visits != 1 {
+		t.Fatalf("expected iteration to stop after 1 visit, got %d", visits)
+	}
+}
+
+func BenchmarkNearbyFood(b *testing.B) {
+	g, _ := newBenchGrid(TargetFoodCount)
+	rng := rand.New(rand.NewSource(1))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := WorldCenterX + (rng.Float64()*2-1)*5000
+		y := WorldCenterY + (rng.Float64()*2-1)*5000
+		_ = g.NearbyFood(x, y, BotFoodSeekRadius)
+	}
+}
+
+func BenchmarkForEachFoodNear(b *testing.B) {
+	g, _ := newBenchGrid(TargetFoodCount)
+	rng := rand.New(rand.NewSource(1))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := WorldCenterX + (rng.Float64()*2-1)*5000
+		y := WorldCenterY + (rng.Float64()*2-1)*5000
+		n := 0
+		g.ForEachFoodNear(x, y, BotFoodSeekRadius, func(FoodID) bool {
+			n++
+			return true
+		})
+	}
+}
+
+func BenchmarkNearbySnakeBody(b *testing.B) {
+	g, _ := newBenchGrid(0)
+	rng := rand.New(rand.NewSource(1))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := WorldCenterX + (rng.Float64()*2-1)*5000
+		y := WorldCenterY + (rng.Float64()*2-1)*5000
+		_ = g.NearbySnakeBody(x, y, BotDangerRadius, "")
+	}
+}
+
+func BenchmarkForEachSnakeBodyNear(b *testing.B) {
+	g, _ := newBenchGrid(0)
+	rng := rand.New(rand.NewSource(1))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := WorldCenterX + (rng.Float64()*2-1)*5000
+		y := WorldCenterY + (rng.Float64()*2-1)*5000
+		n := 0
+		g.ForEachSnakeBodyNear(x, y, BotDangerRadius, "", func(gridEntry) bool {
+			n++
+			return true
+		})
+	}
+}
+
+func BenchmarkGridRebuild(b *testing.B) {
+	g, food := newBenchGrid(TargetFoodCount)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Clear()
+		for _, f := range food {
+			g.InsertFood(f)
+		}
+	}
+}
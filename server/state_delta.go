@@ -0,0 +1,143 @@
+package main
+
+import "slices"
+
+// viewCache is what a connection's client holds from the state messages it
+// was sent, so the next one can carry only what changed. Most of a viewport
+// is food lying still and snakes whose bodies moved by one segment, so a
+// delta is a fraction of a full state. A keyframe (a full state) is sent
+// when there is nothing to build on and every DeltaKeyframeInterval, so a
+// client that went wrong recovers. Deltas rely on every state reaching the
+// client in order; broadcast never lets one replace another unsent (see
+// sendQueue.statePending). Game-loop goroutine only.
+type viewCache struct {
+	snakes map[uint32]sentSnake
+	food   map[uint32]FoodDTO // as sent, without the new flag
+	blobs  []FoodBlobDTO
+	keyAt  uint64 // World.Tick of the last keyframe
+	valid  bool   // false until a keyframe is sent, and after reset
+
+	// spare maps, swapped in each fill to avoid reallocating per tick
+	spareSnakes map[uint32]sentSnake
+	spareFood   map[uint32]FoodDTO
+}
+
+// sentSnake is the body a client holds for one snake
+type sentSnake struct {
+	snake  *Snake     // a new snake reusing the wire ID is sent in full
+	laid   uint64     // snake.Laid when sent
+	length int        // segments sent
+	neck   [2]float64 // newest laid segment as sent
+}
+
+// reset drops the cache so the next state is a keyframe; for viewers that
+// were sent a state without it (nothing followed, a fresh join)
+func (v *viewCache) reset() {
+	v.valid = false
+}
+
+// fill sets msg's snakes, food and blobs from what is in view at tick, as a
+// delta against the cache when possible, and records them as sent
+func (v *viewCache) fill(msg *StateMsg, tick uint64, snakes []*Snake, food []FoodDTO, blobs []FoodBlobDTO) {
+	key := !DeltaStateEnabled || !v.valid || tick-v.keyAt >= uint64(ticksFor(DeltaKeyframeInterval))
+	if key {
+		v.keyAt = tick
+		v.valid = true
+		clear(v.snakes)
+		clear(v.food)
+	} else {
+		msg.Delta = 1
+	}
+
+	prevSnakes, nextSnakes := v.snakes, v.spareSnakes
+	if nextSnakes == nil {
+		nextSnakes = make(map[uint32]sentSnake, len(snakes))
+	}
+	msg.Snakes = make([]SnakeDTO, 0, len(snakes))
+	for _, s := range snakes {
+		if k, ok := prevSnakes[s.NetID].added(s); ok {
+			msg.Snakes = append(msg.Snakes, s.deltaDTO(k))
+		} else {
+			msg.Snakes = append(msg.Snakes, s.ToDTO(0))
+		}
+		nextSnakes[s.NetID] = sentSnake{
+			snake:  s,
+			laid:   s.Laid,
+			length: len(s.Segments),
+			neck:   roundPoint(s.Segments[min(1, len(s.Segments)-1)]),
+		}
+	}
+	clear(prevSnakes)
+	v.snakes, v.spareSnakes = nextSnakes, prevSnakes
+
+	prevFood, nextFood := v.food, v.spareFood
+	if nextFood == nil {
+		nextFood = make(map[uint32]FoodDTO, len(food))
+	}
+	if key {
+		msg.Food = food
+	} else {
+		msg.Food = make([]FoodDTO, 0)
+	}
+	for _, f := range food {
+		sent := f
+		sent.New = 0
+		if !key && prevFood[f.ID] != sent {
+			msg.Food = append(msg.Food, f)
+		}
+		nextFood[f.ID] = sent
+	}
+	if !key {
+		for id := range prevFood {
+			if _, ok := nextFood[id]; !ok {
+				msg.Removed = append(msg.Removed, id)
+			}
+		}
+	}
+	clear(prevFood)
+	v.food, v.spareFood = nextFood, prevFood
+
+	switch {
+	case key:
+		msg.Blobs = blobs
+	case !slices.Equal(blobs, v.blobs):
+		msg.Blobs = blobs
+		if msg.Blobs == nil {
+			msg.Blobs = []FoodBlobDTO{} // cleared, as opposed to null for unchanged
+		}
+	}
+	v.blobs = blobs
+}
+
+// added reports how many segments s laid since the client was sent it, when
+// the client's copy can be brought up to date with just those and the new
+// head: same snake, its old newest segment still in the body, and the tail
+// only trimmed since. A zero sentSnake (not held) never can.
+func (sent sentSnake) added(s *Snake) (int, bool) {
+	if sent.snake != s || s.Laid < sent.laid {
+		return 0, false
+	}
+	k := s.Laid - sent.laid
+	if k+1 >= uint64(len(s.Segments)) || len(s.Segments)-int(k) > sent.length {
+		return 0, false
+	}
+	if roundPoint(s.Segments[k+1]) != sent.neck {
+		return 0, false
+	}
+	return int(k), true
+}
+
+// deltaDTO is s's delta entry for a client holding its body from k
+// segments ago: the new head and the k segments, which replace the held head
+func (s *Snake) deltaDTO(k int) SnakeDTO {
+	dto := s.ToDTO(k + 1)
+	dto.Name, dto.Country, dto.Color = "", "", ""
+	dto.Added, dto.Segments = dto.Segments, nil
+	dto.Length = len(s.Segments)
+	return dto
+}
+
+// roundPoint is p as it goes on the wire
+func roundPoint(p Point) [2]float64 {
+	return [2]float64{roundTo1(p.X), roundTo1(p.Y)}
+}
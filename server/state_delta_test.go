@@ -0,0 +1,101 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// applyState updates the bodies a client holds from a state message, the
+// way game-client.js does: a keyframe or full entry replaces a body, a
+// delta entry puts the new head and laid segments in place of the held
+// head and cuts the result to its length
+func applyState(t *testing.T, held map[uint32][][2]float64, msg StateMsg) map[uint32][][2]float64 {
+	t.Helper()
+	next := make(map[uint32][][2]float64, len(msg.Snakes))
+	for _, dto := range msg.Snakes {
+		if dto.Segments != nil {
+			next[dto.ID] = dto.Segments
+			continue
+		}
+		body, ok := held[dto.ID]
+		if !ok || msg.Delta == 0 {
+			t.Fatalf("delta entry for snake %d, which the client doesn't hold", dto.ID)
+		}
+		body = append(slices.Clone(dto.Added), body[1:]...)
+		next[dto.ID] = body[:min(len(body), dto.Length)]
+	}
+	return next
+}
+
+// TestDeltaRebuildsBodies sends a viewer one snake, changes it, sends it
+// again and checks the body the client rebuilds from the second state is the
+// snake's whole body, and that it came as a delta entry only when one could
+// carry the change
+func TestDeltaRebuildsBodies(t *testing.T) {
+	keyframe := uint64(ticksFor(DeltaKeyframeInterval))
+	move := func(ticks int) func(*Snake) *Snake {
+		return func(s *Snake) *Snake {
+			for range ticks {
+				s.Move()
+			}
+			return s
+		}
+	}
+	tests := []struct {
+		name  string
+		step  func(*Snake) *Snake // the change between states; returns the snake in view
+		ticks uint64              // ticks between the two states
+		hide  bool                // a state without the snake comes in between
+		delta bool
+	}{
+		{name: "still", step: move(0), ticks: 1, delta: true},
+		{name: "laid one", step: move(1), ticks: 1, delta: true},
+		{name: "laid several", step: move(4), ticks: 4, delta: true},
+		{name: "laid while growing", step: func(s *Snake) *Snake {
+			s.Grow(3)
+			return move(5)(s)
+		}, ticks: 5, delta: true},
+		{name: "tail trimmed by boost", step: func(s *Snake) *Snake {
+			s.Segments = s.Segments[:len(s.Segments)-2]
+			return move(2)(s)
+		}, ticks: 2, delta: true},
+		{name: "laid past the held body", step: move(SnakeInitSegments + 2), ticks: 1, delta: false},
+		{name: "respawned under the same ID", step: func(s *Snake) *Snake {
+			r := newSnakeAt(s.ID, s.Name, s.Color, s.Head().X+50, s.Head().Y)
+			r.NetID = s.NetID
+			return r
+		}, ticks: 1, delta: false},
+		{name: "keyframe due", step: move(1), ticks: keyframe, delta: false},
+		{name: "back in view", step: move(1), ticks: 1, hide: true, delta: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSnakeAt("s1", "s1", "#ffffff", WorldCenterX, WorldCenterY)
+			s.NetID = 7
+			var v viewCache
+			tick := uint64(100)
+			send := func(snakes ...*Snake) StateMsg {
+				var msg StateMsg
+				v.fill(&msg, tick, snakes, nil, nil)
+				return msg
+			}
+			held := applyState(t, nil, send(s))
+
+			if tt.hide {
+				tick++
+				held = applyState(t, held, send())
+			}
+			s = tt.step(s)
+			tick += tt.ticks
+			msg := send(s)
+			held = applyState(t, held, msg)
+
+			if delta := msg.Snakes[0].Segments == nil; delta != tt.delta {
+				t.Errorf("sent as a delta entry: %v, want %v", delta, tt.delta)
+			}
+			if want := s.ToDTO(0).Segments; !slices.Equal(held[s.NetID], want) {
+				t.Fatalf("client holds %v,\nwant %v", held[s.NetID], want)
+			}
+		})
+	}
+}
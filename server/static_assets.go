@@ -0,0 +1,199 @@
+package main
+
+//go:generate go run ./cmd/bundleclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// embeddedClient holds the bundled client (see cmd/bundleclient). In a tree
+// that was never bundled it only contains the .gitkeep placeholder.
+//
+//go:embed all:webclient
+var embeddedClient embed.FS
+
+// staticAsset is one embedded file plus its optional pre-compressed forms
+type staticAsset struct {
+	data []byte
+	gz   []byte // nil when not worth compressing
+	br   []byte // Brotli, likewise
+	etag string
+}
+
+// devClientVersion is reported when the client is served from disk, where
+// files can change under a running server and there is no build to identify
+const devClientVersion = "dev"
+
+// embeddedStatic serves the embedded client from memory with ETags, cache
+// headers and Brotli or gzip pre-compression
+type embeddedStatic struct {
+	assets  map[string]*staticAsset // "/index.html" -> asset
+	modTime time.Time
+	version string // build hash over every asset, see clientVersion
+}
+
+// newEmbeddedStatic indexes every file in fsys; returns nil if there is no index.html
+func newEmbeddedStatic(fsys fs.FS) *embeddedStatic {
+	h := &embeddedStatic{assets: make(map[string]*staticAsset), modTime: time.Now()}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") || strings.HasSuffix(p, ".gz") || strings.HasSuffix(p, ".br") {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		asset := &staticAsset{data: data, etag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+		if gz, err := fs.ReadFile(fsys, p+".gz"); err == nil {
+			asset.gz = gz
+		}
+		if br, err := fs.ReadFile(fsys, p+".br"); err == nil {
+			asset.br = br
+		}
+		h.assets["/"+p] = asset
+		return nil
+	})
+	if err != nil || h.assets["/index.html"] == nil {
+		return nil
+	}
+	h.version = h.clientVersion()
+	h.stampIndex()
+	return h
+}
+
+// clientVersion hashes every asset's path and content hash, so any change to
+// any file (protocol.js included) yields a new version
+func (h *embeddedStatic) clientVersion() string {
+	names := make([]string, 0, len(h.assets))
+	for name := range h.assets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sum := sha256.New()
+	for _, name := range names {
+		sum.Write([]byte(name + "\x00" + h.assets[name].etag + "\x00"))
+	}
+	return hex.EncodeToString(sum.Sum(nil)[:6])
+}
+
+// localAssetRef matches src/href attributes pointing at a sibling .js/.css file
+var localAssetRef = regexp.MustCompile(`(src|href)="([\w./-]+\.(?:js|css))"`)
+
+// stampIndex rewrites index.html for this build: ?v=<version> on local
+// script and stylesheet references, so a deploy can't pair new HTML with
+// cached old assets, and a slether-version meta tag the client compares
+// against /api/client-version to notice a deploy while it runs
+func (h *embeddedStatic) stampIndex() {
+	index := h.assets["/index.html"]
+	html := localAssetRef.ReplaceAll(index.data, []byte(`$1="$2?v=`+h.version+`"`))
+	meta := `  <meta name="slether-version" content="` + h.version + `" />` + "\n</head>"
+	html = bytes.Replace(html, []byte("</head>"), []byte(meta), 1)
+
+	sum := sha256.Sum256(html)
+	stamped := &staticAsset{data: html, etag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+	if index.gz != nil {
+		var buf bytes.Buffer
+		zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		zw.Write(html)
+		zw.Close()
+		stamped.gz = buf.Bytes()
+	}
+	if index.br != nil {
+		var buf bytes.Buffer
+		bw := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+		bw.Write(html)
+		bw.Close()
+		stamped.br = buf.Bytes()
+	}
+	h.assets["/index.html"] = stamped
+}
+
+func (h *embeddedStatic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + r.URL.Path)
+	if name == "/" {
+		name = "/index.html"
+	}
+	asset, ok := h.assets[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// HTML must revalidate so a deploy is picked up immediately. Assets
+	// requested with this build's ?v= never change under that URL; the rest
+	// (module imports) aren't fingerprinted, so keep their max-age short
+	switch {
+	case strings.HasSuffix(name, ".html"):
+		w.Header().Set("Cache-Control", "no-cache")
+	case r.URL.Query().Get("v") == h.version:
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	default:
+		w.Header().Set("Cache-Control", "public, max-age=300")
+	}
+
+	// Brotli beats gzip by 15-20% on the client's JS and CSS, so it wins
+	// whenever the browser takes both
+	body, etag := asset.data, asset.etag
+	if asset.gz != nil || asset.br != nil {
+		w.Header().Set("Vary", "Accept-Encoding")
+		accept := r.Header.Get("Accept-Encoding")
+		switch {
+		case asset.br != nil && acceptsEncoding(accept, "br"):
+			body, etag = asset.br, strings.TrimSuffix(asset.etag, `"`)+`-br"`
+			w.Header().Set("Content-Encoding", "br")
+		case asset.gz != nil && acceptsEncoding(accept, "gzip"):
+			body, etag = asset.gz, strings.TrimSuffix(asset.etag, `"`)+`-gz"`
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+	}
+	w.Header().Set("ETag", etag)
+	// ServeContent sniffs Content-Type from name and answers If-None-Match with 304
+	http.ServeContent(w, r, name, h.modTime, bytes.NewReader(body))
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header lists coding
+// without refusing it (q=0)
+func acceptsEncoding(header, coding string) bool {
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), coding) {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q=")
+		return !ok || strings.Trim(q, "0.") != ""
+	}
+	return false
+}
+
+// staticHandler picks where the client is served from: SLETHER_STATIC_DIR if
+// set, else the embedded bundle, else StaticDir on disk (unbundled dev tree).
+// It also returns the client version for /api/client-version.
+func staticHandler() (http.Handler, string) {
+	if dir := os.Getenv("SLETHER_STATIC_DIR"); dir != "" {
+		log.Printf("serving client from %s (SLETHER_STATIC_DIR)", dir)
+		return http.FileServer(http.Dir(dir)), devClientVersion
+	}
+	sub, _ := fs.Sub(embeddedClient, "webclient")
+	if h := newEmbeddedStatic(sub); h != nil {
+		log.Printf("serving embedded client %s (%d files)", h.version, len(h.assets))
+		return h, h.version
+	}
+	log.Printf("no embedded client bundle; serving from %s", StaticDir)
+	return http.FileServer(http.Dir(StaticDir)), devClientVersion
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tenant keys let a third party run its own rooms on a shared server. The
+// operator issues a key for a tenant with the existing rooms it gets and
+// its quotas; with a room quota the key can also create rooms of its own
+// (see hosted_rooms.go). The key works on the per-room admin endpoints,
+// for its tenant's rooms only, and sees no other room — not in
+// GET /admin/rooms and not through ?room=. Everything deployment-wide
+// (bans, sanctions, the MOTD, packs, metrics, the audit log, the live
+// stream and key management itself) stays with the operator token. A room
+// belongs to one tenant at most, so tenants never see each other's rooms
+// or stats; a tenant may hold several keys to rotate them.
+
+// TenantKey is an issued key, without its secret
+type TenantKey struct {
+	ID     string   `json:"id"`
+	Tenant string   `json:"tenant"`
+	Rooms  []string `json:"rooms"`
+	// MaxRooms is how many rooms the key may create and hold at once (0:
+	// none); MaxPlayers caps the players in those rooms together (0: only
+	// each room's own capacity)
+	MaxRooms   int       `json:"max_rooms"`
+	MaxPlayers int       `json:"max_players"`
+	Hash       []byte    `json:"hash,omitempty"` // SHA-256 of the secret
+	Created    time.Time `json:"created"`
+}
+
+// HostedRoom is a room a tenant created with a key, kept with the keys so
+// it is recreated on restart
+type HostedRoom struct {
+	Name    string     `json:"name"`
+	Tenant  string     `json:"tenant"`
+	Key     string     `json:"key"` // ID of the key that created it
+	Config  RoomConfig `json:"config"`
+	Created time.Time  `json:"created"`
+}
+
+// owns reports whether the key grants room
+func (k *TenantKey) owns(room string) bool {
+	return slices.Contains(k.Rooms, room)
+}
+
+// TenantKeys holds the issued tenant keys and the rooms created with them,
+// optionally backed by a JSON file (SLETHER_TENANT_KEYS) rewritten on
+// every change, so both survive a restart. Only hashes are kept; a secret
+// is shown once, when issued. Safe for concurrent use.
+type TenantKeys struct {
+	mu     sync.Mutex
+	path   string // "" = memory only
+	byID   map[string]*TenantKey
+	hosted map[string]*HostedRoom // by room name
+}
+
+// tenantFile is the SLETHER_TENANT_KEYS document
+type tenantFile struct {
+	Keys  []*TenantKey  `json:"keys"`
+	Rooms []*HostedRoom `json:"rooms"`
+}
+
+// loadTenantKeys reads path if set. A missing file starts empty and is
+// created on the first change; a malformed one is an error.
+func loadTenantKeys(path string) (*TenantKeys, error) {
+	k := &TenantKeys{path: path, byID: make(map[string]*TenantKey), hosted: make(map[string]*HostedRoom)}
+	if path == "" {
+		return k, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file tenantFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, key := range file.Keys {
+		k.byID[key.ID] = key
+	}
+	for _, h := range file.Rooms {
+		k.hosted[h.Name] = h
+	}
+	return k, nil
+}
+
+// Errors from Issue and Host
+var (
+	errRoomTaken  = errors.New("room belongs to another tenant")
+	errRoomQuota  = errors.New("room quota reached")
+	errHostedFull = errors.New("no room for more hosted rooms")
+	errNoSuchKey  = errors.New("no such key")
+)
+
+// Issue creates a key for tenant scoped to rooms, with maxRooms and
+// maxPlayers as its quotas, and returns it with its secret, which is not
+// stored
+func (k *TenantKeys) Issue(tenant string, rooms []string, maxRooms, maxPlayers int) (TenantKey, string, error) {
+	id := "tk-" + rand.Text()[:8]
+	secret := id + "." + rand.Text()
+	sum := sha256.Sum256([]byte(secret))
+	key := &TenantKey{
+		ID:         id,
+		Tenant:     tenant,
+		Rooms:      rooms,
+		MaxRooms:   maxRooms,
+		MaxPlayers: maxPlayers,
+		Hash:       sum[:],
+		Created:    time.Now().UTC(),
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, other := range k.byID {
+		if other.Tenant == tenant {
+			continue
+		}
+		for _, room := range rooms {
+			if other.owns(room) {
+				return TenantKey{}, "", fmt.Errorf("%s: %w", room, errRoomTaken)
+			}
+		}
+	}
+	k.byID[id] = key
+	if err := k.save(); err != nil {
+		delete(k.byID, id)
+		return TenantKey{}, "", err
+	}
+	return key.public(), secret, nil
+}
+
+// Revoke deletes the key id and the records of the rooms it created,
+// returning their names for the caller to close; ok is false if there was
+// no such key
+func (k *TenantKeys) Revoke(id string) (rooms []string, ok bool, err error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, ok := k.byID[id]
+	if !ok {
+		return nil, false, nil
+	}
+	delete(k.byID, id)
+	removed := make(map[string]*HostedRoom)
+	for name, h := range k.hosted {
+		if h.Key == id {
+			removed[name] = h
+			delete(k.hosted, name)
+		}
+	}
+	if err := k.save(); err != nil {
+		k.byID[id] = key
+		maps.Copy(k.hosted, removed)
+		return nil, false, err
+	}
+	return slices.Sorted(maps.Keys(removed)), true, nil
+}
+
+// Host records h, a room created with key, if the key still exists and is
+// under its room quota and the server under MaxHostedRooms
+func (k *TenantKeys) Host(key TenantKey, h HostedRoom) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.byID[key.ID]; !ok {
+		return errNoSuchKey
+	}
+	if len(k.hosted) >= MaxHostedRooms {
+		return errHostedFull
+	}
+	n := 0
+	for _, other := range k.hosted {
+		if other.Key == key.ID {
+			n++
+		}
+	}
+	if n >= key.MaxRooms {
+		return fmt.Errorf("%w (%d)", errRoomQuota, key.MaxRooms)
+	}
+	k.hosted[h.Name] = &h
+	if err := k.save(); err != nil {
+		delete(k.hosted, h.Name)
+		return err
+	}
+	return nil
+}
+
+// Unhost deletes the record of the hosted room name
+func (k *TenantKeys) Unhost(name string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	h, ok := k.hosted[name]
+	if !ok {
+		return nil
+	}
+	delete(k.hosted, name)
+	if err := k.save(); err != nil {
+		k.hosted[name] = h
+		return err
+	}
+	return nil
+}
+
+// Hosted returns every hosted room, oldest first, each with the key that
+// created it
+func (k *TenantKeys) Hosted() ([]HostedRoom, []TenantKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	rooms := make([]HostedRoom, 0, len(k.hosted))
+	for _, h := range k.hosted {
+		rooms = append(rooms, *h)
+	}
+	slices.SortFunc(rooms, func(a, b HostedRoom) int { return a.Created.Compare(b.Created) })
+	keys := make([]TenantKey, len(rooms))
+	for i, h := range rooms {
+		if key, ok := k.byID[h.Key]; ok {
+			keys[i] = key.public()
+		}
+	}
+	return rooms, keys
+}
+
+// List returns every key, oldest first, without hashes
+func (k *TenantKeys) List() []TenantKey {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	list := make([]TenantKey, 0, len(k.byID))
+	for _, key := range k.byID {
+		list = append(list, key.public())
+	}
+	slices.SortFunc(list, func(a, b TenantKey) int { return a.Created.Compare(b.Created) })
+	return list
+}
+
+// Lookup finds the key a secret belongs to
+func (k *TenantKeys) Lookup(secret string) (TenantKey, bool) {
+	id, _, ok := strings.Cut(secret, ".")
+	if !ok {
+		return TenantKey{}, false
+	}
+	sum := sha256.Sum256([]byte(secret))
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, ok := k.byID[id]
+	if !ok || subtle.ConstantTimeCompare(sum[:], key.Hash) != 1 {
+		return TenantKey{}, false
+	}
+	return key.public(), true
+}
+
+// public is a copy of the key without its hash
+func (k *TenantKey) public() TenantKey {
+	c := *k
+	c.Hash = nil
+	c.Rooms = slices.Clone(k.Rooms)
+	return c
+}
+
+// save writes every key and hosted room to the backing file (via a temp
+// file and rename, so a crash never leaves half a file); caller holds mu
+func (k *TenantKeys) save() error {
+	if k.path == "" {
+		return nil
+	}
+	file := tenantFile{
+		Keys:  slices.SortedFunc(maps.Values(k.byID), func(a, b *TenantKey) int { return strings.Compare(a.ID, b.ID) }),
+		Rooms: slices.SortedFunc(maps.Values(k.hosted), func(a, b *HostedRoom) int { return strings.Compare(a.Name, b.Name) }),
+	}
+	data, _ := json.MarshalIndent(file, "", "  ")
+	tmp, err := os.CreateTemp(filepath.Dir(k.path), ".tenant-keys-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), k.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// tenantAuth admits the operator token or a tenant key, running h on a
+// copy of the API that knows which
+func (a *AdminAPI) tenantAuth(h func(*AdminAPI, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	operator := a.auth(func(w http.ResponseWriter, r *http.Request) { h(a, w, r) })
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		key, ok := a.keys.Lookup(secret)
+		if !ok {
+			operator(w, r)
+			return
+		}
+		scoped := *a
+		scoped.tenant = &key
+		h(&scoped, w, r)
+	}
+}
+
+// roomAuth is tenantAuth for a per-room endpoint, scoped like inRoom
+func (a *AdminAPI) roomAuth(h func(*AdminAPI, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return a.tenantAuth(func(a *AdminAPI, w http.ResponseWriter, r *http.Request) {
+		a.inRoom(h)(w, r)
+	})
+}
+
+// room resolves ?room= for the caller. A tenant defaults to its first room
+// and gets no other tenant's, as if it didn't exist, so keys can't probe
+// for them.
+func (a *AdminAPI) room(r *http.Request) (*Room, bool) {
+	if a.tenant == nil {
+		return a.rooms.fromRequest(r)
+	}
+	name := r.URL.Query().Get("room")
+	if name == "" {
+		for _, room := range a.rooms.Rooms() {
+			if a.owns(room) {
+				return room, true
+			}
+		}
+		return nil, false
+	}
+	room, ok := a.rooms.Get(name)
+	if !ok || !a.owns(room) {
+		return nil, false
+	}
+	return room, true
+}
+
+// owns reports whether the caller may act on room: the operator on any,
+// a tenant on the rooms its key names and the rooms its tenant created
+func (a *AdminAPI) owns(room *Room) bool {
+	return a.tenant == nil || a.tenant.owns(room.Name) || room.Tenant == a.tenant.Tenant
+}
+
+// issueKeyRequest is the POST /admin/keys body
+type issueKeyRequest struct {
+	Tenant     string   `json:"tenant"`
+	Rooms      []string `json:"rooms"`
+	MaxRooms   int      `json:"max_rooms"`
+	MaxPlayers int      `json:"max_players"`
+}
+
+// handleListKeys lists the issued tenant keys, without secrets
+func (a *AdminAPI) handleListKeys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"keys": a.keys.List()})
+}
+
+// handleIssueKey issues a tenant key for existing rooms, rooms it may
+// create, or both; the secret is in this response only
+func (a *AdminAPI) handleIssueKey(w http.ResponseWriter, r *http.Request) {
+	var req issueKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	req.Tenant = strings.TrimSpace(req.Tenant)
+	if req.Tenant == "" || len(req.Tenant) > TenantNameMax {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("tenant must be 1-%d bytes", TenantNameMax)})
+		return
+	}
+	if req.MaxRooms < 0 || req.MaxRooms > TenantMaxRoomQuota {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("max_rooms must be 0-%d", TenantMaxRoomQuota)})
+		return
+	}
+	if req.MaxPlayers < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "max_players must not be negative"})
+		return
+	}
+	if len(req.Rooms) == 0 && req.MaxRooms == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "rooms must list at least one room, or max_rooms allow creating some"})
+		return
+	}
+	rooms := []string{}
+	for _, name := range req.Rooms {
+		room, ok := a.rooms.Get(name)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("no such room %q", name)})
+			return
+		}
+		if room.Tenant != "" {
+			// Hosted rooms already belong to the tenant that created them
+			writeJSON(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("%s: %v", name, errRoomTaken)})
+			return
+		}
+		if !slices.Contains(rooms, name) {
+			rooms = append(rooms, name)
+		}
+	}
+	key, secret, err := a.keys.Issue(req.Tenant, rooms, req.MaxRooms, req.MaxPlayers)
+	if errors.Is(err, errRoomTaken) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	} else if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	a.record(r, "keys.issue", key)
+	writeJSON(w, http.StatusCreated, map[string]any{"key": key, "secret": secret})
+}
+
+// handleRevokeKey revokes a tenant key by ID and closes the rooms it created
+func (a *AdminAPI) handleRevokeKey(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	rooms, ok, err := a.keys.Revoke(id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such key"})
+		return
+	}
+	for _, name := range rooms {
+		if err := a.rooms.Destroy(name); err != nil {
+			log.Printf("admin: close room %s of revoked key %s: %v", name, id, err)
+		}
+	}
+	a.record(r, "keys.revoke", map[string]any{"id": id, "rooms_closed": rooms})
+	writeJSON(w, http.StatusOK, map[string]any{"revoked": id, "rooms_closed": rooms})
+}
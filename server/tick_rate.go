@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+// TickRate is the simulation rate in ticks per second. It is set once at
+// startup by configureTickRate, before the world or loop exist, and read-only
+// afterwards. Tick-denominated values derive from it via ticksFor and perTick.
+var TickRate = DefaultTickRate
+
+// configureTickRate applies SLETHER_TICK_RATE if set
+func configureTickRate() error {
+	v := os.Getenv("SLETHER_TICK_RATE")
+	if v == "" {
+		return nil
+	}
+	rate, err := strconv.Atoi(v)
+	if err != nil || rate < MinTickRate || rate > MaxTickRate {
+		return fmt.Errorf("SLETHER_TICK_RATE=%q: want an integer in [%d, %d]", v, MinTickRate, MaxTickRate)
+	}
+	TickRate = rate
+	return nil
+}
+
+// tickDuration is the wall time of one tick
+func tickDuration() time.Duration {
+	return time.Second / time.Duration(TickRate)
+}
+
+// ticksFor converts a duration to whole ticks at the current rate (at least 1)
+func ticksFor(d time.Duration) int {
+	return max(1, int(math.Round(d.Seconds()*float64(TickRate))))
+}
+
+// perTick converts a per-second rate (speed, turn rate) to its per-tick amount
+func perTick(perSecond float64) float64 {
+	return perSecond / float64(TickRate)
+}
+
+// randomTicks returns a random tick count between lo and hi inclusive
+func randomTicks(lo, hi time.Duration) int {
+	a, b := ticksFor(lo), ticksFor(hi)
+	return a + rand.Intn(b-a+1)
+}
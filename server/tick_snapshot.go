@@ -0,0 +1,67 @@
+package main
+
+import "time"
+
+// TickSnapshot is an immutable summary of the world as one tick left it,
+// built under the tick lock and published atomically. Post-tick consumers
+// (broadcast, death and kill-feed sends, the admin API) all read the same
+// snapshot instead of re-locking the world and seeing a later state.
+// Nothing in it may be modified after publishing.
+type TickSnapshot struct {
+	Tick        uint64
+	Time        time.Time
+	Leaderboard []LeaderboardEntry
+	HumanBoard  []LeaderboardEntry // human snakes only; nil when Leaderboard has no bots
+	Minimap     []MinimapSnake
+	// Counts at the end of the tick
+	Players     int // connected sessions
+	AliveSnakes int
+	Bots        int // live bot snakes
+	Food        int
+	Cohorts     []CohortStats // bot A/B cohorts
+	BotTarget   int           // bot population being maintained
+	BotCadence  int           // ticks between bot AI decisions
+	FoodTarget  int
+	Apple       *ObjectiveMsg // the golden apple while one is out, for joiners
+	// Events of this tick
+	Deaths        map[string]DeathMsg // victimID -> death message
+	KillFeed      []KillFeedMsg
+	Objective     []ObjectiveMsg    // golden apple spawned / eaten
+	Announcements []AnnouncementMsg // fired by the content pack schedule
+	Ate           map[string]int    // snakeID -> food value eaten
+	// OwnKillFeed is session ID -> KillFeed index -> the entry as a
+	// shadow-muted player sees it, with their own name; nil when none
+	OwnKillFeed map[string]map[int]KillFeedMsg
+}
+
+// emptySnapshot stands in before the first tick is published
+var emptySnapshot = &TickSnapshot{Leaderboard: []LeaderboardEntry{}}
+
+// Snapshot returns the latest published tick snapshot; safe from any goroutine
+func (w *World) Snapshot() *TickSnapshot {
+	if s := w.snapshot.Load(); s != nil {
+		return s
+	}
+	return emptySnapshot
+}
+
+// publishSnapshot fills in the world-derived parts of snap and publishes it.
+// Caller must hold w.mu.Lock (the tick lock).
+func (w *World) publishSnapshot(snap *TickSnapshot) {
+	snap.Tick = w.Tick
+	snap.Time = time.Now()
+	snap.Leaderboard, snap.HumanBoard = w.Leaderboard()
+	snap.Minimap = w.MinimapSnakes()
+	snap.Food = len(w.Food)
+	snap.FoodTarget = w.FoodTarget
+	if w.Apple.Active {
+		msg := w.Apple.msg()
+		snap.Apple = &msg
+	}
+	for _, s := range w.Snakes {
+		if s.Alive {
+			snap.AliveSnakes++
+		}
+	}
+	w.snapshot.Store(snap)
+}
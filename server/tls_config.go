@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// serverTLS is the HTTPS setup for the game server, so it can serve
+// https:// and wss:// itself instead of behind a terminating proxy. The
+// certificate comes either from files or from Let's Encrypt.
+type serverTLS struct {
+	config *tls.Config
+	// manager obtains and renews certificates; nil with certificate files
+	manager *autocert.Manager
+	// files is the certificate loaded from files; nil with autocert
+	files *certFiles
+}
+
+// tlsFromEnv reads the TLS settings, nil (plain HTTP) when none are set:
+//
+//	SLETHER_TLS_CERT, SLETHER_TLS_KEY  PEM certificate chain and key files
+//	SLETHER_TLS_DOMAINS                comma-separated domains to get Let's
+//	                                   Encrypt certificates for (the allowlist:
+//	                                   other names are refused)
+//	SLETHER_TLS_CACHE                  autocert cache directory (default
+//	                                   TLSDefaultCacheDir)
+//	SLETHER_TLS_EMAIL                  optional contact for Let's Encrypt
+func tlsFromEnv() (*serverTLS, error) {
+	cert, key := os.Getenv("SLETHER_TLS_CERT"), os.Getenv("SLETHER_TLS_KEY")
+	domains := os.Getenv("SLETHER_TLS_DOMAINS")
+	switch {
+	case cert == "" && key == "" && domains == "":
+		return nil, nil
+	case domains != "" && (cert != "" || key != ""):
+		return nil, errors.New("set SLETHER_TLS_DOMAINS or SLETHER_TLS_CERT/KEY, not both")
+	case domains != "":
+		return autocertTLS(domains)
+	case cert == "" || key == "":
+		return nil, errors.New("SLETHER_TLS_CERT and SLETHER_TLS_KEY must be set together")
+	}
+	files := &certFiles{certPath: cert, keyPath: key}
+	if err := files.load(); err != nil {
+		return nil, err
+	}
+	return &serverTLS{config: &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"http/1.1"}, // WebSocket upgrades need HTTP/1.1
+		GetCertificate: files.get,
+	}, files: files}, nil
+}
+
+// autocertTLS gets certificates from Let's Encrypt for the allowed domains.
+// Challenges are answered over TLS-ALPN on the HTTPS port itself, and over
+// HTTP on the redirect listener when there is one (see serveRedirect).
+func autocertTLS(list string) (*serverTLS, error) {
+	var domains []string
+	for d := range strings.SplitSeq(list, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" {
+			continue
+		}
+		if strings.ContainsAny(d, "/:* ") {
+			return nil, fmt.Errorf("SLETHER_TLS_DOMAINS: %q is not a domain name", d)
+		}
+		domains = append(domains, d)
+	}
+	if len(domains) == 0 {
+		return nil, errors.New("SLETHER_TLS_DOMAINS: no domains")
+	}
+	cache := os.Getenv("SLETHER_TLS_CACHE")
+	if cache == "" {
+		cache = TLSDefaultCacheDir
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cache),
+		Email:      os.Getenv("SLETHER_TLS_EMAIL"),
+	}
+	log.Printf("tls: Let's Encrypt certificates for %s, cached in %s", strings.Join(domains, ", "), cache)
+	return &serverTLS{config: &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"http/1.1", acme.ALPNProto},
+		GetCertificate: m.GetCertificate,
+	}, manager: m}, nil
+}
+
+// wrap serves TLS on the TCP listeners. Unix sockets stay plain: they are
+// for a local reverse proxy, which terminates TLS itself.
+func (t *serverTLS) wrap(listeners []net.Listener) []net.Listener {
+	out := make([]net.Listener, len(listeners))
+	for i, l := range listeners {
+		if l.Addr().Network() == "tcp" {
+			l = tls.NewListener(l, t.config)
+		}
+		out[i] = l
+	}
+	return out
+}
+
+// reload re-reads certificate files, for renewals without a restart; a
+// no-op with autocert, which renews by itself
+func (t *serverTLS) reload() error {
+	if t.files == nil {
+		return nil
+	}
+	return t.files.load()
+}
+
+// serveRedirect serves plain HTTP on addr (SLETHER_HTTP_REDIRECT, e.g.
+// ":80") next to HTTPS: it answers Let's Encrypt HTTP challenges when
+// autocert is on and redirects everything else to the same URL on the first
+// TLS listener's port. Returns the server, for shutdown.
+func (t *serverTLS) serveRedirect(addr string, listeners []net.Listener) (*http.Server, error) {
+	port := ""
+	for _, l := range listeners {
+		if l.Addr().Network() == "tcp" {
+			_, port, _ = net.SplitHostPort(l.Addr().String())
+			break
+		}
+	}
+	if port == "" {
+		return nil, errors.New("SLETHER_HTTP_REDIRECT needs a TCP listener to redirect to")
+	}
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+	if t.manager != nil {
+		h = t.manager.HTTPHandler(h)
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: HTTPReadHeaderTimeout,
+		ReadTimeout:       HTTPReadTimeout,
+		WriteTimeout:      HTTPWriteTimeout,
+		IdleTimeout:       HTTPIdleTimeout,
+	}
+	log.Printf("redirecting http on %s to https", l.Addr())
+	go func() {
+		if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("redirect listener %s stopped: %v", l.Addr(), err)
+		}
+	}()
+	return srv, nil
+}
+
+// certFiles is a certificate loaded from PEM files, swapped on reload
+type certFiles struct {
+	certPath, keyPath string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+func (c *certFiles) load() error {
+	cert, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+	if err != nil {
+		return fmt.Errorf("tls certificate: %w", err)
+	}
+	c.mu.Lock()
+	c.cert = &cert
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *certFiles) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TrainingEnv wraps the real simulation in a gym-style interface for
+// reinforcement learning: Reset starts an episode, Step applies one action
+// per agent and runs exactly one tick. Agents are ordinary player snakes
+// (the same input path, collision and scoring as humans) whose observations
+// come from observe, so a policy trained here can later run as a bot via
+// SLETHER_BOT_POLICY. Not safe for concurrent use; the HTTP front end
+// serializes calls.
+type TrainingEnv struct {
+	cfg       TrainingConfig
+	world     *World
+	loop      *GameLoop
+	conns     *ConnManager
+	agents    []string
+	lastScore map[string]int
+	done      map[string]bool
+	ticks     int
+	cohorts   []*BotCohort // opponent bot cohorts (SLETHER_BOT_COHORTS / SLETHER_BOT_POLICY)
+}
+
+// TrainingConfig is the POST /reset body
+type TrainingConfig struct {
+	Agents   int `json:"agents"`    // learning snakes, default 1
+	Bots     int `json:"bots"`      // opponent bots, default BotCount; -1 for none
+	MaxTicks int `json:"max_ticks"` // episode cap; 0 = until every agent is dead
+}
+
+// AgentAction is one agent's move for a step: turn in [-1, 1] (fraction of
+// a right angle off the current heading, positive to the right) and boost
+type AgentAction struct {
+	Turn  float64 `json:"turn"`
+	Boost bool    `json:"boost"`
+}
+
+// StepResult is returned by Reset and Step. Obs holds only agents still
+// alive; Rewards and Dones cover every agent that was alive before the step.
+type StepResult struct {
+	Tick    uint64               `json:"tick"`
+	Obs     map[string][]float64 `json:"obs"`
+	Rewards map[string]float64   `json:"rewards"`
+	Dones   map[string]bool      `json:"dones"`
+	Done    bool                 `json:"done"` // episode over: all agents dead or MaxTicks reached
+}
+
+// discardWS is the socket behind training agents: state sent to them goes nowhere
+type discardWS struct{}
+
+func (discardWS) ReadMessage() (int, []byte, error) { return 0, nil, io.EOF }
+func (discardWS) WriteMessage(int, []byte) error    { return nil }
+func (discardWS) SetWriteDeadline(time.Time) error  { return nil }
+func (discardWS) SetReadLimit(int64)                {}
+func (discardWS) Close() error                      { return nil }
+
+// Reset discards any running episode and starts a new one
+func (e *TrainingEnv) Reset(cfg TrainingConfig) (StepResult, error) {
+	if cfg.Agents == 0 {
+		cfg.Agents = 1
+	}
+	if cfg.Bots == 0 {
+		cfg.Bots = BotCount
+	}
+	if cfg.Agents < 0 || cfg.Agents > TrainingMaxAgents || cfg.Bots > MaxPlayers || cfg.MaxTicks < 0 {
+		return StepResult{}, fmt.Errorf("agents must be 1-%d, bots at most %d, max_ticks >= 0", TrainingMaxAgents, MaxPlayers)
+	}
+	e.close()
+	e.cfg = cfg
+	e.world = NewWorld()
+	e.conns = NewConnManager()
+	e.loop = newGameLoop(e.world, e.conns, max(cfg.Bots, 0))
+	e.loop.bots.setCohorts(e.cohorts)
+	e.agents = e.agents[:0]
+	e.lastScore = make(map[string]int)
+	e.done = make(map[string]bool)
+	e.ticks = 0
+	for i := range cfg.Agents {
+		id := fmt.Sprintf("agent-%d", i)
+		c := NewConn(discardWS{})
+		c.ID, c.snakeID = id, id
+		e.conns.Add(c)
+		snake := NewSnake(id, id, randomColor())
+		e.world.AddSnake(snake)
+		e.agents = append(e.agents, id)
+		e.lastScore[id] = snake.Score
+	}
+	e.world.RebuildGrid()
+	return e.result(nil, nil), nil
+}
+
+// Step steers each live agent by its action (missing = straight on, no
+// boost), runs one tick and reports rewards: score gained this tick, or
+// -TrainingDeathPenalty for dying
+func (e *TrainingEnv) Step(actions map[string]AgentAction) (StepResult, error) {
+	if e.loop == nil {
+		return StepResult{}, fmt.Errorf("no episode: reset first")
+	}
+	if e.episodeOver() {
+		return StepResult{}, fmt.Errorf("episode over: reset to start another")
+	}
+	for _, id := range e.agents {
+		snake := e.world.Snakes[id]
+		if e.done[id] || snake == nil {
+			continue
+		}
+		c, _ := e.conns.Get(id)
+		a := actions[id]
+		c.setInput(actionAngle(snake, a.Turn), a.Boost, 0)
+	}
+	e.loop.tick()
+	e.ticks++
+
+	rewards := make(map[string]float64)
+	dones := make(map[string]bool)
+	for _, id := range e.agents {
+		if e.done[id] {
+			continue
+		}
+		snake := e.world.Snakes[id]
+		if snake == nil || !snake.Alive {
+			rewards[id] = -TrainingDeathPenalty
+			e.done[id] = true
+		} else {
+			rewards[id] = float64(snake.Score - e.lastScore[id])
+			e.lastScore[id] = snake.Score
+		}
+		dones[id] = e.done[id] || e.capped()
+	}
+	return e.result(rewards, dones), nil
+}
+
+// capped reports whether the episode hit MaxTicks
+func (e *TrainingEnv) capped() bool {
+	return e.cfg.MaxTicks > 0 && e.ticks >= e.cfg.MaxTicks
+}
+
+func (e *TrainingEnv) episodeOver() bool {
+	if e.capped() {
+		return true
+	}
+	for _, id := range e.agents {
+		if !e.done[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// result observes every live agent through the live view
+func (e *TrainingEnv) result(rewards map[string]float64, dones map[string]bool) StepResult {
+	view := e.world.LiveView()
+	obs := make(map[string][]float64)
+	for _, id := range e.agents {
+		if s := e.world.Snakes[id]; s != nil && s.Alive && !e.done[id] {
+			obs[id] = observe(view, s)
+		}
+	}
+	if rewards == nil {
+		rewards, dones = map[string]float64{}, map[string]bool{}
+	}
+	return StepResult{Tick: e.world.Tick, Obs: obs, Rewards: rewards, Dones: dones, Done: e.episodeOver()}
+}
+
+// close releases the previous episode's goroutines
+func (e *TrainingEnv) close() {
+	if e.loop == nil {
+		return
+	}
+	for _, c := range e.conns.Snapshot() {
+		c.Close()
+	}
+	e.loop.sender.Close()
+}
+
+// serveTraining runs the training environment over HTTP instead of the game:
+//
+//	GET  /spec   observation layout and tick rate
+//	POST /reset  TrainingConfig -> StepResult
+//	POST /step   {"actions": {"agent-0": {"turn": 0.2, "boost": false}}} -> StepResult
+//
+// Bind it to localhost: there is no authentication.
+func serveTraining(addr string, cohorts []*BotCohort) error {
+	var mu sync.Mutex
+	env := &TrainingEnv{cohorts: cohorts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /spec", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"features":   ObsFeatures,
+			"food":       ObsFood,
+			"snakes":     ObsSnakes,
+			"sectors":    ObsSectors,
+			"radius":     ObsRadius,
+			"tick_rate":  TickRate,
+			"max_agents": TrainingMaxAgents,
+		})
+	})
+	mux.HandleFunc("POST /reset", func(w http.ResponseWriter, r *http.Request) {
+		var cfg TrainingConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil && err != io.EOF {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+			return
+		}
+		mu.Lock()
+		res, err := env.Reset(cfg)
+		mu.Unlock()
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+	mux.HandleFunc("POST /step", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Actions map[string]AgentAction `json:"actions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+			return
+		}
+		mu.Lock()
+		res, err := env.Step(req.Actions)
+		mu.Unlock()
+		if err != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+	log.Printf("training environment on %s (%d observation features)", addr, ObsFeatures)
+	return http.ListenAndServe(addr, mux)
+}
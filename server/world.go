@@ -1,73 +1,237 @@
 package main
 
 import (
+	"math"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
-// World holds all game state
+// World holds all game state.
+//
+// Concurrency: the game loop goroutine is the only writer. Other goroutines
+// never mutate the world directly — they Post a WorldCommand, which the loop
+// applies at the start of the next tick. mu is still taken by the loop (write
+// for the simulation phase, read while building broadcasts) so that read-only
+// observers in other goroutines can take rlock for a consistent view.
+// EntityIDs, FoodPool, LockStats, Moderation, Bans, MOTD, Packs, Profiles,
+// Seasons and Records use their own leaf locks, safe to take while mu is
+// held. All but the first three, and Events, are shared by every room's
+// world (see room.go).
 type World struct {
 	mu     sync.RWMutex
 	Snakes map[string]*Snake
-	Food   map[string]*Food
+	Food   map[FoodID]*Food
 	Grid   *SpatialGrid
+	// FoodPool allocates this world's Food structs and IDs (has its own lock)
+	FoodPool *FoodPool
+	// EntityIDs maps snake IDs to compact wire IDs (has its own lock)
+	EntityIDs *EntityIDs
+	// LockStats records mu contention per call site (has its own lock)
+	LockStats *LockStats
+	// Tick counts completed simulation steps; advanced by the game loop
+	Tick uint64
+	// Feeding flags players repeatedly dying to the same killer
+	Feeding *FeedingDetector
+	// Moderation holds shadow sanctions set by admins (has its own lock)
+	Moderation *Moderation
+	// Bans refuses connections from banned IPs (has its own lock)
+	Bans *BanList
+	// Machines flags headless farming clients (see machine_clients.go);
+	// off unless the game server sets a policy
+	Machines *MachineDetector
+	// MOTD is the join-screen branding and rules text (has its own lock)
+	MOTD *MOTDStore
+	// Packs is the library of uploaded content packs (has its own lock)
+	Packs *PackLibrary
+	// FoodSpawns weights where ambient food appears (see food_rebalance.go)
+	FoodSpawns *FoodRebalancer
+	// Apple is the golden apple objective (see golden_apple.go)
+	Apple GoldenApple
+	// Economy audits score created and destroyed (see score_economy.go)
+	Economy *ScoreEconomy
+	// FoodTarget is the ambient food count to maintain (TargetFoodCount
+	// unless tuned live, see admin_live.go)
+	FoodTarget int
+	// Events receives analytics events; nil when disabled (see event_stream.go)
+	Events *EventStream
+	// Log keeps the last ticks' mutations for debugging; nil when disabled
+	// (see world_log.go)
+	Log *WorldLog
+	// Highlights indexes notable moments (see highlights.go)
+	Highlights *Highlights
+	// Profiles holds claimed names and their stats (has its own lock)
+	Profiles *ProfileStore
+	// PublicStats caches the /api/stats document (see public_stats.go)
+	PublicStats *PublicStats
+	// Records keeps final scores across restarts (has its own lock); nil
+	// when disabled (see score_records.go)
+	Records *ScoreRecords
+	// Ranked rates kills between claimed names (immutable, see rating.go)
+	Ranked bool
+	// Seasons rolls seasonal stats over (has its own lock); nil outside
+	// the game server
+	Seasons *Seasons
+	// Map is the static layout (immutable, read without mu — see world_map.go)
+	Map MapFeatures
+	// Pack names the content pack the world was built from, "" for none;
+	// Schedule is its timed events (both immutable, see content_pack.go)
+	Pack     string
+	Schedule []scheduledEvent
+	// epoch is the Tick of the last reset (see world_reset.go); the content
+	// pack schedule counts from it
+	epoch uint64
+	// Board holds the leaderboard settings (immutable, see leaderboard.go)
+	Board LeaderboardConfig
+	// Lang is the community language, "" for international (immutable,
+	// see community.go)
+	Lang string
+	// Room names the room the world belongs to (immutable, see room.go)
+	Room string
+	// Comeback holds the comeback rule (immutable, see comeback.go);
+	// comebackBelow is this tick's threshold, lengths scratch space for it
+	Comeback      ComebackConfig
+	comebackBelow int
+	lengths       []int
+	// HeadStart sizes respawns after a long life (immutable, see head_start.go)
+	HeadStart HeadStartConfig
+	// BotHunt weighs players against bots as bot prey (immutable, see bot_hunt.go)
+	BotHunt BotHuntConfig
+	// snapshot is the latest published TickSnapshot (see tick_snapshot.go)
+	snapshot atomic.Pointer[TickSnapshot]
+	// frozen caches the latest FrozenView (see world_view.go)
+	frozen atomic.Pointer[frozenView]
+	// commands holds mutations posted from outside the loop (see world_commands.go)
+	commands chan WorldCommand
 }
 
 // NewWorld initializes the world with food
 func NewWorld() *World {
-	w := &World{
-		Snakes: make(map[string]*Snake),
-		Food:   make(map[string]*Food),
-		Grid:   NewSpatialGrid(GridCellSize),
+	return NewWorldFromPack(nil)
+}
+
+// NewWorldFromPack builds the world with a validated content pack's map and
+// schedule (nil for the open arena), then spawns food around its features
+func NewWorldFromPack(p *ContentPack) *World {
+	w := newEmptyWorld()
+	if p != nil {
+		w.applyPack(p)
 	}
 	w.spawnInitialFood()
 	return w
 }
 
+// newEmptyWorld creates a world with no snakes and no food
+func newEmptyWorld() *World {
+	return &World{
+		Snakes:      make(map[string]*Snake),
+		Food:        make(map[FoodID]*Food),
+		Grid:        NewSpatialGrid(GridCellSize),
+		FoodPool:    NewFoodPool(),
+		EntityIDs:   NewEntityIDs(),
+		LockStats:   NewLockStats(),
+		Feeding:     NewFeedingDetector(),
+		Moderation:  NewModeration(),
+		Bans:        NewBanList(),
+		Machines:    NewMachineDetector(MachinePolicyOff),
+		MOTD:        &MOTDStore{},
+		Packs:       &PackLibrary{packs: make(map[string]*ContentPack)},
+		FoodSpawns:  NewFoodRebalancer(),
+		Economy:     NewScoreEconomy(),
+		FoodTarget:  TargetFoodCount,
+		Board:       defaultLeaderboard,
+		HeadStart:   defaultHeadStart,
+		BotHunt:     defaultBotHunt,
+		Highlights:  NewHighlights(),
+		Profiles:    newProfileStore(),
+		PublicStats: NewPublicStats(),
+		commands:    make(chan WorldCommand, CommandQueueSize),
+	}
+}
+
+// spawnInitialFood fills a fresh world with InitialFoodCount ambient food.
+// Items landing in an exclusion zone are skipped; like MaintainFoodCount,
+// bounded tries leave any shortfall to the per-tick top-up, so a map
+// mostly covered by obstacles can't hang world construction.
 func (w *World) spawnInitialFood() {
 	// Spawn ~70% as clusters, ~30% scattered
 	clustered := int(float64(InitialFoodCount) * 0.7)
 	scattered := InitialFoodCount - clustered
 
-	for spawned := 0; spawned < clustered; {
-		cluster := NewFoodCluster()
+	for spawned, tries := 0, 0; spawned < clustered && tries < clustered; tries++ {
+		cluster := w.FoodPool.NewFoodCluster()
+		if len(cluster) == 0 {
+			return // pool exhausted
+		}
 		for _, f := range cluster {
 			if spawned >= clustered {
-				break
+				w.FoodPool.Release(f) // truncated cluster: return the unused slot
+				continue
+			}
+			if w.placeAmbient(f) {
+				spawned++
 			}
-			w.Food[f.ID] = f
-			spawned++
 		}
 	}
-	for i := 0; i < scattered; i++ {
-		f := NewFood()
-		w.Food[f.ID] = f
+	for spawned, tries := 0, 0; spawned < scattered && tries < scattered; tries++ {
+		f := w.FoodPool.NewFood()
+		if f == nil {
+			return
+		}
+		if w.placeAmbient(f) {
+			spawned++
+		}
 	}
 }
 
-// AddSnake adds a new snake to the world (caller must hold mu.Lock)
+// AddSnake adds a new snake to the world and assigns its wire ID (caller must hold mu.Lock).
+// A snake replacing one with the same ID (respawn) keeps the same wire ID.
 func (w *World) AddSnake(s *Snake) {
+	s.NetID = w.EntityIDs.Assign(s.ID)
 	w.Snakes[s.ID] = s
+	w.Economy.spawned(s.Score)
+	head := s.Head()
+	w.Log.record(WorldLogEntry{Type: WorldLogSpawn, Snake: s.ID, X: head.X, Y: head.Y, Length: len(s.Segments), Score: s.Score})
 }
 
-// RemoveSnake removes a snake (caller must hold mu.Lock)
+// RemoveSnake removes a snake and releases its wire ID (caller must hold mu.Lock)
 func (w *World) RemoveSnake(id string) {
+	if s, ok := w.Snakes[id]; ok {
+		if s.Alive {
+			w.Economy.despawned(s.Score)
+		}
+		head := s.Head()
+		w.Log.record(WorldLogEntry{Type: WorldLogRemove, Snake: id, X: head.X, Y: head.Y, Length: len(s.Segments), Score: s.Score})
+	}
 	delete(w.Snakes, id)
+	w.EntityIDs.Release(id)
 }
 
 // AddFood adds food items to the world (caller must hold mu.Lock)
 func (w *World) AddFood(items []*Food) {
 	for _, f := range items {
-		w.Food[f.ID] = f
+		w.addFood(f)
 	}
 }
 
-// RemoveFood removes food by ID (caller must hold mu.Lock)
-func (w *World) RemoveFood(id string) {
-	delete(w.Food, id)
+// addFood inserts one item, stamping the tick it appeared so viewers can
+// tell genuinely new food from food that merely scrolled into view
+func (w *World) addFood(f *Food) {
+	f.SpawnTick = w.Tick
+	w.Food[f.ID] = f
 }
 
-// RebuildGrid rebuilds the spatial grid from current state (caller must hold at least RLock)
+// RemoveFood removes food by ID and returns it to the pool (caller must hold mu.Lock).
+// The removed *Food must not be used afterwards.
+func (w *World) RemoveFood(id FoodID) {
+	if f, ok := w.Food[id]; ok {
+		delete(w.Food, id)
+		w.FoodPool.Release(f)
+	}
+}
+
+// RebuildGrid rebuilds the spatial grid and refreshes snake bounding boxes (caller must hold mu.Lock)
 func (w *World) RebuildGrid() {
 	w.Grid.Clear()
 	for _, f := range w.Food {
@@ -75,12 +239,14 @@ func (w *World) RebuildGrid() {
 	}
 	for _, s := range w.Snakes {
 		if s.Alive {
+			s.refreshBounds()
 			w.Grid.InsertSnakeBody(s)
 		}
 	}
 }
 
-// MaintainFoodCount spawns food up to TargetFoodCount (caller must hold mu.Lock).
+// MaintainFoodCount spawns food up to FoodTarget where FoodSpawns
+// weights it (caller must hold mu.Lock).
 // Moving food (level 10) is not counted against the normal food budget.
 func (w *World) MaintainFoodCount() {
 	normalCount := 0
@@ -89,35 +255,42 @@ func (w *World) MaintainFoodCount() {
 			normalCount++
 		}
 	}
-	deficit := TargetFoodCount - normalCount
+	deficit := w.FoodTarget - normalCount
 	if deficit <= 0 {
 		return
 	}
 	spawn := deficit
-	if spawn > FoodSpawnPerTick {
-		spawn = FoodSpawnPerTick
+	if limit := int(math.Ceil(perTick(FoodSpawnPerSec))); spawn > limit {
+		spawn = limit
 	}
-	// Spawn as cluster if deficit is large enough, otherwise individual
-	for spawned := 0; spawned < spawn; {
+	w.FoodSpawns.maybeRefresh(w.Tick, w.Grid)
+	// Spawn as cluster if deficit is large enough, otherwise individual.
+	// Items landing in an exclusion zone are skipped; bounded tries leave
+	// any shortfall to the next tick.
+	spawned := 0
+	for tries := 0; spawned < spawn && tries < spawn; tries++ {
 		if spawn-spawned >= 5 {
-			cluster := NewFoodCluster()
+			cluster := w.FoodPool.newFoodClusterAt(w.FoodSpawns.spawnPoint(200))
 			for _, f := range cluster {
 				if spawned >= spawn {
-					break
+					w.FoodPool.Release(f) // truncated cluster: return the unused slot
+					continue
+				}
+				if w.placeAmbient(f) {
+					spawned++
 				}
-				w.Food[f.ID] = f
-				spawned++
 			}
-		} else {
-			f := NewFood()
-			w.Food[f.ID] = f
+		} else if w.placeAmbient(w.FoodPool.newAmbientFood(w.FoodSpawns.spawnPoint(FoodBoundaryMargin))) {
 			spawned++
 		}
 	}
 }
 
-// Leaderboard returns the top N snakes sorted by score
-func (w *World) Leaderboard() []LeaderboardEntry {
+// Leaderboard returns the top Board.Size snakes sorted by score, leaving
+// bots out unless Board.Bots, and the top Board.Size human snakes so players
+// can see where they stand among humans while bots hold the top slots.
+// humans is nil when the board already has no bots on it.
+func (w *World) Leaderboard() (board, humans []LeaderboardEntry) {
 	snakes := make([]*Snake, 0, len(w.Snakes))
 	for _, s := range w.Snakes {
 		if s.Alive {
@@ -127,53 +300,109 @@ func (w *World) Leaderboard() []LeaderboardEntry {
 	sort.Slice(snakes, func(i, j int) bool {
 		return snakes[i].Score > snakes[j].Score
 	})
-	if len(snakes) > LeaderboardSize {
-		snakes = snakes[:LeaderboardSize]
+	board = make([]LeaderboardEntry, 0, w.Board.Size)
+	botsOnBoard := false
+	for _, s := range snakes {
+		if len(board) == w.Board.Size && len(humans) == w.Board.Size {
+			break
+		}
+		bot := strings.HasPrefix(s.ID, "bot-")
+		if len(board) < w.Board.Size && (w.Board.Bots || !bot) {
+			board = append(board, w.leaderboardEntry(s))
+			botsOnBoard = botsOnBoard || bot
+		}
+		if !bot && len(humans) < w.Board.Size {
+			humans = append(humans, w.leaderboardEntry(s))
+		}
+	}
+	if !botsOnBoard {
+		humans = nil
 	}
-	entries := make([]LeaderboardEntry, len(snakes))
-	for i, s := range snakes {
-		entries[i] = LeaderboardEntry{ID: s.ID, Name: s.Name, Score: s.Score}
+	return board, humans
+}
+
+// leaderboardEntry is s's row on a leaderboard
+func (w *World) leaderboardEntry(s *Snake) LeaderboardEntry {
+	e := LeaderboardEntry{ID: s.NetID, Name: s.Name, Score: s.Score, Country: s.Country}
+	if w.Ranked {
+		e.Rating, _ = w.Profiles.ratingOf(s.ID)
 	}
-	return entries
+	return e
 }
 
-// SnakesInViewport returns snake DTOs visible from a viewport centered on (cx,cy)
-func (w *World) SnakesInViewport(cx, cy float64) []SnakeDTO {
+// SnakesInViewport returns the live snakes visible from a viewport centered on (cx,cy)
+func (w *World) SnakesInViewport(cx, cy float64) []*Snake {
 	halfW := ViewportWidth/2 + ViewportBuffer
 	halfH := ViewportHeight/2 + ViewportBuffer
-	minX := cx - halfW
-	maxX := cx + halfW
-	minY := cy - halfH
-	maxY := cy + halfH
+	view := Rect{MinX: cx - halfW, MaxX: cx + halfW, MinY: cy - halfH, MaxY: cy + halfH}
 
-	result := []SnakeDTO{}
+	var result []*Snake
 	for _, s := range w.Snakes {
 		if !s.Alive {
 			continue
 		}
-		// Check if ANY segment is in viewport (not just head)
-		visible := false
-		for _, seg := range s.Segments {
-			if seg.X >= minX && seg.X <= maxX && seg.Y >= minY && seg.Y <= maxY {
-				visible = true
-				break
+		// Cheap box test first; scan segments only when the box straddles the viewport
+		box := s.Bounds()
+		if !view.Intersects(box) {
+			continue
+		}
+		visible := view.Contains(box)
+		if !visible {
+			// Check if ANY segment is in viewport (not just head)
+			for _, seg := range s.Segments {
+				if view.ContainsPoint(seg.X, seg.Y) {
+					visible = true
+					break
+				}
 			}
 		}
 		if visible {
-			result = append(result, s.ToDTO(0))
+			result = append(result, s)
 		}
 	}
 	return result
 }
 
+// ViewportState builds c's state message for the viewport around snake: a
+// delta against what c was last sent when it can be, otherwise a keyframe
+// (see state_delta.go). Food spawned after tick since is flagged new so the
+// client can animate it in. leaderboard and minimap are shared across
+// viewers, so callers compute them once per batch. Game-loop goroutine only;
+// caller must hold w.mu (read or write).
+func (w *World) ViewportState(c *Conn, snake *Snake, since uint64, leaderboard []LeaderboardEntry, minimap []MinimapSnake) StateMsg {
+	head := snake.Head()
+	foodDTOs, blobDTOs := w.FoodInViewport(head.X, head.Y)
+	for i := range foodDTOs {
+		if f := w.Food[FoodID(foodDTOs[i].ID)]; f != nil && f.SpawnTick > since {
+			foodDTOs[i].New = 1
+		}
+	}
+	msg := StateMsg{
+		Type:        MsgState,
+		Leaderboard: leaderboard,
+		Minimap:     minimap,
+		Pending:     snake.PendingGrowth,
+	}
+	c.view.fill(&msg, w.Tick, w.SnakesInViewport(head.X, head.Y), foodDTOs, blobDTOs)
+	if own := w.ownNames(c); own != nil {
+		for i := range msg.Snakes {
+			// Delta entries carry no name, so only full entries need it
+			if name, ok := own[msg.Snakes[i].ID]; ok && msg.Snakes[i].Name != "" {
+				msg.Snakes[i].Name = name
+			}
+		}
+		msg.Leaderboard = unmuteBoard(msg.Leaderboard, own)
+	}
+	return msg
+}
+
 // MinimapSnakes returns downsampled snake bodies for the minimap.
 // Only includes snakes whose total body length is >= 1px on minimap.
 // Segments are downsampled to keep wire size small.
 func (w *World) MinimapSnakes() []MinimapSnake {
-	const minimapDiameter = 160.0
 	worldDiameter := WorldRadius * 2
-	scale := minimapDiameter / worldDiameter
-	// Minimum body length to appear on minimap (1px = ~131 world units = ~16 segments)
+	scale := MinimapDiameter / worldDiameter
+	// Minimum body length to appear on minimap (1px = ~131 world units = ~44 segments)
 	minSegments := int(1.0 / (scale * SnakeSegmentSpacing))
 	if minSegments < 2 {
 		minSegments = 2
@@ -203,18 +432,19 @@ func (w *World) MinimapSnakes() []MinimapSnake {
 			result = append(result, MinimapSnake{
 				Segments: segs,
 				Color:    s.Color,
-				Width:    roundTo1(s.Width),
+				Width:    roundTo1(s.ShownWidth),
 			})
 		}
 	}
 	return result
 }
 
-// FoodInViewport returns food DTOs visible from viewport centered on (cx,cy)
-func (w *World) FoodInViewport(cx, cy float64) []FoodDTO {
+// FoodInViewport returns food DTOs visible from viewport centered on (cx,cy),
+// plus blobs of aggregated food piles far from the viewer
+func (w *World) FoodInViewport(cx, cy float64) ([]FoodDTO, []FoodBlobDTO) {
 	halfW := ViewportWidth/2 + ViewportBuffer
 	halfH := ViewportHeight/2 + ViewportBuffer
 	vx := cx - halfW
 	vy := cy - halfH
-	return w.Grid.FoodInViewport(w.Food, vx, vy, halfW*2, halfH*2)
+	return w.Grid.FoodInViewport(w.Food, vx, vy, halfW*2, halfH*2, cx, cy)
 }
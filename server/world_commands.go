@@ -0,0 +1,42 @@
+package main
+
+import "errors"
+
+// WorldCommand is a world mutation executed by the game loop at the start of a
+// tick. Connection goroutines (joins, disconnects) and admin tools post
+// commands instead of locking the world, so the simulation is only ever
+// mutated from the loop goroutine.
+type WorldCommand func(w *World)
+
+// errCommandQueueFull is Post's error when the loop is too far behind to
+// take more commands; admin endpoints answer it with 503
+var errCommandQueueFull = errors.New("world command queue full, try again")
+
+// Post enqueues cmd for the next tick. It never blocks: with
+// CommandQueueSize commands already pending it returns errCommandQueueFull,
+// so a flood of joins or admin calls is turned away instead of stalling
+// the goroutines that post them.
+func (w *World) Post(cmd WorldCommand) error {
+	select {
+	case w.commands <- cmd:
+		return nil
+	default:
+		return errCommandQueueFull
+	}
+}
+
+// applyCommands runs queued commands in FIFO order, at most
+// CommandsPerTick of them so a burst can't stretch one tick; the rest wait
+// for the next. Returns how many ran.
+// Called by the game loop at tick start (caller must hold mu.Lock).
+func (w *World) applyCommands() int {
+	for n := 0; n < CommandsPerTick; n++ {
+		select {
+		case cmd := <-w.commands:
+			cmd(w)
+		default:
+			return n
+		}
+	}
+	return CommandsPerTick
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// World log entry types in WorldLogEntry.Type
+const (
+	WorldLogInput  = "input"  // steering applied to a snake, heading, head and length after the move
+	WorldLogSpawn  = "spawn"  // snake added: join, respawn or bot spawn
+	WorldLogRemove = "remove" // snake taken out of the world
+	WorldLogDeath  = "death"  // Other = killer ID, "" for the boundary
+)
+
+// WorldLogEntry is one world mutation
+type WorldLogEntry struct {
+	Type    string  `json:"type"`
+	Snake   string  `json:"snake"`
+	Angle   float64 `json:"angle,omitempty"`   // input: target angle
+	Heading float64 `json:"heading,omitempty"` // input: heading after the turn
+	Boost   bool    `json:"boost,omitempty"`   // input
+	Seq     uint32  `json:"seq,omitempty"`     // input: the client's sequence number
+	X       float64 `json:"x"`                 // head position
+	Y       float64 `json:"y"`
+	Length  int     `json:"length,omitempty"`
+	Score   int     `json:"score,omitempty"`
+	Other   string  `json:"other,omitempty"`  // death: killer ID
+	Mutual  bool    `json:"mutual,omitempty"` // death: the killer died to this snake too
+}
+
+// worldLogTick is the mutations made in one tick
+type worldLogTick struct {
+	Tick    uint64          `json:"tick"`
+	Entries []WorldLogEntry `json:"entries"`
+}
+
+// WorldLogDump is a dumped world log: the last ticks, oldest first
+type WorldLogDump struct {
+	Room     string         `json:"room,omitempty"`
+	Time     time.Time      `json:"time"`
+	Reason   string         `json:"reason"`
+	TickRate int            `json:"tick_rate,omitempty"`
+	Ticks    []worldLogTick `json:"ticks"`
+}
+
+// WorldLog keeps the mutations of a world's last few ticks — inputs applied,
+// spawns, removals and deaths — to investigate a collision or death dispute
+// after the fact. It is much lighter than a replay: enough to see who
+// steered where and what died to what, not to re-simulate (a replay export
+// takes deaths from it, see replay_export.go). Dumped to a JSON file
+// through the admin API or when the game loop panics. Nil when
+// disabled; every method is a no-op on nil.
+//
+// The game loop records into the current tick without locking and hands it
+// to the ring at the end of the tick; mu guards the ring against dumps from
+// other goroutines.
+type WorldLog struct {
+	dir string
+	cur worldLogTick // game-loop goroutine only
+
+	mu     sync.Mutex
+	ring   []worldLogTick
+	next   int // ring slot the next tick goes in
+	filled int
+}
+
+// worldLogFromEnv reads SLETHER_WORLD_LOG, the number of ticks to keep
+// (1-WorldLogMaxTicks; unset or 0 disables the log), and
+// SLETHER_WORLD_LOG_DIR, where dumps are written (default the system's
+// temporary directory)
+func worldLogFromEnv() (*WorldLog, error) {
+	v := os.Getenv("SLETHER_WORLD_LOG")
+	if v == "" || v == "0" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > WorldLogMaxTicks {
+		return nil, fmt.Errorf("SLETHER_WORLD_LOG=%q: want 0-%d ticks", v, WorldLogMaxTicks)
+	}
+	dir := os.Getenv("SLETHER_WORLD_LOG_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	log.Printf("world log: keeping %d ticks, dumps go to %s", n, dir)
+	return NewWorldLog(n, dir), nil
+}
+
+// NewWorldLog keeps the last ticks ticks, dumping into dir
+func NewWorldLog(ticks int, dir string) *WorldLog {
+	return &WorldLog{dir: dir, ring: make([]worldLogTick, ticks)}
+}
+
+// fresh is an empty log like l, for another room's world
+func (l *WorldLog) fresh() *WorldLog {
+	if l == nil {
+		return nil
+	}
+	return NewWorldLog(len(l.ring), l.dir)
+}
+
+// record adds e to the current tick. Game-loop goroutine only.
+func (l *WorldLog) record(e WorldLogEntry) {
+	if l == nil {
+		return
+	}
+	e.X, e.Y = roundTo1(e.X), roundTo1(e.Y)
+	l.cur.Entries = append(l.cur.Entries, e)
+}
+
+// endTick files the current tick's mutations under tick, evicting the
+// oldest tick once the ring is full. Mutations recorded between ticks
+// (bots spawned before the loop starts) are filed with the next tick.
+// Game-loop goroutine only.
+func (l *WorldLog) endTick(tick uint64) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	slot := &l.ring[l.next]
+	// Swap slices so the evicted tick's entries are reused for the next one
+	slot.Tick, slot.Entries, l.cur.Entries = tick, l.cur.Entries, slot.Entries[:0]
+	l.next = (l.next + 1) % len(l.ring)
+	l.filled = min(l.filled+1, len(l.ring))
+	l.mu.Unlock()
+}
+
+// snapshot copies the logged ticks, oldest first. partial adds the tick in
+// progress, which only the game-loop goroutine may ask for.
+func (l *WorldLog) snapshot(reason string, partial bool) WorldLogDump {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	d := WorldLogDump{Time: time.Now(), Reason: reason, TickRate: TickRate, Ticks: make([]worldLogTick, 0, l.filled+1)}
+	for i := range l.filled {
+		t := l.ring[(l.next-l.filled+i+len(l.ring))%len(l.ring)]
+		d.Ticks = append(d.Ticks, worldLogTick{Tick: t.Tick, Entries: slices.Clone(t.Entries)})
+	}
+	if partial && len(l.cur.Entries) > 0 {
+		d.Ticks = append(d.Ticks, worldLogTick{Tick: l.cur.Tick, Entries: slices.Clone(l.cur.Entries)})
+	}
+	return d
+}
+
+// Dump copies the logged ticks, for the admin API
+func (l *WorldLog) Dump(room, reason string) WorldLogDump {
+	d := l.snapshot(reason, false)
+	d.Room = room
+	return d
+}
+
+// write saves d to a new file in the log's directory and returns its path
+func (l *WorldLog) write(d WorldLogDump) (string, error) {
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("worldlog-%s-%s.json", cmp.Or(d.Room, "world"), d.Time.UTC().Format("20060102T150405.000"))
+	path := filepath.Join(l.dir, name)
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// dumpOnPanic is deferred by the game loop's tick: a panicking tick writes
+// the log, the tick in progress included, before the panic carries on.
+// The world lock may still be held, so nothing here takes it.
+func (l *WorldLog) dumpOnPanic(room string, tick uint64) {
+	if l == nil {
+		return
+	}
+	r := recover()
+	if r == nil {
+		return
+	}
+	l.cur.Tick = tick
+	d := l.snapshot(fmt.Sprintf("panic: %v", r), true)
+	d.Room = room
+	if path, err := l.write(d); err != nil {
+		log.Printf("world log: dump on panic: %v", err)
+	} else {
+		log.Printf("world log: dumped %d ticks to %s", len(d.Ticks), path)
+	}
+	panic(r)
+}
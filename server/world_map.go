@@ -0,0 +1,49 @@
+package main
+
+// MapFeature is a static circular feature of the world: an obstacle, a zone,
+// or a portal (which also has a destination).
+type MapFeature struct {
+	X, Y, Radius     float64
+	TargetX, TargetY float64 // portals only
+	Multiplier       float64 // danger zones only: score multiplier (see risk_zones.go)
+}
+
+// MapFeatures is the static layout sent once per connection in the map
+// message. Fixed at world construction, so it may be read without w.mu.
+type MapFeatures struct {
+	Obstacles []MapFeature
+	Zones     []MapFeature
+	Portals   []MapFeature
+}
+
+// MapMsg builds the one-time map message describing the static world
+func (w *World) MapMsg() MapMsg {
+	return MapMsg{
+		Type:         MsgMap,
+		CenterX:      WorldCenterX,
+		CenterY:      WorldCenterY,
+		Radius:       WorldRadius,
+		MinimapScale: MinimapDiameter / (WorldRadius * 2),
+		Obstacles:    mapFeatureDTOs(w.Map.Obstacles),
+		Zones:        mapFeatureDTOs(w.Map.Zones),
+		Portals:      mapFeatureDTOs(w.Map.Portals),
+	}
+}
+
+func mapFeatureDTOs(features []MapFeature) []MapFeatureDTO {
+	if len(features) == 0 {
+		return nil
+	}
+	dtos := make([]MapFeatureDTO, len(features))
+	for i, f := range features {
+		dtos[i] = MapFeatureDTO{
+			X:          roundTo1(f.X),
+			Y:          roundTo1(f.Y),
+			Radius:     roundTo1(f.Radius),
+			TargetX:    roundTo1(f.TargetX),
+			TargetY:    roundTo1(f.TargetY),
+			Multiplier: f.Multiplier,
+		}
+	}
+	return dtos
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// worldReset is what a reset cleared, for the admin response and to tell
+// the players afterwards
+type worldReset struct {
+	Players int `json:"players"` // live player snakes cleared
+	Bots    int `json:"bots"`    // bots replaced
+	Food    int `json:"food"`    // food items respawned from scratch
+	deaths  map[*Conn]DeathMsg
+	apple   bool // the golden apple was out
+}
+
+// resetWorld brings the arena back to a fresh start without restarting the
+// process: every live player snake is cleared (its life ends as if the
+// player left, without a body drop), bots are replaced by fresh ones, food
+// is respawned from scratch, and the golden apple, the content pack
+// schedule, food spawn weighting and feeding records start over. Profiles,
+// seasons, sanctions, highlights and metrics are kept. It runs as a posted
+// command, so the reset lands between two ticks. Caller must hold
+// w.mu.Lock.
+func (gl *GameLoop) resetWorld() worldReset {
+	w := gl.world
+	res := worldReset{deaths: make(map[*Conn]DeathMsg), apple: w.Apple.Active}
+
+	for _, c := range gl.conns.Snapshot() {
+		if s, ok := w.Snakes[c.snakeID]; ok && s.Alive {
+			w.emit(snakeEvent(EventLeave, s))
+			w.Economy.despawned(s.Score)
+			s.Alive = false // kept, like any dead snake, so its wire ID holds
+			res.deaths[c] = DeathMsg{Type: MsgDeath, Score: s.Score}
+			res.Players++
+		}
+		for _, id := range c.takeExtra() {
+			if s, ok := w.Snakes[id]; ok {
+				w.emit(snakeEvent(EventLeave, s))
+				w.RemoveSnake(id)
+			}
+		}
+	}
+	for id := range gl.bots.bots {
+		gl.bots.despawn(id)
+		res.Bots++
+	}
+
+	for id := range w.Food {
+		w.RemoveFood(id)
+	}
+	w.Apple = GoldenApple{}
+	w.epoch = w.Tick
+	w.FoodSpawns = NewFoodRebalancer()
+	w.Feeding = NewFeedingDetector()
+	w.RebuildGrid()
+	if gl.ambientFood {
+		w.spawnInitialFood()
+	}
+	res.Food = len(w.Food)
+
+	for range gl.bots.currentTarget() {
+		gl.bots.SpawnBot()
+	}
+	log.Printf("world reset at tick %d: cleared %d players and %d bots, respawned %d food",
+		w.Tick, res.Players, res.Bots, res.Food)
+	return res
+}
+
+// ResetWorld resets the world at the next tick boundary (see resetWorld),
+// then sends every cleared player to the death screen and tells everyone
+// why. Blocks until the loop has applied it; fails only if the loop can't
+// take the command (see World.Post).
+func (gl *GameLoop) ResetWorld() (worldReset, error) {
+	done := make(chan worldReset, 1)
+	if err := gl.world.Post(func(*World) { done <- gl.resetWorld() }); err != nil {
+		return worldReset{}, err
+	}
+	res := <-done
+
+	for c, msg := range res.deaths {
+		_ = c.Send(msg)
+	}
+	if res.apple {
+		for _, c := range gl.conns.Snapshot() {
+			_ = c.Send(ObjectiveMsg{Type: MsgObjective})
+		}
+	}
+	if msg, err := NewAnnouncement(localize(gl.world.Lang, textReset), SeverityWarn, 8*time.Second); err == nil {
+		gl.conns.Announce(msg, "")
+	}
+	return res, nil
+}
+
+// resetRequest is the optional POST /admin/reset body
+type resetRequest struct {
+	Reason string `json:"reason"` // for the audit log
+}
+
+// handleReset soft-resets the world
+func (a *AdminAPI) handleReset(w http.ResponseWriter, r *http.Request) {
+	var req resetRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	res, err := a.loop.ResetWorld()
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		return
+	}
+	a.record(r, "world.reset", map[string]any{"reason": req.Reason, "result": res})
+	writeJSON(w, http.StatusOK, res)
+}
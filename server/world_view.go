@@ -0,0 +1,254 @@
+package main
+
+// WorldView is the read-only query surface over the world used by code that
+// only looks at it: bot AI, the admin/analytics API and any future spectator
+// camera or auto-pilot. Results are value copies, so callers never touch the
+// world maps or need to know about locking.
+//
+// Two implementations exist: the live view for the game loop goroutine
+// inside the tick (World.LiveView) and a frozen per-tick copy that is safe
+// from any goroutine (World.FrozenView).
+type WorldView interface {
+	// Tick is the World.Tick the view reflects
+	Tick() uint64
+	// Snake looks up one alive snake by ID
+	Snake(id string) (SnakeView, bool)
+	// NearbyFood calls fn for food within radius of (x,y) until fn returns false
+	NearbyFood(x, y, radius float64, fn func(FoodView) bool)
+	// NearbySnakes calls fn for alive snakes whose head is within radius of (x,y)
+	NearbySnakes(x, y, radius float64, fn func(SnakeView) bool)
+	// NearbyBodies calls fn for body segments (heads excluded) within radius
+	// of (x,y), skipping snake excludeID, until fn returns false
+	NearbyBodies(x, y, radius float64, excludeID string, fn func(BodyView) bool)
+	// Density counts what lies within radius of (x,y)
+	Density(x, y, radius float64) Density
+	// Objective reports where the golden apple is, if one is out
+	Objective() (x, y float64, ok bool)
+}
+
+// FoodView is a read-only copy of one food item
+type FoodView struct {
+	ID    FoodID  `json:"id"`
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Value int     `json:"value"`
+}
+
+// SnakeView is a read-only copy of one snake's public state
+type SnakeView struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	X        float64 `json:"x"` // head
+	Y        float64 `json:"y"`
+	Angle    float64 `json:"angle"`
+	Score    int     `json:"score"`
+	Length   int     `json:"length"`
+	Boosting bool    `json:"boosting"`
+	Layer    int     `json:"layer"`
+}
+
+// CanInteract reports whether the viewed snake collides with other (phase layers)
+func (v SnakeView) CanInteract(other SnakeView) bool {
+	return layersInteract(v.Layer, other.Layer)
+}
+
+// BodyView is one body segment of a snake
+type BodyView struct {
+	SnakeID string
+	Layer   int
+	X, Y    float64
+}
+
+// Density summarizes an area
+type Density struct {
+	Food      int `json:"food"`
+	FoodValue int `json:"food_value"`
+	Snakes    int `json:"snakes"`   // heads in the area
+	Segments  int `json:"segments"` // body segments in the area
+}
+
+func snakeViewOf(s *Snake) SnakeView {
+	head := s.Head()
+	return SnakeView{
+		ID:       s.ID,
+		Name:     s.Name,
+		X:        head.X,
+		Y:        head.Y,
+		Angle:    s.Angle,
+		Score:    s.Score,
+		Length:   len(s.Segments),
+		Boosting: s.BoostActive,
+		Layer:    s.Layer(),
+	}
+}
+
+// densityOf implements Density on top of the other view queries
+func densityOf(v WorldView, x, y, radius float64) Density {
+	var d Density
+	v.NearbyFood(x, y, radius, func(f FoodView) bool {
+		d.Food++
+		d.FoodValue += f.Value
+		return true
+	})
+	v.NearbySnakes(x, y, radius, func(SnakeView) bool {
+		d.Snakes++
+		return true
+	})
+	v.NearbyBodies(x, y, radius, "", func(BodyView) bool {
+		d.Segments++
+		return true
+	})
+	return d
+}
+
+// liveView reads the world in place. Only valid on the game loop goroutine
+// while it holds the tick lock; body queries see the grid as of the last
+// RebuildGrid.
+type liveView struct {
+	w *World
+}
+
+// LiveView returns a view over the live world for code running inside the tick
+func (w *World) LiveView() WorldView {
+	return liveView{w: w}
+}
+
+func (v liveView) Tick() uint64 { return v.w.Tick }
+
+func (v liveView) Snake(id string) (SnakeView, bool) {
+	s, ok := v.w.Snakes[id]
+	if !ok || !s.Alive {
+		return SnakeView{}, false
+	}
+	return snakeViewOf(s), true
+}
+
+func (v liveView) NearbyFood(x, y, radius float64, fn func(FoodView) bool) {
+	v.w.Grid.ForEachFoodNear(x, y, radius, func(fid FoodID) bool {
+		f, ok := v.w.Food[fid]
+		if !ok {
+			return true
+		}
+		return fn(FoodView{ID: f.ID, X: f.X, Y: f.Y, Value: f.Value})
+	})
+}
+
+func (v liveView) NearbySnakes(x, y, radius float64, fn func(SnakeView) bool) {
+	r2 := radius * radius
+	for _, s := range v.w.Snakes {
+		if !s.Alive {
+			continue
+		}
+		head := s.Head()
+		if dx, dy := head.X-x, head.Y-y; dx*dx+dy*dy <= r2 && !fn(snakeViewOf(s)) {
+			return
+		}
+	}
+}
+
+func (v liveView) NearbyBodies(x, y, radius float64, excludeID string, fn func(BodyView) bool) {
+	v.w.Grid.ForEachSnakeBodyNear(x, y, radius, excludeID, func(e gridEntry) bool {
+		layer := LayerNormal
+		if s := v.w.Snakes[e.snakeID]; s != nil {
+			if !s.Alive {
+				return true
+			}
+			layer = s.Layer()
+		}
+		return fn(BodyView{SnakeID: e.snakeID, Layer: layer, X: e.x, Y: e.y})
+	})
+}
+
+func (v liveView) Density(x, y, radius float64) Density {
+	return densityOf(v, x, y, radius)
+}
+
+func (v liveView) Objective() (float64, float64, bool) {
+	a := v.w.Apple
+	return a.X, a.Y, a.Active
+}
+
+// frozenView is a copy of the world at one tick, safe to share between
+// goroutines because nothing modifies it after construction
+type frozenView struct {
+	tick   uint64
+	grid   *SpatialGrid // food and body entries, own copy
+	food   map[FoodID]FoodView
+	snakes []SnakeView
+	layers map[string]int // snakeID -> layer, for body entries
+	apple  GoldenApple
+}
+
+// FrozenView returns an immutable copy of the world at its current tick,
+// built on first request and reused until the tick advances. Safe from any
+// goroutine; costs a world copy per tick it is requested in, so it suits
+// analytics and API reads rather than per-tick hot paths.
+func (w *World) FrozenView() WorldView {
+	unlock := w.rlock("frozenView")
+	defer unlock()
+	if v := w.frozen.Load(); v != nil && v.tick == w.Tick {
+		return v
+	}
+	v := &frozenView{
+		tick:   w.Tick,
+		grid:   NewSpatialGrid(GridCellSize),
+		food:   make(map[FoodID]FoodView, len(w.Food)),
+		snakes: make([]SnakeView, 0, len(w.Snakes)),
+		layers: make(map[string]int, len(w.Snakes)),
+		apple:  w.Apple,
+	}
+	for id, f := range w.Food {
+		v.food[id] = FoodView{ID: f.ID, X: f.X, Y: f.Y, Value: f.Value}
+		v.grid.InsertFood(f)
+	}
+	for _, s := range w.Snakes {
+		if !s.Alive {
+			continue
+		}
+		v.snakes = append(v.snakes, snakeViewOf(s))
+		v.layers[s.ID] = s.Layer()
+		v.grid.InsertSnakeBody(s)
+	}
+	w.frozen.Store(v)
+	return v
+}
+
+func (v *frozenView) Tick() uint64 { return v.tick }
+
+func (v *frozenView) Snake(id string) (SnakeView, bool) {
+	for _, s := range v.snakes {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return SnakeView{}, false
+}
+
+func (v *frozenView) NearbyFood(x, y, radius float64, fn func(FoodView) bool) {
+	v.grid.ForEachFoodNear(x, y, radius, func(fid FoodID) bool {
+		return fn(v.food[fid])
+	})
+}
+
+func (v *frozenView) NearbySnakes(x, y, radius float64, fn func(SnakeView) bool) {
+	r2 := radius * radius
+	for _, s := range v.snakes {
+		if dx, dy := s.X-x, s.Y-y; dx*dx+dy*dy <= r2 && !fn(s) {
+			return
+		}
+	}
+}
+
+func (v *frozenView) NearbyBodies(x, y, radius float64, excludeID string, fn func(BodyView) bool) {
+	v.grid.ForEachSnakeBodyNear(x, y, radius, excludeID, func(e gridEntry) bool {
+		return fn(BodyView{SnakeID: e.snakeID, Layer: v.layers[e.snakeID], X: e.x, Y: e.y})
+	})
+}
+
+func (v *frozenView) Density(x, y, radius float64) Density {
+	return densityOf(v, x, y, radius)
+}
+
+func (v *frozenView) Objective() (float64, float64, bool) {
+	return v.apple.X, v.apple.Y, v.apple.Active
+}